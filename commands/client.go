@@ -33,6 +33,8 @@ func ConfigureYorcClientCommand(c *cobra.Command, v *viper.Viper, cfgFile *strin
 	c.PersistentFlags().BoolP("ssl_enabled", "s", false, "Use HTTPS to connect to the Yorc REST API")
 	c.PersistentFlags().BoolP("skip_tls_verify", "", false, "Controls whether a client verifies the server's certificate chain and host name. If set to true, TLS accepts any certificate presented by the server and any host name in that certificate. In this mode, TLS is susceptible to man-in-the-middle attacks. This should be used only for testing. This implies the use of HTTPS to connect to the Yorc REST API.")
 	c.PersistentFlags().StringP("cert_file", "", "", "File path to a PEM-encoded client certificate used to authenticate to the Yorc API. This must be provided along with key-file. If one of key-file or cert-file is not provided then SSL authentication is disabled. If both cert-file and key-file are provided this implies the use of HTTPS to connect to the Yorc REST API.")
+	c.PersistentFlags().StringP("token", "", "", "Bearer token used to authenticate to the Yorc API. This could be a static token defined in the Yorc server configuration or a JSON Web Token issued by an OpenID Connect provider.")
+	c.PersistentFlags().StringP("tenant", "", "", "Tenant whose resources are managed. This is only taken into account for administrators not attached to a tenant or if authentication is disabled.")
 	c.PersistentFlags().StringP("key_file", "", "", "File path to a PEM-encoded client private key used to authenticate to the Yorc API. This must be provided along with cert-file. If one of key-file or cert-file is not provided then SSL authentication is disabled. If both cert-file and key-file are provided this implies the use of HTTPS to connect to the Yorc REST API.")
 
 	v.BindPFlag("yorc_api", c.PersistentFlags().Lookup("yorc_api"))
@@ -42,6 +44,8 @@ func ConfigureYorcClientCommand(c *cobra.Command, v *viper.Viper, cfgFile *strin
 	v.BindPFlag("key_file", c.PersistentFlags().Lookup("key_file"))
 	v.BindPFlag("cert_file", c.PersistentFlags().Lookup("cert_file"))
 	v.BindPFlag("skip_tls_verify", c.PersistentFlags().Lookup("skip_tls_verify"))
+	v.BindPFlag("token", c.PersistentFlags().Lookup("token"))
+	v.BindPFlag("tenant", c.PersistentFlags().Lookup("tenant"))
 
 	v.SetEnvPrefix("yorc")
 	v.AutomaticEnv()
@@ -52,6 +56,8 @@ func ConfigureYorcClientCommand(c *cobra.Command, v *viper.Viper, cfgFile *strin
 	v.BindEnv("key_file")
 	v.BindEnv("cert_file")
 	v.BindEnv("skip_tls_verify")
+	v.BindEnv("token")
+	v.BindEnv("tenant")
 	v.SetDefault("yorc_api", "localhost:8800")
 	v.SetDefault("ssl_enabled", false)
 	v.SetDefault("skip_tls_verify", false)
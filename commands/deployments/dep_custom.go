@@ -35,6 +35,7 @@ func init() {
 	var nodeName string
 	var customCName string
 	var inputs []string
+	var queue bool
 	var customCmd = &cobra.Command{
 		Use:   "custom <id>",
 		Short: "Execute a custom command",
@@ -78,6 +79,9 @@ func init() {
 				httputil.ErrExit(err)
 			}
 			request.Header.Add("Content-Type", "application/json")
+			if queue {
+				request.URL.RawQuery = "queue"
+			}
 
 			response, err := client.Do(request)
 			if err != nil {
@@ -94,5 +98,6 @@ func init() {
 	customCmd.PersistentFlags().StringVarP(&nodeName, "node", "n", "", "Provide the node name (use with flag c and i)")
 	customCmd.PersistentFlags().StringVarP(&customCName, "custom", "c", "", "Provide the custom command name (use with flag n and i)")
 	customCmd.PersistentFlags().StringArrayVarP(&inputs, "input", "i", make([]string, 0), "Provide the input for the custom command (use with flag c and n)")
+	customCmd.PersistentFlags().BoolVarP(&queue, "queue", "", false, "If other tasks are running or queued on the deployment, queue the custom command execution instead of rejecting it.")
 	DeploymentsCmd.AddCommand(customCmd)
 }
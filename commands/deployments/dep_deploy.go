@@ -18,22 +18,28 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/ystia/yorc/commands/httputil"
 	"github.com/ystia/yorc/helper/ziputil"
 	"github.com/ystia/yorc/rest"
+	"gopkg.in/yaml.v2"
 )
 
 func init() {
 	var shouldStreamLogs bool
 	var shouldStreamEvents bool
 	var deploymentID string
+	var inputsFile string
+	var inputs []string
+	var rollback bool
 	var deployCmd = &cobra.Command{
 		Use:   "deploy <csar_path>",
 		Short: "Deploy an application",
@@ -49,46 +55,17 @@ func init() {
 			if err != nil {
 				httputil.ErrExit(err)
 			}
-			absPath, err := filepath.Abs(args[0])
+			csarZip, err := getCSARZip(args[0])
 			if err != nil {
-				return err
+				httputil.ErrExit(err)
 			}
-			fileInfo, err := os.Stat(absPath)
+			inputsDoc, err := readInputs(inputsFile, inputs)
 			if err != nil {
-				return err
-			}
-			var location = ""
-			if !fileInfo.IsDir() {
-				file, err := os.Open(absPath)
-				if err != nil {
-					httputil.ErrExit(err)
-				}
-
-				defer file.Close()
-
-				buff, err := ioutil.ReadAll(file)
-				if err != nil {
-					httputil.ErrExit(err)
-				}
-				fileType := http.DetectContentType(buff)
-				if fileType == "application/zip" {
-					location, err = submitCSAR(buff, client, deploymentID)
-					if err != nil {
-						httputil.ErrExit(err)
-					}
-				}
+				httputil.ErrExit(err)
 			}
-
-			if location == "" {
-				csarZip, err := ziputil.ZipPath(absPath)
-				if err != nil {
-					httputil.ErrExit(err)
-				}
-				location, err = submitCSAR(csarZip, client, deploymentID)
-
-				if err != nil {
-					httputil.ErrExit(err)
-				}
+			location, err := submitCSAR(csarZip, inputsDoc, client, deploymentID, rollback)
+			if err != nil {
+				httputil.ErrExit(err)
 			}
 			taskID := path.Base(location)
 			if deploymentID == "" {
@@ -109,22 +86,115 @@ func init() {
 	deployCmd.PersistentFlags().BoolVarP(&shouldStreamEvents, "stream-events", "e", false, "Stream events after deploying the CSAR.")
 	// Do not impose a max id length as it doesn't have a concrete impact for now
 	//deployCmd.PersistentFlags().StringVarP(&deploymentID, "id", "", "", fmt.Sprintf("Specify a id for this deployment. This id should not already exists, should respect the following format: %q and should be less than %d characters long", rest.YorcDeploymentIDPattern, rest.YorcDeploymentIDMaxLength))
-	deployCmd.PersistentFlags().StringVarP(&deploymentID, "id", "", "", fmt.Sprintf("Specify a id for this deployment. This id should not already exists, should respect the following format: %q", rest.YorcDeploymentIDPattern))
+	deployCmd.PersistentFlags().StringVarP(&deploymentID, "id", "", "", fmt.Sprintf("Specify a id for this deployment. If this id already exists the deployment is updated. It should respect the following format: %q", rest.YorcDeploymentIDPattern))
+	deployCmd.PersistentFlags().StringArrayVarP(&inputs, "input", "", nil, "Value of a topology input given as key=value. This flag may be repeated and overrides values of the inputs file.")
+	deployCmd.PersistentFlags().StringVarP(&inputsFile, "inputs-file", "", "", "Path to a YAML or JSON file defining topology inputs values.")
+	deployCmd.PersistentFlags().BoolVarP(&rollback, "rollback", "", false, "If the deployment fails, uninstall the node instances it created and restore the previous deployment status.")
 	DeploymentsCmd.AddCommand(deployCmd)
 }
 
-func submitCSAR(csarZip []byte, client *httputil.YorcClient, deploymentID string) (string, error) {
+// getCSARZip returns the content of a zipped CSAR from a path to a zip archive, a directory or a single TOSCA YAML file
+func getCSARZip(csarPath string) ([]byte, error) {
+	absPath, err := filepath.Abs(csarPath)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := os.Stat(absPath)
+	if err != nil {
+		return nil, err
+	}
+	if !fileInfo.IsDir() {
+		buff, err := ioutil.ReadFile(absPath)
+		if err != nil {
+			return nil, err
+		}
+		if http.DetectContentType(buff) == "application/zip" {
+			return buff, nil
+		}
+	}
+	return ziputil.ZipPath(absPath)
+}
+
+// readInputs builds an inputs document from an optional inputs file and inputs given as key=value
+//
+// Inputs given as key=value override those of the inputs file. It returns nil if no inputs are given.
+func readInputs(inputsFile string, inputs []string) ([]byte, error) {
+	if inputsFile == "" && len(inputs) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]interface{})
+	if inputsFile != "" {
+		data, err := ioutil.ReadFile(inputsFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read inputs file %q", inputsFile)
+		}
+		if err = yaml.Unmarshal(data, &values); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse inputs file %q", inputsFile)
+		}
+	}
+	for _, input := range inputs {
+		keyValue := strings.SplitN(input, "=", 2)
+		if len(keyValue) != 2 || keyValue[0] == "" {
+			return nil, errors.Errorf("invalid input %q, expecting key=value", input)
+		}
+		values[keyValue[0]] = keyValue[1]
+	}
+	return yaml.Marshal(values)
+}
+
+// newCSARRequest creates a request submitting a CSAR
+//
+// If an inputs document is given, the CSAR and the inputs are sent as a multipart request.
+func newCSARRequest(client *httputil.YorcClient, method, path string, csarZip, inputs []byte) (*http.Request, error) {
+	if inputs == nil {
+		request, err := client.NewRequest(method, path, bytes.NewReader(csarZip))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Add("Content-Type", "application/zip")
+		return request, nil
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	csarPart, err := mw.CreateFormFile("csar", "deployment.zip")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = csarPart.Write(csarZip); err != nil {
+		return nil, err
+	}
+	inputsPart, err := mw.CreateFormFile("inputs", "inputs.yaml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = inputsPart.Write(inputs); err != nil {
+		return nil, err
+	}
+	if err = mw.Close(); err != nil {
+		return nil, err
+	}
+	request, err := client.NewRequest(method, path, &body)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("Content-Type", mw.FormDataContentType())
+	return request, nil
+}
+
+func submitCSAR(csarZip, inputs []byte, client *httputil.YorcClient, deploymentID string, rollback bool) (string, error) {
 	var request *http.Request
 	var err error
 	if deploymentID != "" {
-		request, err = client.NewRequest(http.MethodPut, path.Join("/deployments", deploymentID), bytes.NewReader(csarZip))
+		request, err = newCSARRequest(client, http.MethodPut, path.Join("/deployments", deploymentID), csarZip, inputs)
 	} else {
-		request, err = client.NewRequest(http.MethodPost, "/deployments", bytes.NewReader(csarZip))
+		request, err = newCSARRequest(client, http.MethodPost, "/deployments", csarZip, inputs)
 	}
 	if err != nil {
 		return "", err
 	}
-	request.Header.Add("Content-Type", "application/zip")
+	if rollback {
+		request.URL.RawQuery = "rollback"
+	}
 	response, err := client.Do(request)
 	if err != nil {
 		return "", err
@@ -96,70 +96,76 @@ func StreamsEvents(client *httputil.YorcClient, deploymentID string, colorize, f
 			fmt.Fprint(os.Stderr, "Failed to get latest events index from Yorc, events will appear from the beginning.")
 		}
 	}
-	for {
+	if !stop {
+		resourcePath := "/events/stream"
 		if deploymentID != "" {
-			request, err = client.NewRequest("GET", fmt.Sprintf("/deployments/%s/events?index=%d", deploymentID, lastIdx), nil)
-		} else {
-			request, err = client.NewRequest("GET", fmt.Sprintf("/events?index=%d", lastIdx), nil)
-		}
-		if err != nil {
-			httputil.ErrExit(err)
-		}
-		request.Header.Add("Accept", "application/json")
-		response, err := client.Do(request)
-		if err != nil {
-			httputil.ErrExit(err)
-		}
-		if deploymentID != "" {
-			httputil.HandleHTTPStatusCode(response, deploymentID, "deployment", http.StatusOK)
+			resourcePath = "/deployments/" + deploymentID + "/events/stream"
 		}
-
-		var evts rest.EventsCollection
-		body, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			httputil.ErrExit(err)
-		}
-		err = json.Unmarshal(body, &evts)
-		if err != nil {
-			httputil.ErrExit(err)
-		}
-		if lastIdx == evts.LastIndex {
-			continue
-		}
-		lastIdx = evts.LastIndex
-		for _, event := range evts.Events {
-			ts := event.Timestamp
-			if colorize {
-				ts = color.CyanString("%s", event.Timestamp)
-			}
-			evType, err := events.StatusUpdateTypeString(event.Type)
-			if err != nil {
-				if colorize {
-					fmt.Printf("%s: ", color.MagentaString("Warning"))
-				} else {
-					fmt.Print("Warning: ")
-				}
-				fmt.Printf("Unknown event type: %q\n", event.Type)
-			}
-			switch evType {
-			case events.InstanceStatusChangeType:
-				fmt.Printf("%s:\t Deployment: %s\t Node: %s\t Instance: %s\t State: %s\n", ts, event.DeploymentID, event.Node, event.Instance, event.Status)
-			case events.DeploymentStatusChangeType:
-				fmt.Printf("%s:\t Deployment: %s\t Deployment Status: %s\n", ts, event.DeploymentID, event.Status)
-			case events.CustomCommandStatusChangeType:
-				fmt.Printf("%s:\t Deployment: %s\t Task %q (custom command)\t Status: %s\n", ts, event.DeploymentID, event.TaskID, event.Status)
-			case events.ScalingStatusChangeType:
-				fmt.Printf("%s:\t Deployment: %s\t Task %q (scaling)\t Status: %s\n", ts, event.DeploymentID, event.TaskID, event.Status)
-			case events.WorkflowStatusChangeType:
-				fmt.Printf("%s:\t Deployment: %s\t Task %q (workflow)\t Status: %s\n", ts, event.DeploymentID, event.TaskID, event.Status)
+		streamServerSentEvents(client, resourcePath, deploymentID, lastIdx, func(_ string, data []byte) {
+			var event events.StatusUpdate
+			if err := json.Unmarshal(data, &event); err != nil {
+				httputil.ErrExit(err)
 			}
+			printEvent(event, colorize)
+		})
+		return
+	}
+	if deploymentID != "" {
+		request, err = client.NewRequest("GET", fmt.Sprintf("/deployments/%s/events?index=%d", deploymentID, lastIdx), nil)
+	} else {
+		request, err = client.NewRequest("GET", fmt.Sprintf("/events?index=%d", lastIdx), nil)
+	}
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	request.Header.Add("Accept", "application/json")
+	response, err = client.Do(request)
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	defer response.Body.Close()
+	if deploymentID != "" {
+		httputil.HandleHTTPStatusCode(response, deploymentID, "deployment", http.StatusOK)
+	}
 
-		}
-
-		response.Body.Close()
+	var evts rest.EventsCollection
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	err = json.Unmarshal(body, &evts)
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	for _, event := range evts.Events {
+		printEvent(event, colorize)
+	}
+}
 
-		if stop {
-			return
+func printEvent(event events.StatusUpdate, colorize bool) {
+	ts := event.Timestamp
+	if colorize {
+		ts = color.CyanString("%s", event.Timestamp)
+	}
+	evType, err := events.StatusUpdateTypeString(event.Type)
+	if err != nil {
+		if colorize {
+			fmt.Printf("%s: ", color.MagentaString("Warning"))
+		} else {
+			fmt.Print("Warning: ")
 		}
+		fmt.Printf("Unknown event type: %q\n", event.Type)
+	}
+	switch evType {
+	case events.InstanceStatusChangeType:
+		fmt.Printf("%s:\t Deployment: %s\t Node: %s\t Instance: %s\t State: %s\n", ts, event.DeploymentID, event.Node, event.Instance, event.Status)
+	case events.DeploymentStatusChangeType:
+		fmt.Printf("%s:\t Deployment: %s\t Deployment Status: %s\n", ts, event.DeploymentID, event.Status)
+	case events.CustomCommandStatusChangeType:
+		fmt.Printf("%s:\t Deployment: %s\t Task %q (custom command)\t Status: %s\n", ts, event.DeploymentID, event.TaskID, event.Status)
+	case events.ScalingStatusChangeType:
+		fmt.Printf("%s:\t Deployment: %s\t Task %q (scaling)\t Status: %s\n", ts, event.DeploymentID, event.TaskID, event.Status)
+	case events.WorkflowStatusChangeType:
+		fmt.Printf("%s:\t Deployment: %s\t Task %q (workflow)\t Status: %s\n", ts, event.DeploymentID, event.TaskID, event.Status)
 	}
 }
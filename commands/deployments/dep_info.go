@@ -310,7 +310,7 @@ func GetColoredTaskStatus(colorize bool, status string) string {
 		return color.New(color.FgHiRed, color.Bold).SprintFunc()(status)
 	case strings.ToLower(status) == "done":
 		return color.New(color.FgHiGreen, color.Bold).SprintFunc()(status)
-	case strings.ToLower(status) == "initial":
+	case strings.ToLower(status) == "initial", strings.ToLower(status) == "queued":
 		return color.New(color.Bold).SprintFunc()(status)
 	default:
 		return color.New(color.FgHiYellow, color.Bold).SprintFunc()(status)
@@ -97,50 +97,53 @@ func StreamsLogs(client *httputil.YorcClient, deploymentID string, colorize, fro
 			fmt.Fprint(os.Stderr, "Failed to get latest log index from Yorc, logs will appear from the beginning.")
 		}
 	}
-	var filtersParam string
-	for {
+	if !stop {
+		resourcePath := "/logs/stream"
 		if deploymentID != "" {
-			request, err = client.NewRequest("GET", fmt.Sprintf("/deployments/%s/logs?index=%d%s", deploymentID, lastIdx, filtersParam), nil)
-		} else {
-			request, err = client.NewRequest("GET", fmt.Sprintf("/logs?index=%d%s", lastIdx, filtersParam), nil)
-		}
-		if err != nil {
-			httputil.ErrExit(err)
-		}
-		request.Header.Add("Accept", "application/json")
-		response, err := client.Do(request)
-		if err != nil {
-			httputil.ErrExit(err)
-		}
-
-		if deploymentID != "" {
-			httputil.HandleHTTPStatusCode(response, deploymentID, "deployment", http.StatusOK)
-		}
-
-		var logs rest.LogsCollection
-		body, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			httputil.ErrExit(err)
-		}
-		err = json.Unmarshal(body, &logs)
-		if err != nil {
-			httputil.ErrExit(err)
-		}
-
-		lastIdx = logs.LastIndex
-		for _, log := range logs.Logs {
-			if colorize {
-				fmt.Printf("%s\n", color.CyanString("%s", format(log)))
-			} else {
-				fmt.Printf("%s\n", format(log))
-			}
+			resourcePath = "/deployments/" + deploymentID + "/logs/stream"
 		}
+		streamServerSentEvents(client, resourcePath, deploymentID, lastIdx, func(_ string, data []byte) {
+			printLog(data, colorize)
+		})
+		return
+	}
+	if deploymentID != "" {
+		request, err = client.NewRequest("GET", fmt.Sprintf("/deployments/%s/logs?index=%d", deploymentID, lastIdx), nil)
+	} else {
+		request, err = client.NewRequest("GET", fmt.Sprintf("/logs?index=%d", lastIdx), nil)
+	}
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	request.Header.Add("Accept", "application/json")
+	response, err = client.Do(request)
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	defer response.Body.Close()
+	if deploymentID != "" {
+		httputil.HandleHTTPStatusCode(response, deploymentID, "deployment", http.StatusOK)
+	}
 
-		response.Body.Close()
+	var logs rest.LogsCollection
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	err = json.Unmarshal(body, &logs)
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	for _, log := range logs.Logs {
+		printLog(log, colorize)
+	}
+}
 
-		if stop {
-			return
-		}
+func printLog(log json.RawMessage, colorize bool) {
+	if colorize {
+		fmt.Printf("%s\n", color.CyanString("%s", format(log)))
+	} else {
+		fmt.Printf("%s\n", format(log))
 	}
 }
 
@@ -33,6 +33,8 @@ func init() {
 	var shouldStreamEvents bool
 	var nodeName string
 	var instancesDelta int32
+	var rollback bool
+	var queue bool
 	var scaleCmd = &cobra.Command{
 		Use:   "scale <id>",
 		Short: "Scale a node",
@@ -56,7 +58,7 @@ func init() {
 			}
 			deploymentID := args[0]
 
-			location, err := postScalingRequest(client, deploymentID, nodeName, instancesDelta)
+			location, err := postScalingRequest(client, deploymentID, nodeName, instancesDelta, rollback, queue)
 			if err != nil {
 				return err
 			}
@@ -76,10 +78,12 @@ func init() {
 	scaleCmd.PersistentFlags().Int32VarP(&instancesDelta, "delta", "d", 0, "The non-zero number of instance to add (if > 0) or remove (if < 0).")
 	scaleCmd.PersistentFlags().BoolVarP(&shouldStreamLogs, "stream-logs", "l", false, "Stream logs after issuing the scaling request. In this mode logs can't be filtered, to use this feature see the \"log\" command.")
 	scaleCmd.PersistentFlags().BoolVarP(&shouldStreamEvents, "stream-events", "e", false, "Stream events after  issuing the scaling request.")
+	scaleCmd.PersistentFlags().BoolVarP(&rollback, "rollback", "", false, "If adding instances fails, uninstall and remove the new instances and restore the previous deployment status.")
+	scaleCmd.PersistentFlags().BoolVarP(&queue, "queue", "", false, "If other tasks are running or queued on the deployment, queue the scaling request instead of rejecting it. Instances to add or remove are computed when the scaling actually starts.")
 	DeploymentsCmd.AddCommand(scaleCmd)
 }
 
-func postScalingRequest(client *httputil.YorcClient, deploymentID, nodeName string, instancesDelta int32) (string, error) {
+func postScalingRequest(client *httputil.YorcClient, deploymentID, nodeName string, instancesDelta int32, rollback, queue bool) (string, error) {
 	request, err := client.NewRequest("POST", path.Join("/deployments", deploymentID, "scale", nodeName), nil)
 	if err != nil {
 		httputil.ErrExit(errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg))
@@ -87,6 +91,12 @@ func postScalingRequest(client *httputil.YorcClient, deploymentID, nodeName stri
 
 	query := request.URL.Query()
 	query.Set("delta", strconv.Itoa(int(instancesDelta)))
+	if rollback {
+		query.Set("rollback", "")
+	}
+	if queue {
+		query.Set("queue", "")
+	}
 
 	request.URL.RawQuery = query.Encode()
 
@@ -0,0 +1,103 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ystia/yorc/commands/httputil"
+)
+
+// maxServerSentEventSize is the maximum size of a streamed message, log entries may be up to 512KB
+const maxServerSentEventSize = 1024 * 1024
+
+// streamServerSentEvents reads messages of a text/event-stream resource after a given index and calls handle for each of them
+//
+// The stream is resumed from the last received message if the server closes the connection.
+func streamServerSentEvents(client *httputil.YorcClient, resourcePath, deploymentID string, index uint64, handle func(event string, data []byte)) {
+	for {
+		request, err := client.NewRequest("GET", fmt.Sprintf("%s?index=%d", resourcePath, index), nil)
+		if err != nil {
+			httputil.ErrExit(err)
+		}
+		request.Header.Add("Accept", "text/event-stream")
+		response, err := client.Do(request)
+		if err != nil {
+			httputil.ErrExit(err)
+		}
+		if deploymentID != "" {
+			httputil.HandleHTTPStatusCode(response, deploymentID, "deployment", http.StatusOK)
+		}
+		index, err = readServerSentEvents(response.Body, index, func(event string, data []byte) {
+			if event == "error" {
+				var msg string
+				json.Unmarshal(data, &msg)
+				httputil.ErrExit(errors.Errorf("streaming failed: %s", msg))
+			}
+			handle(event, data)
+		})
+		response.Body.Close()
+		if err != nil {
+			httputil.ErrExit(err)
+		}
+	}
+}
+
+// readServerSentEvents reads messages from a text/event-stream body until its end and returns the last received id
+func readServerSentEvents(r io.Reader, index uint64, handle func(event string, data []byte)) (uint64, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxServerSentEventSize)
+	var event string
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				handle(event, data)
+			}
+			event, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// Comment used as keep-alive
+			continue
+		}
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+		case "id":
+			if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+				index = id
+			}
+		}
+	}
+	return index, scanner.Err()
+}
@@ -26,6 +26,7 @@ import (
 
 func init() {
 	var purge bool
+	var queue bool
 	var shouldStreamLogs bool
 	var shouldStreamEvents bool
 	var undeployCmd = &cobra.Command{
@@ -41,15 +42,18 @@ func init() {
 				httputil.ErrExit(err)
 			}
 
-			url := "/deployments/" + args[0]
-			if purge {
-				url = url + "?purge=true"
-			}
-
-			request, err := client.NewRequest("DELETE", url, nil)
+			request, err := client.NewRequest("DELETE", "/deployments/"+args[0], nil)
 			if err != nil {
 				httputil.ErrExit(err)
 			}
+			query := request.URL.Query()
+			if purge {
+				query.Set("purge", "true")
+			}
+			if queue {
+				query.Set("queue", "")
+			}
+			request.URL.RawQuery = query.Encode()
 
 			request.Header.Add("Accept", "application/json")
 			response, err := client.Do(request)
@@ -74,6 +78,7 @@ func init() {
 
 	DeploymentsCmd.AddCommand(undeployCmd)
 	undeployCmd.PersistentFlags().BoolVarP(&purge, "purge", "p", false, "To use if you want to purge instead of undeploy")
+	undeployCmd.PersistentFlags().BoolVarP(&queue, "queue", "", false, "If other tasks are running or queued on the deployment, queue the undeployment instead of rejecting it.")
 	undeployCmd.PersistentFlags().BoolVarP(&shouldStreamLogs, "stream-logs", "l", false, "Stream logs after undeploying the application. In this mode logs can't be filtered, to use this feature see the \"log\" command.")
 	undeployCmd.PersistentFlags().BoolVarP(&shouldStreamEvents, "stream-events", "e", false, "Stream events after undeploying the CSAR.")
 
@@ -0,0 +1,101 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/commands/httputil"
+)
+
+func init() {
+	var shouldStreamLogs bool
+	var shouldStreamEvents bool
+	var updateOperation string
+	var inputsFile string
+	var inputs []string
+	var rollback bool
+	var updateCmd = &cobra.Command{
+		Use:   "update <deployment_id> <csar_path>",
+		Short: "Update a deployed application",
+		Long: `Update a deployed application with a new version of its CSAR pointed by <csar_path>
+	<csar_path> is handled like for the deploy command.
+	Nodes added to the topology are installed, removed nodes are uninstalled and the update operation
+	is called on nodes whose definition changed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.Errorf("Expecting a deployment id and a path to a file or directory (got %d parameters)", len(args))
+			}
+			if shouldStreamLogs && shouldStreamEvents {
+				return errors.Errorf("You can't provide stream-events and stream-logs flags at same time")
+			}
+			client, err := httputil.GetClient(ClientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			deploymentID := args[0]
+			csarZip, err := getCSARZip(args[1])
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			inputsDoc, err := readInputs(inputsFile, inputs)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			request, err := newCSARRequest(client, http.MethodPut, path.Join("/deployments", deploymentID), csarZip, inputsDoc)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			query := url.Values{}
+			if updateOperation != "" {
+				query.Set("update_operation", updateOperation)
+			}
+			if rollback {
+				query.Set("rollback", "")
+			}
+			request.URL.RawQuery = query.Encode()
+			response, err := client.Do(request)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			defer response.Body.Close()
+			httputil.HandleHTTPStatusCode(response, deploymentID, "deployment", http.StatusCreated, http.StatusNoContent)
+			if response.StatusCode == http.StatusNoContent {
+				fmt.Printf("No changes in the topology nor in the inputs of deployment %s, nothing to update\n", deploymentID)
+				return nil
+			}
+			fmt.Printf("Update submitted. Deployment Id: %s\t(Update Task Id: %s)\n", deploymentID, path.Base(response.Header.Get("Location")))
+			if shouldStreamLogs {
+				StreamsLogs(client, deploymentID, !NoColor, true, false)
+			} else if shouldStreamEvents {
+				StreamsEvents(client, deploymentID, !NoColor, true, false)
+			}
+			return nil
+		},
+	}
+	updateCmd.PersistentFlags().StringVarP(&updateOperation, "operation", "o", "", "Operation called on nodes whose definition changed (defaults to Standard.configure)")
+	updateCmd.PersistentFlags().BoolVarP(&shouldStreamLogs, "stream-logs", "l", false, "Stream logs after submitting the update. In this mode logs can't be filtered, to use this feature see the \"log\" command.")
+	updateCmd.PersistentFlags().BoolVarP(&shouldStreamEvents, "stream-events", "e", false, "Stream events after submitting the update.")
+	updateCmd.PersistentFlags().StringArrayVarP(&inputs, "input", "", nil, "New value of a topology input given as key=value. This flag may be repeated and overrides values of the inputs file.")
+	updateCmd.PersistentFlags().StringVarP(&inputsFile, "inputs-file", "", "", "Path to a YAML or JSON file defining new topology inputs values.")
+	updateCmd.PersistentFlags().BoolVarP(&rollback, "rollback", "", false, "If the update fails, uninstall the node instances it created or modified, restore the previous deployment definition and install again the nodes it removed.")
+	DeploymentsCmd.AddCommand(updateCmd)
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/commands/httputil"
+	"github.com/ystia/yorc/deployments"
+)
+
+func init() {
+	var inputsFile string
+	var inputs []string
+	var validateCmd = &cobra.Command{
+		Use:   "validate <csar_path>",
+		Short: "Validate a CSAR without deploying it",
+		Long: `Validate a CSAR pointed by <csar_path> without storing nor deploying anything.
+	<csar_path> is handled like for the deploy command.
+	Errors and warnings found in the archive are printed and the command exits with a non-zero status
+	if the archive is not valid.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.Errorf("Expecting a path to a file or directory (got %d parameters)", len(args))
+			}
+			client, err := httputil.GetClient(ClientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			csarZip, err := getCSARZip(args[0])
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			inputsDoc, err := readInputs(inputsFile, inputs)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			request, err := newCSARRequest(client, http.MethodPost, "/deployments/validate", csarZip, inputsDoc)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			request.Header.Add("Accept", "application/json")
+			response, err := client.Do(request)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			defer response.Body.Close()
+			httputil.HandleHTTPStatusCode(response, args[0], "deployment", http.StatusOK)
+			body, err := ioutil.ReadAll(response.Body)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			var report deployments.ValidationReport
+			if err = json.Unmarshal(body, &report); err != nil {
+				httputil.ErrExit(err)
+			}
+			printValidationReport(report, !NoColor)
+			if !report.Valid {
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+	validateCmd.PersistentFlags().StringArrayVarP(&inputs, "input", "", nil, "Value of a topology input given as key=value. This flag may be repeated and overrides values of the inputs file.")
+	validateCmd.PersistentFlags().StringVarP(&inputsFile, "inputs-file", "", "", "Path to a YAML or JSON file defining topology inputs values.")
+	DeploymentsCmd.AddCommand(validateCmd)
+}
+
+func printValidationReport(report deployments.ValidationReport, colorize bool) {
+	errorPrefix, warningPrefix := "ERROR", "WARNING"
+	if colorize {
+		errorPrefix = color.New(color.FgHiRed, color.Bold).SprintFunc()(errorPrefix)
+		warningPrefix = color.New(color.FgHiYellow, color.Bold).SprintFunc()(warningPrefix)
+	}
+	for _, issue := range report.Errors {
+		fmt.Printf("%s\t%s: %s\n", errorPrefix, issue.Location, issue.Message)
+	}
+	for _, issue := range report.Warnings {
+		fmt.Printf("%s\t%s: %s\n", warningPrefix, issue.Location, issue.Message)
+	}
+	if report.Valid {
+		fmt.Printf("CSAR is valid (%d warning(s))\n", len(report.Warnings))
+		return
+	}
+	fmt.Printf("CSAR is not valid: %d error(s), %d warning(s)\n", len(report.Errors), len(report.Warnings))
+}
@@ -32,7 +32,7 @@ var cancelTaskCmd = &cobra.Command{
 	Use:   "cancel <DeploymentId> <TaskId>",
 	Short: "Cancel a deployment task",
 	Long: `Cancel a task specifying the deployment id and the task id.
-	The task should be in status "INITIAL" or "RUNNING" to be canceled.`,
+	The task should be in status "INITIAL", "RUNNING" or "QUEUED" to be canceled.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) != 2 {
 			return errors.Errorf("Expecting a deployment id and a task id (got %d parameters)", len(args))
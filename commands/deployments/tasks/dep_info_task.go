@@ -137,7 +137,7 @@ func getColoredTaskStepStatus(colorize bool, status string) string {
 		return status
 	}
 	switch {
-	case strings.ToLower(status) == "error":
+	case strings.ToLower(status) == "error", strings.ToLower(status) == "timeout":
 		return color.New(color.FgHiRed, color.Bold).SprintFunc()(status)
 	case strings.ToLower(status) == "canceled":
 		return color.New(color.FgHiYellow, color.Bold).SprintFunc()(status)
@@ -18,8 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"os"
-	"path"
+	"time"
 
 	"github.com/ystia/yorc/helper/tabutil"
 	"github.com/ystia/yorc/rest"
@@ -42,7 +41,8 @@ var tasksCmd = &cobra.Command{
 	Use:   "tasks <DeploymentId>",
 	Short: "List tasks of a deployment",
 	Long: `Display info about the tasks related to a given deployment.
-    It prints the tasks ID, type and status.`,
+    It prints the tasks ID, type, status and creation date ordered by creation date.
+    Tasks waiting for previous tasks to complete are in status "QUEUED".`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) != 1 {
 			return errors.Errorf("Expecting a deployment id (got %d parameters)", len(args))
@@ -52,10 +52,7 @@ var tasksCmd = &cobra.Command{
 			httputil.ErrExit(err)
 		}
 		colorize := !deployments.NoColor
-		if colorize {
-			commErrorMsg = color.New(color.FgHiRed, color.Bold).SprintFunc()(commErrorMsg)
-		}
-		request, err := client.NewRequest("GET", "/deployments/"+args[0], nil)
+		request, err := client.NewRequest("GET", "/deployments/"+args[0]+"/tasks", nil)
 		if err != nil {
 			httputil.ErrExit(err)
 		}
@@ -66,12 +63,12 @@ var tasksCmd = &cobra.Command{
 		}
 		defer response.Body.Close()
 		httputil.HandleHTTPStatusCode(response, args[0], "deployment", http.StatusOK)
-		var dep rest.Deployment
+		var tasksCol rest.DeploymentTasksCollection
 		body, err := ioutil.ReadAll(response.Body)
 		if err != nil {
 			httputil.ErrExit(err)
 		}
-		err = json.Unmarshal(body, &dep)
+		err = json.Unmarshal(body, &tasksCol)
 		if err != nil {
 			httputil.ErrExit(err)
 		}
@@ -80,27 +77,11 @@ var tasksCmd = &cobra.Command{
 		}
 		fmt.Println("Tasks:")
 		tasksTable := tabutil.NewTable()
-		tasksTable.AddHeaders("Id", "Type", "Status")
-		errs := make([]error, 0)
-		for _, atomLink := range dep.Links {
-			if atomLink.Rel == rest.LinkRelTask {
-				var task rest.Task
-				err = httputil.GetJSONEntityFromAtomGetRequest(client, atomLink, &task)
-				if err != nil {
-					errs = append(errs, err)
-					tasksTable.AddRow(path.Base(atomLink.Href), commErrorMsg)
-					continue
-				}
-				tasksTable.AddRow(task.ID, task.Type, deployments.GetColoredTaskStatus(colorize, task.Status))
-			}
+		tasksTable.AddHeaders("Id", "Type", "Status", "Created")
+		for _, task := range tasksCol.Tasks {
+			tasksTable.AddRow(task.ID, task.Type, deployments.GetColoredTaskStatus(colorize, task.Status), task.CreationDate.Format(time.RFC3339))
 		}
 		fmt.Println(tasksTable.Render())
-		if len(errs) > 0 {
-			fmt.Fprintln(os.Stderr, "\n\nErrors encountered:")
-			for _, err := range errs {
-				fmt.Fprintln(os.Stderr, "###################\n", err)
-			}
-		}
 		return nil
 	},
 }
@@ -30,6 +30,7 @@ func init() {
 	var shouldStreamLogs bool
 	var shouldStreamEvents bool
 	var continueOnError bool
+	var queue bool
 	var workflowName string
 	var wfExecCmd = &cobra.Command{
 		Use:     "execute <id>",
@@ -47,13 +48,18 @@ func init() {
 				return errors.New("Missing mandatory \"workflow-name\" parameter")
 			}
 			url := fmt.Sprintf("/deployments/%s/workflows/%s", args[0], workflowName)
-			if continueOnError {
-				url = url + "?continueOnError"
-			}
 			request, err := client.NewRequest("POST", url, nil)
 			if err != nil {
 				httputil.ErrExit(err)
 			}
+			query := request.URL.Query()
+			if continueOnError {
+				query.Set("continueOnError", "")
+			}
+			if queue {
+				query.Set("queue", "")
+			}
+			request.URL.RawQuery = query.Encode()
 			request.Header.Add("Content-Type", "application/json")
 			response, err := client.Do(request)
 			defer response.Body.Close()
@@ -76,6 +82,7 @@ func init() {
 	}
 	wfExecCmd.PersistentFlags().StringVarP(&workflowName, "workflow-name", "w", "", "The workflows name")
 	wfExecCmd.PersistentFlags().BoolVarP(&continueOnError, "continue-on-error", "", false, "By default if an error occurs in a step of a workflow then other running steps are cancelled and the workflow is stopped. This flag allows to continue to the next steps even if an error occurs.")
+	wfExecCmd.PersistentFlags().BoolVarP(&queue, "queue", "", false, "If other tasks are running or queued on the deployment, queue the workflow execution instead of rejecting it.")
 	wfExecCmd.PersistentFlags().BoolVarP(&shouldStreamLogs, "stream-logs", "l", false, "Stream logs after triggering a workflow. In this mode logs can't be filtered, to use this feature see the \"log\" command.")
 	wfExecCmd.PersistentFlags().BoolVarP(&shouldStreamEvents, "stream-events", "e", false, "Stream events after triggering a workflow.")
 	workflowsCmd.AddCommand(wfExecCmd)
@@ -0,0 +1,145 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflows
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/commands/deployments"
+	"github.com/ystia/yorc/commands/httputil"
+	"github.com/ystia/yorc/scheduling"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Perform commands on scheduled workflows and custom commands",
+	Long: `Allow to schedule recurring executions of workflows and custom commands of a deployment, to list, update
+and delete these schedules.`,
+	Aliases: []string{"schedules", "sched"},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := cmd.Help()
+		if err != nil {
+			fmt.Print(err)
+		}
+	},
+}
+
+func init() {
+	workflowsCmd.AddCommand(scheduleCmd)
+
+	var schedule scheduling.Schedule
+	var inputs []string
+	var overlapPolicy string
+	var createCmd = &cobra.Command{
+		Use:   "create <id>",
+		Short: "Schedule recurring executions of a workflow or of a custom command of deployment <id>",
+		Long: `Schedules recurring executions of a workflow or of a custom command of a deployment.
+Occurrences are defined by a standard 5 fields cron expression (minutes, hours, day of month, month and day of week),
+a descriptor (@yearly, @monthly, @weekly, @daily or @hourly) or an "@every <duration>" expression.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.Errorf("Expecting an id (got %d parameters)", len(args))
+			}
+			schedule.OverlapPolicy = scheduling.OverlapPolicy(overlapPolicy)
+			location, err := submitSchedule("POST", fmt.Sprintf("/deployments/%s/schedules", args[0]), schedule, inputs, args[0], http.StatusCreated)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Schedule created. path :", location)
+			return nil
+		},
+	}
+	addScheduleFlags(createCmd, &schedule, &inputs, &overlapPolicy)
+	scheduleCmd.AddCommand(createCmd)
+
+	var updatedSchedule scheduling.Schedule
+	var updatedInputs []string
+	var updatedOverlapPolicy string
+	var updateCmd = &cobra.Command{
+		Use:   "update <id> <schedule_id>",
+		Short: "Replace the definition of a schedule of deployment <id>",
+		Long: `Replaces the definition of a schedule of a deployment.
+Next occurrences are computed from the time of the update.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.Errorf("Expecting a deployment id and a schedule id (got %d parameters)", len(args))
+			}
+			updatedSchedule.OverlapPolicy = scheduling.OverlapPolicy(updatedOverlapPolicy)
+			_, err := submitSchedule("PUT", fmt.Sprintf("/deployments/%s/schedules/%s", args[0], args[1]), updatedSchedule, updatedInputs, args[1], http.StatusOK)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Schedule updated.")
+			return nil
+		},
+	}
+	addScheduleFlags(updateCmd, &updatedSchedule, &updatedInputs, &updatedOverlapPolicy)
+	scheduleCmd.AddCommand(updateCmd)
+}
+
+func addScheduleFlags(cmd *cobra.Command, schedule *scheduling.Schedule, inputs *[]string, overlapPolicy *string) {
+	cmd.Flags().StringVarP(&schedule.Cron, "cron", "", "", "Cron expression, descriptor or \"@every <duration>\" expression defining occurrences (ex: \"0 2 * * *\" or \"@daily\")")
+	cmd.Flags().StringVarP(&schedule.TimeZone, "time-zone", "", "", "Time zone used to compute occurrences (ex: \"Europe/Paris\"). Defaults to UTC")
+	cmd.Flags().StringVarP(&schedule.WorkflowName, "workflow-name", "w", "", "The name of the workflow to execute")
+	cmd.Flags().BoolVarP(&schedule.ContinueOnError, "continue-on-error", "", false, "Continue to the next steps of the workflow even if an error occurs.")
+	cmd.Flags().StringVarP(&schedule.NodeName, "node", "n", "", "The name of the node of the custom command to execute")
+	cmd.Flags().StringVarP(&schedule.CustomCommandName, "custom", "c", "", "The name of the custom command to execute")
+	cmd.Flags().StringArrayVarP(inputs, "input", "", nil, "Value of an input given as key=value. This flag may be repeated.")
+	cmd.Flags().StringVarP(overlapPolicy, "overlap-policy", "", string(scheduling.OverlapSkip), "What to do when an occurrence fires while another task is running on the deployment: \"skip\" it or \"queue\" it until running tasks end")
+}
+
+// submitSchedule sends a schedule definition and returns the location of the schedule
+func submitSchedule(method, url string, schedule scheduling.Schedule, inputs []string, resourceID string, expectedStatus int) (string, error) {
+	if schedule.Cron == "" {
+		return "", errors.New("Missing mandatory \"cron\" parameter")
+	}
+	if len(inputs) > 0 {
+		schedule.Inputs = make(map[string]string, len(inputs))
+	}
+	for _, input := range inputs {
+		keyValue := strings.SplitN(input, "=", 2)
+		if len(keyValue) != 2 {
+			return "", errors.Errorf("invalid input %q, expecting key=value", input)
+		}
+		schedule.Inputs[keyValue[0]] = keyValue[1]
+	}
+	client, err := httputil.GetClient(deployments.ClientConfig)
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	body, err := json.Marshal(schedule)
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	request, err := client.NewRequest(method, url, bytes.NewBuffer(body))
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	request.Header.Add("Content-Type", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	defer response.Body.Close()
+	httputil.HandleHTTPStatusCode(response, resourceID, "deployment/schedule", expectedStatus)
+	return response.Header.Get("Location"), nil
+}
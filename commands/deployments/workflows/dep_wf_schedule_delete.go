@@ -0,0 +1,56 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflows
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/commands/deployments"
+	"github.com/ystia/yorc/commands/httputil"
+)
+
+func init() {
+	var deleteCmd = &cobra.Command{
+		Use:   "delete <id> <schedule_id> [schedule_id...]",
+		Short: "Delete schedules of a given deployment <id>",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.Errorf("Expecting a deployment id and at least a schedule id (got %d parameters)", len(args))
+			}
+			client, err := httputil.GetClient(deployments.ClientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			for _, id := range args[1:] {
+				request, err := client.NewRequest("DELETE", fmt.Sprintf("/deployments/%s/schedules/%s", args[0], id), nil)
+				if err != nil {
+					httputil.ErrExit(err)
+				}
+				response, err := client.Do(request)
+				if err != nil {
+					httputil.ErrExit(err)
+				}
+				httputil.HandleHTTPStatusCode(response, id, "schedule", http.StatusOK)
+				response.Body.Close()
+			}
+			return nil
+		},
+	}
+	scheduleCmd.AddCommand(deleteCmd)
+}
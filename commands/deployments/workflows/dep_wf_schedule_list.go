@@ -0,0 +1,109 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/commands/deployments"
+	"github.com/ystia/yorc/commands/httputil"
+	"github.com/ystia/yorc/helper/tabutil"
+	"github.com/ystia/yorc/rest"
+	"github.com/ystia/yorc/scheduling"
+)
+
+func init() {
+	var listCmd = &cobra.Command{
+		Use:     "list <id>",
+		Short:   "List schedules of a given deployment <id>",
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.Errorf("Expecting an id (got %d parameters)", len(args))
+			}
+			client, err := httputil.GetClient(deployments.ClientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			request, err := client.NewRequest("GET", fmt.Sprintf("/deployments/%s/schedules", args[0]), nil)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			request.Header.Add("Accept", "application/json")
+			response, err := client.Do(request)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			defer response.Body.Close()
+			httputil.HandleHTTPStatusCode(response, args[0], "schedules", http.StatusOK)
+			body, err := ioutil.ReadAll(response.Body)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			var col rest.SchedulesCollection
+			if err = json.Unmarshal(body, &col); err != nil {
+				httputil.ErrExit(err)
+			}
+			table := tabutil.NewTable()
+			table.AddHeaders("ID", "Cron", "Time Zone", "Target", "Inputs", "Overlap Policy", "Last Occurrence", "Next Occurrence", "Last Task", "Pending")
+			for _, s := range col.Schedules {
+				addScheduleRow(table, s)
+			}
+			fmt.Println("Schedules:")
+			fmt.Println(table.Render())
+			return nil
+		},
+	}
+	scheduleCmd.AddCommand(listCmd)
+}
+
+// addScheduleRow adds a schedule to a table
+func addScheduleRow(table tabutil.Table, s scheduling.Schedule) {
+	target := "workflow " + s.WorkflowName
+	if s.CustomCommandName != "" {
+		target = fmt.Sprintf("custom command %s on node %s", s.CustomCommandName, s.NodeName)
+	}
+	inputs := make([]string, 0, len(s.Inputs))
+	for k, v := range s.Inputs {
+		inputs = append(inputs, k+"="+v)
+	}
+	sort.Strings(inputs)
+	var lastOccurrence, nextOccurrence, lastTask, pending string
+	if s.Status != nil {
+		lastOccurrence = formatOccurrence(s.Status.LastOccurrence)
+		nextOccurrence = formatOccurrence(s.Status.NextOccurrence)
+		lastTask = s.Status.LastTaskID
+		if s.Status.Pending {
+			pending = "yes"
+		}
+	}
+	table.AddRow(s.ID, s.Cron, s.TimeZone, target, strings.Join(inputs, ","), string(s.OverlapPolicy), lastOccurrence, nextOccurrence, lastTask, pending)
+}
+
+func formatOccurrence(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -37,6 +37,7 @@ func init() {
 	var host string
 	var port uint64
 	var labels []string
+	var discover bool
 
 	var addCmd = &cobra.Command{
 		Use:   "add <hostname>",
@@ -92,6 +93,9 @@ func init() {
 
 			httputil.HandleHTTPStatusCode(response, args[0], "host pool", http.StatusCreated)
 			fmt.Println("Command submitted. path :", response.Header.Get("Location"))
+			if discover {
+				discoverHosts(client, args)
+			}
 			return nil
 		},
 	}
@@ -102,6 +106,7 @@ func init() {
 	addCmd.Flags().StringVarP(&privateKey, "key", "k", "", "Need to provide a private key or a password for the host pool")
 	addCmd.Flags().StringVarP(&password, "password", "p", "", "Need to provide a private key or a password for the host pool")
 	addCmd.Flags().StringSliceVarP(&labels, "label", "", nil, "Label in form 'key=value' to add to the host. May be specified several time.")
+	addCmd.Flags().BoolVarP(&discover, "discover", "", false, "Discover the host hardware once added and maintain the corresponding labels.")
 
 	hostsPoolCmd.AddCommand(addCmd)
 }
@@ -0,0 +1,135 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostspool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/ystia/yorc/commands/httputil"
+	"github.com/ystia/yorc/helper/tabutil"
+	"github.com/ystia/yorc/rest"
+)
+
+func init() {
+	var filters []string
+	allocationsCmd := &cobra.Command{
+		Use:   "allocations",
+		Short: "List hosts pool allocations",
+		Long: `Lists allocations of the hosts of the hosts pool managed by this Yorc cluster.
+
+Allocations that will never be released by their owner could be force-released using the "release" sub-command.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := httputil.GetClient(clientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			request, err := client.NewRequest("GET", "/hosts_pool", nil)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			q := request.URL.Query()
+			for i := range filters {
+				q.Add("filter", filters[i])
+			}
+			request.URL.RawQuery = q.Encode()
+			request.Header.Add("Accept", "application/json")
+			response, err := client.Do(request)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			defer response.Body.Close()
+			httputil.HandleHTTPStatusCode(response, "", "host pool", http.StatusOK, http.StatusNoContent)
+			if response.StatusCode == http.StatusNoContent {
+				fmt.Println("No allocations")
+				return nil
+			}
+			var hostsColl rest.HostsCollection
+			body, err := ioutil.ReadAll(response.Body)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			err = json.Unmarshal(body, &hostsColl)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+
+			allocationsTable := tabutil.NewTable()
+			allocationsTable.AddHeaders("Host", "Allocation ID", "Deployment", "Node", "Instance", "Shareable", "Lease Expiration")
+			for _, hostLink := range hostsColl.Hosts {
+				if hostLink.Rel != rest.LinkRelHost {
+					continue
+				}
+				var host rest.Host
+				err = httputil.GetJSONEntityFromAtomGetRequest(client, hostLink, &host)
+				if err != nil {
+					httputil.ErrExit(err)
+				}
+				for _, alloc := range host.Allocations {
+					var leaseExpiration string
+					if alloc.LeaseTTL > 0 {
+						leaseExpiration = alloc.LeaseExpiration.Format(time.RFC3339)
+					}
+					allocationsTable.AddRow(host.Name, alloc.ID, alloc.DeploymentID, alloc.NodeName, alloc.Instance, strconv.FormatBool(alloc.Shareable), leaseExpiration)
+				}
+			}
+			fmt.Println("Hosts pool allocations:")
+			fmt.Println(allocationsTable.Render())
+			return nil
+		},
+	}
+	allocationsCmd.Flags().StringSliceVarP(&filters, "filter", "f", nil, "Filter hosts based on their labels. May be specified several time, filters are joined by a logical 'and'. See the documentation for the filters grammar.")
+
+	releaseCmd := &cobra.Command{
+		Use:   "release <hostname> <allocation_id> [allocation_id...]",
+		Short: "Force-release hosts pool allocations",
+		Long: `Releases allocations of a host of the hosts pool managed by this Yorc cluster whatever their owner is.
+
+Resources consumed by those allocations are given back to the host. This is intended to clean up allocations
+that will never be released by their deployment.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.Errorf("Expecting a hostname and at least one allocation ID (got %d parameters)", len(args))
+			}
+			client, err := httputil.GetClient(clientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			for _, allocationID := range args[1:] {
+				request, err := client.NewRequest("DELETE", "/hosts_pool/"+url.PathEscape(args[0])+"/allocations/"+url.PathEscape(allocationID), nil)
+				if err != nil {
+					httputil.ErrExit(err)
+				}
+
+				response, err := client.Do(request)
+				if err != nil {
+					httputil.ErrExit(err)
+				}
+				httputil.HandleHTTPStatusCode(response, allocationID, "host pool allocation", http.StatusOK)
+				response.Body.Close()
+			}
+			return nil
+		},
+	}
+	allocationsCmd.AddCommand(releaseCmd)
+	hostsPoolCmd.AddCommand(allocationsCmd)
+}
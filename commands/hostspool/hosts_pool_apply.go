@@ -40,6 +40,7 @@ import (
 
 func init() {
 	var autoApprove bool
+	var discover bool
 	var applyCmd = &cobra.Command{
 		Use:   "apply <path to Hosts Pool configuration>",
 		Short: "Apply a Hosts Pool configuration",
@@ -146,11 +147,13 @@ func init() {
 						// This is an update
 						//  Check if there is any change before registering the
 						// need to update
-						if host.Connection != newDef.Connection ||
-							!reflect.DeepEqual(host.Labels, newDef.Labels) {
+						// Discovered labels are kept unless they are redefined
+						newLabels := withDiscoveredLabels(&host, newDef.Labels)
+						if !reflect.DeepEqual(host.Connection, newDef.Connection) ||
+							!reflect.DeepEqual(host.Labels, newLabels) {
 							update = true
 							hostsImpacted = append(hostsImpacted, host.Name)
-							addUpdateRows(hostsToUpdateTable, colorize, &host, &rest.Host{Host: hostspool.Host{Connection: newDef.Connection, Labels: newDef.Labels}})
+							addUpdateRows(hostsToUpdateTable, colorize, &host, &rest.Host{Host: hostspool.Host{Connection: newDef.Connection, Labels: newLabels}})
 						}
 
 						// This host is now computed, removing it from the map
@@ -166,8 +169,10 @@ func init() {
 			}
 
 			// Hosts left in newPoolMap are hosts to create
+			hostsCreated := make(map[string]bool, len(newPoolMap))
 			for _, host := range newPoolMap {
 				creation = true
+				hostsCreated[host.Name] = true
 				hostsImpacted = append(hostsImpacted, host.Name)
 				addRow(hostsToCreateTable, colorize, hostCreation, &rest.Host{Host: hostspool.Host{Name: host.Name, Connection: host.Connection, Labels: host.Labels}}, false)
 			}
@@ -262,6 +267,7 @@ func init() {
 			// Verify the status of each updated/new host and log
 			// connection failures
 			connectionFailure := false
+			var hostsToDiscover []string
 			hostsTable := tabutil.NewTable()
 			hostsTable.AddHeaders(
 				"Name", "Connection", "Status", "Message")
@@ -293,6 +299,8 @@ func init() {
 				if host.Status == hostspool.HostStatusError {
 					connectionFailure = true
 					addHostInErrorRow(hostsTable, colorize, hostError, &host)
+				} else if discover && hostsCreated[name] {
+					hostsToDiscover = append(hostsToDiscover, name)
 				}
 			}
 			fmt.Println("New hosts pool configuration applied successfully.")
@@ -301,12 +309,18 @@ func init() {
 				fmt.Println("")
 				fmt.Println(hostsTable.Render())
 			}
+			if len(hostsToDiscover) > 0 {
+				sort.Strings(hostsToDiscover)
+				discoverHosts(client, hostsToDiscover)
+			}
 
 			return nil
 		},
 	}
 	applyCmd.PersistentFlags().BoolVarP(&autoApprove, "auto-approve", "", false,
 		"Skip interactive approval before applying this new Hosts Pool configuration.")
+	applyCmd.PersistentFlags().BoolVarP(&discover, "discover", "", false,
+		"Discover the hardware of created hosts and maintain the corresponding labels.")
 	hostsPoolCmd.AddCommand(applyCmd)
 }
 
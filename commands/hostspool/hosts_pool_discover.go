@@ -0,0 +1,123 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostspool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/ystia/yorc/commands/httputil"
+	"github.com/ystia/yorc/helper/tabutil"
+	"github.com/ystia/yorc/rest"
+)
+
+func init() {
+	discoverCmd := &cobra.Command{
+		Use:   "discover <hostname> [hostname...]",
+		Short: "Discover hardware of hosts pool hosts",
+		Long: `Connects to hosts of the hosts pool managed by this Yorc cluster to discover their hardware
+(CPUs, memory, disk, architecture, OS and GPUs) and maintains the corresponding labels.
+
+Labels defined by users are never overridden by discovered ones.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.Errorf("Expecting at least one hostname (got %d parameters)", len(args))
+			}
+			client, err := httputil.GetClient(clientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			discoverHosts(client, args)
+			return nil
+		},
+	}
+	hostsPoolCmd.AddCommand(discoverCmd)
+}
+
+// discoverHosts runs the hardware discovery of the given hosts and prints labels they got
+func discoverHosts(client *httputil.YorcClient, hostnames []string) {
+	labelsTable := tabutil.NewTable()
+	labelsTable.AddHeaders("Host", "Label", "Value")
+	for _, hostname := range hostnames {
+		request, err := client.NewRequest("POST", "/hosts_pool/"+url.PathEscape(hostname)+"/discover", nil)
+		if err != nil {
+			httputil.ErrExit(err)
+		}
+		request.Header.Add("Accept", "application/json")
+		response, err := client.Do(request)
+		if err != nil {
+			httputil.ErrExit(err)
+		}
+		httputil.HandleHTTPStatusCode(response, hostname, "host pool", http.StatusOK)
+		body, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			httputil.ErrExit(err)
+		}
+		var discovery rest.HostDiscovery
+		err = json.Unmarshal(body, &discovery)
+		if err != nil {
+			httputil.ErrExit(err)
+		}
+		names := make([]string, 0, len(discovery.Labels))
+		for k := range discovery.Labels {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			labelsTable.AddRow(hostname, k, discovery.Labels[k])
+		}
+	}
+	fmt.Println("Discovered labels:")
+	fmt.Println(labelsTable.Render())
+}
+
+// withDiscoveredLabels returns the given labels completed by labels discovered on a host that they do not redefine
+func withDiscoveredLabels(host *rest.Host, labels map[string]string) map[string]string {
+	if len(host.DiscoveredLabels) == 0 {
+		return labels
+	}
+	merged := make(map[string]string, len(labels)+len(host.DiscoveredLabels))
+	for _, k := range host.DiscoveredLabels {
+		if v, ok := host.Labels[k]; ok {
+			merged[k] = v
+		}
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return merged
+}
+
+// withoutDiscoveredLabels returns labels of a host that were not discovered
+func withoutDiscoveredLabels(host *rest.Host) map[string]string {
+	if len(host.DiscoveredLabels) == 0 {
+		return host.Labels
+	}
+	labels := make(map[string]string, len(host.Labels))
+	for k, v := range host.Labels {
+		labels[k] = v
+	}
+	for _, k := range host.DiscoveredLabels {
+		delete(labels, k)
+	}
+	return labels
+}
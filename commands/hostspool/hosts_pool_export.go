@@ -73,7 +73,8 @@ func init() {
 					host := rest.HostConfig{
 						Name:       restHost.Name,
 						Connection: restHost.Connection,
-						Labels:     restHost.Labels,
+						// Discovered labels are maintained by Yorc
+						Labels: withoutDiscoveredLabels(&restHost),
 					}
 					pool.Hosts = append(pool.Hosts, host)
 				}
@@ -113,17 +113,53 @@ func GetClient(cc config.Client) (*YorcClient, error) {
 		}
 		return &YorcClient{
 			baseURL: "https://" + yorcAPI,
-			Client:  &http.Client{Transport: tr},
+			Client:  &http.Client{Transport: withCredentials(tr, cc)},
 		}, nil
 	}
 
 	return &YorcClient{
 		baseURL: "http://" + yorcAPI,
-		Client:  &http.Client{},
+		Client:  &http.Client{Transport: withCredentials(http.DefaultTransport, cc)},
 	}, nil
 
 }
 
+// credentialsTransport is an http.RoundTripper adding a bearer token and a tenant to requests
+type credentialsTransport struct {
+	token  string
+	tenant string
+	base   http.RoundTripper
+}
+
+func (t *credentialsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrip should not modify the original request
+	r := new(http.Request)
+	*r = *req
+	if t.token != "" {
+		r.Header = make(http.Header, len(req.Header)+1)
+		for k, v := range req.Header {
+			r.Header[k] = v
+		}
+		r.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	if t.tenant != "" && req.URL.Query().Get("tenant") == "" {
+		u := *req.URL
+		q := u.Query()
+		q.Set("tenant", t.tenant)
+		u.RawQuery = q.Encode()
+		r.URL = &u
+	}
+	return t.base.RoundTrip(r)
+}
+
+// withCredentials wraps a transport to send the credentials and the tenant defined in the client configuration
+func withCredentials(tr http.RoundTripper, cc config.Client) http.RoundTripper {
+	if cc.Token == "" && cc.Tenant == "" {
+		return tr
+	}
+	return &credentialsTransport{token: cc.Token, tenant: cc.Tenant, base: tr}
+}
+
 // HandleHTTPStatusCode handles Yorc HTTP status code and displays error if needed
 func HandleHTTPStatusCode(response *http.Response, resourceID string, resourceType string, expectedStatusCodes ...int) {
 	HandleHTTPStatusCodeWithCustomizedErrorMessage(
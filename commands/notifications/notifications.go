@@ -0,0 +1,61 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ystia/yorc/commands"
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/helper/tabutil"
+	"github.com/ystia/yorc/notifications"
+)
+
+func init() {
+	commands.RootCmd.AddCommand(notificationsCmd)
+	commands.ConfigureYorcClientCommand(notificationsCmd, notifViper, &cfgFile, &noColor)
+}
+
+var notifViper = viper.New()
+var clientConfig config.Client
+
+var noColor bool
+var cfgFile string
+
+var notificationsCmd = &cobra.Command{
+	Use:           "notifications",
+	Aliases:       []string{"notif", "notifs", "n"},
+	Short:         "Perform commands on webhooks notifications",
+	Long:          `Allow to subscribe webhooks to events notifications, to list and delete subscriptions and to check undelivered notifications`,
+	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		clientConfig = commands.GetYorcClientConfig(notifViper, cfgFile)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := cmd.Help()
+		if err != nil {
+			fmt.Print(err)
+		}
+	},
+}
+
+// addSubscriptionRow adds a subscription to a table
+func addSubscriptionRow(table tabutil.Table, s notifications.Subscription) {
+	table.AddRow(s.ID, s.URL, s.Tenant, strings.Join(s.Deployments, ","), strings.Join(s.Types, ","), strings.Join(s.Statuses, ","), strings.Join(s.Nodes, ","))
+}
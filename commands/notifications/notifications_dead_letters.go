@@ -0,0 +1,92 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/commands/httputil"
+	"github.com/ystia/yorc/helper/tabutil"
+	"github.com/ystia/yorc/rest"
+)
+
+func init() {
+	var purge bool
+	var deadLettersCmd = &cobra.Command{
+		Use:     "dead-letters <subscription_id>",
+		Aliases: []string{"dl"},
+		Short:   "List notifications that could not be delivered",
+		Long:    `Lists notifications of a subscription that could not be delivered after all retries, or purges them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.Errorf("Expecting a subscription id (got %d parameters)", len(args))
+			}
+			client, err := httputil.GetClient(clientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			resourcePath := "/notifications/subscriptions/" + args[0] + "/dead_letters"
+			if purge {
+				request, err := client.NewRequest("DELETE", resourcePath, nil)
+				if err != nil {
+					httputil.ErrExit(err)
+				}
+				response, err := client.Do(request)
+				if err != nil {
+					httputil.ErrExit(err)
+				}
+				defer response.Body.Close()
+				httputil.HandleHTTPStatusCode(response, args[0], "subscription", http.StatusOK)
+				return nil
+			}
+			request, err := client.NewRequest("GET", resourcePath, nil)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			request.Header.Add("Accept", "application/json")
+			response, err := client.Do(request)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			defer response.Body.Close()
+			httputil.HandleHTTPStatusCode(response, args[0], "dead letters", http.StatusOK)
+			body, err := ioutil.ReadAll(response.Body)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			var col rest.DeadLettersCollection
+			if err = json.Unmarshal(body, &col); err != nil {
+				httputil.ErrExit(err)
+			}
+			table := tabutil.NewTable()
+			table.AddHeaders("Timestamp", "Deployment", "Type", "Node", "Instance", "Task", "Status", "Attempts", "Error")
+			for _, dl := range col.DeadLetters {
+				e := dl.Notification.Event
+				table.AddRow(dl.Timestamp, e.DeploymentID, e.Type, e.Node, e.Instance, e.TaskID, e.Status, dl.Attempts, dl.Error)
+			}
+			fmt.Println("Dead letters:")
+			fmt.Println(table.Render())
+			return nil
+		},
+	}
+	deadLettersCmd.Flags().BoolVarP(&purge, "purge", "", false, "Delete notifications that could not be delivered instead of listing them")
+	notificationsCmd.AddCommand(deadLettersCmd)
+}
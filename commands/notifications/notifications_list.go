@@ -0,0 +1,70 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/commands/httputil"
+	"github.com/ystia/yorc/helper/tabutil"
+	"github.com/ystia/yorc/rest"
+)
+
+func init() {
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List webhooks subscriptions",
+		Long:  `Lists webhooks subscribed to events notifications.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := httputil.GetClient(clientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			request, err := client.NewRequest("GET", "/notifications/subscriptions", nil)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			request.Header.Add("Accept", "application/json")
+			response, err := client.Do(request)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			defer response.Body.Close()
+			httputil.HandleHTTPStatusCode(response, "", "subscriptions", http.StatusOK)
+			body, err := ioutil.ReadAll(response.Body)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			var col rest.SubscriptionsCollection
+			if err = json.Unmarshal(body, &col); err != nil {
+				httputil.ErrExit(err)
+			}
+			table := tabutil.NewTable()
+			table.AddHeaders("ID", "URL", "Tenant", "Deployments", "Types", "Statuses", "Nodes")
+			for _, s := range col.Subscriptions {
+				addSubscriptionRow(table, s)
+			}
+			fmt.Println("Subscriptions:")
+			fmt.Println(table.Render())
+			return nil
+		},
+	}
+	notificationsCmd.AddCommand(listCmd)
+}
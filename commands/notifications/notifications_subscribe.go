@@ -0,0 +1,72 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/commands/httputil"
+	"github.com/ystia/yorc/notifications"
+)
+
+func init() {
+	var subscription notifications.Subscription
+	var subscribeCmd = &cobra.Command{
+		Use:   "subscribe <webhook_url>",
+		Short: "Subscribe a webhook to events notifications",
+		Long: `Registers a webhook notified of deployments, instances and tasks status changes.
+Notifications are signed using the given secret, see the documentation for details.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.Errorf("Expecting a webhook URL (got %d parameters)", len(args))
+			}
+			client, err := httputil.GetClient(clientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			subscription.URL = args[0]
+			body, err := json.Marshal(subscription)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			request, err := client.NewRequest("POST", "/notifications/subscriptions", bytes.NewBuffer(body))
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			request.Header.Add("Content-Type", "application/json")
+			response, err := client.Do(request)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			defer response.Body.Close()
+			httputil.HandleHTTPStatusCode(response, "", "subscription", http.StatusCreated)
+			fmt.Println("Subscription created. path :", response.Header.Get("Location"))
+			return nil
+		},
+	}
+	subscribeCmd.Flags().StringVarP(&subscription.Secret, "secret", "s", "", "Secret used to sign notifications")
+	subscribeCmd.Flags().StringVarP(&subscription.Tenant, "subscription_tenant", "", "", "Only notify events of deployments of this tenant. Defaults to all tenants or to your own tenant if you belong to one")
+	subscribeCmd.Flags().StringSliceVarP(&subscription.Deployments, "deployment", "d", nil, "Only notify events of this deployment. May be specified several time")
+	subscribeCmd.Flags().StringSliceVarP(&subscription.Types, "type", "t", nil, "Only notify events of this type (instance, deployment, custom-command, scaling or workflow). May be specified several time")
+	subscribeCmd.Flags().StringSliceVarP(&subscription.Statuses, "status", "", nil, "Only notify events with this status (for instance 'failed' or 'deployment_failed'). May be specified several time")
+	subscribeCmd.Flags().StringSliceVarP(&subscription.Nodes, "node", "n", nil, "Only notify events concerning this node. May be specified several time")
+	notificationsCmd.AddCommand(subscribeCmd)
+}
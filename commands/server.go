@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -47,6 +48,7 @@ var ansibleConfiguration = map[string]interface{}{
 	"ansible.connection_retries":         5,
 	"ansible.operation_remote_base_dir":  ".yorc",
 	"ansible.keep_operation_remote_path": config.DefaultKeepOperationRemotePath,
+	"ansible.operation_timeout":          time.Duration(0),
 }
 
 var consulConfiguration = map[string]interface{}{
@@ -193,6 +195,7 @@ func setConfig() {
 	serverCmd.PersistentFlags().StringP("resources_prefix", "x", "", "Prefix created resources (like Computes and so on)")
 	serverCmd.PersistentFlags().Duration("wf_step_graceful_termination_timeout", config.DefaultWfStepGracefulTerminationTimeout, "Timeout to wait for a graceful termination of a workflow step during concurrent workflow step failure. After this delay the step is set on error.")
 	serverCmd.PersistentFlags().String("server_id", config.DefaultServerID, "The server ID used to identify the server node in a cluster.")
+	serverCmd.PersistentFlags().Duration("operation_timeout", 0, "Default timeout of operations that do not define their own timeout and which executor does not define a default one. A zero value means no timeout.")
 
 	// Flags definition for Yorc HTTP REST API
 	serverCmd.PersistentFlags().Int("http_port", config.DefaultHTTPPort, "Port number for the Yorc HTTP REST API. If omitted or set to '0' then the default port number is used, any positive integer will be used as it, and finally any negative value will let use a random port.")
@@ -221,6 +224,7 @@ func setConfig() {
 	serverCmd.PersistentFlags().Int("ansible_connection_retries", 5, "Number of retries in case of Ansible SSH connection failure")
 	serverCmd.PersistentFlags().String("operation_remote_base_dir", ".yorc", "Name of the temporary directory used by Ansible on the nodes")
 	serverCmd.PersistentFlags().Bool("keep_operation_remote_path", config.DefaultKeepOperationRemotePath, "Define wether the path created to store artifacts on the nodes will be removed at the end of workflow executions.")
+	serverCmd.PersistentFlags().Duration("ansible_operation_timeout", 0, "Default timeout of operations executed by Ansible that do not define their own timeout. A zero value means that the server-wide operation_timeout applies.")
 
 	//Bind Consul persistent flags
 	for key := range consulConfiguration {
@@ -235,6 +239,7 @@ func setConfig() {
 	viper.BindPFlag("resources_prefix", serverCmd.PersistentFlags().Lookup("resources_prefix"))
 	viper.BindPFlag("wf_step_graceful_termination_timeout", serverCmd.PersistentFlags().Lookup("wf_step_graceful_termination_timeout"))
 	viper.BindPFlag("server_id", serverCmd.PersistentFlags().Lookup("server_id"))
+	viper.BindPFlag("operation_timeout", serverCmd.PersistentFlags().Lookup("operation_timeout"))
 
 	//Bind Flags Yorc HTTP REST API
 	viper.BindPFlag("http_port", serverCmd.PersistentFlags().Lookup("http_port"))
@@ -273,6 +278,7 @@ func setConfig() {
 	}
 
 	viper.BindEnv("wf_step_graceful_termination_timeout")
+	viper.BindEnv("operation_timeout")
 
 	//Bind Ansible environment variables flags
 	for key := range ansibleConfiguration {
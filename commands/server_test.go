@@ -18,6 +18,7 @@ import (
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -186,6 +187,7 @@ func TestConfigFile(t *testing.T) {
 				ConnectionRetries:       11,
 				OperationRemoteBaseDir:  "test_base_dir2",
 				KeepOperationRemotePath: true,
+				OperationTimeout:        2 * time.Hour,
 			},
 			ConsulConfig: config.Consul{
 				Token:          "testToken2",
@@ -267,6 +269,7 @@ func TestAnsibleEnvVariables(t *testing.T) {
 		ConnectionRetries:       12,
 		OperationRemoteBaseDir:  "testEnvBaseDir",
 		KeepOperationRemotePath: true,
+		OperationTimeout:        30 * time.Minute,
 	}
 
 	// Set Ansible configuration environment ariables
@@ -275,6 +278,7 @@ func TestAnsibleEnvVariables(t *testing.T) {
 	os.Setenv("YORC_ANSIBLE_CONNECTION_RETRIES", strconv.Itoa(expectedAnsibleConfig.ConnectionRetries))
 	os.Setenv("YORC_OPERATION_REMOTE_BASE_DIR", expectedAnsibleConfig.OperationRemoteBaseDir)
 	os.Setenv("YORC_KEEP_OPERATION_REMOTE_PATH", strconv.FormatBool(expectedAnsibleConfig.KeepOperationRemotePath))
+	os.Setenv("YORC_ANSIBLE_OPERATION_TIMEOUT", expectedAnsibleConfig.OperationTimeout.String())
 
 	testResetConfig()
 	setConfig()
@@ -289,6 +293,7 @@ func TestAnsibleEnvVariables(t *testing.T) {
 	os.Unsetenv("YORC_ANSIBLE_CONNECTION_RETRIES")
 	os.Unsetenv("YORC_OPERATION_REMOTE_BASE_DIR")
 	os.Unsetenv("YORC_KEEP_OPERATION_REMOTE_PATH")
+	os.Unsetenv("YORC_ANSIBLE_OPERATION_TIMEOUT")
 }
 
 // Tests Consul configuration using environment variables
@@ -348,6 +353,7 @@ func TestAnsiblePersistentFlags(t *testing.T) {
 		ConnectionRetries:       15,
 		OperationRemoteBaseDir:  "testPFlagBaseDir",
 		KeepOperationRemotePath: true,
+		OperationTimeout:        time.Hour,
 	}
 
 	ansiblePFlagConfiguration := map[string]string{
@@ -356,6 +362,7 @@ func TestAnsiblePersistentFlags(t *testing.T) {
 		"ansible_connection_retries": strconv.Itoa(expectedAnsibleConfig.ConnectionRetries),
 		"operation_remote_base_dir":  expectedAnsibleConfig.OperationRemoteBaseDir,
 		"keep_operation_remote_path": strconv.FormatBool(expectedAnsibleConfig.KeepOperationRemotePath),
+		"ansible_operation_timeout":  expectedAnsibleConfig.OperationTimeout.String(),
 	}
 
 	testResetConfig()
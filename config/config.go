@@ -56,6 +56,18 @@ const DefaultWfStepGracefulTerminationTimeout = 2 * time.Minute
 // DefaultServerID is the default server ID used to identify the server node in a cluster
 const DefaultServerID = "server_0"
 
+// DefaultNotificationsMaxRetries is the default number of retries of a failed webhook notification delivery
+const DefaultNotificationsMaxRetries = 5
+
+// DefaultNotificationsRetryInitialBackoff is the default delay before the first retry of a failed webhook notification delivery
+const DefaultNotificationsRetryInitialBackoff = time.Second
+
+// DefaultNotificationsRetryMaxBackoff is the default maximum delay between two retries of a failed webhook notification delivery
+const DefaultNotificationsRetryMaxBackoff = 30 * time.Second
+
+// DefaultNotificationsTimeout is the default timeout of a webhook notification request
+const DefaultNotificationsTimeout = 10 * time.Second
+
 // Configuration holds config information filled by Cobra and Viper (see commands package for more information)
 type Configuration struct {
 	Ansible                          Ansible               `mapstructure:"ansible"`
@@ -76,7 +88,13 @@ type Configuration struct {
 	Infrastructures                  map[string]DynamicMap `mapstructure:"infrastructures"`
 	Vault                            DynamicMap            `mapstructure:"vault"`
 	WfStepGracefulTerminationTimeout time.Duration         `mapstructure:"wf_step_graceful_termination_timeout"`
+	OperationTimeout                 time.Duration         `mapstructure:"operation_timeout"`
 	ServerID                         string                `mapstructure:"server_id"`
+	Auth                             Auth                  `mapstructure:"auth"`
+	Tenants                          map[string]Tenant     `mapstructure:"tenants"`
+	Notifications                    Notifications         `mapstructure:"notifications"`
+	Tasks                            Tasks                 `mapstructure:"tasks"`
+	ExecutionsLimits                 ExecutionsLimits      `mapstructure:"executions_limits"`
 }
 
 // DockerSandbox holds the configuration for a docker sandbox
@@ -110,6 +128,7 @@ type Ansible struct {
 	OperationRemoteBaseDir  string           `mapstructure:"operation_remote_base_dir"`
 	KeepOperationRemotePath bool             `mapstructure:"keep_operation_remote_path"`
 	HostedOperations        HostedOperations `mapstructure:"hosted_operations"`
+	OperationTimeout        time.Duration    `mapstructure:"operation_timeout"`
 }
 
 // Consul configuration
@@ -136,6 +155,91 @@ type Telemetry struct {
 	DisableGoRuntimeMetrics bool   `mapstructure:"disable_go_runtime_metrics"`
 }
 
+// Auth holds the configuration of the REST API authentication and authorization
+//
+// Authentication is disabled if no authentication method is configured.
+type Auth struct {
+	// Tokens are static bearer tokens
+	Tokens []AuthToken `mapstructure:"tokens"`
+	// ClientCertificates allows to identify users by the common name of their TLS client certificate
+	ClientCertificates bool `mapstructure:"client_certificates"`
+	// OIDC allows to authenticate users using JSON Web Tokens issued by an OpenID Connect provider
+	OIDC OIDC `mapstructure:"oidc"`
+	// UsersRoles defines the roles of users indexed by user names
+	UsersRoles map[string][]string `mapstructure:"users_roles"`
+	// UsersTenants defines the tenant of users indexed by user names
+	UsersTenants map[string]string `mapstructure:"users_tenants"`
+}
+
+// AuthToken defines a static bearer token and the user it identifies
+type AuthToken struct {
+	Token  string   `mapstructure:"token"`
+	User   string   `mapstructure:"user"`
+	Roles  []string `mapstructure:"roles"`
+	Tenant string   `mapstructure:"tenant"`
+}
+
+// OIDC holds the configuration for validating JSON Web Tokens issued by an OpenID Connect provider
+type OIDC struct {
+	Issuer      string `mapstructure:"issuer"`
+	Audience    string `mapstructure:"audience"`
+	JWKSFile    string `mapstructure:"jwks_file"`
+	JWKSURL     string `mapstructure:"jwks_url"`
+	UserClaim   string `mapstructure:"user_claim"`
+	RolesClaim  string `mapstructure:"roles_claim"`
+	TenantClaim string `mapstructure:"tenant_claim"`
+}
+
+// IsEnabled checks if an OIDC key set is configured
+func (o OIDC) IsEnabled() bool {
+	return o.JWKSFile != "" || o.JWKSURL != ""
+}
+
+// IsEnabled checks if at least one authentication method is configured
+func (a Auth) IsEnabled() bool {
+	return len(a.Tokens) > 0 || a.ClientCertificates || a.OIDC.IsEnabled()
+}
+
+// Tenant holds the configuration specific to a tenant
+type Tenant struct {
+	// Infrastructures overrides the infrastructures configuration for deployments of this tenant
+	Infrastructures map[string]DynamicMap `mapstructure:"infrastructures"`
+	Quotas          TenantQuotas          `mapstructure:"quotas"`
+}
+
+// TenantQuotas limits the resources used by a tenant
+//
+// A zero value means unlimited.
+type TenantQuotas struct {
+	MaxDeployments int `mapstructure:"max_deployments"`
+	MaxInstances   int `mapstructure:"max_instances"`
+}
+
+// ForTenant returns a copy of the configuration where the infrastructures configuration
+// is overridden by the one specific to the given tenant if any
+func (cfg Configuration) ForTenant(tenant string) Configuration {
+	t, ok := cfg.Tenants[tenant]
+	if !ok || len(t.Infrastructures) == 0 {
+		return cfg
+	}
+	infras := make(map[string]DynamicMap, len(cfg.Infrastructures)+len(t.Infrastructures))
+	for infraName, infraCfg := range cfg.Infrastructures {
+		infras[infraName] = infraCfg
+	}
+	for infraName, tenantInfraCfg := range t.Infrastructures {
+		merged := make(DynamicMap)
+		for k, v := range cfg.Infrastructures[infraName] {
+			merged.Set(k, v)
+		}
+		for k, v := range tenantInfraCfg {
+			merged.Set(k, v)
+		}
+		infras[infraName] = merged
+	}
+	cfg.Infrastructures = infras
+	return cfg
+}
+
 // DynamicMap allows to store configuration parameters that are not known in advance.
 // This is particularly useful when configuration parameters may be defined in a plugin such for infrastructures.
 //
@@ -272,4 +376,46 @@ type Client struct {
 	CertFile      string `mapstructure:"cert_file"`
 	CAFile        string `mapstructure:"ca_file"`
 	CAPath        string `mapstructure:"ca_path"`
+	Token         string `mapstructure:"token"`
+	Tenant        string `mapstructure:"tenant"`
+}
+
+// Tasks holds the configuration of the dispatching of tasks to workers
+//
+// Task types are referenced by their case insensitive names (deploy, undeploy, scaleout, scalein, purge,
+// customcommand, customworkflow, query, heal, update and rollback).
+type Tasks struct {
+	// Priorities defines the priority of tasks indexed by task type, tasks with a higher priority are dispatched first.
+	// The default priority is 0.
+	Priorities map[string]int `mapstructure:"priorities"`
+	// MaxConcurrentTasks limits the number of tasks of a given type processed at the same time by all Yorc servers, indexed by task type.
+	// A zero or negative value means unlimited.
+	MaxConcurrentTasks map[string]int `mapstructure:"max_concurrent_tasks"`
+	// DisableFairSharing disables the dispatching of tasks of a same priority of the deployments having the fewest tasks being processed first.
+	// In this case tasks of a same priority are only dispatched in their creation order.
+	DisableFairSharing bool `mapstructure:"disable_fair_sharing"`
+}
+
+// ExecutionsLimits holds the limits of the number of delegates and operations executions running at the same time on all Yorc servers
+//
+// A zero or negative value means unlimited. Limits should be identical on all Yorc servers.
+type ExecutionsLimits struct {
+	// PerDeployment limits the number of executions of a deployment
+	PerDeployment int `mapstructure:"per_deployment"`
+	// PerInfrastructure limits the number of executions on an infrastructure, indexed by infrastructure name (openstack, aws, slurm, ...)
+	PerInfrastructure map[string]int `mapstructure:"per_infrastructure"`
+}
+
+// Notifications holds the configuration of the delivery of events to webhooks
+//
+// Zero values mean that defaults are used.
+type Notifications struct {
+	// MaxRetries is the number of retries of a failed delivery before recording it as a dead letter, a negative value disables retries
+	MaxRetries int `mapstructure:"max_retries"`
+	// RetryInitialBackoff is the delay before the first retry, it is doubled for each subsequent retry
+	RetryInitialBackoff time.Duration `mapstructure:"retry_initial_backoff"`
+	// RetryMaxBackoff is the maximum delay between two retries
+	RetryMaxBackoff time.Duration `mapstructure:"retry_max_backoff"`
+	// Timeout is the timeout of webhooks requests
+	Timeout time.Duration `mapstructure:"timeout"`
 }
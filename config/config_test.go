@@ -224,3 +224,32 @@ func TestHostedOperations_Format(t *testing.T) {
 		})
 	}
 }
+
+func TestConfiguration_ForTenant(t *testing.T) {
+	cfg := Configuration{
+		WorkingDirectory: "work",
+		Infrastructures: map[string]DynamicMap{
+			"openstack": {"auth_url": "http://os:5000", "tenant_name": "shared", "region": "RegionOne"},
+			"slurm":     {"url": "slurm.example.com"},
+		},
+		Tenants: map[string]Tenant{
+			"team1": {Infrastructures: map[string]DynamicMap{
+				"openstack": {"tenant_name": "team1", "password": "secret"},
+				"aws":       {"region": "eu-west-1"},
+			}},
+			"team2": {Quotas: TenantQuotas{MaxInstances: 5}},
+		},
+	}
+
+	got := cfg.ForTenant("team1")
+	assert.Equal(t, "work", got.WorkingDirectory)
+	assert.Equal(t, DynamicMap{"auth_url": "http://os:5000", "tenant_name": "team1", "region": "RegionOne", "password": "secret"}, got.Infrastructures["openstack"])
+	assert.Equal(t, DynamicMap{"url": "slurm.example.com"}, got.Infrastructures["slurm"])
+	assert.Equal(t, DynamicMap{"region": "eu-west-1"}, got.Infrastructures["aws"])
+	// Original configuration is left unchanged
+	assert.Equal(t, "shared", cfg.Infrastructures["openstack"].GetString("tenant_name"))
+	assert.Len(t, cfg.Infrastructures, 2)
+
+	assert.Equal(t, cfg.Infrastructures, cfg.ForTenant("team2").Infrastructures)
+	assert.Equal(t, cfg.Infrastructures, cfg.ForTenant("unknown").Infrastructures)
+}
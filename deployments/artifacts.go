@@ -24,6 +24,25 @@ import (
 	"github.com/ystia/yorc/helper/consulutil"
 )
 
+// ArtifactLocationLocalFile is the get_artifact location keyword letting the orchestrator choose where an artifact is delivered
+const ArtifactLocationLocalFile = "LOCAL_FILE"
+
+// An ArtifactDelivery is the delivery of an artifact on the host of an operation requested by a get_artifact function
+type ArtifactDelivery struct {
+	// InputName is the name of the operation input using the get_artifact function
+	InputName string
+	// NodeName is the name of the node holding the artifact
+	NodeName     string
+	ArtifactName string
+	// File is the path of the artifact relative to the root of the deployment archive
+	File string
+	// Location is the path where the artifact should be delivered on the operation host.
+	// If empty the artifact is delivered with the operation implementation at its path relative to the archive root.
+	Location string
+	// Remove indicates that the delivered artifact should be removed once the operation is done
+	Remove bool
+}
+
 // GetArtifactsForType returns a map of artifact name / artifact file for the given type.
 //
 // The returned artifacts paths are relative to root of the deployment archive.
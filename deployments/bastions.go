@@ -0,0 +1,81 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"strconv"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/helper/sshutil"
+)
+
+// GetInstanceBastions returns the chain of bastions used to reach a compute node instance
+//
+// Bastions are defined by the bastions attribute or property of the endpoint capability of the compute node.
+// If none is defined, the bastions defined in the configuration of the infrastructure of the compute node are used.
+// An empty chain means that the instance is directly reachable.
+func GetInstanceBastions(kv *api.KV, cfg config.Configuration, deploymentID, nodeName, instanceName string) ([]sshutil.Bastion, error) {
+	var bastions []sshutil.Bastion
+	for i := 0; ; i++ {
+		index := strconv.Itoa(i)
+		found, host, err := GetInstanceCapabilityAttribute(kv, deploymentID, nodeName, instanceName, "endpoint", "bastions", index, "host")
+		if err != nil {
+			return nil, err
+		}
+		if !found || host == "" {
+			break
+		}
+		b := sshutil.Bastion{Host: config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("bastion.host", host).(string)}
+		_, port, err := GetInstanceCapabilityAttribute(kv, deploymentID, nodeName, instanceName, "endpoint", "bastions", index, "port")
+		if err != nil {
+			return nil, err
+		}
+		if port != "" {
+			b.Port, err = strconv.Atoi(port)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid port %q for bastion %q of node %q", port, host, nodeName)
+			}
+		}
+		_, user, err := GetInstanceCapabilityAttribute(kv, deploymentID, nodeName, instanceName, "endpoint", "bastions", index, "credentials", "user")
+		if err != nil {
+			return nil, err
+		}
+		b.User = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("bastion.user", user).(string)
+		_, password, err := GetInstanceCapabilityAttribute(kv, deploymentID, nodeName, instanceName, "endpoint", "bastions", index, "credentials", "token")
+		if err != nil {
+			return nil, err
+		}
+		b.Password = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("bastion.password", password).(string)
+		_, privateKey, err := GetInstanceCapabilityAttribute(kv, deploymentID, nodeName, instanceName, "endpoint", "bastions", index, "credentials", "keys", "0")
+		if err != nil {
+			return nil, err
+		}
+		b.PrivateKey = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("bastion.privateKey", privateKey).(string)
+		bastions = append(bastions, b)
+	}
+	if len(bastions) > 0 {
+		return bastions, nil
+	}
+
+	infra, err := GetNodeInfrastructure(kv, deploymentID, nodeName)
+	if err != nil || infra == "" {
+		return nil, err
+	}
+	bastions, err = sshutil.BastionsFromConfig(cfg.Infrastructures[infra].Get("bastions"))
+	return bastions, errors.Wrapf(err, "invalid bastions configuration for infrastructure %q", infra)
+}
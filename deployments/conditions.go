@@ -0,0 +1,171 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/tosca"
+)
+
+// storeConditionClauses stores a list of condition clauses under the given prefix
+//
+// Each clause is stored under its index, nested clauses are stored under and/or/not sub-keys and assertions
+// under an assert sub-key.
+func storeConditionClauses(consulStore consulutil.ConsulStore, prefix string, conditions []tosca.ConditionClause) {
+	for i, cc := range conditions {
+		ccPrefix := path.Join(prefix, strconv.Itoa(i))
+		switch {
+		case cc.And != nil:
+			storeConditionClauses(consulStore, path.Join(ccPrefix, "and"), cc.And)
+		case cc.Or != nil:
+			storeConditionClauses(consulStore, path.Join(ccPrefix, "or"), cc.Or)
+		case cc.Not != nil:
+			storeConditionClauses(consulStore, path.Join(ccPrefix, "not"), cc.Not)
+		default:
+			for j, assertion := range cc.Assert {
+				assertionPrefix := path.Join(ccPrefix, "assert", strconv.Itoa(j))
+				consulStore.StoreConsulKeyAsString(path.Join(assertionPrefix, "attribute"), assertion.AttributeName)
+				for k, constraint := range assertion.Constraints {
+					constraintPrefix := path.Join(assertionPrefix, "constraints", strconv.Itoa(k))
+					consulStore.StoreConsulKeyAsString(path.Join(constraintPrefix, "operator"), constraint.Operator)
+					for l, value := range constraint.Values {
+						consulStore.StoreConsulKeyAsString(path.Join(constraintPrefix, "values", strconv.Itoa(l)), value)
+					}
+				}
+			}
+		}
+	}
+}
+
+// getIndexedSubKeys returns sub-keys of the given prefix that are indexes, sorted by index
+func getIndexedSubKeys(kv *api.KV, prefix string) ([]string, error) {
+	keys, _, err := kv.Keys(prefix+"/", "/", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	indexes := make([]int, 0, len(keys))
+	for _, key := range keys {
+		i, err := strconv.Atoi(path.Base(key))
+		if err != nil {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	results := make([]string, len(indexes))
+	for i, index := range indexes {
+		results[i] = path.Join(prefix, strconv.Itoa(index))
+	}
+	return results, nil
+}
+
+// ReadConditionClauses reads a list of condition clauses stored under the given prefix
+//
+// An empty list is returned if there is no condition clause at this prefix.
+func ReadConditionClauses(kv *api.KV, prefix string) ([]tosca.ConditionClause, error) {
+	ccKeys, err := getIndexedSubKeys(kv, prefix)
+	if err != nil {
+		return nil, err
+	}
+	conditions := make([]tosca.ConditionClause, 0, len(ccKeys))
+	for _, ccKey := range ccKeys {
+		cc := tosca.ConditionClause{}
+		subKeys, _, err := kv.Keys(ccKey+"/", "/", nil)
+		if err != nil {
+			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		for _, subKey := range subKeys {
+			switch path.Base(subKey) {
+			case "and":
+				cc.And, err = ReadConditionClauses(kv, path.Join(ccKey, "and"))
+			case "or":
+				cc.Or, err = ReadConditionClauses(kv, path.Join(ccKey, "or"))
+			case "not":
+				cc.Not, err = ReadConditionClauses(kv, path.Join(ccKey, "not"))
+			case "assert":
+				cc.Assert, err = readAssertions(kv, path.Join(ccKey, "assert"))
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		conditions = append(conditions, cc)
+	}
+	return conditions, nil
+}
+
+func readAssertions(kv *api.KV, prefix string) ([]tosca.AssertionDefinition, error) {
+	assertionsKeys, err := getIndexedSubKeys(kv, prefix)
+	if err != nil {
+		return nil, err
+	}
+	assertions := make([]tosca.AssertionDefinition, 0, len(assertionsKeys))
+	for _, assertionKey := range assertionsKeys {
+		kvp, _, err := kv.Get(path.Join(assertionKey, "attribute"), nil)
+		if err != nil {
+			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if kvp == nil || len(kvp.Value) == 0 {
+			return nil, errors.Errorf("Missing attribute name for assertion %q", assertionKey)
+		}
+		assertion := tosca.AssertionDefinition{AttributeName: string(kvp.Value)}
+		constraintsKeys, err := getIndexedSubKeys(kv, path.Join(assertionKey, "constraints"))
+		if err != nil {
+			return nil, err
+		}
+		for _, constraintKey := range constraintsKeys {
+			kvp, _, err = kv.Get(path.Join(constraintKey, "operator"), nil)
+			if err != nil {
+				return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+			}
+			if kvp == nil || len(kvp.Value) == 0 {
+				return nil, errors.Errorf("Missing operator for constraint %q", constraintKey)
+			}
+			constraint := tosca.ConstraintClause{Operator: string(kvp.Value)}
+			valuesKeys, err := getIndexedSubKeys(kv, path.Join(constraintKey, "values"))
+			if err != nil {
+				return nil, err
+			}
+			constraint.Values = make([]string, len(valuesKeys))
+			for i, valueKey := range valuesKeys {
+				kvp, _, err = kv.Get(valueKey, nil)
+				if err != nil {
+					return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+				}
+				if kvp != nil {
+					constraint.Values[i] = string(kvp.Value)
+				}
+			}
+			assertion.Constraints = append(assertion.Constraints, constraint)
+		}
+		assertions = append(assertions, assertion)
+	}
+	return assertions, nil
+}
+
+// AreConditionsSatisfiedForInstance checks if the given condition clauses are satisfied for a given node instance
+//
+// Attributes are resolved using GetInstanceAttribute so node states, attributes and properties may be used in conditions.
+func AreConditionsSatisfiedForInstance(kv *api.KV, deploymentID, nodeName, instanceName string, conditions []tosca.ConditionClause) (bool, error) {
+	return tosca.EvaluateConditions(conditions, func(attributeName string) (bool, string, error) {
+		return GetInstanceAttribute(kv, deploymentID, nodeName, instanceName, attributeName)
+	})
+}
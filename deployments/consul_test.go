@@ -39,6 +39,9 @@ func TestRunConsulDeploymentsPackageTests(t *testing.T) {
 		t.Run("testDeploymentNodes", func(t *testing.T) {
 			testDeploymentNodes(t, srv, kv)
 		})
+		t.Run("testGetNodeInfrastructure", func(t *testing.T) {
+			testGetNodeInfrastructure(t, srv, kv)
+		})
 		t.Run("testRequirements", func(t *testing.T) {
 			testRequirements(t, srv, kv)
 		})
@@ -66,6 +69,12 @@ func TestRunConsulDeploymentsPackageTests(t *testing.T) {
 		t.Run("testInlineWorkflow", func(t *testing.T) {
 			testInlineWorkflow(t, kv)
 		})
+		t.Run("testConditionalWorkflow", func(t *testing.T) {
+			testConditionalWorkflow(t, kv)
+		})
+		t.Run("testGroupsAndPolicies", func(t *testing.T) {
+			testGroupsAndPolicies(t, kv)
+		})
 		t.Run("testCheckCycleInNestedWorkflows", func(t *testing.T) {
 			testCheckCycleInNestedWorkflows(t, kv)
 		})
@@ -96,5 +105,17 @@ func TestRunConsulDeploymentsPackageTests(t *testing.T) {
 		t.Run("TestOperationHost", func(t *testing.T) {
 			testOperationHost(t, kv)
 		})
+		t.Run("testTenants", func(t *testing.T) {
+			testTenants(t, kv)
+		})
+		t.Run("testUpdateDeploymentDefinition", func(t *testing.T) {
+			testUpdateDeploymentDefinition(t, kv)
+		})
+		t.Run("testRestoreDeploymentDefinition", func(t *testing.T) {
+			testRestoreDeploymentDefinition(t, kv)
+		})
+		t.Run("testStoreDeploymentDefinitionWithInputs", func(t *testing.T) {
+			testStoreDeploymentDefinitionWithInputs(t, kv)
+		})
 	})
 }
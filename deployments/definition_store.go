@@ -25,6 +25,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/pkg/errors"
@@ -52,23 +53,46 @@ var consulStoreKey ctxConsulStoreKey
 
 var reg = registry.GetRegistry()
 
-// StoreDeploymentDefinition takes a defPath and parse it as a tosca.Topology then it store it in consul under
-// consulutil.DeploymentKVPrefix/deploymentID
-func StoreDeploymentDefinition(ctx context.Context, kv *api.KV, deploymentID string, defPath string) error {
+// ReadTopology parses the definition file at defPath as a tosca.Topology
+func ReadTopology(defPath string) (tosca.Topology, error) {
 	topology := tosca.Topology{}
 	definition, err := os.Open(defPath)
 	if err != nil {
-		return errors.Wrapf(err, "Failed to open definition file %q", defPath)
+		return topology, errors.Wrapf(err, "Failed to open definition file %q", defPath)
 	}
+	defer definition.Close()
 	defBytes, err := ioutil.ReadAll(definition)
 	if err != nil {
-		return errors.Wrapf(err, "Failed to open definition file %q", defPath)
+		return topology, errors.Wrapf(err, "Failed to open definition file %q", defPath)
 	}
 
 	err = yaml.Unmarshal(defBytes, &topology)
 	if err != nil {
-		return errors.Wrapf(err, "Failed to unmarshal yaml definition for file %q", defPath)
+		return topology, errors.Wrapf(err, "Failed to unmarshal yaml definition for file %q", defPath)
 	}
+	return topology, nil
+}
+
+// StoreDeploymentDefinition takes a defPath and parse it as a tosca.Topology then it store it in consul under
+// consulutil.DeploymentKVPrefix/deploymentID
+func StoreDeploymentDefinition(ctx context.Context, kv *api.KV, deploymentID string, defPath string) error {
+	return StoreDeploymentDefinitionWithInputs(ctx, kv, deploymentID, defPath, nil)
+}
+
+// StoreDeploymentDefinitionWithInputs works like StoreDeploymentDefinition and additionally stores input values
+// supplied at deployment time
+//
+// Input values are validated against the topology inputs definitions before storing anything, an error that
+// could be checked with IsInvalidInputsError is returned if they are not valid.
+func StoreDeploymentDefinitionWithInputs(ctx context.Context, kv *api.KV, deploymentID string, defPath string, inputs map[string]*tosca.ValueAssignment) error {
+	topology, err := ReadTopology(defPath)
+	if err != nil {
+		return err
+	}
+	if err = ValidateInputValues(topology, inputs); err != nil {
+		return err
+	}
+	applyInputValues(&topology, inputs)
 
 	err = storeDeployment(ctx, topology, deploymentID, filepath.Dir(defPath))
 	if err != nil {
@@ -122,6 +146,8 @@ func storeTopology(ctx context.Context, topology tosca.Topology, deploymentID, t
 		storeOutputs(ctx, topology, topologyPrefix)
 		storeSubstitutionMappings(ctx, topology, topologyPrefix)
 		storeNodes(ctx, topology, topologyPrefix, importPath, rootDefPath)
+		storeGroups(ctx, topology, topologyPrefix)
+		storePolicies(ctx, topology, topologyPrefix)
 	} else {
 		// For imported templates, storing substitution mappings if any
 		// as they contain details on service to application/node type mapping
@@ -137,13 +163,21 @@ func storeTopology(ctx context.Context, topology tosca.Topology, deploymentID, t
 	}
 	storeCapabilityTypes(ctx, topology, topologyPrefix, importPath)
 	storeArtifactTypes(ctx, topology, topologyPrefix, importPath)
+	storeGroupTypes(ctx, topology, topologyPrefix, importPath)
+	storePolicyTypes(ctx, topology, topologyPrefix, importPath)
 
 	// Detect potential cycles in inline workflows
 	if err := checkNestedWorkflows(topology); err != nil {
 		return err
 	}
+	if err := checkWorkflowsSteps(topology); err != nil {
+		return err
+	}
 
 	if isRootTopologyTemplate {
+		if err := checkGroupsAndPolicies(topology); err != nil {
+			return err
+		}
 		storeWorkflows(ctx, topology, deploymentID)
 	}
 	return nil
@@ -284,6 +318,7 @@ func storeInputs(ctx context.Context, topology tosca.Topology, topologyPrefix st
 		consulStore.StoreConsulKeyAsString(path.Join(inputPrefix, "name"), inputName)
 		consulStore.StoreConsulKeyAsString(path.Join(inputPrefix, "description"), input.Description)
 		storeValueAssignment(consulStore, path.Join(inputPrefix, "default"), input.Default)
+		storeValueAssignment(consulStore, path.Join(inputPrefix, "value"), input.Value)
 		if input.Required == nil {
 			// Required by default
 			consulStore.StoreConsulKeyAsString(path.Join(inputPrefix, "required"), "true")
@@ -543,6 +578,13 @@ func storeInterfaces(consulStore consulutil.ConsulStore, interfaces map[string]t
 				}
 				consulStore.StoreConsulKeyAsString(operationPrefix+"/implementation/operation_host", strings.ToUpper(operationDef.Implementation.OperationHost))
 			}
+			if operationDef.Implementation.Timeout != "" {
+				timeout, err := ParseOperationTimeout(operationDef.Implementation.Timeout)
+				if err != nil {
+					return errors.Wrapf(err, "invalid timeout for operation %q of interface %q", opName, intTypeName)
+				}
+				consulStore.StoreConsulKeyAsString(operationPrefix+"/implementation/timeout", timeout.String())
+			}
 		}
 	}
 	return nil
@@ -725,12 +767,91 @@ func storeArtifactTypes(ctx context.Context, topology tosca.Topology, topologyPr
 	}
 }
 
+// storeGroupTypes stores topology groups types
+func storeGroupTypes(ctx context.Context, topology tosca.Topology, topologyPrefix, importPath string) {
+	consulStore := ctx.Value(consulStoreKey).(consulutil.ConsulStore)
+	for groupTypeName, groupType := range topology.GroupTypes {
+		groupTypePrefix := path.Join(topologyPrefix, "types", groupTypeName)
+		storeCommonType(consulStore, groupType.Type, groupTypePrefix, importPath)
+		consulStore.StoreConsulKeyAsString(groupTypePrefix+"/name", groupTypeName)
+		propertiesPrefix := groupTypePrefix + "/properties"
+		for propName, propDefinition := range groupType.Properties {
+			propPrefix := propertiesPrefix + "/" + propName
+			storePropertyDefinition(ctx, propPrefix, propName, propDefinition)
+		}
+		attributesPrefix := groupTypePrefix + "/attributes"
+		for attrName, attrDefinition := range groupType.Attributes {
+			attrPrefix := attributesPrefix + "/" + attrName
+			storeAttributeDefinition(ctx, attrPrefix, attrName, attrDefinition)
+		}
+		consulStore.StoreConsulKeyAsString(groupTypePrefix+"/members", strings.Join(groupType.Members, ","))
+	}
+}
+
+// storePolicyTypes stores topology policies types
+func storePolicyTypes(ctx context.Context, topology tosca.Topology, topologyPrefix, importPath string) {
+	consulStore := ctx.Value(consulStoreKey).(consulutil.ConsulStore)
+	for policyTypeName, policyType := range topology.PolicyTypes {
+		policyTypePrefix := path.Join(topologyPrefix, "types", policyTypeName)
+		storeCommonType(consulStore, policyType.Type, policyTypePrefix, importPath)
+		consulStore.StoreConsulKeyAsString(policyTypePrefix+"/name", policyTypeName)
+		propertiesPrefix := policyTypePrefix + "/properties"
+		for propName, propDefinition := range policyType.Properties {
+			propPrefix := propertiesPrefix + "/" + propName
+			storePropertyDefinition(ctx, propPrefix, propName, propDefinition)
+		}
+		consulStore.StoreConsulKeyAsString(policyTypePrefix+"/targets", strings.Join(policyType.Targets, ","))
+	}
+}
+
+// storeGroups stores topology template groups
+func storeGroups(ctx context.Context, topology tosca.Topology, topologyPrefix string) {
+	consulStore := ctx.Value(consulStoreKey).(consulutil.ConsulStore)
+	groupsPrefix := path.Join(topologyPrefix, "groups")
+	for groupName, group := range topology.TopologyTemplate.Groups {
+		groupPrefix := path.Join(groupsPrefix, groupName)
+		consulStore.StoreConsulKeyAsString(groupPrefix+"/name", groupName)
+		consulStore.StoreConsulKeyAsString(groupPrefix+"/type", group.Type)
+		consulStore.StoreConsulKeyAsString(groupPrefix+"/description", group.Description)
+		storeStringMap(consulStore, groupPrefix+"/metadata", group.Metadata)
+		for propName, propValue := range group.Properties {
+			storeValueAssignment(consulStore, groupPrefix+"/properties/"+url.QueryEscape(propName), propValue)
+		}
+		consulStore.StoreConsulKeyAsString(groupPrefix+"/members", strings.Join(group.Members, ","))
+	}
+}
+
+// storePolicies stores topology template policies
+func storePolicies(ctx context.Context, topology tosca.Topology, topologyPrefix string) {
+	consulStore := ctx.Value(consulStoreKey).(consulutil.ConsulStore)
+	policiesPrefix := path.Join(topologyPrefix, "policies")
+	for policyName, policy := range topology.TopologyTemplate.Policies {
+		policyPrefix := path.Join(policiesPrefix, policyName)
+		consulStore.StoreConsulKeyAsString(policyPrefix+"/name", policyName)
+		consulStore.StoreConsulKeyAsString(policyPrefix+"/type", policy.Type)
+		consulStore.StoreConsulKeyAsString(policyPrefix+"/description", policy.Description)
+		storeStringMap(consulStore, policyPrefix+"/metadata", policy.Metadata)
+		for propName, propValue := range policy.Properties {
+			storeValueAssignment(consulStore, policyPrefix+"/properties/"+url.QueryEscape(propName), propValue)
+		}
+		consulStore.StoreConsulKeyAsString(policyPrefix+"/targets", strings.Join(policy.Targets, ","))
+	}
+}
+
 // storeWorkflows stores topology workflows
 func storeWorkflows(ctx context.Context, topology tosca.Topology, deploymentID string) {
 	consulStore := ctx.Value(consulStoreKey).(consulutil.ConsulStore)
 	workflowsPrefix := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "workflows")
 	for wfName, workflow := range topology.TopologyTemplate.Workflows {
 		workflowPrefix := workflowsPrefix + "/" + url.QueryEscape(wfName)
+		for i, precondition := range workflow.Preconditions {
+			preconditionPrefix := path.Join(workflowPrefix, "preconditions", strconv.Itoa(i))
+			consulStore.StoreConsulKeyAsString(preconditionPrefix+"/target", precondition.Target)
+			if precondition.TargetRelationShip != "" {
+				consulStore.StoreConsulKeyAsString(preconditionPrefix+"/target_relationship", precondition.TargetRelationShip)
+			}
+			storeConditionClauses(consulStore, preconditionPrefix+"/condition", precondition.Condition)
+		}
 		for stepName, step := range workflow.Steps {
 			stepPrefix := workflowPrefix + "/steps/" + url.QueryEscape(stepName)
 			if step.Target != "" {
@@ -742,6 +863,16 @@ func storeWorkflows(ctx context.Context, topology tosca.Topology, deploymentID s
 			if step.OperationHost != "" {
 				consulStore.StoreConsulKeyAsString(stepPrefix+"/operation_host", strings.ToUpper(step.OperationHost))
 			}
+			storeConditionClauses(consulStore, stepPrefix+"/filter", step.Filter)
+			if step.Retry != nil {
+				consulStore.StoreConsulKeyAsString(stepPrefix+"/retry/retries", strconv.Itoa(step.Retry.Retries))
+				consulStore.StoreConsulKeyAsString(stepPrefix+"/retry/delay", step.Retry.Delay)
+				consulStore.StoreConsulKeyAsString(stepPrefix+"/retry/backoff", strconv.FormatFloat(step.Retry.Backoff, 'f', -1, 64))
+				consulStore.StoreConsulKeyAsString(stepPrefix+"/retry/max_delay", step.Retry.MaxDelay)
+			}
+			if step.Timeout != "" {
+				consulStore.StoreConsulKeyAsString(stepPrefix+"/timeout", step.Timeout)
+			}
 			activitiesPrefix := stepPrefix + "/activities"
 			for actIndex, activity := range step.Activities {
 				activityPrefix := activitiesPrefix + "/" + strconv.Itoa(actIndex)
@@ -765,10 +896,64 @@ func storeWorkflows(ctx context.Context, topology tosca.Topology, deploymentID s
 				// store in consul a prefix for the next step to be executed ; this prefix is stepPrefix/next/onSuccess_value
 				consulStore.StoreConsulKeyAsString(fmt.Sprintf("%s/next/%s", stepPrefix, url.QueryEscape(next)), "")
 			}
+			for _, onFailure := range step.OnFailure {
+				// store in consul a prefix for the step to be executed on failure ; this prefix is stepPrefix/on-failure/onFailure_value
+				consulStore.StoreConsulKeyAsString(fmt.Sprintf("%s/on-failure/%s", stepPrefix, url.QueryEscape(onFailure)), "")
+			}
 		}
 	}
 }
 
+// checkWorkflowsSteps checks Yorc specific extensions of workflows steps
+func checkWorkflowsSteps(topology tosca.Topology) error {
+	for wfName, workflow := range topology.TopologyTemplate.Workflows {
+		for stepName, step := range workflow.Steps {
+			if step.Timeout != "" {
+				if _, err := time.ParseDuration(step.Timeout); err != nil {
+					return errors.Wrapf(err, "invalid timeout for step %q of workflow %q", stepName, wfName)
+				}
+			}
+			if step.Retry == nil {
+				continue
+			}
+			if step.Retry.Retries < 0 || step.Retry.Backoff < 0 {
+				return errors.Errorf("invalid retry policy for step %q of workflow %q: retries and backoff should not be negative", stepName, wfName)
+			}
+			for _, d := range []string{step.Retry.Delay, step.Retry.MaxDelay} {
+				if d == "" {
+					continue
+				}
+				if _, err := time.ParseDuration(d); err != nil {
+					return errors.Wrapf(err, "invalid retry policy for step %q of workflow %q", stepName, wfName)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkGroupsAndPolicies checks that groups members are node templates and that
+// policies targets are node templates or groups
+func checkGroupsAndPolicies(topology tosca.Topology) error {
+	for groupName, group := range topology.TopologyTemplate.Groups {
+		for _, member := range group.Members {
+			if _, ok := topology.TopologyTemplate.NodeTemplates[member]; !ok {
+				return errors.Errorf("member %q of group %q is not a node template", member, groupName)
+			}
+		}
+	}
+	for policyName, policy := range topology.TopologyTemplate.Policies {
+		for _, target := range policy.Targets {
+			_, isNode := topology.TopologyTemplate.NodeTemplates[target]
+			_, isGroup := topology.TopologyTemplate.Groups[target]
+			if !isNode && !isGroup {
+				return errors.Errorf("target %q of policy %q is neither a node template nor a group", target, policyName)
+			}
+		}
+	}
+	return nil
+}
+
 // checkNestedWorkflows detect potential cycle in all nested workflows
 func checkNestedWorkflows(topology tosca.Topology) error {
 	for wfName, workflow := range topology.TopologyTemplate.Workflows {
@@ -805,13 +990,19 @@ func createInstancesForNode(ctx context.Context, kv *api.KV, deploymentID, nodeN
 	if err != nil {
 		return err
 	}
-	createNodeInstances(consulStore, kv, nbInstances, deploymentID, nodeName)
+	err = createNodeInstances(consulStore, kv, nbInstances, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
 	ip, networkNodeName, err := checkFloattingIP(kv, deploymentID, nodeName)
 	if err != nil {
 		return err
 	}
 	if ip {
-		createNodeInstances(consulStore, kv, nbInstances, deploymentID, networkNodeName)
+		err = createNodeInstances(consulStore, kv, nbInstances, deploymentID, networkNodeName)
+		if err != nil {
+			return err
+		}
 	}
 
 	bs, bsNames, err := checkBlockStorage(kv, deploymentID, nodeName)
@@ -821,7 +1012,10 @@ func createInstancesForNode(ctx context.Context, kv *api.KV, deploymentID, nodeN
 
 	if bs {
 		for _, name := range bsNames {
-			createNodeInstances(consulStore, kv, nbInstances, deploymentID, name)
+			err = createNodeInstances(consulStore, kv, nbInstances, deploymentID, name)
+			if err != nil {
+				return err
+			}
 		}
 
 	}
@@ -1111,17 +1305,29 @@ func fixAlienBlockStorages(ctx context.Context, kv *api.KV, deploymentID, nodeNa
 
 /**
 This function create a given number of floating IP instances
+
+Existing instances of the node are kept as is, this allows to update the definition of a deployed topology.
 */
-func createNodeInstances(consulStore consulutil.ConsulStore, kv *api.KV, numberInstances uint32, deploymentID, nodeName string) {
+func createNodeInstances(consulStore consulutil.ConsulStore, kv *api.KV, numberInstances uint32, deploymentID, nodeName string) error {
 
 	nodePath := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "nodes", nodeName)
 
+	existingInstances, _, err := kv.Keys(path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", nodeName)+"/", "/", nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if len(existingInstances) > 0 {
+		consulStore.StoreConsulKeyAsString(path.Join(nodePath, "nbInstances"), strconv.Itoa(len(existingInstances)))
+		return nil
+	}
+
 	consulStore.StoreConsulKeyAsString(path.Join(nodePath, "nbInstances"), strconv.FormatUint(uint64(numberInstances), 10))
 
 	for i := uint32(0); i < numberInstances; i++ {
 		instanceName := strconv.FormatUint(uint64(i), 10)
 		createNodeInstance(kv, consulStore, deploymentID, nodeName, instanceName)
 	}
+	return nil
 }
 
 /**
@@ -1191,7 +1397,10 @@ func createMissingBlockStorageForNode(consulStore consulutil.ConsulStore, kv *ap
 	}
 
 	for _, name := range bsName {
-		createNodeInstances(consulStore, kv, nbInstances, deploymentID, name)
+		err = createNodeInstances(consulStore, kv, nbInstances, deploymentID, name)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -26,6 +26,7 @@ import (
 
 	"github.com/ystia/yorc/helper/consulutil"
 	"github.com/ystia/yorc/prov"
+	"github.com/ystia/yorc/tosca"
 )
 
 func testDefinitionStore(t *testing.T, kv *api.KV) {
@@ -961,3 +962,112 @@ func testTopologyTemplateMetadata(t *testing.T, kv *api.KV) {
 	}
 
 }
+
+func testConditionalWorkflow(t *testing.T, kv *api.KV) {
+	// t.Parallel()
+	deploymentID := strings.Replace(t.Name(), "/", "_", -1)
+	err := StoreDeploymentDefinition(context.Background(), kv, deploymentID, "testdata/conditional_workflow.yaml")
+	require.Nil(t, err)
+
+	wf, err := ReadWorkflow(kv, deploymentID, "maintenance")
+	require.Nil(t, err)
+	require.Len(t, wf.Steps, 3)
+
+	require.Len(t, wf.Preconditions, 1)
+	require.Equal(t, "Compute", wf.Preconditions[0].Target)
+	require.Len(t, wf.Preconditions[0].Condition, 1)
+	require.Len(t, wf.Preconditions[0].Condition[0].Assert, 1)
+	require.Equal(t, "state", wf.Preconditions[0].Condition[0].Assert[0].AttributeName)
+	require.Equal(t, []tosca.ConstraintClause{{Operator: "equal", Values: []string{"started"}}}, wf.Preconditions[0].Condition[0].Assert[0].Constraints)
+
+	step := wf.Steps["Compute_backup"]
+	require.Equal(t, []string{"Compute_stop"}, step.OnSuccess)
+	require.Equal(t, []string{"Compute_notify"}, step.OnFailure)
+	require.Len(t, step.Filter, 2)
+	require.Equal(t, "environment", step.Filter[0].Assert[0].AttributeName)
+	require.Equal(t, []string{"production", "staging"}, step.Filter[0].Assert[0].Constraints[0].Values)
+	require.Len(t, step.Filter[1].Or, 2)
+	require.Len(t, step.Filter[1].Or[1].Not, 1)
+	require.Equal(t, "state", step.Filter[1].Or[1].Not[0].Assert[0].AttributeName)
+
+	preconditions, err := GetWorkflowPreconditions(kv, deploymentID, "maintenance")
+	require.Nil(t, err)
+	require.Equal(t, wf.Preconditions, preconditions)
+
+	// Workflow names are escaped in Consul keys
+	preconditions, err = GetWorkflowPreconditions(kv, deploymentID, "maintenance/backup only")
+	require.Nil(t, err)
+	require.Len(t, preconditions, 1)
+	require.Equal(t, []tosca.ConstraintClause{{Operator: "equal", Values: []string{"stopped"}}}, preconditions[0].Condition[0].Assert[0].Constraints)
+
+	satisfied, err := AreConditionsSatisfiedForInstance(kv, deploymentID, "Compute", "0", step.Filter)
+	require.Nil(t, err)
+	require.True(t, satisfied)
+	satisfied, err = AreConditionsSatisfiedForInstance(kv, deploymentID, "Compute", "0", wf.Preconditions[0].Condition)
+	require.Nil(t, err)
+	require.False(t, satisfied)
+}
+
+func testGroupsAndPolicies(t *testing.T, kv *api.KV) {
+	// t.Parallel()
+	deploymentID := strings.Replace(t.Name(), "/", "_", -1)
+	err := StoreDeploymentDefinition(context.Background(), kv, deploymentID, "testdata/groups_policies.yaml")
+	require.Nil(t, err)
+
+	groups, err := GetGroups(kv, deploymentID)
+	require.Nil(t, err)
+	require.Equal(t, []string{"Servers"}, groups)
+
+	groupType, err := GetGroupType(kv, deploymentID, "Servers")
+	require.Nil(t, err)
+	require.Equal(t, "yorc.test.groups.Servers", groupType)
+	description, err := GetGroupDescription(kv, deploymentID, "Servers")
+	require.Nil(t, err)
+	require.Equal(t, "My servers", description)
+	members, err := GetGroupMembers(kv, deploymentID, "Servers")
+	require.Nil(t, err)
+	require.Equal(t, []string{"Compute1", "Compute2"}, members)
+	found, value, err := GetGroupProperty(kv, deploymentID, "Servers", "zone")
+	require.Nil(t, err)
+	require.True(t, found)
+	require.Equal(t, "zone-a", value)
+	nodeGroups, err := GetGroupsForNode(kv, deploymentID, "Compute2")
+	require.Nil(t, err)
+	require.Equal(t, []string{"Servers"}, nodeGroups)
+
+	policies, err := GetPolicies(kv, deploymentID)
+	require.Nil(t, err)
+	require.Len(t, policies, 2)
+	require.Contains(t, policies, "ScalingPolicy")
+	require.Contains(t, policies, "PlacementPolicy")
+
+	policyType, err := GetPolicyType(kv, deploymentID, "ScalingPolicy")
+	require.Nil(t, err)
+	require.Equal(t, "yorc.test.policies.Scaling", policyType)
+	found, value, err = GetPolicyMetadata(kv, deploymentID, "ScalingPolicy", "owner")
+	require.Nil(t, err)
+	require.True(t, found)
+	require.Equal(t, "ops", value)
+	targets, err := GetPolicyTargets(kv, deploymentID, "ScalingPolicy")
+	require.Nil(t, err)
+	require.Equal(t, []string{"Servers"}, targets)
+	targets, err = GetPolicyTargetsNodes(kv, deploymentID, "ScalingPolicy")
+	require.Nil(t, err)
+	require.Equal(t, []string{"Compute1", "Compute2"}, targets)
+
+	found, value, err = GetPolicyProperty(kv, deploymentID, "ScalingPolicy", "max_instances")
+	require.Nil(t, err)
+	require.True(t, found)
+	require.Equal(t, "5", value)
+	found, value, err = GetPolicyProperty(kv, deploymentID, "ScalingPolicy", "min_instances")
+	require.Nil(t, err)
+	require.True(t, found)
+	require.Equal(t, "1", value)
+	propNames, err := GetPolicyPropertiesNames(kv, deploymentID, "ScalingPolicy")
+	require.Nil(t, err)
+	require.Len(t, propNames, 2)
+
+	scalingPolicies, err := GetPoliciesForType(kv, deploymentID, "tosca.policies.Scaling")
+	require.Nil(t, err)
+	require.Equal(t, []string{"ScalingPolicy"}, scalingPolicies)
+}
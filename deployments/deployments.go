@@ -89,6 +89,19 @@ func GetDeploymentTemplateName(kv *api.KV, deploymentID string) (string, error)
 	return string(kvp.Value), nil
 }
 
+// GetDeploymentsIDs returns the IDs of all known deployments
+func GetDeploymentsIDs(kv *api.KV) ([]string, error) {
+	depPaths, _, err := kv.Keys(consulutil.DeploymentKVPrefix+"/", "/", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	ids := make([]string, len(depPaths))
+	for i, depPath := range depPaths {
+		ids[i] = path.Base(depPath)
+	}
+	return ids, nil
+}
+
 // DoesDeploymentExists checks if a given deploymentId refer to an existing deployment
 func DoesDeploymentExists(kv *api.KV, deploymentID string) (bool, error) {
 	if _, err := GetDeploymentStatus(kv, deploymentID); err != nil {
@@ -0,0 +1,198 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/helper/collections"
+	"github.com/ystia/yorc/helper/consulutil"
+)
+
+// GetGroups returns the names of the different groups for a given deployment.
+func GetGroups(kv *api.KV, deploymentID string) ([]string, error) {
+	return getTopologyTemplateElementsNames(kv, deploymentID, "groups")
+}
+
+// DoesGroupExist checks if a given group exists in a deployment
+func DoesGroupExist(kv *api.KV, deploymentID, groupName string) (bool, error) {
+	found, _, err := getTopologyTemplateElementValue(kv, deploymentID, "groups", groupName, "name")
+	return found, err
+}
+
+// GetGroupType returns the type of a given group identified by its name
+func GetGroupType(kv *api.KV, deploymentID, groupName string) (string, error) {
+	found, groupType, err := getTopologyTemplateElementValue(kv, deploymentID, "groups", groupName, "type")
+	if err != nil {
+		return "", err
+	}
+	if !found || groupType == "" {
+		return "", errors.Errorf("Missing mandatory parameter \"type\" for group %q", groupName)
+	}
+	return groupType, nil
+}
+
+// GetGroupDescription returns the description of a given group
+func GetGroupDescription(kv *api.KV, deploymentID, groupName string) (string, error) {
+	_, description, err := getTopologyTemplateElementValue(kv, deploymentID, "groups", groupName, "description")
+	return description, err
+}
+
+// GetGroupMetadata retrieves the related group metadata key if exists
+func GetGroupMetadata(kv *api.KV, deploymentID, groupName, key string) (bool, string, error) {
+	return getTopologyTemplateElementValue(kv, deploymentID, "groups", groupName, path.Join("metadata", key))
+}
+
+// GetGroupMembers returns the names of the nodes members of a given group
+func GetGroupMembers(kv *api.KV, deploymentID, groupName string) ([]string, error) {
+	return getTopologyTemplateElementList(kv, deploymentID, "groups", groupName, "members")
+}
+
+// GetGroupsForNode returns the names of the groups a given node is member of
+func GetGroupsForNode(kv *api.KV, deploymentID, nodeName string) ([]string, error) {
+	groups, err := GetGroups(kv, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0)
+	for _, group := range groups {
+		members, err := GetGroupMembers(kv, deploymentID, group)
+		if err != nil {
+			return nil, err
+		}
+		if collections.ContainsString(members, nodeName) {
+			result = append(result, group)
+		}
+	}
+	return result, nil
+}
+
+// GetGroupProperty retrieves the value for a given property in a given group
+//
+// It returns true if a value is found false otherwise as first return parameter.
+// If the property is not found in the group then the type hierarchy is explored to find a default value.
+func GetGroupProperty(kv *api.KV, deploymentID, groupName, propertyName string, nestedKeys ...string) (bool, string, error) {
+	groupType, err := GetGroupType(kv, deploymentID, groupName)
+	if err != nil {
+		return false, "", err
+	}
+	return getTopologyTemplateElementProperty(kv, deploymentID, "groups", groupName, groupType, propertyName, nestedKeys...)
+}
+
+// GetGroupPropertiesNames returns the names of the properties of a given group
+//
+// This includes properties defined in the group template and in its type hierarchy.
+func GetGroupPropertiesNames(kv *api.KV, deploymentID, groupName string) ([]string, error) {
+	groupType, err := GetGroupType(kv, deploymentID, groupName)
+	if err != nil {
+		return nil, err
+	}
+	return getTopologyTemplateElementPropertiesNames(kv, deploymentID, "groups", groupName, groupType)
+}
+
+func getTopologyTemplateElementPropertiesNames(kv *api.KV, deploymentID, elementKind, elementName, elementType string) ([]string, error) {
+	typeProps, err := GetTypeProperties(kv, deploymentID, elementType, true)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(typeProps))
+	for _, propName := range typeProps {
+		if !collections.ContainsString(names, propName) {
+			names = append(names, propName)
+		}
+	}
+	keys, _, err := kv.Keys(path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", elementKind, elementName, "properties")+"/", "/", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	for _, key := range keys {
+		propName, err := url.QueryUnescape(path.Base(key))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to unescape property name %q", path.Base(key))
+		}
+		if !collections.ContainsString(names, propName) {
+			names = append(names, propName)
+		}
+	}
+	return names, nil
+}
+
+func getTopologyTemplateElementsNames(kv *api.KV, deploymentID, elementKind string) ([]string, error) {
+	keys, _, err := kv.Keys(path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", elementKind)+"/", "/", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	names := make([]string, len(keys))
+	for i := range keys {
+		names[i] = path.Base(keys[i])
+	}
+	return names, nil
+}
+
+func getTopologyTemplateElementValue(kv *api.KV, deploymentID, elementKind, elementName, key string) (bool, string, error) {
+	kvp, _, err := kv.Get(path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", elementKind, elementName, key), nil)
+	if err != nil {
+		return false, "", errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil || len(kvp.Value) == 0 {
+		return false, "", nil
+	}
+	return true, string(kvp.Value), nil
+}
+
+func getTopologyTemplateElementList(kv *api.KV, deploymentID, elementKind, elementName, key string) ([]string, error) {
+	found, value, err := getTopologyTemplateElementValue(kv, deploymentID, elementKind, elementName, key)
+	if err != nil || !found {
+		return nil, err
+	}
+	return strings.Split(value, ","), nil
+}
+
+// getTopologyTemplateElementProperty retrieves a property of a group or a policy and falls back to the default
+// value defined in its type hierarchy.
+//
+// As groups and policies are not related to a node, TOSCA functions are resolved without node context.
+func getTopologyTemplateElementProperty(kv *api.KV, deploymentID, elementKind, elementName, elementType, propertyName string, nestedKeys ...string) (bool, string, error) {
+	var propDataType string
+	hasProp, err := TypeHasProperty(kv, deploymentID, elementType, propertyName, true)
+	if err != nil {
+		return false, "", err
+	}
+	if hasProp {
+		propDataType, err = GetTypePropertyDataType(kv, deploymentID, elementType, propertyName)
+		if err != nil {
+			return false, "", err
+		}
+	}
+	propPath := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", elementKind, elementName, "properties", url.QueryEscape(propertyName))
+	found, result, err := getValueAssignmentWithDataType(kv, deploymentID, propPath, "", "", "", propDataType, nestedKeys...)
+	if err != nil {
+		return false, "", errors.Wrapf(err, "Failed to get property %q for %q", propertyName, elementName)
+	}
+	if found {
+		return true, result, nil
+	}
+	ok, value, isFunction, err := getTypeDefaultProperty(kv, deploymentID, elementType, propertyName, nestedKeys...)
+	if err != nil || !ok || !isFunction {
+		return ok, value, err
+	}
+	value, err = resolveValueAssignmentAsString(kv, deploymentID, "", "", "", value, nestedKeys...)
+	return true, value, err
+}
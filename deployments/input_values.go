@@ -0,0 +1,236 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ystia/yorc/helper/collections"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/tosca"
+)
+
+type invalidInputsError struct {
+	msg string
+}
+
+func (e invalidInputsError) Error() string {
+	return e.msg
+}
+
+func newInvalidInputsError(format string, args ...interface{}) error {
+	return errors.WithStack(invalidInputsError{msg: fmt.Sprintf(format, args...)})
+}
+
+// IsInvalidInputsError checks if an error is due to input values that can't be parsed or do not match their definitions
+func IsInvalidInputsError(err error) bool {
+	_, ok := errors.Cause(err).(invalidInputsError)
+	return ok
+}
+
+// ParseInputValues parses a YAML or JSON document mapping inputs names to their values
+func ParseInputValues(data []byte) (map[string]*tosca.ValueAssignment, error) {
+	values := make(map[string]*tosca.ValueAssignment)
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, newInvalidInputsError("failed to parse inputs document: %v", err)
+	}
+	if values == nil {
+		// Empty document
+		values = make(map[string]*tosca.ValueAssignment)
+	}
+	return values, nil
+}
+
+// MarshalInputValues serializes input values in a document that could be parsed using ParseInputValues
+func MarshalInputValues(values map[string]*tosca.ValueAssignment) ([]byte, error) {
+	raw := make(map[string]interface{}, len(values))
+	for name, va := range values {
+		if va != nil {
+			raw[name] = va.Value
+		}
+	}
+	b, err := yaml.Marshal(raw)
+	return b, errors.Wrap(err, "failed to serialize input values")
+}
+
+// ValidateInputValues checks input values supplied at deployment time against the inputs definitions of a topology
+//
+// Every supplied value should match an input definition, have the expected type and satisfy its constraints.
+// Required inputs without default value should be supplied unless they are listed in alreadySet.
+func ValidateInputValues(topology tosca.Topology, values map[string]*tosca.ValueAssignment, alreadySet ...string) error {
+	definitions := topology.TopologyTemplate.Inputs
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def, ok := definitions[name]
+		if !ok {
+			return newInvalidInputsError("unknown input %q", name)
+		}
+		if err := validateInputValue(name, def, values[name]); err != nil {
+			return err
+		}
+	}
+
+	names = make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := definitions[name]
+		if def.Required != nil && !*def.Required || def.Default != nil || def.Value != nil {
+			continue
+		}
+		if values[name] == nil && !collections.ContainsString(alreadySet, name) {
+			return newInvalidInputsError("missing value for required input %q", name)
+		}
+	}
+	return nil
+}
+
+func validateInputValue(name string, def tosca.ParameterDefinition, va *tosca.ValueAssignment) error {
+	if va == nil || va.Value == nil {
+		return newInvalidInputsError("missing value for input %q", name)
+	}
+	switch va.Type {
+	case tosca.ValueAssignmentFunction:
+		return newInvalidInputsError("input %q: TOSCA functions are not allowed in input values", name)
+	case tosca.ValueAssignmentList:
+		if def.Type != "list" && !strings.HasPrefix(def.Type, "list:") && tosca.IsBuiltinType(def.Type) {
+			return newInvalidInputsError("input %q: expecting a value of type %q, got a list", name, def.Type)
+		}
+		if tosca.IsBuiltinType(def.EntrySchema.Type) {
+			for i, v := range va.GetList() {
+				if err := checkScalarType(def.EntrySchema.Type, v); err != nil {
+					return newInvalidInputsError("input %q: invalid entry %d: %v", name, i, err)
+				}
+			}
+		}
+		return checkInputConstraints(name, def, strconv.Itoa(len(va.GetList())), true)
+	case tosca.ValueAssignmentMap:
+		if tosca.IsBuiltinType(def.Type) && def.Type != "map" && !strings.HasPrefix(def.Type, "map:") {
+			return newInvalidInputsError("input %q: expecting a value of type %q, got a map", name, def.Type)
+		}
+		return checkInputConstraints(name, def, strconv.Itoa(len(va.GetMap())), true)
+	}
+	if strings.HasPrefix(def.Type, "list") || strings.HasPrefix(def.Type, "map") {
+		return newInvalidInputsError("input %q: expecting a value of type %q, got %q", name, def.Type, va.GetLiteral())
+	}
+	if err := checkScalarType(def.Type, va.Value); err != nil {
+		return newInvalidInputsError("input %q: %v", name, err)
+	}
+	return checkInputConstraints(name, def, va.GetLiteral(), false)
+}
+
+// checkScalarType checks that a literal value could be converted into the given TOSCA type
+//
+// Values of non-builtin types are not checked.
+func checkScalarType(typeName string, value interface{}) error {
+	if _, ok := value.(map[interface{}]interface{}); ok {
+		return errors.Errorf("expecting a value of type %q, got a map", typeName)
+	}
+	if _, ok := value.([]interface{}); ok {
+		return errors.Errorf("expecting a value of type %q, got a list", typeName)
+	}
+	s := fmt.Sprint(value)
+	var err error
+	switch typeName {
+	case "integer":
+		_, err = strconv.ParseInt(s, 10, 64)
+	case "float":
+		_, err = strconv.ParseFloat(s, 64)
+	case "boolean":
+		_, err = strconv.ParseBool(s)
+	case "timestamp":
+		if _, ok := value.(time.Time); !ok {
+			_, err = time.Parse(time.RFC3339, s)
+		}
+	default:
+		return nil
+	}
+	return errors.Wrapf(err, "expecting a value of type %q, got %q", typeName, s)
+}
+
+// collectionLengthOperators maps length constraints operators to the operators comparing the number of entries
+// of lists and maps
+var collectionLengthOperators = map[string]string{
+	"length":     "equal",
+	"min_length": "greater_or_equal",
+	"max_length": "less_or_equal",
+}
+
+// checkInputConstraints evaluates the constraints of an input definition
+//
+// For lists and maps only length constraints are evaluated against the number of entries.
+func checkInputConstraints(name string, def tosca.ParameterDefinition, value string, isCollection bool) error {
+	for _, cc := range def.Constraints {
+		if isCollection {
+			op, ok := collectionLengthOperators[cc.Operator]
+			if !ok {
+				continue
+			}
+			cc = tosca.ConstraintClause{Operator: op, Values: cc.Values}
+		}
+		ok, err := cc.Evaluate(value)
+		if err != nil {
+			return newInvalidInputsError("input %q: failed to evaluate constraint %q: %v", name, cc.Operator, err)
+		}
+		if !ok {
+			return newInvalidInputsError("input %q: value %q does not satisfy constraint %s %v", name, value, cc.Operator, cc.Values)
+		}
+	}
+	return nil
+}
+
+// applyInputValues sets supplied input values in the inputs definitions of a topology
+func applyInputValues(topology *tosca.Topology, values map[string]*tosca.ValueAssignment) {
+	for name, va := range values {
+		def := topology.TopologyTemplate.Inputs[name]
+		def.Value = va
+		topology.TopologyTemplate.Inputs[name] = def
+	}
+}
+
+// GetInputsWithValue returns the names of the inputs of a deployment having a value supplied at deployment time
+func GetInputsWithValue(kv *api.KV, deploymentID string) ([]string, error) {
+	inputsPrefix := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/inputs")
+	inputs, _, err := kv.Keys(inputsPrefix+"/", "/", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	names := make([]string, 0)
+	for _, input := range inputs {
+		keys, _, err := kv.Keys(path.Join(input, "value"), "/", nil)
+		if err != nil {
+			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if len(keys) > 0 {
+			names = append(names, path.Base(input))
+		}
+	}
+	return names, nil
+}
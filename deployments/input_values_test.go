@@ -0,0 +1,159 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ystia/yorc/testutil"
+	"github.com/ystia/yorc/tosca"
+)
+
+func TestValidateInputValues(t *testing.T) {
+	t.Parallel()
+	var topology tosca.Topology
+	err := yaml.Unmarshal([]byte(`
+topology_template:
+  inputs:
+    name:
+      type: string
+      constraints:
+        - min_length: 3
+    port:
+      type: integer
+      default: 80
+      constraints:
+        - in_range: [1, 65535]
+    debug:
+      type: boolean
+      required: false
+    flavor:
+      type: string
+      default: small
+      constraints:
+        - valid_values: [small, medium, large]
+    zones:
+      type: list
+      required: false
+      entry_schema:
+        type: integer
+      constraints:
+        - max_length: 2
+    labels:
+      type: map
+      required: false
+`), &topology)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		inputs     string
+		alreadySet []string
+		wantErr    bool
+	}{
+		{"RequiredOnly", `name: myapp`, nil, false},
+		{"AllInputs", `{"name": "myapp", "port": "8080", "debug": true, "flavor": "large", "zones": [1, 2], "labels": {"env": "dev"}}`, nil, false},
+		{"MissingRequired", `port: 8080`, nil, true},
+		{"RequiredAlreadySet", `port: 8080`, []string{"name"}, false},
+		{"UnknownInput", `{name: myapp, unknown: value}`, nil, true},
+		{"InvalidInteger", `{name: myapp, port: eighty}`, nil, true},
+		{"InvalidBoolean", `{name: myapp, debug: maybe}`, nil, true},
+		{"InvalidEntrySchema", `{name: myapp, zones: [a]}`, nil, true},
+		{"ListForScalar", `{name: [myapp]}`, nil, true},
+		{"ScalarForMap", `{name: myapp, labels: env}`, nil, true},
+		{"MinLengthConstraint", `name: my`, nil, true},
+		{"RangeConstraint", `{name: myapp, port: 70000}`, nil, true},
+		{"ValidValuesConstraint", `{name: myapp, flavor: huge}`, nil, true},
+		{"ListLengthConstraint", `{name: myapp, zones: [1, 2, 3]}`, nil, true},
+		{"FunctionNotAllowed", `{name: {get_input: flavor}}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := ParseInputValues([]byte(tt.inputs))
+			require.NoError(t, err)
+			err = ValidateInputValues(topology, values, tt.alreadySet...)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.True(t, IsInvalidInputsError(err), "unexpected error type: %v", err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseAndMarshalInputValues(t *testing.T) {
+	t.Parallel()
+	_, err := ParseInputValues([]byte(`[not, a, map]`))
+	require.True(t, IsInvalidInputsError(err))
+
+	values, err := ParseInputValues([]byte(""))
+	require.NoError(t, err)
+	require.Len(t, values, 0)
+
+	values, err = ParseInputValues([]byte(`{"name": "myapp", "zones": [1, 2], "labels": {"env": "dev"}}`))
+	require.NoError(t, err)
+	data, err := MarshalInputValues(values)
+	require.NoError(t, err)
+	parsed, err := ParseInputValues(data)
+	require.NoError(t, err)
+	require.Equal(t, values, parsed)
+}
+
+func testStoreDeploymentDefinitionWithInputs(t *testing.T, kv *api.KV) {
+	deploymentID := testutil.BuildDeploymentID(t)
+	ctx := context.Background()
+
+	err := StoreDeploymentDefinitionWithInputs(ctx, kv, deploymentID, "testdata/input_values.yaml", nil)
+	require.Error(t, err)
+	require.True(t, IsInvalidInputsError(err))
+
+	inputs, err := ParseInputValues([]byte(`{flavor: large, nb_instances: 3, tags: {env: dev}}`))
+	require.NoError(t, err)
+	err = StoreDeploymentDefinitionWithInputs(ctx, kv, deploymentID, "testdata/input_values.yaml", inputs)
+	require.NoError(t, err)
+
+	value, err := GetInputValue(kv, deploymentID, "flavor")
+	require.NoError(t, err)
+	require.Equal(t, "large", value)
+	value, err = GetInputValue(kv, deploymentID, "tags", "env")
+	require.NoError(t, err)
+	require.Equal(t, "dev", value)
+	// Input values are taken into account when creating instances
+	ids, err := GetNodeInstancesIds(kv, deploymentID, "Compute")
+	require.NoError(t, err)
+	require.Len(t, ids, 3)
+
+	names, err := GetInputsWithValue(kv, deploymentID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"flavor", "nb_instances", "tags"}, names)
+
+	// Supplied values are kept on update unless overridden
+	inputs, err = ParseInputValues([]byte(`flavor: small`))
+	require.NoError(t, err)
+	err = UpdateDeploymentDefinition(ctx, kv, deploymentID, "testdata/input_values.yaml", inputs)
+	require.NoError(t, err)
+	value, err = GetInputValue(kv, deploymentID, "flavor")
+	require.NoError(t, err)
+	require.Equal(t, "small", value)
+	value, err = GetInputValue(kv, deploymentID, "tags", "env")
+	require.NoError(t, err)
+	require.Equal(t, "dev", value)
+}
@@ -17,6 +17,7 @@ package deployments
 import (
 	"context"
 	"path"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -32,6 +33,9 @@ import (
 	"vbom.ml/util/sortorder"
 )
 
+// infrastructureTypeRegexp matches node types specific to an infrastructure like yorc.nodes.openstack.Compute
+var infrastructureTypeRegexp = regexp.MustCompile(`^yorc\.nodes\.([^.]+)\.`)
+
 // IsNodeDerivedFrom check if the node's type is derived from another type.
 //
 // Basically this function is a shorthand for GetNodeType and IsNodeTypeDerivedFrom.
@@ -204,6 +208,33 @@ func GetHostedOnNode(kv *api.KV, deploymentID, nodeName string) (string, error)
 	return "", nil
 }
 
+// GetNodeInfrastructure returns the name of the infrastructure of a node
+//
+// It is the infrastructure of the first yorc.nodes.<infrastructure>.* type found in the type hierarchy of the node
+// or of the nodes hosting it. An empty string is returned for a node not related to an infrastructure.
+func GetNodeInfrastructure(kv *api.KV, deploymentID, nodeName string) (string, error) {
+	for nodeName != "" {
+		nodeType, err := GetNodeType(kv, deploymentID, nodeName)
+		if err != nil {
+			return "", err
+		}
+		for nodeType != "" {
+			if m := infrastructureTypeRegexp.FindStringSubmatch(nodeType); m != nil {
+				return strings.ToLower(m[1]), nil
+			}
+			nodeType, err = GetParentType(kv, deploymentID, nodeType)
+			if err != nil {
+				return "", err
+			}
+		}
+		nodeName, err = GetHostedOnNode(kv, deploymentID, nodeName)
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
 // GetHostedOnNodeInstance returns the node name and instance name of the instance
 // defined in the first found relationship derived from "tosca.relationships.HostedOn"
 //
@@ -639,14 +670,63 @@ func SelectNodeStackInstances(kv *api.KV, deploymentID, nodeName string, instanc
 	return nodesMap, nil
 }
 
-// CreateNewNodeStackInstances create the given number of new instances of the given node and all other nodes hosted on this one and all linked nodes
+// SelectNodeInstanceStack selects a given instance of a node, the instances of nodes hosted on it and the instances of all nodes linked to it.
 //
-// CreateNewNodeStackInstances returns a map of newly created instances IDs indexed by node name
-func CreateNewNodeStackInstances(kv *api.KV, deploymentID, nodeName string, instances int) (map[string]string, error) {
-	nodesMap := make(map[string]string)
-	ctx := context.Background()
-	_, errGroup, consulStore := consulutil.WithContext(ctx)
+// For each node it returns a coma separated list of selected instances
+func SelectNodeInstanceStack(kv *api.KV, deploymentID, nodeName, instance string) (map[string]string, error) {
+	nodesMap := map[string]string{nodeName: instance}
+	hostedNodes, err := GetNodesHostedOn(kv, deploymentID, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range hostedNodes {
+		instances, err := GetNodeInstancesIds(kv, deploymentID, node)
+		if err != nil {
+			return nil, err
+		}
+		hostedInstances := make([]string, 0)
+		for _, hostedInstance := range instances {
+			isHosted, err := isInstanceHostedOn(kv, deploymentID, node, hostedInstance, nodeName, instance)
+			if err != nil {
+				return nil, err
+			}
+			if isHosted {
+				hostedInstances = append(hostedInstances, hostedInstance)
+			}
+		}
+		if len(hostedInstances) > 0 {
+			nodesMap[node] = strings.Join(hostedInstances, ",")
+		}
+	}
+
+	// TODO: Improve the way we relate node instances names to dependent (linked nodes) instances names
+	linkedNodes, err := getInstancesDependentLinkedNodes(kv, deploymentID, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range linkedNodes {
+		nodesMap[node] = instance
+	}
+	return nodesMap, nil
+}
+
+// isInstanceHostedOn checks if a given node instance is hosted on another given node instance by traversing the hostedOn hierarchy
+func isInstanceHostedOn(kv *api.KV, deploymentID, nodeName, instance, hostNode, hostInstance string) (bool, error) {
+	for nodeName != "" {
+		var err error
+		nodeName, instance, err = GetHostedOnNodeInstance(kv, deploymentID, nodeName, instance)
+		if err != nil {
+			return false, err
+		}
+		if nodeName == hostNode {
+			return instance == hostInstance, nil
+		}
+	}
+	return false, nil
+}
 
+// getNodeStackNodes returns the given node and the nodes hosted on it or linked to its instances
+func getNodeStackNodes(kv *api.KV, deploymentID, nodeName string) ([]string, error) {
 	nodes, err := GetNodes(kv, deploymentID)
 	if err != nil {
 		return nil, err
@@ -669,7 +749,21 @@ func CreateNewNodeStackInstances(kv *api.KV, deploymentID, nodeName string, inst
 	if err != nil {
 		return nil, err
 	}
-	stackNodes = append(stackNodes, linkedNodes...)
+	return append(stackNodes, linkedNodes...), nil
+}
+
+// CreateNewNodeStackInstances create the given number of new instances of the given node and all other nodes hosted on this one and all linked nodes
+//
+// CreateNewNodeStackInstances returns a map of newly created instances IDs indexed by node name
+func CreateNewNodeStackInstances(kv *api.KV, deploymentID, nodeName string, instances int) (map[string]string, error) {
+	nodesMap := make(map[string]string)
+	ctx := context.Background()
+	_, errGroup, consulStore := consulutil.WithContext(ctx)
+
+	stackNodes, err := getNodeStackNodes(kv, deploymentID, nodeName)
+	if err != nil {
+		return nil, err
+	}
 
 	// Now get existing nodes instances ids to have the
 	existingIds, err := GetNodeInstancesIds(kv, deploymentID, nodeName)
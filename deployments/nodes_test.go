@@ -15,6 +15,8 @@
 package deployments
 
 import (
+	"path"
+	"strings"
 	"testing"
 
 	"fmt"
@@ -521,3 +523,37 @@ func testGetNodeInstancesIds(t *testing.T, kv *api.KV) {
 	require.NoError(t, err)
 	require.Equal(t, node2ExpectedResult, instancesIDs)
 }
+
+func testGetNodeInfrastructure(t *testing.T, srv1 *testutil.TestServer, kv *api.KV) {
+	deploymentID := strings.Replace(t.Name(), "/", "_", -1)
+	topoPrefix := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology")
+	srv1.PopulateKV(t, map[string][]byte{
+		topoPrefix + "/types/yorc.nodes.openstack.Compute/derived_from": []byte("yorc.nodes.Compute"),
+		topoPrefix + "/types/yorc.nodes.Compute/derived_from":           []byte("tosca.nodes.Compute"),
+		topoPrefix + "/types/yorc.nodes.slurm.Job/derived_from":         []byte("tosca.nodes.Root"),
+		topoPrefix + "/types/my.nodes.Job/derived_from":                 []byte("yorc.nodes.slurm.Job"),
+		topoPrefix + "/types/my.nodes.Soft/derived_from":                []byte("tosca.nodes.SoftwareComponent"),
+
+		topoPrefix + "/nodes/Compute/type":                     []byte("yorc.nodes.openstack.Compute"),
+		topoPrefix + "/nodes/Soft/type":                        []byte("my.nodes.Soft"),
+		topoPrefix + "/nodes/Soft/requirements/0/relationship": []byte("tosca.relationships.HostedOn"),
+		topoPrefix + "/nodes/Soft/requirements/0/node":         []byte("Compute"),
+		topoPrefix + "/nodes/Job/type":                         []byte("my.nodes.Job"),
+		topoPrefix + "/nodes/Standalone/type":                  []byte("my.nodes.Soft"),
+	})
+
+	infraTests := []struct {
+		nodeName string
+		want     string
+	}{
+		{"Compute", "openstack"},
+		{"Soft", "openstack"},
+		{"Job", "slurm"},
+		{"Standalone", ""},
+	}
+	for _, tt := range infraTests {
+		infra, err := GetNodeInfrastructure(kv, deploymentID, tt.nodeName)
+		require.NoError(t, err, "node %q", tt.nodeName)
+		require.Equal(t, tt.want, infra, "node %q", tt.nodeName)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -507,6 +508,90 @@ func GetOperationInput(kv *api.KV, deploymentID, nodeName string, operation prov
 	return results, err
 }
 
+// GetOperationArtifactDeliveries returns the artifacts that should be delivered on the host of an operation as
+// requested by get_artifact functions used in the operation inputs
+func GetOperationArtifactDeliveries(kv *api.KV, deploymentID, nodeName string, operation prov.Operation) ([]ArtifactDelivery, error) {
+	inputKeys, err := GetOperationInputs(kv, deploymentID, operation.ImplementedInType, operation.Name)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]ArtifactDelivery, 0)
+	for _, input := range inputKeys {
+		f, err := getOperationInputFunction(kv, deploymentID, operation, input)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			continue
+		}
+		getArtifactFuncs := f.GetFunctionsByOperator(tosca.GetArtifactOperator)
+		if len(getArtifactFuncs) == 0 {
+			continue
+		}
+		instances, err := GetNodeInstancesIds(kv, deploymentID, nodeName)
+		if err != nil {
+			return nil, err
+		}
+		var instanceName string
+		if len(instances) > 0 {
+			// Artifacts do not depend on instances but nested functions may
+			instanceName = instances[0]
+		}
+		fr := resolver(kv, deploymentID).context(withNodeName(nodeName), withInstanceName(instanceName), withRequirementIndex(operation.RelOp.RequirementIndex))
+		for _, ga := range getArtifactFuncs {
+			operands, err := fr.resolveOperands(ga)
+			if err != nil {
+				return nil, err
+			}
+			delivery, err := fr.resolveGetArtifact(operands)
+			if err != nil {
+				return nil, err
+			}
+			delivery.InputName = input
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// getOperationInputFunction returns the TOSCA function assigned to an operation input or nil if the input is not
+// assigned by a function
+func getOperationInputFunction(kv *api.KV, deploymentID string, operation prov.Operation, inputName string) (*tosca.Function, error) {
+	isPropDef, err := IsOperationInputAPropertyDefinition(kv, deploymentID, operation.ImplementedInType, operation.Name, inputName)
+	if err != nil || isPropDef {
+		return nil, err
+	}
+	operationPath, interfacePath := getOperationAndInterfacePath(deploymentID, operation.ImplementedInType, operation.Name)
+	found, res, isFunction, err := getValueAssignmentWithoutResolve(kv, deploymentID, path.Join(operationPath, "inputs", inputName, "data"), "")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		found, res, isFunction, err = getValueAssignmentWithoutResolve(kv, deploymentID, path.Join(interfacePath, "inputs", inputName, "data"), "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if found {
+		if !isFunction {
+			return nil, nil
+		}
+		va := &tosca.ValueAssignment{}
+		if err = yaml.Unmarshal([]byte(res), va); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal TOSCA Function definition %q", res)
+		}
+		return va.GetFunction(), nil
+	}
+	newOp, err := getParentOperation(kv, deploymentID, operation)
+	if err != nil {
+		if IsOperationNotImplemented(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return getOperationInputFunction(kv, deploymentID, newOp, inputName)
+}
+
 // GetOperationInputPropertyDefinitionDefault retrieves the default value of an input of type property definition for a given operation
 func GetOperationInputPropertyDefinitionDefault(kv *api.KV, deploymentID, nodeName string, operation prov.Operation, inputName string) ([]OperationInputResult, error) {
 	isPropDef, err := IsOperationInputAPropertyDefinition(kv, deploymentID, operation.ImplementedInType, operation.Name, inputName)
@@ -606,3 +691,41 @@ func GetOperationHostFromTypeOperationByName(kv *api.KV, deploymentID, typeName,
 	}
 	return string(kvp.Value), nil
 }
+
+// ParseOperationTimeout parses the timeout of an operation implementation
+//
+// As defined by the TOSCA specification the timeout may be a number of seconds, Yorc also accepts
+// a duration (ex: 30m).
+func ParseOperationTimeout(timeout string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(timeout); err == nil {
+		if seconds < 0 {
+			return 0, errors.Errorf("negative timeout %q", timeout)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, errors.Wrapf(err, "timeout %q is neither a number of seconds nor a duration", timeout)
+	}
+	if d < 0 {
+		return 0, errors.Errorf("negative timeout %q", timeout)
+	}
+	return d, nil
+}
+
+// GetOperationTimeout returns the timeout declared on the implementation of the given operation if any.
+//
+// A zero duration is returned if no timeout is defined. The given operation name should be in format
+// <interface_name>.<operation_name>. This function doesn't explore the type heirarchy to find the operation.
+func GetOperationTimeout(kv *api.KV, deploymentID, typeName, operationName string) (time.Duration, error) {
+	opPath, _ := getOperationAndInterfacePath(deploymentID, typeName, operationName)
+	kvp, _, err := kv.Get(path.Join(opPath, "implementation/timeout"), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil || len(kvp.Value) == 0 {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(string(kvp.Value))
+	return d, errors.Wrapf(err, "invalid timeout for operation %q of type %q", operationName, typeName)
+}
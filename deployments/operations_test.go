@@ -17,6 +17,7 @@ package deployments
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/stretchr/testify/assert"
@@ -145,3 +146,31 @@ func testOperationHost(t *testing.T, kv *api.KV) {
 		})
 	}
 }
+
+func TestParseOperationTimeout(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		timeout string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"Seconds", "600", 10 * time.Minute, false},
+		{"Duration", "1h30m", 90 * time.Minute, false},
+		{"Zero", "0", 0, false},
+		{"NegativeSeconds", "-10", 0, true},
+		{"NegativeDuration", "-1m", 0, true},
+		{"Invalid", "soon", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOperationTimeout(tt.timeout)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"path"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/helper/collections"
+)
+
+// GetPolicies returns the names of the different policies for a given deployment.
+func GetPolicies(kv *api.KV, deploymentID string) ([]string, error) {
+	return getTopologyTemplateElementsNames(kv, deploymentID, "policies")
+}
+
+// DoesPolicyExist checks if a given policy exists in a deployment
+func DoesPolicyExist(kv *api.KV, deploymentID, policyName string) (bool, error) {
+	found, _, err := getTopologyTemplateElementValue(kv, deploymentID, "policies", policyName, "name")
+	return found, err
+}
+
+// GetPolicyType returns the type of a given policy identified by its name
+func GetPolicyType(kv *api.KV, deploymentID, policyName string) (string, error) {
+	found, policyType, err := getTopologyTemplateElementValue(kv, deploymentID, "policies", policyName, "type")
+	if err != nil {
+		return "", err
+	}
+	if !found || policyType == "" {
+		return "", errors.Errorf("Missing mandatory parameter \"type\" for policy %q", policyName)
+	}
+	return policyType, nil
+}
+
+// GetPolicyDescription returns the description of a given policy
+func GetPolicyDescription(kv *api.KV, deploymentID, policyName string) (string, error) {
+	_, description, err := getTopologyTemplateElementValue(kv, deploymentID, "policies", policyName, "description")
+	return description, err
+}
+
+// GetPolicyMetadata retrieves the related policy metadata key if exists
+func GetPolicyMetadata(kv *api.KV, deploymentID, policyName, key string) (bool, string, error) {
+	return getTopologyTemplateElementValue(kv, deploymentID, "policies", policyName, path.Join("metadata", key))
+}
+
+// GetPolicyTargets returns the targets of a given policy
+//
+// Targets are nodes or groups names as defined in the topology template.
+func GetPolicyTargets(kv *api.KV, deploymentID, policyName string) ([]string, error) {
+	return getTopologyTemplateElementList(kv, deploymentID, "policies", policyName, "targets")
+}
+
+// GetPolicyTargetsNodes returns the names of the nodes targeted by a given policy
+//
+// Groups targets are expanded into their members.
+func GetPolicyTargetsNodes(kv *api.KV, deploymentID, policyName string) ([]string, error) {
+	targets, err := GetPolicyTargets(kv, deploymentID, policyName)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]string, 0, len(targets))
+	for _, target := range targets {
+		isGroup, err := DoesGroupExist(kv, deploymentID, target)
+		if err != nil {
+			return nil, err
+		}
+		members := []string{target}
+		if isGroup {
+			members, err = GetGroupMembers(kv, deploymentID, target)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, member := range members {
+			if !collections.ContainsString(nodes, member) {
+				nodes = append(nodes, member)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// GetPoliciesForType returns the names of the policies of a given type or derived from this type
+func GetPoliciesForType(kv *api.KV, deploymentID, policyType string) ([]string, error) {
+	policies, err := GetPolicies(kv, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0)
+	for _, policy := range policies {
+		pType, err := GetPolicyType(kv, deploymentID, policy)
+		if err != nil {
+			return nil, err
+		}
+		derived, err := IsTypeDerivedFrom(kv, deploymentID, pType, policyType)
+		if err != nil {
+			return nil, err
+		}
+		if derived {
+			result = append(result, policy)
+		}
+	}
+	return result, nil
+}
+
+// GetPolicyPropertiesNames returns the names of the properties of a given policy
+//
+// This includes properties defined in the policy template and in its type hierarchy.
+func GetPolicyPropertiesNames(kv *api.KV, deploymentID, policyName string) ([]string, error) {
+	policyType, err := GetPolicyType(kv, deploymentID, policyName)
+	if err != nil {
+		return nil, err
+	}
+	return getTopologyTemplateElementPropertiesNames(kv, deploymentID, "policies", policyName, policyType)
+}
+
+// GetPolicyProperty retrieves the value for a given property in a given policy
+//
+// It returns true if a value is found false otherwise as first return parameter.
+// If the property is not found in the policy then the type hierarchy is explored to find a default value.
+func GetPolicyProperty(kv *api.KV, deploymentID, policyName, propertyName string, nestedKeys ...string) (bool, string, error) {
+	policyType, err := GetPolicyType(kv, deploymentID, policyName)
+	if err != nil {
+		return false, "", err
+	}
+	return getTopologyTemplateElementProperty(kv, deploymentID, "policies", policyName, policyType, propertyName, nestedKeys...)
+}
@@ -15,9 +15,12 @@
 package deployments
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/ystia/yorc/helper/collections"
 	"github.com/ystia/yorc/log"
@@ -81,6 +84,40 @@ func (fr *functionResolver) resolveFunction(fn *tosca.Function) (string, error)
 	if fn == nil {
 		return "", errors.Errorf("Trying to resolve a nil function")
 	}
+	operands, err := fr.resolveOperands(fn)
+	if err != nil {
+		return "", err
+	}
+	switch fn.Operator {
+	case tosca.ConcatOperator:
+		return strings.Join(operands, ""), nil
+	case tosca.GetInputOperator:
+		return fr.resolveGetInput(operands)
+	case tosca.GetOperationOutputOperator:
+		return fr.resolveGetOperationOutput(operands)
+	case tosca.GetPropertyOperator:
+		return fr.resolveGetPropertyOrAttribute("property", operands)
+	case tosca.GetAttributeOperator:
+		return fr.resolveGetPropertyOrAttribute("attribute", operands)
+	case tosca.TokenOperator:
+		return resolveToken(operands)
+	case tosca.GetNodesOfTypeOperator:
+		return fr.resolveGetNodesOfType(operands)
+	case tosca.GetArtifactOperator:
+		delivery, err := fr.resolveGetArtifact(operands)
+		if err != nil {
+			return "", err
+		}
+		if delivery.Location != "" {
+			return delivery.Location, nil
+		}
+		return delivery.File, nil
+	}
+	return "", errors.Errorf("Unsupported function %q", string(fn.Operator))
+}
+
+// resolveOperands returns the operands of a function as strings, nested functions are resolved
+func (fr *functionResolver) resolveOperands(fn *tosca.Function) ([]string, error) {
 	operands := make([]string, len(fn.Operands))
 	for i, op := range fn.Operands {
 		if op.IsLiteral() {
@@ -89,7 +126,7 @@ func (fr *functionResolver) resolveFunction(fn *tosca.Function) (string, error)
 			if isQuoted(s) {
 				s, err = strconv.Unquote(s)
 				if err != nil {
-					return "", errors.Wrapf(err, "failed to unquote literal operand of function %v", fn)
+					return nil, errors.Wrapf(err, "failed to unquote literal operand of function %v", fn)
 				}
 			}
 			operands[i] = s
@@ -97,24 +134,12 @@ func (fr *functionResolver) resolveFunction(fn *tosca.Function) (string, error)
 			subFn := op.(*tosca.Function)
 			r, err := fr.resolveFunction(subFn)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 			operands[i] = r
 		}
 	}
-	switch fn.Operator {
-	case tosca.ConcatOperator:
-		return strings.Join(operands, ""), nil
-	case tosca.GetInputOperator:
-		return fr.resolveGetInput(operands)
-	case tosca.GetOperationOutputOperator:
-		return fr.resolveGetOperationOutput(operands)
-	case tosca.GetPropertyOperator:
-		return fr.resolveGetPropertyOrAttribute("property", operands)
-	case tosca.GetAttributeOperator:
-		return fr.resolveGetPropertyOrAttribute("attribute", operands)
-	}
-	return "", errors.Errorf("Unsupported function %q", string(fn.Operator))
+	return operands, nil
 }
 
 func (fr *functionResolver) resolveGetInput(operands []string) (string, error) {
@@ -197,33 +222,9 @@ func (fr *functionResolver) resolveGetPropertyOrAttribute(rType string, operands
 		return "", errors.Errorf(`Can't resolve %q %s keyword is supported only in the context of a relationship`, funcString, entity)
 	}
 	// First get the node on which we should resolve the get_property
-
-	var err error
-	var actualNode string
-	switch entity {
-	case funcKeywordSELF, funcKeywordSOURCE:
-		actualNode = fr.nodeName
-	case funcKeywordHOST:
-		actualNode, err = GetHostedOnNode(fr.kv, fr.deploymentID, fr.nodeName)
-		if err != nil {
-			return "", err
-		}
-	case funcKeywordTARGET, funcKeywordRTARGET:
-		actualNode, err = GetTargetNodeForRequirement(fr.kv, fr.deploymentID, fr.nodeName, fr.requirementIndex)
-		if err != nil {
-			return "", err
-		}
-	case funcKeywordREQTARGET:
-		actualNode, err = GetTargetNodeForRequirementByName(fr.kv, fr.deploymentID, fr.nodeName, operands[1])
-		if err != nil {
-			return "", err
-		}
-	default:
-		actualNode = entity
-	}
-
-	if actualNode == "" {
-		return "", errors.Errorf(`Can't resolve %q without a specified node name`, funcString)
+	actualNode, err := fr.resolveEntityNode(funcString, entity, operands[1])
+	if err != nil {
+		return "", err
 	}
 	var args []string
 	var found bool
@@ -278,6 +279,130 @@ func (fr *functionResolver) resolveGetPropertyOrAttribute(rType string, operands
 	return result, nil
 }
 
+// resolveEntityNode returns the name of the node designated by the modelable entity of a function
+//
+// reqName is only used by the REQ_TARGET keyword.
+func (fr *functionResolver) resolveEntityNode(funcString, entity, reqName string) (string, error) {
+	var err error
+	var actualNode string
+	switch entity {
+	case funcKeywordSELF, funcKeywordSOURCE:
+		actualNode = fr.nodeName
+	case funcKeywordHOST:
+		actualNode, err = GetHostedOnNode(fr.kv, fr.deploymentID, fr.nodeName)
+	case funcKeywordTARGET, funcKeywordRTARGET:
+		actualNode, err = GetTargetNodeForRequirement(fr.kv, fr.deploymentID, fr.nodeName, fr.requirementIndex)
+	case funcKeywordREQTARGET:
+		actualNode, err = GetTargetNodeForRequirementByName(fr.kv, fr.deploymentID, fr.nodeName, reqName)
+	default:
+		actualNode = entity
+	}
+	if err != nil {
+		return "", err
+	}
+	if actualNode == "" {
+		return "", errors.Errorf(`Can't resolve %q without a specified node name`, funcString)
+	}
+	return actualNode, nil
+}
+
+// resolveToken splits a string using a set of separator characters and returns the substring at a given index
+//
+// Each separator character delimits a substring so consecutive separators delimit empty substrings, which
+// can't be returned.
+func resolveToken(operands []string) (string, error) {
+	funcString := fmt.Sprintf("token: [%s]", strings.Join(operands, ", "))
+	if len(operands) != 3 {
+		return "", errors.Errorf("expecting exactly three parameters for a token function (%s)", funcString)
+	}
+	if operands[1] == "" {
+		return "", errors.Errorf("Can't resolve %q: no separator characters given", funcString)
+	}
+	index, err := strconv.Atoi(operands[2])
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid substring index in %q", funcString)
+	}
+	// Replace all separators by the first one to split on any of them
+	separator, _ := utf8.DecodeRuneInString(operands[1])
+	tokens := strings.Split(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(operands[1], r) {
+			return separator
+		}
+		return r
+	}, operands[0]), string(separator))
+	if index < 0 || index >= len(tokens) {
+		return "", errors.Errorf("Can't resolve %q: substring index %d out of range (%d substrings)", funcString, index, len(tokens))
+	}
+	if tokens[index] == "" {
+		return "", errors.Errorf("Can't resolve %q: substring at index %d is empty", funcString, index)
+	}
+	return tokens[index], nil
+}
+
+// resolveGetNodesOfType returns a JSON list of the names of nodes of a given type or derived from it
+func (fr *functionResolver) resolveGetNodesOfType(operands []string) (string, error) {
+	if len(operands) != 1 {
+		return "", errors.Errorf("expecting exactly one parameter for a get_nodes_of_type function (get_nodes_of_type: [%s])", strings.Join(operands, ", "))
+	}
+	nodes, err := GetNodes(fr.kv, fr.deploymentID)
+	if err != nil {
+		return "", err
+	}
+	result := make([]string, 0)
+	for _, node := range nodes {
+		isOfType, err := IsNodeDerivedFrom(fr.kv, fr.deploymentID, node, operands[0])
+		if err != nil {
+			return "", err
+		}
+		if isOfType {
+			result = append(result, node)
+		}
+	}
+	sort.Strings(result)
+	j, err := json.Marshal(result)
+	return string(j), errors.Wrap(err, "failed to generate JSON representation of nodes list")
+}
+
+// resolveGetArtifact returns the delivery of the artifact designated by a get_artifact function
+func (fr *functionResolver) resolveGetArtifact(operands []string) (ArtifactDelivery, error) {
+	funcString := fmt.Sprintf("get_artifact: [%s]", strings.Join(operands, ", "))
+	if len(operands) < 2 || len(operands) > 4 {
+		return ArtifactDelivery{}, errors.Errorf("expecting two to four parameters for a get_artifact function (%s)", funcString)
+	}
+	entity := operands[0]
+	switch {
+	case entity == funcKeywordREQTARGET:
+		return ArtifactDelivery{}, errors.Errorf(`Can't resolve %q %s keyword is not supported by this function`, funcString, entity)
+	case entity == funcKeywordHOST && fr.requirementIndex != "":
+		return ArtifactDelivery{}, errors.Errorf(`Can't resolve %q %s keyword is not supported in the context of a relationship`, funcString, funcKeywordHOST)
+	case fr.requirementIndex == "" && (entity == funcKeywordSOURCE || entity == funcKeywordTARGET || entity == funcKeywordRTARGET):
+		return ArtifactDelivery{}, errors.Errorf(`Can't resolve %q %s keyword is supported only in the context of a relationship`, funcString, entity)
+	}
+	actualNode, err := fr.resolveEntityNode(funcString, entity, "")
+	if err != nil {
+		return ArtifactDelivery{}, err
+	}
+	artifacts, err := GetArtifactsForNode(fr.kv, fr.deploymentID, actualNode)
+	if err != nil {
+		return ArtifactDelivery{}, err
+	}
+	file, ok := artifacts[operands[1]]
+	if !ok {
+		return ArtifactDelivery{}, errors.Errorf("Can't resolve %q: node %q has no artifact named %q", funcString, actualNode, operands[1])
+	}
+	delivery := ArtifactDelivery{NodeName: actualNode, ArtifactName: operands[1], File: file}
+	if len(operands) > 2 && operands[2] != ArtifactLocationLocalFile {
+		delivery.Location = operands[2]
+	}
+	if len(operands) > 3 {
+		delivery.Remove, err = strconv.ParseBool(operands[3])
+		if err != nil {
+			return ArtifactDelivery{}, errors.Wrapf(err, "invalid remove flag in %q", funcString)
+		}
+	}
+	return delivery, nil
+}
+
 func getFuncNestedArgs(nestedKeys ...string) []string {
 	res := make([]string, 0, len(nestedKeys))
 	for _, key := range nestedKeys {
@@ -17,6 +17,7 @@ package deployments
 import (
 	"context"
 	"path"
+	"sort"
 	"testing"
 
 	yaml "gopkg.in/yaml.v2"
@@ -25,6 +26,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/ystia/yorc/helper/consulutil"
 	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/prov"
 	"github.com/ystia/yorc/testutil"
 	"github.com/ystia/yorc/tosca"
 )
@@ -41,6 +43,12 @@ func testResolver(t *testing.T, kv *api.KV) {
 	t.Run("deployments/resolver/testResolveComplex", func(t *testing.T) {
 		testResolveComplex(t, kv)
 	})
+	t.Run("deployments/resolver/testResolveToscaFunctions", func(t *testing.T) {
+		testResolveToscaFunctions(t, kv)
+	})
+	t.Run("deployments/resolver/testGetOperationArtifactDeliveries", func(t *testing.T) {
+		testGetOperationArtifactDeliveries(t, kv)
+	})
 
 }
 
@@ -154,3 +162,73 @@ func testResolveComplex(t *testing.T, kv *api.KV) {
 		})
 	}
 }
+
+func testResolveToscaFunctions(t *testing.T, kv *api.KV) {
+	// t.Parallel()
+	deploymentID := testutil.BuildDeploymentID(t)
+	err := StoreDeploymentDefinition(context.Background(), kv, deploymentID, "testdata/tosca_functions.yaml")
+	require.Nil(t, err, "Failed to parse testdata/tosca_functions.yaml definition: %+v", err)
+	r := resolver(kv, deploymentID)
+
+	resolverTests := []struct {
+		name             string
+		functionAsString string
+		wantErr          bool
+		want             string
+	}{
+		{"ResolveToken", `{token: [get_property: [SELF, url], ":/", 4]}`, false, `8080`},
+		{"ResolveTokenFirst", `{token: ["a.b.c", ".", 0]}`, false, `a`},
+		{"ResolveTokenAfterEmpty", `{token: ["a..c", ".", 2]}`, false, `c`},
+		{"ResolveTokenEmpty", `{token: ["a..c", ".", 1]}`, true, ``},
+		{"ResolveTokenNoSeparator", `{token: ["a.b.c", "", 0]}`, true, ``},
+		{"ResolveTokenOutOfRange", `{token: ["a.b.c", ".", 3]}`, true, ``},
+		{"ResolveTokenInvalidIndex", `{token: ["a.b.c", ".", one]}`, true, ``},
+		{"ResolveGetNodesOfType", `{get_nodes_of_type: tosca.nodes.Compute}`, false, `["Compute1","Compute2"]`},
+		{"ResolveGetNodesOfTypeParent", `{get_nodes_of_type: tosca.nodes.Root}`, false, `["App","Compute1","Compute2"]`},
+		{"ResolveGetNodesOfTypeNone", `{get_nodes_of_type: tosca.nodes.BlockStorage}`, false, `[]`},
+		{"ResolveGetArtifact", `{get_artifact: [SELF, war]}`, false, `files/app.war`},
+		{"ResolveGetArtifactLocation", `{get_artifact: [SELF, war, /opt/app/app.war]}`, false, `/opt/app/app.war`},
+		{"ResolveGetArtifactLocalFile", `{get_artifact: [SELF, war, LOCAL_FILE]}`, false, `files/app.war`},
+		{"ResolveGetArtifactUnknown", `{get_artifact: [SELF, missing]}`, true, ``},
+		{"ResolveGetArtifactReqTarget", `{get_artifact: [REQ_TARGET, war]}`, true, ``},
+		{"ResolveGetArtifactInvalidRemove", `{get_artifact: [SELF, war, /opt/app/app.war, maybe]}`, true, ``},
+	}
+	for _, tt := range resolverTests {
+		t.Run(tt.name, func(t *testing.T) {
+			va := generateToscaValueAssignmentFromString(t, tt.functionAsString)
+			require.Equal(t, tosca.ValueAssignmentFunction, va.Type)
+			got, err := r.context(withNodeName("App"), withInstanceName("0"), withRequirementIndex("")).resolveFunction(va.GetFunction())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resolveFunction() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err == nil && got != tt.want {
+				t.Errorf("resolveFunction() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func testGetOperationArtifactDeliveries(t *testing.T, kv *api.KV) {
+	// t.Parallel()
+	deploymentID := testutil.BuildDeploymentID(t)
+	err := StoreDeploymentDefinition(context.Background(), kv, deploymentID, "testdata/tosca_functions.yaml")
+	require.Nil(t, err, "Failed to parse testdata/tosca_functions.yaml definition: %+v", err)
+
+	operation := prov.Operation{
+		Name:                   "standard.create",
+		ImplementedInType:      "yorc.tests.nodes.FunctionsApp",
+		ImplementationArtifact: "tosca.artifacts.Implementation.Bash",
+		RelOp:                  prov.RelationshipOperation{},
+	}
+	deliveries, err := GetOperationArtifactDeliveries(kv, deploymentID, "App", operation)
+	require.Nil(t, err, "%+v", err)
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].InputName < deliveries[j].InputName
+	})
+	require.Equal(t, []ArtifactDelivery{
+		{InputName: "CONF", NodeName: "App", ArtifactName: "conf", File: "files/app.conf"},
+		{InputName: "WAR", NodeName: "App", ArtifactName: "war", File: "files/app.war", Location: "/opt/app/app.war", Remove: true},
+	}, deliveries)
+}
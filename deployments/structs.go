@@ -37,6 +37,14 @@ const (
 	UNDEPLOYMENT_FAILED
 	// SCALING_IN_PROGRESS instances are currently added or removed to the deployment
 	SCALING_IN_PROGRESS
+	// UPDATE_IN_PROGRESS the deployment is being updated to a new version of its topology
+	UPDATE_IN_PROGRESS
+	// UPDATE_FAILED the update of the deployment encountered an error
+	UPDATE_FAILED
+	// ROLLBACK_IN_PROGRESS instances created or modified by a failed task are being uninstalled
+	ROLLBACK_IN_PROGRESS
+	// ROLLBACK_FAILED the rollback of a failed task encountered an error
+	ROLLBACK_FAILED
 
 	endOfDepStatusConst // Do not remove this line and define new const before it. It is used to get const value from string
 )
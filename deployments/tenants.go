@@ -0,0 +1,156 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/helper/consulutil"
+)
+
+// DefaultTenant is the tenant of deployments created without an explicit tenant
+const DefaultTenant = "default"
+
+type tenantQuotaExceeded struct {
+	tenant string
+	msg    string
+}
+
+func (e tenantQuotaExceeded) Error() string {
+	return fmt.Sprintf("quota exceeded for tenant %q: %s", e.tenant, e.msg)
+}
+
+// IsTenantQuotaExceededError checks if an error is due to a tenant quota exceeded
+func IsTenantQuotaExceededError(err error) bool {
+	_, ok := errors.Cause(err).(tenantQuotaExceeded)
+	return ok
+}
+
+// SetDeploymentTenant stores the tenant a deployment belongs to
+func SetDeploymentTenant(kv *api.KV, deploymentID, tenant string) error {
+	if tenant == "" {
+		tenant = DefaultTenant
+	}
+	_, err := kv.Put(&api.KVPair{Key: path.Join(consulutil.DeploymentKVPrefix, deploymentID, "tenant"), Value: []byte(tenant)}, nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
+
+// GetDeploymentTenant returns the tenant a deployment belongs to
+//
+// Deployments created before the introduction of tenants belong to the DefaultTenant.
+func GetDeploymentTenant(kv *api.KV, deploymentID string) (string, error) {
+	kvp, _, err := kv.Get(path.Join(consulutil.DeploymentKVPrefix, deploymentID, "tenant"), nil)
+	if err != nil {
+		return "", errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil || len(kvp.Value) == 0 {
+		return DefaultTenant, nil
+	}
+	return string(kvp.Value), nil
+}
+
+// GetTenantDeploymentsIDs returns the IDs of deployments belonging to a given tenant
+func GetTenantDeploymentsIDs(kv *api.KV, tenant string) ([]string, error) {
+	depIDs, err := GetDeploymentsIDs(kv)
+	if err != nil {
+		return nil, err
+	}
+	tenantDepIDs := make([]string, 0)
+	for _, depID := range depIDs {
+		depTenant, err := GetDeploymentTenant(kv, depID)
+		if err != nil {
+			return nil, err
+		}
+		if depTenant == tenant {
+			tenantDepIDs = append(tenantDepIDs, depID)
+		}
+	}
+	return tenantDepIDs, nil
+}
+
+// countDeploymentInstances returns the number of node instances of a deployment
+func countDeploymentInstances(kv *api.KV, deploymentID string) (int, error) {
+	instancesPath := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances")
+	nodes, _, err := kv.Keys(instancesPath+"/", "/", nil)
+	if err != nil {
+		return 0, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	var count int
+	for _, node := range nodes {
+		instances, _, err := kv.Keys(node, "/", nil)
+		if err != nil {
+			return 0, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		count += len(instances)
+	}
+	return count, nil
+}
+
+// CheckTenantQuotas checks that the quotas configured for a tenant are not exceeded by its deployments
+// and the given number of additional instances.
+//
+// An error that could be checked with IsTenantQuotaExceededError is returned if a quota is exceeded.
+func CheckTenantQuotas(kv *api.KV, cfg config.Configuration, tenant string, additionalInstances int) error {
+	quotas := cfg.Tenants[tenant].Quotas
+	if quotas.MaxDeployments <= 0 && quotas.MaxInstances <= 0 {
+		return nil
+	}
+	depIDs, err := GetTenantDeploymentsIDs(kv, tenant)
+	if err != nil {
+		return err
+	}
+	if quotas.MaxDeployments > 0 && len(depIDs) > quotas.MaxDeployments {
+		return tenantQuotaExceeded{tenant: tenant, msg: fmt.Sprintf("%d deployments while at most %d are allowed", len(depIDs), quotas.MaxDeployments)}
+	}
+	if quotas.MaxInstances <= 0 {
+		return nil
+	}
+	instances := additionalInstances
+	for _, depID := range depIDs {
+		count, err := countDeploymentInstances(kv, depID)
+		if err != nil {
+			return err
+		}
+		instances += count
+	}
+	if instances > quotas.MaxInstances {
+		return tenantQuotaExceeded{tenant: tenant, msg: fmt.Sprintf("%d instances while at most %d are allowed", instances, quotas.MaxInstances)}
+	}
+	return nil
+}
+
+// CheckTenantQuotasForScaleOut checks that adding the given number of instances to a node of a deployment
+// does not exceed the quotas of the deployment tenant.
+//
+// Nodes hosted on the scaled node or linked to its instances are taken into account as they are scaled too.
+func CheckTenantQuotasForScaleOut(kv *api.KV, cfg config.Configuration, deploymentID, nodeName string, instances int) error {
+	tenant, err := GetDeploymentTenant(kv, deploymentID)
+	if err != nil {
+		return err
+	}
+	if cfg.Tenants[tenant].Quotas.MaxInstances <= 0 {
+		return nil
+	}
+	stackNodes, err := getNodeStackNodes(kv, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+	return CheckTenantQuotas(kv, cfg, tenant, instances*len(stackNodes))
+}
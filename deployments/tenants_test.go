@@ -0,0 +1,80 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/helper/consulutil"
+)
+
+func testTenants(t *testing.T, kv *api.KV) {
+	storeDep := func(deploymentID, tenant string, instances map[string]int) {
+		_, err := kv.Put(&api.KVPair{Key: path.Join(consulutil.DeploymentKVPrefix, deploymentID, "status"), Value: []byte(DEPLOYED.String())}, nil)
+		require.NoError(t, err)
+		if tenant != "" {
+			require.NoError(t, SetDeploymentTenant(kv, deploymentID, tenant))
+		}
+		for nodeName, nb := range instances {
+			for i := 0; i < nb; i++ {
+				_, err = kv.Put(&api.KVPair{Key: path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances", nodeName, strconv.Itoa(i), "attributes/state"), Value: []byte("started")}, nil)
+				require.NoError(t, err)
+			}
+		}
+	}
+	storeDep("tenantDep1", "tenantA", map[string]int{"Compute": 2, "Soft": 2})
+	storeDep("tenantDep2", "tenantA", map[string]int{"Compute": 1})
+	storeDep("tenantDep3", "tenantB", map[string]int{"Compute": 3})
+	storeDep("tenantDep4", "", map[string]int{"Compute": 1})
+
+	tenant, err := GetDeploymentTenant(kv, "tenantDep1")
+	require.NoError(t, err)
+	require.Equal(t, "tenantA", tenant)
+	tenant, err = GetDeploymentTenant(kv, "tenantDep4")
+	require.NoError(t, err)
+	require.Equal(t, DefaultTenant, tenant)
+
+	depIDs, err := GetTenantDeploymentsIDs(kv, "tenantA")
+	require.NoError(t, err)
+	require.Equal(t, []string{"tenantDep1", "tenantDep2"}, depIDs)
+
+	count, err := countDeploymentInstances(kv, "tenantDep1")
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+
+	cfg := config.Configuration{Tenants: map[string]config.Tenant{
+		"tenantA": {Quotas: config.TenantQuotas{MaxDeployments: 2, MaxInstances: 6}},
+		"tenantB": {Quotas: config.TenantQuotas{MaxDeployments: 0, MaxInstances: 2}},
+	}}
+	require.NoError(t, CheckTenantQuotas(kv, cfg, "tenantA", 0))
+	require.NoError(t, CheckTenantQuotas(kv, cfg, "tenantA", 1))
+	err = CheckTenantQuotas(kv, cfg, "tenantA", 2)
+	require.Error(t, err)
+	require.True(t, IsTenantQuotaExceededError(err))
+	err = CheckTenantQuotas(kv, cfg, "tenantB", 0)
+	require.True(t, IsTenantQuotaExceededError(err))
+	// No quotas for other tenants
+	require.NoError(t, CheckTenantQuotas(kv, cfg, DefaultTenant, 100))
+
+	cfg.Tenants["tenantA"] = config.Tenant{Quotas: config.TenantQuotas{MaxDeployments: 1}}
+	err = CheckTenantQuotas(kv, cfg, "tenantA", 0)
+	require.True(t, IsTenantQuotaExceededError(err))
+}
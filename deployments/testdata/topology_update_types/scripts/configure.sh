@@ -0,0 +1,3 @@
+#!/usr/bin/env bash
+
+echo "Configuring application on port ${PORT}"
@@ -0,0 +1,762 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/helper/collections"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/tosca"
+)
+
+// DefaultUpdateOperation is the operation run on node templates changed by a deployment update when no other
+// operation is specified
+const DefaultUpdateOperation = "Standard.configure"
+
+// updateStagingDir is the directory, under a deployment's KV path, where an updated definition is stored before
+// replacing the current one
+const updateStagingDir = ".update"
+
+// maxNbTransactionOps is the maximum number of operations within a Consul transaction
+const maxNbTransactionOps = 64
+
+// TopologyDiff describes the differences between the topology of a deployment and an updated version of it
+type TopologyDiff struct {
+	AddedNodes       []string   `json:"added_nodes,omitempty"`
+	RemovedNodes     []string   `json:"removed_nodes,omitempty"`
+	ChangedNodes     []NodeDiff `json:"changed_nodes,omitempty"`
+	AddedWorkflows   []string   `json:"added_workflows,omitempty"`
+	RemovedWorkflows []string   `json:"removed_workflows,omitempty"`
+	ChangedWorkflows []string   `json:"changed_workflows,omitempty"`
+	ChangedInputs    []string   `json:"changed_inputs,omitempty"`
+	ChangedTypes     []string   `json:"changed_types,omitempty"`
+}
+
+// NodeDiff describes the differences of a node template existing in both versions of a topology
+//
+// Each field lists the names of the added, removed or modified elements. Types lists the changed types used by the
+// node template and Inputs the changed inputs it references using get_input.
+type NodeDiff struct {
+	Name         string   `json:"name"`
+	Properties   []string `json:"properties,omitempty"`
+	Attributes   []string `json:"attributes,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Requirements []string `json:"requirements,omitempty"`
+	Artifacts    []string `json:"artifacts,omitempty"`
+	Types        []string `json:"types,omitempty"`
+	Inputs       []string `json:"inputs,omitempty"`
+}
+
+// IsEmpty checks if there is no difference between two topologies
+func (d TopologyDiff) IsEmpty() bool {
+	return len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 && len(d.ChangedNodes) == 0 &&
+		len(d.AddedWorkflows) == 0 && len(d.RemovedWorkflows) == 0 && len(d.ChangedWorkflows) == 0 &&
+		len(d.ChangedInputs) == 0 && len(d.ChangedTypes) == 0
+}
+
+func (d NodeDiff) isEmpty() bool {
+	return len(d.Properties) == 0 && len(d.Attributes) == 0 && len(d.Capabilities) == 0 &&
+		len(d.Requirements) == 0 && len(d.Artifacts) == 0 && len(d.Types) == 0 && len(d.Inputs) == 0
+}
+
+// DiffDeploymentDefinitions computes the differences between the current deployment definition at currentDefPath
+// and an updated one at updatedDefPath
+//
+// In addition to the differences computed by DiffTopologies, types defined in the definitions and in their imports
+// are compared, including the content of the files implementing their operations. Inputs given a new value in inputs
+// are considered as changed. Existing node templates using a changed type, or referencing a changed input using
+// get_input, are considered as changed.
+func DiffDeploymentDefinitions(currentDefPath, updatedDefPath string, inputs map[string]*tosca.ValueAssignment) (TopologyDiff, error) {
+	current, currentTypes, err := readDefinitionTypes(currentDefPath)
+	if err != nil {
+		return TopologyDiff{}, err
+	}
+	updated, updatedTypes, err := readDefinitionTypes(updatedDefPath)
+	if err != nil {
+		return TopologyDiff{}, err
+	}
+	diff := DiffTopologies(current, updated)
+	diff.ChangedTypes = diffDefinitionTypes(currentTypes, updatedTypes)
+	for name := range inputs {
+		if !collections.ContainsString(diff.ChangedInputs, name) {
+			diff.ChangedInputs = append(diff.ChangedInputs, name)
+		}
+	}
+	sort.Strings(diff.ChangedInputs)
+	markChangedNodes(&diff, current, updated, updatedTypes, diff.ChangedInputs)
+	return diff, nil
+}
+
+// DiffTopologies computes the differences between the node templates, workflows and inputs definitions of the
+// current topology of a deployment and an updated one
+//
+// A node template whose type changed is considered as removed then added.
+func DiffTopologies(current, updated tosca.Topology) TopologyDiff {
+	var diff TopologyDiff
+	currentNodes := current.TopologyTemplate.NodeTemplates
+	updatedNodes := updated.TopologyTemplate.NodeTemplates
+	for name, node := range currentNodes {
+		updatedNode, ok := updatedNodes[name]
+		if !ok || updatedNode.Type != node.Type {
+			diff.RemovedNodes = append(diff.RemovedNodes, name)
+			continue
+		}
+		nodeDiff := diffNodeTemplates(name, node, updatedNode)
+		if !nodeDiff.isEmpty() {
+			diff.ChangedNodes = append(diff.ChangedNodes, nodeDiff)
+		}
+	}
+	for name, node := range updatedNodes {
+		currentNode, ok := currentNodes[name]
+		if !ok || currentNode.Type != node.Type {
+			diff.AddedNodes = append(diff.AddedNodes, name)
+		}
+	}
+
+	currentWfs := current.TopologyTemplate.Workflows
+	updatedWfs := updated.TopologyTemplate.Workflows
+	for name, wf := range currentWfs {
+		updatedWf, ok := updatedWfs[name]
+		if !ok {
+			diff.RemovedWorkflows = append(diff.RemovedWorkflows, name)
+		} else if !reflect.DeepEqual(wf, updatedWf) {
+			diff.ChangedWorkflows = append(diff.ChangedWorkflows, name)
+		}
+	}
+	for name := range updatedWfs {
+		if _, ok := currentWfs[name]; !ok {
+			diff.AddedWorkflows = append(diff.AddedWorkflows, name)
+		}
+	}
+
+	diff.ChangedInputs = diffMapKeys(toInterfaceMap(current.TopologyTemplate.Inputs), toInterfaceMap(updated.TopologyTemplate.Inputs))
+
+	sort.Strings(diff.AddedNodes)
+	sort.Strings(diff.RemovedNodes)
+	sort.Slice(diff.ChangedNodes, func(i, j int) bool { return diff.ChangedNodes[i].Name < diff.ChangedNodes[j].Name })
+	sort.Strings(diff.AddedWorkflows)
+	sort.Strings(diff.RemovedWorkflows)
+	sort.Strings(diff.ChangedWorkflows)
+	return diff
+}
+
+func diffNodeTemplates(name string, current, updated tosca.NodeTemplate) NodeDiff {
+	nodeDiff := NodeDiff{Name: name}
+	nodeDiff.Properties = diffValueAssignments(current.Properties, updated.Properties)
+	nodeDiff.Attributes = diffValueAssignments(current.Attributes, updated.Attributes)
+	nodeDiff.Capabilities = diffMapKeys(toInterfaceMap(current.Capabilities), toInterfaceMap(updated.Capabilities))
+	nodeDiff.Requirements = diffMapKeys(requirementsByName(current.Requirements), requirementsByName(updated.Requirements))
+	nodeDiff.Artifacts = diffMapKeys(toInterfaceMap(current.Artifacts), toInterfaceMap(updated.Artifacts))
+	return nodeDiff
+}
+
+func diffValueAssignments(current, updated map[string]*tosca.ValueAssignment) []string {
+	c := make(map[string]interface{}, len(current))
+	for k, v := range current {
+		c[k] = v
+	}
+	u := make(map[string]interface{}, len(updated))
+	for k, v := range updated {
+		u[k] = v
+	}
+	return diffMapKeys(c, u)
+}
+
+// requirementsByName groups requirement assignments by name as a requirement may be declared several times
+func requirementsByName(requirements []tosca.RequirementAssignmentMap) map[string]interface{} {
+	res := make(map[string]interface{})
+	for _, reqMap := range requirements {
+		for name, req := range reqMap {
+			var reqs []tosca.RequirementAssignment
+			if r, ok := res[name]; ok {
+				reqs = r.([]tosca.RequirementAssignment)
+			}
+			res[name] = append(reqs, req)
+		}
+	}
+	return res
+}
+
+// toInterfaceMap converts a map with string keys into a map[string]interface{}
+func toInterfaceMap(m interface{}) map[string]interface{} {
+	res := make(map[string]interface{})
+	v := reflect.ValueOf(m)
+	for _, k := range v.MapKeys() {
+		res[k.String()] = v.MapIndex(k).Interface()
+	}
+	return res
+}
+
+// diffMapKeys returns the sorted keys added, removed or whose value changed between two maps
+func diffMapKeys(current, updated map[string]interface{}) []string {
+	var keys []string
+	for k, v := range current {
+		if u, ok := updated[k]; !ok || !reflect.DeepEqual(v, u) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range updated {
+		if _, ok := current[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// typeDefinition is a type defined in a deployment definition or in one of its imports
+type typeDefinition struct {
+	definition interface{}
+	parent     string
+	// references lists the types used by the definition, except its parent
+	references []string
+	// values lists the value assignments of the definition, they may reference inputs
+	values []*tosca.ValueAssignment
+	// implementations holds the checksums of the files implementing the type operations or delivered as its
+	// artifacts indexed by path
+	implementations map[string]string
+}
+
+// typeReferences collects the types and the value assignments used by a type definition
+type typeReferences struct {
+	types  []string
+	values []*tosca.ValueAssignment
+}
+
+func (r *typeReferences) addProperties(properties map[string]tosca.PropertyDefinition) {
+	for _, p := range properties {
+		r.types = append(r.types, p.Type, p.EntrySchema.Type)
+		r.values = append(r.values, p.Default)
+	}
+}
+
+func (r *typeReferences) addAttributes(attributes map[string]tosca.AttributeDefinition) {
+	for _, a := range attributes {
+		r.types = append(r.types, a.Type, a.EntrySchema.Type)
+		r.values = append(r.values, a.Default)
+	}
+}
+
+func (r *typeReferences) addInputs(inputs map[string]tosca.Input) {
+	for _, input := range inputs {
+		if input.PropDef != nil {
+			r.types = append(r.types, input.PropDef.Type, input.PropDef.EntrySchema.Type)
+			r.values = append(r.values, input.PropDef.Default)
+		}
+		r.values = append(r.values, input.ValueAssign)
+	}
+}
+
+func (r *typeReferences) addInterfaces(interfaces map[string]tosca.InterfaceDefinition) {
+	for _, interfaceDef := range interfaces {
+		r.addInputs(interfaceDef.Inputs)
+		for _, operation := range interfaceDef.Operations {
+			r.addInputs(operation.Inputs)
+		}
+	}
+}
+
+// readDefinitionTypes reads the topology defined at defPath and the types defined in it and in its imports
+func readDefinitionTypes(defPath string) (tosca.Topology, map[string]typeDefinition, error) {
+	topology, err := ReadTopology(defPath)
+	if err != nil {
+		return topology, nil, err
+	}
+	types := make(map[string]typeDefinition)
+	err = addDefinitionTypes(types, topology, filepath.Dir(defPath), "", make(map[string]bool))
+	return topology, types, err
+}
+
+// addDefinitionTypes indexes the types of a topology and of its imports
+//
+// Imports are resolved the same way than storeImports does. Internal imports are skipped as they are the same for
+// all versions of a deployment.
+func addDefinitionTypes(types map[string]typeDefinition, topology tosca.Topology, rootDefPath, importPath string, visitedImports map[string]bool) error {
+	for _, element := range topology.Imports {
+		importURI := strings.Trim(element.File, " \t")
+		if strings.HasPrefix(importURI, "<") && strings.HasSuffix(importURI, ">") {
+			continue
+		}
+		importFile := filepath.Join(rootDefPath, filepath.FromSlash(importPath), filepath.FromSlash(importURI))
+		if visitedImports[importFile] {
+			continue
+		}
+		visitedImports[importFile] = true
+		importedTopology, err := ReadTopology(importFile)
+		if err != nil {
+			return err
+		}
+		err = addDefinitionTypes(types, importedTopology, rootDefPath, path.Dir(path.Join(importPath, importURI)), visitedImports)
+		if err != nil {
+			return err
+		}
+	}
+
+	dir := filepath.Join(rootDefPath, filepath.FromSlash(importPath))
+	for name, t := range topology.DataTypes {
+		refs := &typeReferences{}
+		refs.addProperties(t.Properties)
+		types[name] = typeDefinition{definition: t, parent: t.DerivedFrom, references: refs.types, values: refs.values}
+	}
+	for name, t := range topology.ArtifactTypes {
+		types[name] = typeDefinition{definition: t, parent: t.DerivedFrom}
+	}
+	for name, t := range topology.CapabilityTypes {
+		refs := &typeReferences{}
+		refs.addProperties(t.Properties)
+		refs.addAttributes(t.Attributes)
+		types[name] = typeDefinition{definition: t, parent: t.DerivedFrom, references: refs.types, values: refs.values}
+	}
+	for name, t := range topology.NodeTypes {
+		refs := &typeReferences{}
+		refs.addProperties(t.Properties)
+		refs.addAttributes(t.Attributes)
+		refs.addInterfaces(t.Interfaces)
+		for _, capability := range t.Capabilities {
+			refs.types = append(refs.types, capability.Type)
+			for _, v := range capability.Properties {
+				refs.values = append(refs.values, v)
+			}
+			for _, v := range capability.Attributes {
+				refs.values = append(refs.values, v)
+			}
+		}
+		for _, reqMap := range t.Requirements {
+			for _, req := range reqMap {
+				refs.types = append(refs.types, req.Relationship)
+			}
+		}
+		implementations, err := implementationsChecksums(dir, t.Interfaces, t.Artifacts)
+		if err != nil {
+			return err
+		}
+		types[name] = typeDefinition{definition: t, parent: t.DerivedFrom, references: refs.types, values: refs.values, implementations: implementations}
+	}
+	for name, t := range topology.RelationshipTypes {
+		refs := &typeReferences{}
+		refs.addProperties(t.Properties)
+		refs.addAttributes(t.Attributes)
+		refs.addInterfaces(t.Interfaces)
+		implementations, err := implementationsChecksums(dir, t.Interfaces, t.Artifacts)
+		if err != nil {
+			return err
+		}
+		types[name] = typeDefinition{definition: t, parent: t.DerivedFrom, references: refs.types, values: refs.values, implementations: implementations}
+	}
+	for name, t := range topology.GroupTypes {
+		types[name] = typeDefinition{definition: t, parent: t.DerivedFrom}
+	}
+	for name, t := range topology.PolicyTypes {
+		types[name] = typeDefinition{definition: t, parent: t.DerivedFrom}
+	}
+	return nil
+}
+
+// implementationsChecksums computes the checksums of the files, found in the given directory, implementing the
+// given interfaces operations or delivered as the given artifacts
+//
+// Artifacts from repositories are ignored. Missing files have an empty checksum, they are reported by the
+// deployment definition validation.
+func implementationsChecksums(dir string, interfaces map[string]tosca.InterfaceDefinition, artifacts tosca.ArtifactDefMap) (map[string]string, error) {
+	files := make([]string, 0)
+	for _, interfaceDef := range interfaces {
+		for opName, operation := range interfaceDef.Operations {
+			if opName == "description" {
+				// Interfaces descriptions are parsed as operations
+				continue
+			}
+			implementation := operation.Implementation
+			if implementation.Artifact.Repository != "" {
+				continue
+			}
+			file := implementation.Artifact.File
+			if implementation.Artifact == (tosca.ArtifactDefinition{}) {
+				file = implementation.Primary
+			}
+			files = append(files, file)
+			files = append(files, implementation.Dependencies...)
+		}
+	}
+	for _, artifact := range artifacts {
+		if artifact.Repository == "" {
+			files = append(files, artifact.File)
+		}
+	}
+
+	checksums := make(map[string]string, len(files))
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(file)))
+		if os.IsNotExist(err) {
+			checksums[file] = ""
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to open implementation file %q", file)
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read implementation file %q", file)
+		}
+		checksums[file] = hex.EncodeToString(h.Sum(nil))
+	}
+	return checksums, nil
+}
+
+// diffDefinitionTypes returns the sorted names of the types added, removed or whose definition changed
+func diffDefinitionTypes(current, updated map[string]typeDefinition) []string {
+	var names []string
+	for name, t := range current {
+		u, ok := updated[name]
+		if !ok || !reflect.DeepEqual(t.definition, u.definition) || !reflect.DeepEqual(t.implementations, u.implementations) {
+			names = append(names, name)
+		}
+	}
+	for name := range updated {
+		if _, ok := current[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// markChangedNodes adds to the changed nodes of a diff the node templates existing in both topologies that use
+// one of the changed types of the diff or that reference one of the given changed inputs
+func markChangedNodes(diff *TopologyDiff, current, updated tosca.Topology, types map[string]typeDefinition, changedInputs []string) {
+	changedTypes := make(map[string]bool, len(diff.ChangedTypes))
+	for _, t := range diff.ChangedTypes {
+		changedTypes[t] = true
+	}
+	inputs := make(map[string]bool, len(changedInputs))
+	for _, input := range changedInputs {
+		inputs[input] = true
+	}
+	if len(changedTypes) == 0 && len(inputs) == 0 {
+		return
+	}
+
+	for name, node := range updated.TopologyTemplate.NodeTemplates {
+		currentNode, ok := current.TopologyTemplate.NodeTemplates[name]
+		if !ok || currentNode.Type != node.Type {
+			continue
+		}
+		usedTypes := nodeUsedTypes(node, types)
+		var nodeTypes []string
+		for t := range usedTypes {
+			if changedTypes[t] {
+				nodeTypes = append(nodeTypes, t)
+			}
+		}
+		nodeInputs := nodeReferencedInputs(node, usedTypes, types, inputs)
+		if len(nodeTypes) == 0 && len(nodeInputs) == 0 {
+			continue
+		}
+		sort.Strings(nodeTypes)
+		i := sort.Search(len(diff.ChangedNodes), func(i int) bool { return diff.ChangedNodes[i].Name >= name })
+		if i == len(diff.ChangedNodes) || diff.ChangedNodes[i].Name != name {
+			diff.ChangedNodes = append(diff.ChangedNodes, NodeDiff{})
+			copy(diff.ChangedNodes[i+1:], diff.ChangedNodes[i:])
+			diff.ChangedNodes[i] = NodeDiff{Name: name}
+		}
+		diff.ChangedNodes[i].Types = nodeTypes
+		diff.ChangedNodes[i].Inputs = nodeInputs
+	}
+}
+
+// nodeUsedTypes returns the types used by a node template: its type, the relationship types of its requirements
+// and the types they reference, with all their parents
+func nodeUsedTypes(node tosca.NodeTemplate, types map[string]typeDefinition) map[string]bool {
+	used := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if name == "" || used[name] {
+			return
+		}
+		used[name] = true
+		t, ok := types[name]
+		if !ok {
+			return
+		}
+		visit(t.parent)
+		for _, ref := range t.references {
+			visit(ref)
+		}
+	}
+	visit(node.Type)
+	for _, reqMap := range node.Requirements {
+		for _, req := range reqMap {
+			visit(req.Relationship)
+		}
+	}
+	return used
+}
+
+// nodeReferencedInputs returns the sorted names of the given inputs referenced by the value assignments of a node
+// template or of the types it uses
+func nodeReferencedInputs(node tosca.NodeTemplate, usedTypes map[string]bool, types map[string]typeDefinition, inputs map[string]bool) []string {
+	if len(inputs) == 0 {
+		return nil
+	}
+	values := make([]*tosca.ValueAssignment, 0)
+	for _, v := range node.Properties {
+		values = append(values, v)
+	}
+	for _, v := range node.Attributes {
+		values = append(values, v)
+	}
+	for _, capability := range node.Capabilities {
+		for _, v := range capability.Properties {
+			values = append(values, v)
+		}
+		for _, v := range capability.Attributes {
+			values = append(values, v)
+		}
+	}
+	for _, reqMap := range node.Requirements {
+		for _, req := range reqMap {
+			for _, v := range req.RelationshipProps {
+				values = append(values, v)
+			}
+		}
+	}
+	for t := range usedTypes {
+		values = append(values, types[t].values...)
+	}
+
+	referenced := make(map[string]bool)
+	for _, va := range values {
+		if va != nil && va.Type == tosca.ValueAssignmentFunction {
+			addReferencedInputs(va.GetFunction(), inputs, referenced)
+		}
+	}
+	if len(referenced) == 0 {
+		return nil
+	}
+	return setToSortedSlice(referenced)
+}
+
+// addReferencedInputs adds to referenced the given inputs used by a function or by its nested functions
+func addReferencedInputs(f *tosca.Function, inputs, referenced map[string]bool) {
+	if f == nil {
+		return
+	}
+	for i, op := range f.Operands {
+		if !op.IsLiteral() {
+			addReferencedInputs(op.(*tosca.Function), inputs, referenced)
+		} else if i == 0 && f.Operator == tosca.GetInputOperator {
+			if name := string(op.(tosca.LiteralOperand)); inputs[name] {
+				referenced[name] = true
+			}
+		}
+	}
+}
+
+// UpdateDeploymentDefinition replaces the stored definition of a deployment by the topology defined at defPath
+//
+// Nodes instances and relationships instances are kept, only instances of nodes that do not exist yet are created.
+// Instances of removed nodes should have been deleted before calling this function.
+// Input values previously supplied for inputs still defined in the new topology are kept unless a new value is given in inputs.
+// The new definition is fully stored before replacing the current one, so that the current definition is left
+// untouched if the new one can't be stored.
+func UpdateDeploymentDefinition(ctx context.Context, kv *api.KV, deploymentID string, defPath string, inputs map[string]*tosca.ValueAssignment) error {
+	topology, err := ReadTopology(defPath)
+	if err != nil {
+		return err
+	}
+
+	depPath := path.Join(consulutil.DeploymentKVPrefix, deploymentID)
+	inputsPrefix := path.Join(depPath, "topology", "inputs")
+	previousInputs, err := GetInputsWithValue(kv, deploymentID)
+	if err != nil {
+		return err
+	}
+	if err = ValidateInputValues(topology, inputs, previousInputs...); err != nil {
+		return err
+	}
+	applyInputValues(&topology, inputs)
+	previousValues := make(api.KVPairs, 0)
+	for _, name := range previousInputs {
+		if _, ok := topology.TopologyTemplate.Inputs[name]; !ok || inputs[name] != nil {
+			continue
+		}
+		kvps, _, err := kv.List(path.Join(inputsPrefix, name, "value"), nil)
+		if err != nil {
+			return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		previousValues = append(previousValues, kvps...)
+	}
+
+	// Store the new definition in a staging area first so that a failure while storing it leaves the current
+	// definition untouched
+	stagingID := path.Join(deploymentID, updateStagingDir)
+	stagingPath := path.Join(consulutil.DeploymentKVPrefix, stagingID)
+	if _, err = kv.DeleteTree(stagingPath+"/", nil); err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	errCtx, errGroup, consulStore := consulutil.WithContext(ctx)
+	errCtx = context.WithValue(errCtx, errGrpKey, errGroup)
+	errCtx = context.WithValue(errCtx, consulStoreKey, consulStore)
+	for _, kvp := range previousValues {
+		consulStore.StoreConsulKeyWithFlags(path.Join(stagingPath, strings.TrimPrefix(kvp.Key, depPath)), kvp.Value, kvp.Flags)
+	}
+	errGroup.Go(func() error {
+		return storeTopology(errCtx, topology, stagingID, path.Join(stagingPath, "topology"), "", "", filepath.Dir(defPath))
+	})
+	if err = errGroup.Wait(); err != nil {
+		kv.DeleteTree(stagingPath+"/", nil)
+		return errors.Wrapf(err, "Failed to store updated TOSCA Definition for deployment with id %q, (file path %q)", deploymentID, defPath)
+	}
+	if err = swapStagedDefinition(kv, depPath, stagingPath); err != nil {
+		return errors.Wrapf(err, "Failed to replace TOSCA Definition for deployment with id %q", deploymentID)
+	}
+	err = registerImplementationTypes(ctx, kv, deploymentID)
+	if err != nil {
+		return err
+	}
+
+	return enhanceNodes(ctx, kv, deploymentID)
+}
+
+// SnapshotDeploymentDefinition copies the stored definition of a deployment (its topology without instances and its
+// workflows) under snapshotPath so that it can be restored later using RestoreDeploymentDefinition
+func SnapshotDeploymentDefinition(ctx context.Context, kv *api.KV, deploymentID, snapshotPath string) error {
+	depPath := path.Join(consulutil.DeploymentKVPrefix, deploymentID)
+	topologyKeys, _, err := kv.Keys(path.Join(depPath, "topology")+"/", "/", nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if _, err = kv.DeleteTree(snapshotPath+"/", nil); err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	_, errGroup, consulStore := consulutil.WithContext(ctx)
+	for _, key := range append(topologyKeys, path.Join(depPath, "workflows")+"/") {
+		switch path.Base(key) {
+		case "instances", "relationship_instances":
+			continue
+		}
+		var kvps api.KVPairs
+		if strings.HasSuffix(key, "/") {
+			kvps, _, err = kv.List(key, nil)
+		} else {
+			// Listing a key which is not a tree would also return its siblings sharing the same prefix
+			var kvp *api.KVPair
+			kvp, _, err = kv.Get(key, nil)
+			if kvp != nil {
+				kvps = append(kvps, kvp)
+			}
+		}
+		if err != nil {
+			return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		for _, kvp := range kvps {
+			consulStore.StoreConsulKeyWithFlags(path.Join(snapshotPath, strings.TrimPrefix(kvp.Key, depPath)), kvp.Value, kvp.Flags)
+		}
+	}
+	return errors.Wrapf(errGroup.Wait(), "Failed to snapshot TOSCA Definition for deployment with id %q", deploymentID)
+}
+
+// RestoreDeploymentDefinition replaces the stored definition of a deployment by a snapshot taken by
+// SnapshotDeploymentDefinition, then removes this snapshot
+//
+// Like for UpdateDeploymentDefinition, existing instances are kept and instances of nodes that do not have any
+// are created.
+func RestoreDeploymentDefinition(ctx context.Context, kv *api.KV, deploymentID, snapshotPath string) error {
+	keys, _, err := kv.Keys(path.Join(snapshotPath, "topology")+"/", "/", nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if len(keys) == 0 {
+		return errors.Errorf("no TOSCA Definition snapshot found at %q for deployment with id %q", snapshotPath, deploymentID)
+	}
+	depPath := path.Join(consulutil.DeploymentKVPrefix, deploymentID)
+	if err = swapStagedDefinition(kv, depPath, snapshotPath); err != nil {
+		return errors.Wrapf(err, "Failed to restore TOSCA Definition for deployment with id %q", deploymentID)
+	}
+	// Implementation types are not registered again as the snapshot already contains them
+	return enhanceNodes(ctx, kv, deploymentID)
+}
+
+// swapStagedDefinition replaces the topology (except instances) and the workflows stored under depPath by the
+// definition staged under stagingPath, then removes the staging area.
+//
+// The swap is done within a single Consul transaction unless it exceeds the maximum number of operations
+// allowed in a transaction. In this case it is split and the staging area is only removed by the last transaction.
+func swapStagedDefinition(kv *api.KV, depPath, stagingPath string) error {
+	topologyKeys, _, err := kv.Keys(path.Join(depPath, "topology")+"/", "/", nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	stagedKVPs, _, err := kv.List(stagingPath+"/", nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+
+	ops := make(api.KVTxnOps, 0, len(topologyKeys)+len(stagedKVPs)+2)
+	for _, key := range topologyKeys {
+		switch path.Base(key) {
+		case "instances", "relationship_instances":
+			continue
+		}
+		ops = append(ops, &api.KVTxnOp{Verb: api.KVDeleteTree, Key: key})
+	}
+	ops = append(ops, &api.KVTxnOp{Verb: api.KVDeleteTree, Key: path.Join(depPath, "workflows") + "/"})
+	for _, kvp := range stagedKVPs {
+		ops = append(ops, &api.KVTxnOp{
+			Verb:  api.KVSet,
+			Key:   path.Join(depPath, strings.TrimPrefix(kvp.Key, stagingPath)),
+			Value: kvp.Value,
+			Flags: kvp.Flags,
+		})
+	}
+	ops = append(ops, &api.KVTxnOp{Verb: api.KVDeleteTree, Key: stagingPath + "/"})
+
+	// Need to split the transaction if there are more than the max number of
+	// operations in a transaction supported by Consul
+	for begin := 0; begin < len(ops); begin += maxNbTransactionOps {
+		end := begin + maxNbTransactionOps
+		if end > len(ops) {
+			end = len(ops)
+		}
+		ok, response, _, err := kv.Txn(ops[begin:end], nil)
+		if err != nil {
+			return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if !ok {
+			var errs []string
+			for _, e := range response.Errors {
+				errs = append(errs, e.What)
+			}
+			return errors.Errorf("transaction failed: %s", strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/testutil"
+)
+
+func TestDiffTopologies(t *testing.T) {
+	t.Parallel()
+	current, err := ReadTopology("testdata/topology_update_current.yaml")
+	require.NoError(t, err)
+	updated, err := ReadTopology("testdata/topology_update_updated.yaml")
+	require.NoError(t, err)
+
+	require.True(t, DiffTopologies(current, current).IsEmpty())
+
+	diff := DiffTopologies(current, updated)
+	require.False(t, diff.IsEmpty())
+	require.Equal(t, []string{"NewSoft"}, diff.AddedNodes)
+	require.Equal(t, []string{"OldSoft"}, diff.RemovedNodes)
+	require.Equal(t, []NodeDiff{{Name: "Soft", Properties: []string{"admin_credential", "component_version"}}}, diff.ChangedNodes)
+	require.Equal(t, []string{"maintenance"}, diff.AddedWorkflows)
+	require.Equal(t, []string{"obsolete"}, diff.RemovedWorkflows)
+	require.Equal(t, []string{"install"}, diff.ChangedWorkflows)
+
+	// A type change is handled as a node replacement
+	node := updated.TopologyTemplate.NodeTemplates["Soft"]
+	node.Type = "tosca.nodes.WebServer"
+	updated.TopologyTemplate.NodeTemplates["Soft"] = node
+	diff = DiffTopologies(current, updated)
+	require.Equal(t, []string{"NewSoft", "Soft"}, diff.AddedNodes)
+	require.Equal(t, []string{"OldSoft", "Soft"}, diff.RemovedNodes)
+	require.Len(t, diff.ChangedNodes, 0)
+}
+
+// copyDefinition copies the deployment definition directory src into a temporary directory
+func copyDefinition(t *testing.T, src string) string {
+	dst, err := ioutil.TempDir("", "yorc-topology-diff-")
+	require.NoError(t, err)
+	err = filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(dst, rel), content, info.Mode())
+	})
+	require.NoError(t, err)
+	return dst
+}
+
+// replaceInFile replaces old by new in the given file
+func replaceInFile(t *testing.T, file, old, new string) {
+	content, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	require.Contains(t, string(content), old)
+	require.NoError(t, ioutil.WriteFile(file, []byte(strings.Replace(string(content), old, new, 1)), 0644))
+}
+
+func TestDiffDeploymentDefinitions(t *testing.T) {
+	t.Parallel()
+	currentDefPath := "testdata/topology_update_types/topology.yaml"
+
+	t.Run("NoChanges", func(t *testing.T) {
+		updatedDir := copyDefinition(t, "testdata/topology_update_types")
+		defer os.RemoveAll(updatedDir)
+		diff, err := DiffDeploymentDefinitions(currentDefPath, filepath.Join(updatedDir, "topology.yaml"), nil)
+		require.NoError(t, err)
+		require.True(t, diff.IsEmpty(), "unexpected diff %+v", diff)
+	})
+
+	t.Run("InputValue", func(t *testing.T) {
+		inputs, err := ParseInputValues([]byte("port: 9090"))
+		require.NoError(t, err)
+		diff, err := DiffDeploymentDefinitions(currentDefPath, currentDefPath, inputs)
+		require.NoError(t, err)
+		require.False(t, diff.IsEmpty())
+		require.Equal(t, []string{"port"}, diff.ChangedInputs)
+		require.Equal(t, []NodeDiff{{Name: "App", Inputs: []string{"port"}}}, diff.ChangedNodes)
+	})
+
+	t.Run("InputDefault", func(t *testing.T) {
+		updatedDir := copyDefinition(t, "testdata/topology_update_types")
+		defer os.RemoveAll(updatedDir)
+		replaceInFile(t, filepath.Join(updatedDir, "topology.yaml"), "default: 8080", "default: 9090")
+		diff, err := DiffDeploymentDefinitions(currentDefPath, filepath.Join(updatedDir, "topology.yaml"), nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"port"}, diff.ChangedInputs)
+		require.Len(t, diff.ChangedTypes, 0)
+		require.Equal(t, []NodeDiff{{Name: "App", Inputs: []string{"port"}}}, diff.ChangedNodes)
+	})
+
+	t.Run("InputUsedByOperation", func(t *testing.T) {
+		updatedDir := copyDefinition(t, "testdata/topology_update_types")
+		defer os.RemoveAll(updatedDir)
+		replaceInFile(t, filepath.Join(updatedDir, "topology.yaml"), "default: info", "default: debug")
+		diff, err := DiffDeploymentDefinitions(currentDefPath, filepath.Join(updatedDir, "topology.yaml"), nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"log_level"}, diff.ChangedInputs)
+		require.Equal(t, []NodeDiff{{Name: "App", Inputs: []string{"log_level"}}}, diff.ChangedNodes)
+	})
+
+	t.Run("OperationImplementation", func(t *testing.T) {
+		updatedDir := copyDefinition(t, "testdata/topology_update_types")
+		defer os.RemoveAll(updatedDir)
+		replaceInFile(t, filepath.Join(updatedDir, "scripts", "configure.sh"), "Configuring application", "Reconfiguring application")
+		diff, err := DiffDeploymentDefinitions(currentDefPath, filepath.Join(updatedDir, "topology.yaml"), nil)
+		require.NoError(t, err)
+		require.Len(t, diff.ChangedInputs, 0)
+		require.Equal(t, []string{"ystia.tests.nodes.App"}, diff.ChangedTypes)
+		require.Equal(t, []NodeDiff{{Name: "App", Types: []string{"ystia.tests.nodes.App"}}}, diff.ChangedNodes)
+	})
+
+	t.Run("NodeType", func(t *testing.T) {
+		updatedDir := copyDefinition(t, "testdata/topology_update_types")
+		defer os.RemoveAll(updatedDir)
+		replaceInFile(t, filepath.Join(updatedDir, "types.yaml"), "implementation: scripts/configure.sh", "implementation:\n            file: scripts/configure.sh\n            type: tosca.artifacts.Implementation.Bash\n            timeout: 5m")
+		diff, err := DiffDeploymentDefinitions(currentDefPath, filepath.Join(updatedDir, "topology.yaml"), nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"ystia.tests.nodes.App"}, diff.ChangedTypes)
+		require.Equal(t, []NodeDiff{{Name: "App", Types: []string{"ystia.tests.nodes.App"}}}, diff.ChangedNodes)
+	})
+
+	t.Run("DataType", func(t *testing.T) {
+		updatedDir := copyDefinition(t, "testdata/topology_update_types")
+		defer os.RemoveAll(updatedDir)
+		replaceInFile(t, filepath.Join(updatedDir, "types.yaml"), "timeout:\n        type: integer", "timeout:\n        type: string")
+		diff, err := DiffDeploymentDefinitions(currentDefPath, filepath.Join(updatedDir, "topology.yaml"), nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"ystia.tests.datatypes.Settings"}, diff.ChangedTypes)
+		require.Equal(t, []NodeDiff{{Name: "App", Types: []string{"ystia.tests.datatypes.Settings"}}}, diff.ChangedNodes)
+	})
+
+	t.Run("MissingImport", func(t *testing.T) {
+		updatedDir := copyDefinition(t, "testdata/topology_update_types")
+		defer os.RemoveAll(updatedDir)
+		require.NoError(t, os.Remove(filepath.Join(updatedDir, "types.yaml")))
+		_, err := DiffDeploymentDefinitions(currentDefPath, filepath.Join(updatedDir, "topology.yaml"), nil)
+		require.Error(t, err)
+	})
+}
+
+func testUpdateDeploymentDefinition(t *testing.T, kv *api.KV) {
+	deploymentID := testutil.BuildDeploymentID(t)
+	ctx := context.Background()
+	err := StoreDeploymentDefinition(ctx, kv, deploymentID, "testdata/topology_update_current.yaml")
+	require.NoError(t, err)
+	err = SetInstanceAttribute(deploymentID, "Soft", "0", "state", "started")
+	require.NoError(t, err)
+
+	// Instances of removed nodes are expected to be deleted before the update
+	require.NoError(t, DeleteInstance(kv, deploymentID, "OldSoft", "0"))
+	err = UpdateDeploymentDefinition(ctx, kv, deploymentID, "testdata/topology_update_updated.yaml", nil)
+	require.NoError(t, err)
+
+	nodes, err := GetNodes(kv, deploymentID)
+	require.NoError(t, err)
+	require.Contains(t, nodes, "NewSoft")
+	require.NotContains(t, nodes, "OldSoft")
+
+	// Existing instances are kept
+	found, state, err := GetInstanceAttribute(kv, deploymentID, "Soft", "0", "state")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "started", state)
+	ids, err := GetNodeInstancesIds(kv, deploymentID, "NewSoft")
+	require.NoError(t, err)
+	require.Equal(t, []string{"0"}, ids)
+
+	wfs, _, err := kv.Keys(path.Join(consulutil.DeploymentKVPrefix, deploymentID, "workflows")+"/", "/", nil)
+	require.NoError(t, err)
+	require.Len(t, wfs, 3)
+	require.NotContains(t, wfs, path.Join(consulutil.DeploymentKVPrefix, deploymentID, "workflows", "obsolete")+"/")
+}
+
+func testRestoreDeploymentDefinition(t *testing.T, kv *api.KV) {
+	deploymentID := testutil.BuildDeploymentID(t)
+	ctx := context.Background()
+	snapshotPath := path.Join(consulutil.TasksPrefix, deploymentID, "rollback", "definition")
+	err := StoreDeploymentDefinition(ctx, kv, deploymentID, "testdata/topology_update_current.yaml")
+	require.NoError(t, err)
+	err = SetInstanceAttribute(deploymentID, "Soft", "0", "state", "started")
+	require.NoError(t, err)
+
+	err = RestoreDeploymentDefinition(ctx, kv, deploymentID, snapshotPath)
+	require.Error(t, err, "restoring a missing snapshot should fail")
+
+	err = SnapshotDeploymentDefinition(ctx, kv, deploymentID, snapshotPath)
+	require.NoError(t, err)
+	require.NoError(t, DeleteInstance(kv, deploymentID, "OldSoft", "0"))
+	err = UpdateDeploymentDefinition(ctx, kv, deploymentID, "testdata/topology_update_updated.yaml", nil)
+	require.NoError(t, err)
+
+	// Instances of nodes added by the update are expected to be deleted before the restore
+	require.NoError(t, DeleteInstance(kv, deploymentID, "NewSoft", "0"))
+	err = RestoreDeploymentDefinition(ctx, kv, deploymentID, snapshotPath)
+	require.NoError(t, err)
+
+	nodes, err := GetNodes(kv, deploymentID)
+	require.NoError(t, err)
+	require.Contains(t, nodes, "OldSoft")
+	require.NotContains(t, nodes, "NewSoft")
+	found, version, err := GetNodeProperty(kv, deploymentID, "Soft", "component_version")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "1.0", version)
+
+	// Existing instances are kept and removed nodes get new instances
+	found, state, err := GetInstanceAttribute(kv, deploymentID, "Soft", "0", "state")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "started", state)
+	ids, err := GetNodeInstancesIds(kv, deploymentID, "OldSoft")
+	require.NoError(t, err)
+	require.Equal(t, []string{"0"}, ids)
+
+	wfs, _, err := kv.Keys(path.Join(consulutil.DeploymentKVPrefix, deploymentID, "workflows")+"/", "/", nil)
+	require.NoError(t, err)
+	require.Contains(t, wfs, path.Join(consulutil.DeploymentKVPrefix, deploymentID, "workflows", "obsolete")+"/")
+	require.NotContains(t, wfs, path.Join(consulutil.DeploymentKVPrefix, deploymentID, "workflows", "maintenance")+"/")
+
+	snapshotKeys, _, err := kv.Keys(snapshotPath+"/", "/", nil)
+	require.NoError(t, err)
+	require.Len(t, snapshotKeys, 0, "snapshot should be removed once restored")
+}
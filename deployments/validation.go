@@ -0,0 +1,642 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ystia/yorc/tosca"
+)
+
+// A ValidationIssue is a problem found while validating a deployment definition
+type ValidationIssue struct {
+	// Location is the path of the faulty element within the definition (ex: node_templates/Compute/requirements/local_storage)
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+// A ValidationReport is the result of a deployment definition validation
+//
+// A definition is valid if no errors were found, warnings report elements that may not behave as expected at runtime.
+type ValidationReport struct {
+	Valid    bool              `json:"valid"`
+	Errors   []ValidationIssue `json:"errors"`
+	Warnings []ValidationIssue `json:"warnings"`
+}
+
+// typeOrigin records where a TOSCA type was defined
+type typeOrigin struct {
+	// importPath is the directory of the definition file relative to the root of the CSAR
+	importPath string
+	// internal is true for types coming from definitions embedded into Yorc or its plugins
+	internal bool
+}
+
+// definitionValidator holds the state of a deployment definition validation
+type definitionValidator struct {
+	rootDefPath       string
+	topology          tosca.Topology
+	parents           map[string]string
+	origins           map[string]typeOrigin
+	nodeTypes         map[string]tosca.NodeType
+	relationshipTypes map[string]tosca.RelationshipType
+	capabilityTypes   map[string]tosca.CapabilityType
+	artifactTypes     map[string]tosca.ArtifactType
+	visitedImports    map[string]bool
+	report            ValidationReport
+}
+
+// ValidateDeploymentDefinition checks the deployment definition at defPath without storing anything
+//
+// Imports and types are resolved, requirements are checked against the capabilities of their targets, TOSCA functions
+// are checked for being statically resolvable and operations implementations should exist and be supported by a
+// registered executor. Given inputs values are validated as they would be at deployment time.
+func ValidateDeploymentDefinition(defPath string, inputs map[string]*tosca.ValueAssignment) ValidationReport {
+	v := &definitionValidator{
+		rootDefPath:       filepath.Dir(defPath),
+		parents:           make(map[string]string),
+		origins:           make(map[string]typeOrigin),
+		nodeTypes:         make(map[string]tosca.NodeType),
+		relationshipTypes: make(map[string]tosca.RelationshipType),
+		capabilityTypes:   make(map[string]tosca.CapabilityType),
+		artifactTypes:     make(map[string]tosca.ArtifactType),
+		visitedImports:    make(map[string]bool),
+		report:            ValidationReport{Errors: make([]ValidationIssue, 0), Warnings: make([]ValidationIssue, 0)},
+	}
+	topology, err := ReadTopology(defPath)
+	if err != nil {
+		v.errorf("", "%v", err)
+		return v.result()
+	}
+	v.topology = topology
+	v.addTopology(topology, "", "", false)
+
+	for _, check := range []func(tosca.Topology) error{checkNestedWorkflows, checkWorkflowsSteps, checkGroupsAndPolicies} {
+		if err := check(topology); err != nil {
+			v.errorf("topology_template", "%v", err)
+		}
+	}
+	if err := ValidateInputValues(topology, inputs); err != nil {
+		v.errorf("topology_template/inputs", "%v", err)
+	}
+	v.checkTypesHierarchy()
+	v.checkNodeTemplates()
+	v.checkOutputs()
+	v.checkImplementations()
+	v.checkDelegates()
+	return v.result()
+}
+
+func (v *definitionValidator) errorf(location, format string, args ...interface{}) {
+	v.report.Errors = append(v.report.Errors, ValidationIssue{Location: location, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *definitionValidator) warnf(location, format string, args ...interface{}) {
+	v.report.Warnings = append(v.report.Warnings, ValidationIssue{Location: location, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *definitionValidator) result() ValidationReport {
+	v.report.Valid = len(v.report.Errors) == 0
+	sortValidationIssues(v.report.Errors)
+	sortValidationIssues(v.report.Warnings)
+	return v.report
+}
+
+// sortValidationIssues sorts issues by location to get a stable report
+func sortValidationIssues(issues []ValidationIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Location == issues[j].Location {
+			return issues[i].Message < issues[j].Message
+		}
+		return issues[i].Location < issues[j].Location
+	})
+}
+
+// addTopology indexes the types of a topology and of its imports
+//
+// Imports are resolved the same way than storeImports does.
+func (v *definitionValidator) addTopology(topology tosca.Topology, location, importPath string, internal bool) {
+	for _, element := range topology.Imports {
+		importURI := strings.Trim(element.File, " \t")
+		importLocation := path.Join(location, "imports", importURI)
+		importedTopology := tosca.Topology{}
+		var defBytes []byte
+		var err error
+		var nestedImportPath string
+		isInternal := strings.HasPrefix(importURI, "<") && strings.HasSuffix(importURI, ">")
+		if isInternal {
+			importURI = strings.Trim(importURI, "<>")
+			if v.visitedImports[importURI] {
+				continue
+			}
+			v.visitedImports[importURI] = true
+			if defBytes, err = reg.GetToscaDefinition(importURI); err != nil {
+				v.errorf(importLocation, "failed to import internal definition %s: %v", importURI, err)
+				continue
+			}
+		} else {
+			nestedImportPath = path.Dir(path.Join(importPath, importURI))
+			importFile := filepath.Join(v.rootDefPath, filepath.FromSlash(importPath), filepath.FromSlash(importURI))
+			if v.visitedImports[importFile] {
+				continue
+			}
+			v.visitedImports[importFile] = true
+			if defBytes, err = ioutil.ReadFile(importFile); os.IsNotExist(err) {
+				v.errorf(importLocation, "imported definition %q not found in archive", path.Join(importPath, importURI))
+				continue
+			} else if err != nil {
+				v.errorf(importLocation, "failed to read imported definition %s: %v", importURI, err)
+				continue
+			}
+		}
+		if err = yaml.Unmarshal(defBytes, &importedTopology); err != nil {
+			v.errorf(importLocation, "failed to parse imported definition %s: %v", importURI, err)
+			continue
+		}
+		v.addTopology(importedTopology, importLocation, nestedImportPath, isInternal)
+	}
+
+	origin := typeOrigin{importPath: importPath, internal: internal}
+	addType := func(name string, t tosca.Type) {
+		v.parents[name] = t.DerivedFrom
+		v.origins[name] = origin
+	}
+	for name, t := range topology.DataTypes {
+		addType(name, t.Type)
+	}
+	for name, t := range topology.ArtifactTypes {
+		addType(name, t.Type)
+		v.artifactTypes[name] = t
+	}
+	for name, t := range topology.CapabilityTypes {
+		addType(name, t.Type)
+		v.capabilityTypes[name] = t
+	}
+	for name, t := range topology.NodeTypes {
+		addType(name, t.Type)
+		v.nodeTypes[name] = t
+	}
+	for name, t := range topology.RelationshipTypes {
+		addType(name, t.Type)
+		v.relationshipTypes[name] = t
+	}
+	for name, t := range topology.GroupTypes {
+		addType(name, t.Type)
+	}
+	for name, t := range topology.PolicyTypes {
+		addType(name, t.Type)
+	}
+}
+
+// typeHierarchy returns the given type followed by its known ancestors
+func (v *definitionValidator) typeHierarchy(typeName string) []string {
+	hierarchy := make([]string, 0)
+	for typeName != "" {
+		if _, known := v.parents[typeName]; !known {
+			break
+		}
+		for _, t := range hierarchy {
+			if t == typeName {
+				// Cycles are reported by checkTypesHierarchy
+				return hierarchy
+			}
+		}
+		hierarchy = append(hierarchy, typeName)
+		typeName = v.parents[typeName]
+	}
+	return hierarchy
+}
+
+func (v *definitionValidator) isDerivedFrom(typeName, parentType string) bool {
+	for _, t := range v.typeHierarchy(typeName) {
+		if t == parentType {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTypesHierarchy reports types derived from unknown types
+func (v *definitionValidator) checkTypesHierarchy() {
+	for name, parent := range v.parents {
+		parent := parent
+		if parent == "" || tosca.IsBuiltinType(parent) {
+			continue
+		}
+		if _, known := v.parents[parent]; !known {
+			v.warnf(path.Join("types", name), "type %q is derived from unknown type %q", name, parent)
+		}
+	}
+}
+
+func (v *definitionValidator) checkNodeTemplates() {
+	nodeTemplates := v.topology.TopologyTemplate.NodeTemplates
+	for nodeName, node := range nodeTemplates {
+		location := path.Join("node_templates", nodeName)
+		if _, ok := v.nodeTypes[node.Type]; !ok {
+			v.errorf(location, "node template %q has an unknown type %q", nodeName, node.Type)
+		}
+		for propName, prop := range node.Properties {
+			v.checkValueAssignment(path.Join(location, "properties", propName), prop, nodeName, false)
+		}
+		for attrName, attr := range node.Attributes {
+			v.checkValueAssignment(path.Join(location, "attributes", attrName), attr, nodeName, false)
+		}
+		for capName, capability := range node.Capabilities {
+			for propName, prop := range capability.Properties {
+				v.checkValueAssignment(path.Join(location, "capabilities", capName, "properties", propName), prop, nodeName, false)
+			}
+			for attrName, attr := range capability.Attributes {
+				v.checkValueAssignment(path.Join(location, "capabilities", capName, "attributes", attrName), attr, nodeName, false)
+			}
+		}
+		for _, reqMap := range node.Requirements {
+			for reqName, req := range reqMap {
+				v.checkRequirement(path.Join(location, "requirements", reqName), nodeName, node.Type, reqName, req)
+			}
+		}
+	}
+}
+
+// requirementDefinition looks for the definition of a requirement in a node type hierarchy
+func (v *definitionValidator) requirementDefinition(nodeType, reqName string) (tosca.RequirementDefinition, bool) {
+	for _, t := range v.typeHierarchy(nodeType) {
+		for _, reqDefMap := range v.nodeTypes[t].Requirements {
+			if reqDef, ok := reqDefMap[reqName]; ok {
+				return reqDef, true
+			}
+		}
+	}
+	return tosca.RequirementDefinition{}, false
+}
+
+func (v *definitionValidator) checkRequirement(location, nodeName, nodeType, reqName string, req tosca.RequirementAssignment) {
+	reqDef, defined := v.requirementDefinition(nodeType, reqName)
+	if !defined {
+		v.warnf(location, "requirement %q is not defined by node type %q", reqName, nodeType)
+	}
+	for propName, prop := range req.RelationshipProps {
+		v.checkValueAssignment(path.Join(location, "relationship", "properties", propName), prop, nodeName, true)
+	}
+
+	relationship := req.Relationship
+	if relationship == "" {
+		relationship = reqDef.Relationship
+	}
+	if relationship != "" {
+		if _, ok := v.relationshipTypes[relationship]; !ok {
+			v.errorf(location, "requirement %q uses an unknown relationship type %q", reqName, relationship)
+		}
+	}
+
+	if req.Node == "" {
+		v.warnf(location, "requirement %q of node template %q has no target node", reqName, nodeName)
+		return
+	}
+	target, ok := v.topology.TopologyTemplate.NodeTemplates[req.Node]
+	if !ok {
+		v.errorf(location, "requirement %q targets an unknown node template %q", reqName, req.Node)
+		return
+	}
+	capability := req.Capability
+	if capability == "" {
+		capability = reqDef.Capability
+	}
+	if capability == "" || len(v.typeHierarchy(target.Type)) == 0 {
+		return
+	}
+	if !v.hasCapability(target.Type, capability) {
+		v.errorf(location, "requirement %q can't be fulfilled by node template %q of type %q: no capability matching %q", reqName, req.Node, target.Type, capability)
+	}
+}
+
+// hasCapability checks if a node type has a capability with the given name or of a type derived from the given one
+func (v *definitionValidator) hasCapability(nodeType, capability string) bool {
+	_, isType := v.capabilityTypes[capability]
+	for _, t := range v.typeHierarchy(nodeType) {
+		for capName, capDef := range v.nodeTypes[t].Capabilities {
+			if capName == capability || isType && v.isDerivedFrom(capDef.Type, capability) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *definitionValidator) checkOutputs() {
+	outputs := v.topology.TopologyTemplate.Outputs
+	for outputName, output := range outputs {
+		v.checkValueAssignment(path.Join("topology_template/outputs", outputName), output.Value, "", false)
+	}
+}
+
+// checkValueAssignment checks that TOSCA functions of a value can be resolved
+//
+// nodeName is the node template in which the value is defined if any. It is used to resolve the SELF keyword.
+// inRelationship should be set if the value is defined in the context of a relationship.
+func (v *definitionValidator) checkValueAssignment(location string, va *tosca.ValueAssignment, nodeName string, inRelationship bool) {
+	if va == nil || va.Type != tosca.ValueAssignmentFunction {
+		return
+	}
+	v.checkFunction(location, va.GetFunction(), nodeName, inRelationship)
+}
+
+func (v *definitionValidator) checkFunction(location string, f *tosca.Function, nodeName string, inRelationship bool) {
+	if f == nil {
+		return
+	}
+	literal := func(i int) string {
+		if i >= len(f.Operands) || !f.Operands[i].IsLiteral() {
+			return ""
+		}
+		return string(f.Operands[i].(tosca.LiteralOperand))
+	}
+	for _, op := range f.Operands {
+		if !op.IsLiteral() {
+			v.checkFunction(location, op.(*tosca.Function), nodeName, inRelationship)
+		}
+	}
+
+	minOperands := map[tosca.Operator]int{
+		tosca.GetInputOperator:           1,
+		tosca.GetPropertyOperator:        2,
+		tosca.GetAttributeOperator:       2,
+		tosca.GetOperationOutputOperator: 4,
+		tosca.TokenOperator:              3,
+		tosca.GetNodesOfTypeOperator:     1,
+		tosca.GetArtifactOperator:        2,
+	}
+	if len(f.Operands) < minOperands[f.Operator] {
+		v.errorf(location, "%q: expecting at least %d parameters", f, minOperands[f.Operator])
+		return
+	}
+
+	switch f.Operator {
+	case tosca.GetInputOperator:
+		inputName := literal(0)
+		if _, ok := v.topology.TopologyTemplate.Inputs[inputName]; inputName != "" && !ok {
+			v.errorf(location, "%q: unknown input %q", f, inputName)
+		}
+	case tosca.TokenOperator:
+		if len(f.Operands) != 3 {
+			v.errorf(location, "%q: expecting exactly 3 parameters", f)
+		}
+	case tosca.GetNodesOfTypeOperator:
+		typeName := literal(0)
+		if _, ok := v.nodeTypes[typeName]; typeName != "" && !ok {
+			v.warnf(location, "%q: unknown node type %q", f, typeName)
+		}
+	case tosca.GetPropertyOperator, tosca.GetAttributeOperator, tosca.GetOperationOutputOperator, tosca.GetArtifactOperator:
+		entity := literal(0)
+		switch entity {
+		case funcKeywordSELF:
+			v.checkFunctionAttribute(location, f, nodeName, literal(1), len(f.Operands))
+		case funcKeywordREQTARGET:
+			if f.Operator == tosca.GetArtifactOperator {
+				v.errorf(location, "%q: keyword %q is not supported", f, funcKeywordREQTARGET)
+			}
+		case funcKeywordHOST:
+			if inRelationship {
+				v.errorf(location, "%q: keyword %q is not supported in the context of a relationship", f, funcKeywordHOST)
+			}
+		case funcKeywordSOURCE, funcKeywordTARGET, funcKeywordRTARGET:
+			if !inRelationship {
+				v.errorf(location, "%q: keyword %q is only supported in the context of a relationship", f, entity)
+			}
+		case "":
+		default:
+			if _, ok := v.topology.TopologyTemplate.NodeTemplates[entity]; !ok {
+				v.errorf(location, "%q: unknown node template %q", f, entity)
+				return
+			}
+			v.checkFunctionAttribute(location, f, entity, literal(1), len(f.Operands))
+		}
+	}
+}
+
+// checkFunctionAttribute checks that a get_property or get_attribute function refers to a property or an attribute
+// of the given node template and that a get_artifact function refers to one of its artifacts
+//
+// Only simple forms (entity and property name) are checked, attributes are only warned about as they may be set at
+// runtime.
+func (v *definitionValidator) checkFunctionAttribute(location string, f *tosca.Function, nodeName, name string, nbOperands int) {
+	if nodeName == "" || name == "" || f.Operator == tosca.GetOperationOutputOperator {
+		return
+	}
+	if nbOperands != 2 && f.Operator != tosca.GetArtifactOperator {
+		return
+	}
+	node := v.topology.TopologyTemplate.NodeTemplates[nodeName]
+	hierarchy := v.typeHierarchy(node.Type)
+	if len(hierarchy) == 0 {
+		return
+	}
+	if f.Operator == tosca.GetArtifactOperator {
+		if _, ok := node.Artifacts[name]; ok {
+			return
+		}
+		for _, t := range hierarchy {
+			if _, ok := v.nodeTypes[t].Artifacts[name]; ok {
+				return
+			}
+		}
+		v.errorf(location, "%q: node template %q has no artifact %q", f, nodeName, name)
+		return
+	}
+	if _, ok := node.Properties[name]; ok {
+		return
+	}
+	for _, t := range hierarchy {
+		if _, ok := v.nodeTypes[t].Properties[name]; ok {
+			return
+		}
+	}
+	if f.Operator == tosca.GetPropertyOperator {
+		v.errorf(location, "%q: node template %q has no property %q", f, nodeName, name)
+		return
+	}
+	if _, ok := node.Attributes[name]; ok {
+		return
+	}
+	for _, t := range hierarchy {
+		if _, ok := v.nodeTypes[t].Attributes[name]; ok {
+			return
+		}
+	}
+	v.warnf(location, "%q: node template %q has no attribute %q defined", f, nodeName, name)
+}
+
+// usedTypes returns the node and relationship types used by the node templates of the topology with their ancestors
+func (v *definitionValidator) usedTypes() ([]string, []string) {
+	nodeTypes := make(map[string]bool)
+	relTypes := make(map[string]bool)
+	for _, node := range v.topology.TopologyTemplate.NodeTemplates {
+		for _, t := range v.typeHierarchy(node.Type) {
+			nodeTypes[t] = true
+		}
+		for _, reqMap := range node.Requirements {
+			for reqName, req := range reqMap {
+				relationship := req.Relationship
+				if relationship == "" {
+					reqDef, _ := v.requirementDefinition(node.Type, reqName)
+					relationship = reqDef.Relationship
+				}
+				for _, t := range v.typeHierarchy(relationship) {
+					relTypes[t] = true
+				}
+			}
+		}
+	}
+	return setToSortedSlice(nodeTypes), setToSortedSlice(relTypes)
+}
+
+func setToSortedSlice(set map[string]bool) []string {
+	result := make([]string, 0, len(set))
+	for k := range set {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// checkImplementations checks operations implementations of the types used in the topology
+func (v *definitionValidator) checkImplementations() {
+	extensions := make(map[string]string)
+	artNames := make([]string, 0, len(v.artifactTypes))
+	for artName := range v.artifactTypes {
+		artNames = append(artNames, artName)
+	}
+	// Sort artifact types to get a deterministic report of duplicated extensions
+	sort.Strings(artNames)
+	for _, artName := range artNames {
+		if !v.isDerivedFrom(artName, "tosca.artifacts.Implementation") {
+			continue
+		}
+		for _, ext := range v.artifactTypes[artName].FileExt {
+			ext = strings.ToLower(ext)
+			if check, ok := extensions[ext]; ok {
+				v.errorf(path.Join("artifact_types", artName), "duplicate implementation artifact file extension %q found in artifact %q and %q", ext, check, artName)
+				continue
+			}
+			extensions[ext] = artName
+		}
+	}
+
+	nodeTypes, relTypes := v.usedTypes()
+	for _, t := range nodeTypes {
+		v.checkTypeInterfaces(path.Join("node_types", t), t, v.nodeTypes[t].Interfaces, extensions, false)
+	}
+	for _, t := range relTypes {
+		v.checkTypeInterfaces(path.Join("relationship_types", t), t, v.relationshipTypes[t].Interfaces, extensions, true)
+	}
+}
+
+func (v *definitionValidator) checkTypeInterfaces(location, typeName string, interfaces map[string]tosca.InterfaceDefinition, extensions map[string]string, isRelationshipType bool) {
+	origin := v.origins[typeName]
+	for intName, interfaceDef := range interfaces {
+		intLocation := path.Join(location, "interfaces", intName)
+		for inputName, input := range interfaceDef.Inputs {
+			v.checkValueAssignment(path.Join(intLocation, "inputs", inputName), input.ValueAssign, "", isRelationshipType)
+		}
+		for opName, operation := range interfaceDef.Operations {
+			if opName == "description" {
+				// Interfaces descriptions are parsed as operations
+				continue
+			}
+			opLocation := path.Join(intLocation, opName)
+			for inputName, input := range operation.Inputs {
+				v.checkValueAssignment(path.Join(opLocation, "inputs", inputName), input.ValueAssign, "", isRelationshipType)
+			}
+
+			if operation.Implementation.Timeout != "" {
+				if _, err := ParseOperationTimeout(operation.Implementation.Timeout); err != nil {
+					v.errorf(opLocation, "invalid implementation timeout: %v", err)
+				}
+			}
+
+			artifact := operation.Implementation.Artifact
+			file, artType := artifact.File, artifact.Type
+			if artifact == (tosca.ArtifactDefinition{}) {
+				file = operation.Implementation.Primary
+			}
+			if file == "" {
+				continue
+			}
+			if artType == "" {
+				ext := strings.ToLower(path.Ext(file))
+				if artType = extensions[strings.TrimPrefix(ext, ".")]; artType == "" {
+					v.errorf(opLocation, "failed to resolve implementation artifact type for implementation %q", file)
+					continue
+				}
+			}
+			if !origin.internal && artifact.Repository == "" {
+				implPath := filepath.Join(v.rootDefPath, filepath.FromSlash(origin.importPath), filepath.FromSlash(file))
+				if _, err := os.Stat(implPath); err != nil {
+					v.errorf(opLocation, "implementation artifact %q not found in archive", path.Join(origin.importPath, file))
+				}
+			}
+			if !v.hasOperationExecutor(artType) {
+				v.errorf(opLocation, "no registered executor supports implementation artifact type %q", artType)
+			}
+		}
+	}
+}
+
+// hasOperationExecutor checks if an executor is registered for an artifact type or one of its parents
+//
+// This follows the lookup done at runtime by the workflow engine.
+func (v *definitionValidator) hasOperationExecutor(artifactType string) bool {
+	hierarchy := v.typeHierarchy(artifactType)
+	if len(hierarchy) == 0 {
+		hierarchy = []string{artifactType}
+	}
+	for _, t := range hierarchy {
+		if _, err := reg.GetOperationExecutor(t); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDelegates checks that a delegate executor is registered for targets of workflows delegate activities
+func (v *definitionValidator) checkDelegates() {
+	workflows := v.topology.TopologyTemplate.Workflows
+	for wfName, workflow := range workflows {
+		for stepName, step := range workflow.Steps {
+			location := path.Join("topology_template/workflows", wfName, "steps", stepName)
+			for _, activity := range step.Activities {
+				if activity.Delegate == "" {
+					continue
+				}
+				node, ok := v.topology.TopologyTemplate.NodeTemplates[step.Target]
+				if !ok {
+					v.errorf(location, "delegate activity targets an unknown node template %q", step.Target)
+					continue
+				}
+				if _, err := reg.GetDelegateExecutor(node.Type); err != nil {
+					v.errorf(location, "no registered delegate executor supports node type %q", node.Type)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/prov"
+	"github.com/ystia/yorc/tosca"
+)
+
+type validationMockExecutor struct{}
+
+func (m *validationMockExecutor) ExecDelegate(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName, delegateOperation string) error {
+	return nil
+}
+
+func (m *validationMockExecutor) ExecOperation(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation) error {
+	return nil
+}
+
+func TestValidateDeploymentDefinition(t *testing.T) {
+	executor := &validationMockExecutor{}
+	reg.RegisterDelegates([]string{"yorc.tests.nodes.validation.Compute"}, executor, "tests")
+	reg.RegisterOperationExecutor([]string{"tosca.artifacts.Implementation.Bash"}, executor, "tests")
+
+	inputs, err := ParseInputValues([]byte("port: 8080"))
+	require.NoError(t, err)
+	report := ValidateDeploymentDefinition("testdata/validation/valid.yaml", inputs)
+	require.True(t, report.Valid, "unexpected errors: %+v", report.Errors)
+	require.Len(t, report.Errors, 0)
+	require.Len(t, report.Warnings, 0)
+
+	report = ValidateDeploymentDefinition("testdata/validation/valid.yaml", map[string]*tosca.ValueAssignment{})
+	require.False(t, report.Valid)
+	require.Equal(t, []ValidationIssue{{Location: "topology_template/inputs", Message: `missing value for required input "port"`}}, report.Errors)
+
+	report = ValidateDeploymentDefinition("testdata/validation/invalid.yaml", nil)
+	require.False(t, report.Valid)
+	require.Equal(t, []ValidationIssue{
+		{Location: "node_templates/App/attributes/host", Message: `"token: [get_attribute: [SELF, url], \":\"]": expecting at least 3 parameters`},
+		{Location: "node_templates/App/attributes/name", Message: `"get_property: [Database, name]": unknown node template "Database"`},
+		{Location: "node_templates/App/attributes/version", Message: `"get_property: [SELF, version]": node template "App" has no property "version"`},
+		{Location: "node_templates/App/attributes/war", Message: `"get_artifact: [SELF, war]": node template "App" has no artifact "war"`},
+		{Location: "node_templates/App/properties/port", Message: `"get_input: port": unknown input "port"`},
+		{Location: "node_templates/App/requirements/host", Message: `requirement "host" can't be fulfilled by node template "App" of type "yorc.tests.nodes.validation.BrokenApp": no capability matching "tosca.capabilities.Container"`},
+		{Location: "node_templates/App/requirements/monitoring", Message: `requirement "monitoring" uses an unknown relationship type "yorc.tests.relationships.DoesNotExist"`},
+		{Location: "node_templates/Unknown", Message: `node template "Unknown" has an unknown type "yorc.tests.nodes.validation.DoesNotExist"`},
+		{Location: "node_types/yorc.tests.nodes.validation.BrokenApp/interfaces/Standard/configure", Message: `implementation artifact "imports/scripts/configure.py" not found in archive`},
+		{Location: "node_types/yorc.tests.nodes.validation.BrokenApp/interfaces/Standard/configure", Message: `no registered executor supports implementation artifact type "tosca.artifacts.Implementation.Python"`},
+		{Location: "node_types/yorc.tests.nodes.validation.BrokenApp/interfaces/Standard/delete", Message: `invalid implementation timeout: timeout "soon" is neither a number of seconds nor a duration: time: invalid duration "soon"`},
+		{Location: "node_types/yorc.tests.nodes.validation.BrokenApp/interfaces/Standard/start", Message: `implementation artifact "imports/scripts/start.sh" not found in archive`},
+		{Location: "node_types/yorc.tests.nodes.validation.BrokenApp/interfaces/Standard/stop", Message: `failed to resolve implementation artifact type for implementation "scripts/stop.unknown"`},
+		{Location: "topology_template/workflows/install/steps/Unknown_install", Message: `no registered delegate executor supports node type "yorc.tests.nodes.validation.DoesNotExist"`},
+	}, report.Errors)
+	require.Equal(t, []ValidationIssue{
+		{Location: "node_templates/App/attributes/computes", Message: `"get_nodes_of_type: yorc.tests.nodes.validation.DoesNotExist": unknown node type "yorc.tests.nodes.validation.DoesNotExist"`},
+		{Location: "node_templates/App/attributes/url", Message: `"get_attribute: [Compute, url]": node template "Compute" has no attribute "url" defined`},
+		{Location: "node_templates/App/requirements/monitoring", Message: `requirement "monitoring" is not defined by node type "yorc.tests.nodes.validation.BrokenApp"`},
+	}, report.Warnings)
+
+	report = ValidateDeploymentDefinition("testdata/validation/missing.yaml", nil)
+	require.False(t, report.Valid)
+	require.Len(t, report.Errors, 1)
+}
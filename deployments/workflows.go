@@ -49,6 +49,10 @@ func ReadWorkflow(kv *api.KV, deploymentID, workflowName string) (tosca.Workflow
 	if err != nil {
 		return wf, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
 	}
+	wf.Preconditions, err = readWfPreconditions(kv, workflowPath)
+	if err != nil {
+		return wf, err
+	}
 	wf.Steps = make(map[string]tosca.Step, len(steps))
 	for _, stepKey := range steps {
 		stepName, err := url.QueryUnescape(path.Base(stepKey))
@@ -83,6 +87,26 @@ func readWfStep(kv *api.KV, stepKey string, stepName string, wfName string) (tos
 	if kvp != nil && len(kvp.Value) != 0 {
 		step.TargetRelationShip = string(kvp.Value)
 	}
+	// Get the step's filter (not mandatory)
+	step.Filter, err = ReadConditionClauses(kv, path.Join(stepKey, "filter"))
+	if err != nil {
+		return step, err
+	}
+	if len(step.Filter) == 0 {
+		step.Filter = nil
+	}
+	// Get the step's retry policy and timeout (not mandatory)
+	step.Retry, err = readWfStepRetryPolicy(kv, stepKey)
+	if err != nil {
+		return step, err
+	}
+	kvp, _, err = kv.Get(path.Join(stepKey, "timeout"), nil)
+	if err != nil {
+		return step, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp != nil && len(kvp.Value) != 0 {
+		step.Timeout = string(kvp.Value)
+	}
 	// Get the step's activities
 	activitiesKeys, _, err := kv.List(stepKey+"/activities", nil)
 	if err != nil {
@@ -124,6 +148,9 @@ func readWfStep(kv *api.KV, stepKey string, stepName string, wfName string) (tos
 	if kvp != nil && len(kvp.Value) > 0 {
 		step.Target = string(kvp.Value)
 	}
+	if step.Target == "" && len(step.Filter) > 0 {
+		return step, errors.Errorf("Missing mandatory attribute \"target\" for step %q as it defines a filter", path.Base(stepKey))
+	}
 
 	// Get the next steps of the current step and use it to set the OnSuccess filed
 	nextSteps, _, err := kv.Keys(stepKey+"/next/", "/", nil)
@@ -140,5 +167,84 @@ func readWfStep(kv *api.KV, stepKey string, stepName string, wfName string) (tos
 			step.OnSuccess[i] = nextName
 		}
 	}
+
+	// Get the steps to run on failure of the current step and use it to set the OnFailure filed
+	onFailureSteps, _, err := kv.Keys(stepKey+"/on-failure/", "/", nil)
+	if err != nil {
+		return step, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if len(onFailureSteps) > 0 {
+		step.OnFailure = make([]string, len(onFailureSteps))
+		for i, onFailureKey := range onFailureSteps {
+			onFailureName, err := url.QueryUnescape(path.Base(onFailureKey))
+			if err != nil {
+				return step, errors.Wrapf(err, "Failed to get back step name from Consul")
+			}
+			step.OnFailure[i] = onFailureName
+		}
+	}
 	return step, nil
 }
+
+func readWfStepRetryPolicy(kv *api.KV, stepKey string) (*tosca.RetryPolicy, error) {
+	kvps, _, err := kv.List(path.Join(stepKey, "retry")+"/", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if len(kvps) == 0 {
+		return nil, nil
+	}
+	retry := &tosca.RetryPolicy{}
+	for _, kvp := range kvps {
+		value := string(kvp.Value)
+		switch path.Base(kvp.Key) {
+		case "retries":
+			retry.Retries, err = strconv.Atoi(value)
+		case "delay":
+			retry.Delay = value
+		case "backoff":
+			retry.Backoff, err = strconv.ParseFloat(value, 64)
+		case "max_delay":
+			retry.MaxDelay = value
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid retry policy value for key %q", kvp.Key)
+		}
+	}
+	return retry, nil
+}
+
+// GetWorkflowPreconditions returns the preconditions of a given workflow
+func GetWorkflowPreconditions(kv *api.KV, deploymentID, workflowName string) ([]tosca.Precondition, error) {
+	return readWfPreconditions(kv, path.Join(consulutil.DeploymentKVPrefix, deploymentID, "workflows", url.QueryEscape(workflowName)))
+}
+
+func readWfPreconditions(kv *api.KV, workflowPath string) ([]tosca.Precondition, error) {
+	preconditionsKeys, err := getIndexedSubKeys(kv, path.Join(workflowPath, "preconditions"))
+	if err != nil || len(preconditionsKeys) == 0 {
+		return nil, err
+	}
+	preconditions := make([]tosca.Precondition, len(preconditionsKeys))
+	for i, preconditionKey := range preconditionsKeys {
+		kvp, _, err := kv.Get(path.Join(preconditionKey, "target"), nil)
+		if err != nil {
+			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if kvp == nil || len(kvp.Value) == 0 {
+			return nil, errors.Errorf("Missing mandatory attribute \"target\" for precondition %q", preconditionKey)
+		}
+		preconditions[i].Target = string(kvp.Value)
+		kvp, _, err = kv.Get(path.Join(preconditionKey, "target_relationship"), nil)
+		if err != nil {
+			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if kvp != nil && len(kvp.Value) != 0 {
+			preconditions[i].TargetRelationShip = string(kvp.Value)
+		}
+		preconditions[i].Condition, err = ReadConditionClauses(kv, path.Join(preconditionKey, "condition"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return preconditions, nil
+}
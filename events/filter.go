@@ -0,0 +1,95 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Filter selects status updates and log entries
+//
+// Each criterion matches if it is empty or if it contains the corresponding value of a status update or a log entry.
+// Levels only apply to log entries and Types only apply to status updates.
+type Filter struct {
+	Nodes     []string
+	Instances []string
+	Tasks     []string
+	Levels    []string
+	Types     []string
+}
+
+// LogLevelFromString returns a LogLevel from its case-insensitive textual representation
+func LogLevelFromString(level string) (LogLevel, error) {
+	for l := INFO; l <= ERROR; l++ {
+		if strings.EqualFold(l.String(), level) {
+			return l, nil
+		}
+	}
+	return INFO, errors.Errorf("%s does not belong to LogLevel values", level)
+}
+
+// Validate checks that levels and types of a filter are known values
+func (f Filter) Validate() error {
+	for _, level := range f.Levels {
+		if _, err := LogLevelFromString(level); err != nil {
+			return err
+		}
+	}
+	for _, t := range f.Types {
+		if _, err := StatusUpdateTypeString(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsEmpty checks if a filter has no criteria and so matches everything
+func (f Filter) IsEmpty() bool {
+	return len(f.Nodes) == 0 && len(f.Instances) == 0 && len(f.Tasks) == 0 && len(f.Levels) == 0 && len(f.Types) == 0
+}
+
+func matchesCriterion(values []string, value string, ignoreCase bool) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value || ignoreCase && strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesStatusUpdate checks if a status update matches the filter
+func (f Filter) MatchesStatusUpdate(e StatusUpdate) bool {
+	return matchesCriterion(f.Nodes, e.Node, false) &&
+		matchesCriterion(f.Instances, e.Instance, false) &&
+		matchesCriterion(f.Tasks, e.TaskID, false) &&
+		matchesCriterion(f.Types, e.Type, false)
+}
+
+// MatchesLogEntry checks if a log entry, in its flat map representation, matches the filter
+func (f Filter) MatchesLogEntry(flat map[string]interface{}) bool {
+	field := func(name string) string {
+		s, _ := flat[name].(string)
+		return s
+	}
+	return matchesCriterion(f.Nodes, field(NodeID.String()), false) &&
+		matchesCriterion(f.Instances, field(InstanceID.String()), false) &&
+		matchesCriterion(f.Tasks, field(ExecutionID.String()), false) &&
+		matchesCriterion(f.Levels, field("level"), true)
+}
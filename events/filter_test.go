@@ -0,0 +1,77 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterMatchesStatusUpdate(t *testing.T) {
+	instanceEvent := StatusUpdate{Type: InstanceStatusChangeType.String(), Node: "Compute", Instance: "0", Status: "started"}
+	workflowEvent := StatusUpdate{Type: WorkflowStatusChangeType.String(), TaskID: "t1", Status: "DONE"}
+	tests := []struct {
+		name   string
+		filter Filter
+		event  StatusUpdate
+		want   bool
+	}{
+		{"EmptyFilter", Filter{}, instanceEvent, true},
+		{"MatchingNode", Filter{Nodes: []string{"Soft", "Compute"}}, instanceEvent, true},
+		{"OtherNode", Filter{Nodes: []string{"Soft"}}, instanceEvent, false},
+		{"MatchingNodeAndInstance", Filter{Nodes: []string{"Compute"}, Instances: []string{"0"}}, instanceEvent, true},
+		{"OtherInstance", Filter{Nodes: []string{"Compute"}, Instances: []string{"1"}}, instanceEvent, false},
+		{"MatchingTask", Filter{Tasks: []string{"t1"}}, workflowEvent, true},
+		{"TaskOnInstanceEvent", Filter{Tasks: []string{"t1"}}, instanceEvent, false},
+		{"MatchingType", Filter{Types: []string{"workflow"}}, workflowEvent, true},
+		{"OtherType", Filter{Types: []string{"deployment", "scaling"}}, workflowEvent, false},
+		{"LevelsIgnored", Filter{Levels: []string{"ERROR"}}, workflowEvent, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.MatchesStatusUpdate(tt.event))
+		})
+	}
+}
+
+func TestFilterMatchesLogEntry(t *testing.T) {
+	entry := map[string]interface{}{"level": "WARN", "nodeId": "Compute", "instanceId": "0", "executionId": "t1", "content": "msg"}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"EmptyFilter", Filter{}, true},
+		{"MatchingLevelIgnoringCase", Filter{Levels: []string{"error", "warn"}}, true},
+		{"OtherLevel", Filter{Levels: []string{"ERROR"}}, false},
+		{"MatchingAll", Filter{Nodes: []string{"Compute"}, Instances: []string{"0"}, Tasks: []string{"t1"}}, true},
+		{"OtherTask", Filter{Tasks: []string{"t2"}}, false},
+		{"TypesIgnored", Filter{Types: []string{"workflow"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.MatchesLogEntry(entry))
+		})
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	assert.NoError(t, Filter{Levels: []string{"info", "ERROR"}, Types: []string{"instance"}}.Validate())
+	assert.Error(t, Filter{Levels: []string{"TRACE"}}.Validate())
+	assert.Error(t, Filter{Types: []string{"unknown"}}.Validate())
+	assert.True(t, Filter{}.IsEmpty())
+	assert.False(t, Filter{Tasks: []string{"t1"}}.IsEmpty())
+}
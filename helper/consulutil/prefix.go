@@ -43,3 +43,12 @@ const YorcManagementPrefix = yorcPrefix + "/.yorc_management"
 
 // MonitoringKVPrefix is the prefix in Consul KV store for monitoring
 const MonitoringKVPrefix string = yorcPrefix + "/monitoring"
+
+// NotificationsPrefix is the prefix on KV store for the webhooks notifications service
+const NotificationsPrefix = yorcPrefix + "/notifications"
+
+// SchedulingPrefix is the prefix on KV store for the workflows scheduling service
+const SchedulingPrefix = yorcPrefix + "/scheduling"
+
+// ExecutionsSemaphoresPrefix is the prefix on KV store for the semaphores limiting the number of concurrent executions
+const ExecutionsSemaphoresPrefix = yorcPrefix + "/executions-semaphores"
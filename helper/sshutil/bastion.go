@@ -0,0 +1,188 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sshutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// A Bastion is a host (also known as jump host) used to reach hosts that are not directly reachable
+type Bastion struct {
+	// Host is the address of the bastion host
+	Host string `json:"host" yaml:"host" mapstructure:"host"`
+	// Port is the SSH port of the bastion host. Defaults to 22 if set to 0.
+	Port int `json:"port,omitempty" yaml:"port,omitempty" mapstructure:"port"`
+	// User is the user used to connect to the bastion host
+	User string `json:"user,omitempty" yaml:"user,omitempty" mapstructure:"user"`
+	// Password is the password used to connect to the bastion host. One of Password or PrivateKey is required.
+	Password string `json:"password,omitempty" yaml:"password,omitempty" mapstructure:"password"`
+	// PrivateKey is the path or the content of the SSH private key used to connect to the bastion host.
+	// One of Password or PrivateKey is required.
+	PrivateKey string `json:"private_key,omitempty" yaml:"private_key,omitempty" mapstructure:"private_key"`
+}
+
+// String allows to stringify a bastion without its credentials
+func (b Bastion) String() string {
+	return b.User + "@" + b.address()
+}
+
+func (b Bastion) port() int {
+	if b.Port == 0 {
+		return 22
+	}
+	return b.Port
+}
+
+func (b Bastion) address() string {
+	return fmt.Sprintf("%s:%d", b.Host, b.port())
+}
+
+func (b Bastion) clientConfig() (*ssh.ClientConfig, error) {
+	conf := &ssh.ClientConfig{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		User:            b.User,
+	}
+	if b.PrivateKey != "" {
+		keyAuth, err := ReadPrivateKey(b.PrivateKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read private key of bastion %q", b.Host)
+		}
+		conf.Auth = append(conf.Auth, keyAuth)
+	}
+	if b.Password != "" {
+		conf.Auth = append(conf.Auth, ssh.Password(b.Password))
+	}
+	return conf, nil
+}
+
+// BastionsFromConfig decodes a chain of bastions from a configuration value
+//
+// The value could either be a single bastion definition or a list of bastions definitions. The first bastion of the
+// chain should be directly reachable and each following bastion should be reachable from the previous one.
+func BastionsFromConfig(value interface{}) ([]Bastion, error) {
+	if value == nil {
+		return nil, nil
+	}
+	var bastions []Bastion
+	decoderConfig := &mapstructure.DecoderConfig{WeaklyTypedInput: true, Result: &bastions}
+	switch value.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		value = []interface{}{value}
+	}
+	decoder, err := mapstructure.NewDecoder(decoderConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode bastions definition")
+	}
+	if err = decoder.Decode(value); err != nil {
+		return nil, errors.Wrap(err, "failed to decode bastions definition")
+	}
+	for i, b := range bastions {
+		if b.Host == "" {
+			return nil, errors.Errorf("missing host for bastion number %d", i+1)
+		}
+	}
+	return bastions, nil
+}
+
+// ProxyCommand returns an OpenSSH ProxyCommand connecting to a host through the given chain of bastions
+//
+// An empty string is returned if there is no bastion. Private keys given by their content and passwords are written
+// in files readable only by the current user in credentialsDir, which is created if needed. Bastions using a password
+// instead of a private key rely on sshpass reading the password from such a file.
+func ProxyCommand(bastions []Bastion, credentialsDir string) (string, error) {
+	if len(bastions) == 0 {
+		return "", nil
+	}
+	i := len(bastions) - 1
+	b := bastions[i]
+	args := []string{"ssh", "-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null"}
+	if b.PrivateKey != "" {
+		keyPath, err := privateKeyFile(b.PrivateKey, credentialsDir, fmt.Sprintf("bastion-%d.pem", i))
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to store private key of bastion %q", b.Host)
+		}
+		args = append(args, "-i", ShellQuote(keyPath))
+	}
+	if b.port() != 22 {
+		args = append(args, "-p", strconv.Itoa(b.port()))
+	}
+	if len(bastions) > 1 {
+		inner, err := ProxyCommand(bastions[:i], credentialsDir)
+		if err != nil {
+			return "", err
+		}
+		// % tokens of the nested command should only be expanded by the ssh command connecting to this bastion
+		inner = strings.Replace(inner, "%", "%%", -1)
+		args = append(args, "-o", ShellQuote("ProxyCommand="+inner))
+	}
+	args = append(args, "-W", "%h:%p", ShellQuote(b.User+"@"+b.Host))
+	cmd := strings.Join(args, " ")
+	if b.PrivateKey == "" && b.Password != "" {
+		passwordPath, err := writeCredentialsFile(credentialsDir, fmt.Sprintf("bastion-%d.pass", i), b.Password)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to store password of bastion %q", b.Host)
+		}
+		cmd = "sshpass -f " + ShellQuote(passwordPath) + " " + cmd
+	}
+	return cmd, nil
+}
+
+// privateKeyFile returns the path of a private key given either by its path or by its content
+//
+// A private key given by its content is written in the credentials directory.
+func privateKeyFile(pk, credentialsDir, fileName string) (string, error) {
+	keyPath, err := homedir.Expand(pk)
+	if err == nil {
+		if _, err = os.Stat(keyPath); err == nil {
+			return keyPath, nil
+		}
+	}
+	return writeCredentialsFile(credentialsDir, fileName, pk)
+}
+
+func writeCredentialsFile(credentialsDir, fileName, content string) (string, error) {
+	if err := os.MkdirAll(credentialsDir, 0700); err != nil {
+		return "", errors.Wrapf(err, "failed to create credentials directory %q", credentialsDir)
+	}
+	filePath := filepath.Join(credentialsDir, fileName)
+	// Remove a previous file that may have been created with other permissions
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return "", errors.Wrapf(err, "failed to remove credentials file %q", filePath)
+	}
+	if err := ioutil.WriteFile(filePath, []byte(content), 0600); err != nil {
+		return "", errors.Wrapf(err, "failed to write credentials file %q", filePath)
+	}
+	return filePath, nil
+}
+
+// ShellQuote quotes a string so that it is used as a single word by a POSIX shell
+func ShellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./-_~", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -16,11 +16,14 @@ package sshutil
 
 import (
 	"fmt"
-	"golang.org/x/crypto/ssh"
 	"net"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
 )
 
 type pool struct {
@@ -50,9 +53,9 @@ func (p *pool) openSession(client *SSHClient) (*ssh.Session, error) {
 	}
 
 	addr := fmt.Sprintf("%s:%d", client.Host, client.Port)
-	k := getUserKey(addr, client.Config)
+	k := getUserKey(addr, client.Config, client.Bastions)
 	for {
-		c := p.getConn(k, addr, client.Config, deadline)
+		c := p.getConn(k, addr, client.Config, client.Bastions, deadline)
 		if c.err != nil {
 			p.removeConn(k, c)
 			return nil, c.err
@@ -63,7 +66,7 @@ func (p *pool) openSession(client *SSHClient) (*ssh.Session, error) {
 		}
 		sessionDeadline = deadline
 		p.removeConn(k, c)
-		c.c.Close()
+		c.close()
 		if p.Timeout > 0 && time.Now().After(deadline) {
 			return nil, err
 		}
@@ -73,8 +76,17 @@ func (p *pool) openSession(client *SSHClient) (*ssh.Session, error) {
 type conn struct {
 	netC net.Conn
 	c    *ssh.Client
-	ok   chan bool
-	err  error
+	// bastions are the clients connected to the bastions used to reach the host
+	bastions []*ssh.Client
+	ok       chan bool
+	err      error
+}
+
+func (c *conn) close() {
+	c.c.Close()
+	for i := len(c.bastions) - 1; i >= 0; i-- {
+		c.bastions[i].Close()
+	}
 }
 
 func (c *conn) newSession(deadline time.Time) (*ssh.Session, error) {
@@ -87,7 +99,7 @@ func (c *conn) newSession(deadline time.Time) (*ssh.Session, error) {
 
 // getConn gets an ssh connection from the pool for key.
 // If none is available, it dials anew.
-func (p *pool) getConn(k, addr string, config *ssh.ClientConfig, deadline time.Time) *conn {
+func (p *pool) getConn(k, addr string, config *ssh.ClientConfig, bastions []Bastion, deadline time.Time) *conn {
 	p.mu.Lock()
 	if p.tab == nil {
 		p.tab = make(map[string]*conn)
@@ -101,7 +113,11 @@ func (p *pool) getConn(k, addr string, config *ssh.ClientConfig, deadline time.T
 	c = &conn{ok: make(chan bool)}
 	p.tab[k] = c
 	p.mu.Unlock()
-	c.netC, c.c, c.err = p.dial("tcp", addr, config, deadline)
+	if len(bastions) == 0 {
+		c.netC, c.c, c.err = p.dial("tcp", addr, config, deadline)
+	} else {
+		c.netC, c.c, c.bastions, c.err = p.dialThroughBastions(addr, config, bastions, deadline)
+	}
 	close(c.ok)
 	return c
 }
@@ -132,6 +148,87 @@ func (p *pool) dial(network, addr string, config *ssh.ClientConfig, deadline tim
 	return netC, sshC, nil
 }
 
-func getUserKey(addr string, config *ssh.ClientConfig) string {
-	return strconv.Quote(addr) + "-" + strconv.Quote(config.User)
+// dialThroughBastions connects to addr through a chain of bastions
+//
+// The returned net.Conn is the connection to the first bastion, closing it closes the whole chain.
+// Connections tunneled through bastions don't support deadlines, so the connection to the first bastion is closed
+// if the whole chain is not established before the deadline.
+func (p *pool) dialThroughBastions(addr string, config *ssh.ClientConfig, bastions []Bastion, deadline time.Time) (net.Conn, *ssh.Client, []*ssh.Client, error) {
+	bastionConfig, err := bastions[0].clientConfig()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	netC, bastionC, err := p.dial("tcp", bastions[0].address(), bastionConfig, deadline)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "failed to connect to bastion %q", bastions[0].Host)
+	}
+	var timedOut int32
+	var timer *time.Timer
+	if !deadline.IsZero() {
+		timer = time.AfterFunc(time.Until(deadline), func() {
+			atomic.StoreInt32(&timedOut, 1)
+			netC.Close()
+		})
+		defer timer.Stop()
+	}
+	wrapHopError := func(err error, format string, args ...interface{}) error {
+		if atomic.LoadInt32(&timedOut) == 1 {
+			format += " (connection timed out)"
+		}
+		return errors.Wrapf(err, format, args...)
+	}
+	bastionsC := []*ssh.Client{bastionC}
+	closeAll := func() {
+		for i := len(bastionsC) - 1; i >= 0; i-- {
+			bastionsC[i].Close()
+		}
+	}
+
+	hops := make([]string, 0, len(bastions))
+	configs := make([]*ssh.ClientConfig, 0, len(bastions))
+	for _, b := range bastions[1:] {
+		bastionConfig, err = b.clientConfig()
+		if err != nil {
+			closeAll()
+			return nil, nil, nil, err
+		}
+		hops = append(hops, b.address())
+		configs = append(configs, bastionConfig)
+	}
+	hops = append(hops, addr)
+	configs = append(configs, config)
+
+	var sshC *ssh.Client
+	for i, hop := range hops {
+		hopC, err := bastionsC[len(bastionsC)-1].Dial("tcp", hop)
+		if err != nil {
+			closeAll()
+			return nil, nil, nil, wrapHopError(err, "failed to reach %q through bastion %q", hop, bastions[i].Host)
+		}
+		c, chans, reqs, err := ssh.NewClientConn(hopC, hop, configs[i])
+		if err != nil {
+			hopC.Close()
+			closeAll()
+			return nil, nil, nil, wrapHopError(err, "failed to connect to %q through bastion %q", hop, bastions[i].Host)
+		}
+		sshC = ssh.NewClient(c, chans, reqs)
+		if i < len(hops)-1 {
+			bastionsC = append(bastionsC, sshC)
+		}
+	}
+	if timer != nil && !timer.Stop() {
+		// The deadline was reached while the last hop was established, the chain is already closed
+		sshC.Close()
+		closeAll()
+		return nil, nil, nil, errors.Errorf("connection to %q through bastions timed out", addr)
+	}
+	return netC, sshC, bastionsC, nil
+}
+
+func getUserKey(addr string, config *ssh.ClientConfig, bastions []Bastion) string {
+	k := strconv.Quote(addr) + "-" + strconv.Quote(config.User)
+	for _, b := range bastions {
+		k += "-" + strconv.Quote(b.String())
+	}
+	return k
 }
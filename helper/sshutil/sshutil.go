@@ -49,6 +49,8 @@ type SSHClient struct {
 	Config *ssh.ClientConfig
 	Host   string
 	Port   int
+	// Bastions is an optional chain of bastions used to reach the host
+	Bastions []Bastion
 }
 
 // Sessions Pool used to provide reusable sessions for each sshClient
@@ -91,6 +93,35 @@ func (client *SSHClient) RunCommand(cmd string) (string, error) {
 	return stdOutErrStr, err
 }
 
+// RunCommandWithContext allows to run a specified command, the remote process is killed if the context is cancelled
+func (client *SSHClient) RunCommandWithContext(ctx context.Context, cmd string) (string, error) {
+	session, err := client.newSession()
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to create new session")
+	}
+
+	chDone := make(chan struct{})
+	defer close(chDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Debug("[SSHSession] Cancellation has been sent: a sigkill signal is sent to remote process")
+			session.Signal(ssh.SIGKILL)
+			session.Close()
+		case <-chDone:
+		}
+	}()
+
+	log.Debugf("[SSHSession] cmd: %q", cmd)
+	stdOutErrBytes, err := session.CombinedOutput(cmd)
+	stdOutErrStr := strings.Trim(string(stdOutErrBytes[:]), "\x00")
+	log.Debugf("[SSHSession] stdout/stderr: %q", stdOutErrStr)
+	if ctx.Err() != nil {
+		err = errors.Wrap(ctx.Err(), "command interrupted")
+	}
+	return stdOutErrStr, err
+}
+
 func (client *SSHClient) newSession() (*ssh.Session, error) {
 	session, err := sessionsPool.openSession(client)
 	if err != nil {
@@ -170,8 +201,9 @@ func (client *SSHClient) CopyFile(source io.Reader, remotePath, permissions stri
 	scpHostPort := fmt.Sprintf("%s:%d", client.Host, client.Port)
 	scpClient := scp.NewClient(scpHostPort, client.Config)
 
-	// Connect to the remote server
-	err := scpClient.Connect()
+	// Connect to the remote server using a pooled session, so that bastions are used if any
+	var err error
+	scpClient.Session, err = client.newSession()
 	if err != nil {
 		return errors.Wrapf(err, "Couldn't establish a connection to the remote host:%q", scpHostPort)
 	}
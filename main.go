@@ -20,6 +20,7 @@ import (
 	_ "github.com/ystia/yorc/commands/deployments/tasks"
 	_ "github.com/ystia/yorc/commands/deployments/workflows"
 	_ "github.com/ystia/yorc/commands/hostspool"
+	_ "github.com/ystia/yorc/commands/notifications"
 	"github.com/ystia/yorc/log"
 )
 
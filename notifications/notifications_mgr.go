@@ -0,0 +1,248 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/log"
+)
+
+// eventsWaitTime is the maximum duration of the Consul blocking queries on status updates
+const eventsWaitTime = time.Minute
+
+var defaultNotificationsManager *notificationsMgr
+
+type notificationsMgr struct {
+	cc             *api.Client
+	cfg            config.Configuration
+	chShutdown     chan struct{}
+	chStopDispatch chan struct{}
+	isRunning      bool
+	isRunningLock  sync.Mutex
+	serviceKey     string
+}
+
+// Start allows to instantiate a default Notifications Manager and to start dispatching status updates to webhooks
+//
+// Only the leader of the Yorc cluster dispatches notifications.
+func Start(cfg config.Configuration, cc *api.Client) {
+	defaultNotificationsManager = &notificationsMgr{
+		cc:         cc,
+		cfg:        cfg,
+		chShutdown: make(chan struct{}),
+		serviceKey: "service/notifications/leader",
+	}
+
+	// Watch leader election for notifications service
+	go consulutil.WatchLeaderElection(cc, defaultNotificationsManager.serviceKey, defaultNotificationsManager.chShutdown, defaultNotificationsManager.startDispatching, defaultNotificationsManager.stopDispatching)
+}
+
+// Stop allows to stop dispatching notifications
+func Stop() {
+	defaultNotificationsManager.stopDispatching()
+
+	// Stop watch leader election
+	close(defaultNotificationsManager.chShutdown)
+}
+
+func handleError(err error) {
+	err = errors.Wrap(err, "[WARN] Error during notifications dispatching")
+	log.Print(err)
+	log.Debugf("%+v", err)
+}
+
+func (mgr *notificationsMgr) startDispatching() {
+	mgr.isRunningLock.Lock()
+	defer mgr.isRunningLock.Unlock()
+	if mgr.isRunning {
+		return
+	}
+	log.Debugf("Notifications service is now running.")
+	mgr.isRunning = true
+	mgr.chStopDispatch = make(chan struct{})
+	go mgr.dispatch(mgr.chStopDispatch)
+}
+
+func (mgr *notificationsMgr) stopDispatching() {
+	mgr.isRunningLock.Lock()
+	defer mgr.isRunningLock.Unlock()
+	if mgr.isRunning {
+		log.Debugf("Notifications service is about to be stopped")
+		close(mgr.chStopDispatch)
+		mgr.isRunning = false
+	}
+}
+
+// dispatch runs a dispatcher for each subscription
+//
+// Each subscription has its own cursor on status updates so that a slow or failing webhook only delays its own
+// notifications.
+func (mgr *notificationsMgr) dispatch(chStop chan struct{}) {
+	kv := mgr.cc.KV()
+	d := newDeliverer(mgr.cfg.Notifications, chStop)
+	dispatchers := make(map[string]chan struct{})
+	defer func() {
+		for _, chStopSubscription := range dispatchers {
+			close(chStopSubscription)
+		}
+	}()
+	var waitIndex uint64
+	for {
+		select {
+		case <-chStop:
+			log.Debugf("Ending notifications dispatching has been requested: stop it now.")
+			return
+		case <-mgr.chShutdown:
+			log.Debugf("Shutdown has been sent: stop notifications dispatching now.")
+			return
+		default:
+		}
+		keys, qm, err := kv.Keys(subscriptionsPrefix+"/", "/", &api.QueryOptions{WaitIndex: waitIndex, WaitTime: eventsWaitTime})
+		if err != nil {
+			handleError(errors.Wrap(err, consulutil.ConsulGenericErrMsg))
+			time.Sleep(time.Second)
+			continue
+		}
+		waitIndex = qm.LastIndex
+		subscriptions := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			subscriptionID := path.Base(key)
+			subscriptions[subscriptionID] = true
+			if _, ok := dispatchers[subscriptionID]; !ok {
+				chStopSubscription := make(chan struct{})
+				dispatchers[subscriptionID] = chStopSubscription
+				go mgr.dispatchSubscription(subscriptionID, d, chStopSubscription)
+			}
+		}
+		for subscriptionID, chStopSubscription := range dispatchers {
+			if !subscriptions[subscriptionID] {
+				close(chStopSubscription)
+				delete(dispatchers, subscriptionID)
+			}
+		}
+	}
+}
+
+// dispatchSubscription notifies status updates matching a subscription from its cursor
+//
+// The cursor is moved forward once the status updates are delivered or recorded as dead letters,
+// so a newly elected leader resumes from it.
+func (mgr *notificationsMgr) dispatchSubscription(subscriptionID string, d *deliverer, chStop chan struct{}) {
+	kv := mgr.cc.KV()
+	var index uint64
+	var err error
+	for {
+		select {
+		case <-chStop:
+			log.Debugf("Subscription %q removed: stop dispatching its notifications.", subscriptionID)
+			return
+		case <-d.shutdownCh:
+			return
+		default:
+		}
+		if index == 0 {
+			if index, err = getCursor(kv, subscriptionID); err != nil {
+				handleError(err)
+				time.Sleep(time.Second)
+				continue
+			}
+		}
+
+		evts, lastIndex, err := events.StatusEvents(kv, "", index, eventsWaitTime)
+		if err != nil {
+			handleError(err)
+			time.Sleep(time.Second)
+			continue
+		}
+		if lastIndex == index || lastIndex == 0 {
+			// long poll ended due to a timeout
+			continue
+		}
+		if len(evts) > 0 {
+			subscription, err := GetSubscription(kv, subscriptionID)
+			if IsSubscriptionNotFoundError(err) {
+				return
+			}
+			if err == nil {
+				err = dispatchStatusUpdates(kv, d, subscription, evts)
+			}
+			if err != nil {
+				handleError(err)
+				time.Sleep(time.Second)
+				continue
+			}
+		}
+		select {
+		case <-d.shutdownCh:
+			// Deliveries may have been interrupted, let the next leader dispatch these status updates again
+			return
+		case <-chStop:
+			return
+		default:
+		}
+		index = lastIndex
+		if err = storeCursor(kv, subscriptionID, index); err != nil {
+			handleError(err)
+		}
+	}
+}
+
+// dispatchStatusUpdates notifies status updates matching a subscription
+//
+// Notifications are delivered in order. Notifications that could not be delivered are recorded as dead letters.
+func dispatchStatusUpdates(kv *api.KV, d *deliverer, subscription Subscription, evts []events.StatusUpdate) error {
+	tenants := make(map[string]string)
+	for _, e := range evts {
+		tenant, ok := tenants[e.DeploymentID]
+		if !ok {
+			var err error
+			tenant, err = deployments.GetDeploymentTenant(kv, e.DeploymentID)
+			if err != nil {
+				return err
+			}
+			tenants[e.DeploymentID] = tenant
+		}
+		if !subscription.Matches(tenant, e) {
+			continue
+		}
+		n := Notification{ID: fmt.Sprint(uuid.NewV4()), SubscriptionID: subscription.ID, Event: e}
+		attempts, err := d.deliver(subscription, n)
+		if err == nil {
+			continue
+		}
+		select {
+		case <-d.shutdownCh:
+			return nil
+		default:
+		}
+		log.Printf("[WARN] Failed to deliver notification %q to subscription %q after %d attempts: %v", n.ID, subscription.ID, attempts, err)
+		if err = storeDeadLetter(kv, DeadLetter{Attempts: attempts, Error: err.Error(), Notification: n}); err != nil {
+			handleError(err)
+		}
+	}
+	return nil
+}
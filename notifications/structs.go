@@ -0,0 +1,97 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/collections"
+)
+
+// Subscription registers a webhook notified of status updates
+//
+// Deployments, Types, Statuses and Nodes restrict the notified status updates, an empty criterion matches every status update.
+type Subscription struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Secret is used to sign notifications, it is never returned by the REST API
+	Secret string `json:"secret,omitempty"`
+	// Tenant restricts notifications to deployments of this tenant, an empty tenant means all tenants
+	Tenant      string   `json:"tenant,omitempty"`
+	Deployments []string `json:"deployments,omitempty"`
+	Types       []string `json:"types,omitempty"`
+	Statuses    []string `json:"statuses,omitempty"`
+	Nodes       []string `json:"nodes,omitempty"`
+}
+
+// Notification is the payload posted to webhooks
+type Notification struct {
+	// ID identifies a notification, it is the same for all delivery attempts
+	ID             string              `json:"id"`
+	SubscriptionID string              `json:"subscription_id"`
+	Event          events.StatusUpdate `json:"event"`
+}
+
+// DeadLetter records a notification that could not be delivered
+type DeadLetter struct {
+	Timestamp    string       `json:"timestamp"`
+	Attempts     int          `json:"attempts"`
+	Error        string       `json:"error"`
+	Notification Notification `json:"notification"`
+}
+
+// Validate checks that a subscription has a valid webhook URL and filters
+func (s Subscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return errors.Wrapf(err, "invalid webhook URL %q", s.URL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return errors.Errorf("invalid webhook URL %q, expecting an absolute http or https URL", s.URL)
+	}
+	for _, t := range s.Types {
+		if _, err := events.StatusUpdateTypeString(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches checks if a status update of a deployment belonging to a given tenant should be notified to a subscription
+func (s Subscription) Matches(tenant string, e events.StatusUpdate) bool {
+	if s.Tenant != "" && s.Tenant != tenant {
+		return false
+	}
+	if len(s.Deployments) > 0 && !collections.ContainsString(s.Deployments, e.DeploymentID) {
+		return false
+	}
+	if len(s.Statuses) > 0 && !containsStringIgnoreCase(s.Statuses, e.Status) {
+		return false
+	}
+	return events.Filter{Nodes: s.Nodes, Types: s.Types}.MatchesStatusUpdate(e)
+}
+
+func containsStringIgnoreCase(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ystia/yorc/events"
+)
+
+func TestSubscriptionValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription Subscription
+		wantErr      bool
+	}{
+		{"HTTPURL", Subscription{URL: "http://chatops.example.com/hooks/yorc"}, false},
+		{"HTTPSURLWithTypes", Subscription{URL: "https://tickets.example.com/", Types: []string{"deployment", "workflow"}}, false},
+		{"RelativeURL", Subscription{URL: "/hooks/yorc"}, true},
+		{"UnsupportedScheme", Subscription{URL: "ftp://example.com/hooks"}, true},
+		{"EmptyURL", Subscription{}, true},
+		{"UnknownType", Subscription{URL: "http://example.com", Types: []string{"unknown"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.subscription.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSubscriptionMatches(t *testing.T) {
+	depFailed := events.StatusUpdate{Type: "deployment", DeploymentID: "app1", Status: "deployment_failed"}
+	instStarted := events.StatusUpdate{Type: "instance", DeploymentID: "app2", Node: "Compute", Instance: "0", Status: "started"}
+	tests := []struct {
+		name         string
+		subscription Subscription
+		tenant       string
+		event        events.StatusUpdate
+		want         bool
+	}{
+		{"NoCriteria", Subscription{}, "default", depFailed, true},
+		{"SameTenant", Subscription{Tenant: "team1"}, "team1", depFailed, true},
+		{"OtherTenant", Subscription{Tenant: "team1"}, "team2", depFailed, false},
+		{"MatchingDeployment", Subscription{Deployments: []string{"app0", "app1"}}, "default", depFailed, true},
+		{"OtherDeployment", Subscription{Deployments: []string{"app0"}}, "default", depFailed, false},
+		{"MatchingStatusIgnoringCase", Subscription{Statuses: []string{"DEPLOYMENT_FAILED"}}, "default", depFailed, true},
+		{"OtherStatus", Subscription{Statuses: []string{"deployed"}}, "default", depFailed, false},
+		{"MatchingType", Subscription{Types: []string{"instance"}}, "default", instStarted, true},
+		{"OtherType", Subscription{Types: []string{"deployment"}}, "default", instStarted, false},
+		{"MatchingNode", Subscription{Nodes: []string{"Compute"}}, "default", instStarted, true},
+		{"NodeOnDeploymentEvent", Subscription{Nodes: []string{"Compute"}}, "default", depFailed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.subscription.Matches(tt.tenant, tt.event))
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/consulutil"
+)
+
+var subscriptionsPrefix = path.Join(consulutil.NotificationsPrefix, "subscriptions")
+var deadLettersPrefix = path.Join(consulutil.NotificationsPrefix, "dead_letters")
+
+// cursorsPrefix stores for each subscription the index of its last dispatched status updates
+var cursorsPrefix = path.Join(consulutil.NotificationsPrefix, "cursors")
+
+type subscriptionNotFound struct {
+	id string
+}
+
+func (e subscriptionNotFound) Error() string {
+	return fmt.Sprintf("subscription %q not found", e.id)
+}
+
+// IsSubscriptionNotFoundError checks if an error is due to a subscription that does not exist
+func IsSubscriptionNotFoundError(err error) bool {
+	_, ok := errors.Cause(err).(subscriptionNotFound)
+	return ok
+}
+
+// CreateSubscription validates and stores a new subscription and returns its generated ID
+func CreateSubscription(kv *api.KV, subscription Subscription) (string, error) {
+	if err := subscription.Validate(); err != nil {
+		return "", err
+	}
+	subscription.ID = fmt.Sprint(uuid.NewV4())
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode subscription")
+	}
+	// Only status updates occurring after the subscription creation are notified
+	index, err := events.GetStatusEventsIndex(kv, "")
+	if err != nil {
+		return "", errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if err = storeCursor(kv, subscription.ID, index); err != nil {
+		return "", err
+	}
+	_, err = kv.Put(&api.KVPair{Key: path.Join(subscriptionsPrefix, subscription.ID), Value: data}, nil)
+	if err != nil {
+		return "", errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	return subscription.ID, nil
+}
+
+// GetSubscription returns a subscription given its ID
+//
+// An error that could be checked with IsSubscriptionNotFoundError is returned if the subscription does not exist.
+func GetSubscription(kv *api.KV, id string) (Subscription, error) {
+	var subscription Subscription
+	kvp, _, err := kv.Get(path.Join(subscriptionsPrefix, id), nil)
+	if err != nil {
+		return subscription, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil {
+		return subscription, errors.WithStack(subscriptionNotFound{id: id})
+	}
+	err = json.Unmarshal(kvp.Value, &subscription)
+	return subscription, errors.Wrapf(err, "failed to decode subscription %q", id)
+}
+
+// ListSubscriptions returns all the registered subscriptions
+func ListSubscriptions(kv *api.KV) ([]Subscription, error) {
+	kvps, _, err := kv.List(subscriptionsPrefix+"/", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	subscriptions := make([]Subscription, 0, len(kvps))
+	for _, kvp := range kvps {
+		var subscription Subscription
+		if err = json.Unmarshal(kvp.Value, &subscription); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode subscription %q", path.Base(kvp.Key))
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, nil
+}
+
+// DeleteSubscription removes a subscription and its dead letters
+func DeleteSubscription(kv *api.KV, id string) error {
+	if _, err := GetSubscription(kv, id); err != nil {
+		return err
+	}
+	_, err := kv.Delete(path.Join(subscriptionsPrefix, id), nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	_, err = kv.Delete(path.Join(cursorsPrefix, id), nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	return DeleteDeadLetters(kv, id)
+}
+
+// getCursor returns the index after which status updates should be dispatched to a subscription
+//
+// If the subscription has no cursor, only new status updates are dispatched.
+func getCursor(kv *api.KV, subscriptionID string) (uint64, error) {
+	kvp, _, err := kv.Get(path.Join(cursorsPrefix, subscriptionID), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil || len(kvp.Value) == 0 {
+		return events.GetStatusEventsIndex(kv, "")
+	}
+	index, err := strconv.ParseUint(string(kvp.Value), 10, 64)
+	return index, errors.Wrapf(err, "invalid cursor %q for subscription %q", string(kvp.Value), subscriptionID)
+}
+
+func storeCursor(kv *api.KV, subscriptionID string, index uint64) error {
+	_, err := kv.Put(&api.KVPair{Key: path.Join(cursorsPrefix, subscriptionID), Value: []byte(strconv.FormatUint(index, 10))}, nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
+
+// storeDeadLetter records a notification that could not be delivered to its subscription
+func storeDeadLetter(kv *api.KV, deadLetter DeadLetter) error {
+	if deadLetter.Timestamp == "" {
+		deadLetter.Timestamp = time.Now().Format(time.RFC3339Nano)
+	}
+	data, err := json.Marshal(deadLetter)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode dead letter")
+	}
+	key := path.Join(deadLettersPrefix, deadLetter.Notification.SubscriptionID, deadLetter.Timestamp)
+	_, err = kv.Put(&api.KVPair{Key: key, Value: data}, nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
+
+// ListDeadLetters returns notifications that could not be delivered to a given subscription ordered by failure time
+func ListDeadLetters(kv *api.KV, subscriptionID string) ([]DeadLetter, error) {
+	kvps, _, err := kv.List(path.Join(deadLettersPrefix, subscriptionID)+"/", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	deadLetters := make([]DeadLetter, 0, len(kvps))
+	for _, kvp := range kvps {
+		var deadLetter DeadLetter
+		if err = json.Unmarshal(kvp.Value, &deadLetter); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode dead letter %q", kvp.Key)
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	return deadLetters, nil
+}
+
+// DeleteDeadLetters removes all the dead letters of a given subscription
+func DeleteDeadLetters(kv *api.KV, subscriptionID string) error {
+	_, err := kv.DeleteTree(path.Join(deadLettersPrefix, subscriptionID)+"/", nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
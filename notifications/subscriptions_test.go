@@ -0,0 +1,140 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/events"
+)
+
+func testSubscriptions(t *testing.T, kv *api.KV) {
+	_, err := CreateSubscription(kv, Subscription{URL: "not an url"})
+	require.Error(t, err)
+
+	sub := Subscription{URL: "https://chatops.example.com/hooks", Secret: "s3cr3t", Types: []string{"deployment"}, Statuses: []string{"deployment_failed"}}
+	id, err := CreateSubscription(kv, sub)
+	require.NoError(t, err)
+	require.NotEmpty(t, id)
+
+	got, err := GetSubscription(kv, id)
+	require.NoError(t, err)
+	sub.ID = id
+	require.Equal(t, sub, got)
+
+	subs, err := ListSubscriptions(kv)
+	require.NoError(t, err)
+	require.Equal(t, []Subscription{sub}, subs)
+
+	n := Notification{ID: "n1", SubscriptionID: id, Event: events.StatusUpdate{Type: "deployment", DeploymentID: "app", Status: "deployment_failed"}}
+	require.NoError(t, storeDeadLetter(kv, DeadLetter{Attempts: 3, Error: "connection refused", Notification: n}))
+	deadLetters, err := ListDeadLetters(kv, id)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	require.Equal(t, n, deadLetters[0].Notification)
+	require.Equal(t, 3, deadLetters[0].Attempts)
+	require.NotEmpty(t, deadLetters[0].Timestamp)
+
+	require.NoError(t, DeleteSubscription(kv, id))
+	_, err = GetSubscription(kv, id)
+	require.True(t, IsSubscriptionNotFoundError(err))
+	deadLetters, err = ListDeadLetters(kv, id)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 0)
+	err = DeleteSubscription(kv, id)
+	require.True(t, IsSubscriptionNotFoundError(err))
+}
+
+func testDispatchStatusUpdates(t *testing.T, kv *api.KV) {
+	var lock sync.Mutex
+	received := make([]Notification, 0)
+	okSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+		lock.Lock()
+		received = append(received, n)
+		lock.Unlock()
+	}))
+	defer okSrv.Close()
+	koSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer koSrv.Close()
+
+	require.NoError(t, deployments.SetDeploymentTenant(kv, "dispatchApp1", "team1"))
+	require.NoError(t, deployments.SetDeploymentTenant(kv, "dispatchApp2", "team2"))
+	okID, err := CreateSubscription(kv, Subscription{URL: okSrv.URL, Tenant: "team1"})
+	require.NoError(t, err)
+	koID, err := CreateSubscription(kv, Subscription{URL: koSrv.URL, Types: []string{"deployment"}})
+	require.NoError(t, err)
+	defer DeleteSubscription(kv, okID)
+	defer DeleteSubscription(kv, koID)
+
+	evts := []events.StatusUpdate{
+		{Type: "deployment", DeploymentID: "dispatchApp1", Status: "deploying"},
+		{Type: "instance", DeploymentID: "dispatchApp1", Node: "Compute", Instance: "0", Status: "started"},
+		{Type: "deployment", DeploymentID: "dispatchApp2", Status: "deployment_failed"},
+	}
+	d := newDeliverer(config.Notifications{MaxRetries: 1, RetryInitialBackoff: time.Millisecond}, make(chan struct{}))
+	for _, id := range []string{okID, koID} {
+		s, err := GetSubscription(kv, id)
+		require.NoError(t, err)
+		require.NoError(t, dispatchStatusUpdates(kv, d, s, evts))
+	}
+
+	require.Len(t, received, 2)
+	require.Equal(t, evts[0], received[0].Event)
+	require.Equal(t, evts[1], received[1].Event)
+	require.Equal(t, okID, received[0].SubscriptionID)
+
+	deadLetters, err := ListDeadLetters(kv, koID)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 2)
+	for _, dl := range deadLetters {
+		require.Equal(t, 2, dl.Attempts)
+		require.Equal(t, "deployment", dl.Notification.Event.Type)
+	}
+}
+
+func testSubscriptionCursor(t *testing.T, kv *api.KV) {
+	id, err := CreateSubscription(kv, Subscription{URL: "http://localhost/hook"})
+	require.NoError(t, err)
+	index, err := events.GetStatusEventsIndex(kv, "")
+	require.NoError(t, err)
+	cursor, err := getCursor(kv, id)
+	require.NoError(t, err)
+	require.True(t, cursor <= index, "cursor of a new subscription should not be after the current status updates index")
+
+	require.NoError(t, storeCursor(kv, id, index+10))
+	cursor, err = getCursor(kv, id)
+	require.NoError(t, err)
+	require.Equal(t, index+10, cursor)
+
+	require.NoError(t, DeleteSubscription(kv, id))
+	kvp, _, err := kv.Get(path.Join(cursorsPrefix, id), nil)
+	require.NoError(t, err)
+	require.Nil(t, kvp)
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/log"
+)
+
+// Headers set on webhooks requests
+const (
+	// SignatureHeader holds the hex encoded HMAC-SHA256 of the request body computed with the subscription secret
+	// prefixed by "sha256=". It is only set if the subscription has a secret.
+	SignatureHeader = "X-Yorc-Signature"
+	// DeliveryHeader holds the notification ID
+	DeliveryHeader = "X-Yorc-Delivery"
+	// EventHeader holds the type of the notified status update
+	EventHeader = "X-Yorc-Event"
+)
+
+// Sign returns the value of the SignatureHeader for a given body and secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverer posts notifications to webhooks retrying failed deliveries with an exponential backoff
+type deliverer struct {
+	client         *http.Client
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	// shutdownCh interrupts retries
+	shutdownCh <-chan struct{}
+}
+
+func newDeliverer(cfg config.Notifications, shutdownCh <-chan struct{}) *deliverer {
+	d := &deliverer{
+		client:         &http.Client{Timeout: cfg.Timeout},
+		maxRetries:     cfg.MaxRetries,
+		initialBackoff: cfg.RetryInitialBackoff,
+		maxBackoff:     cfg.RetryMaxBackoff,
+		shutdownCh:     shutdownCh,
+	}
+	if d.client.Timeout == 0 {
+		d.client.Timeout = config.DefaultNotificationsTimeout
+	}
+	if d.maxRetries == 0 {
+		d.maxRetries = config.DefaultNotificationsMaxRetries
+	} else if d.maxRetries < 0 {
+		d.maxRetries = 0
+	}
+	if d.initialBackoff == 0 {
+		d.initialBackoff = config.DefaultNotificationsRetryInitialBackoff
+	}
+	if d.maxBackoff == 0 {
+		d.maxBackoff = config.DefaultNotificationsRetryMaxBackoff
+	}
+	return d
+}
+
+// post sends a notification once, any response status code other than 2xx is considered as a failure
+func (d *deliverer) post(subscription Subscription, notification Notification, body []byte) error {
+	req, err := http.NewRequest("POST", subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Yorc")
+	req.Header.Set(DeliveryHeader, notification.ID)
+	req.Header.Set(EventHeader, notification.Event.Type)
+	if subscription.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(subscription.Secret, body))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "webhook request failed")
+	}
+	// Drain the body to allow the connection reuse
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook responded with status %q", resp.Status)
+	}
+	return nil
+}
+
+// deliver posts a notification to the webhook of a subscription
+//
+// It returns the number of attempts and the last error if the notification could not be delivered after all retries.
+func (d *deliverer) deliver(subscription Subscription, notification Notification) (int, error) {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to encode notification")
+	}
+	backoff := d.initialBackoff
+	for attempt := 1; ; attempt++ {
+		err = d.post(subscription, notification, body)
+		if err == nil || attempt > d.maxRetries {
+			return attempt, err
+		}
+		log.Debugf("Delivery %q of subscription %q failed (attempt %d), retrying in %v: %v", notification.ID, subscription.ID, attempt, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-d.shutdownCh:
+			return attempt, errors.Wrap(err, "delivery interrupted by shutdown")
+		}
+		backoff *= 2
+		if backoff > d.maxBackoff {
+			backoff = d.maxBackoff
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/events"
+)
+
+func TestSign(t *testing.T) {
+	// Reference value computed with: echo -n '{"id":"1"}' | openssl dgst -sha256 -hmac s3cr3t
+	assert.Equal(t, "sha256=448e17f4aa91f0d1aa4acb9ad400b1cd5f5e8059cfe7f5994dbeee591977c5dd", Sign("s3cr3t", []byte(`{"id":"1"}`)))
+}
+
+func TestDeliverer(t *testing.T) {
+	var calls int32
+	failures := int32(2)
+	var received Notification
+	var headers http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= atomic.LoadInt32(&failures) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		headers = r.Header
+		body, _ = ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	cfg := config.Notifications{MaxRetries: 3, RetryInitialBackoff: time.Millisecond, RetryMaxBackoff: 2 * time.Millisecond}
+	d := newDeliverer(cfg, make(chan struct{}))
+	s := Subscription{ID: "sub1", URL: srv.URL, Secret: "s3cr3t"}
+	n := Notification{ID: "n1", SubscriptionID: "sub1", Event: events.StatusUpdate{Type: "deployment", DeploymentID: "app1", Status: "deployed"}}
+
+	t.Run("SucceedAfterRetries", func(t *testing.T) {
+		attempts, err := d.deliver(s, n)
+		require.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, n, received)
+		assert.Equal(t, "n1", headers.Get(DeliveryHeader))
+		assert.Equal(t, "deployment", headers.Get(EventHeader))
+		assert.Equal(t, "application/json", headers.Get("Content-Type"))
+		assert.Equal(t, Sign("s3cr3t", body), headers.Get(SignatureHeader))
+	})
+
+	t.Run("FailAfterMaxRetries", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		atomic.StoreInt32(&failures, 10)
+		attempts, err := d.deliver(s, n)
+		assert.Error(t, err)
+		assert.Equal(t, 4, attempts)
+		assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("NoRetries", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		cfg.MaxRetries = -1
+		attempts, err := newDeliverer(cfg, make(chan struct{})).deliver(s, n)
+		assert.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("InterruptedRetries", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		cfg.MaxRetries = 3
+		cfg.RetryInitialBackoff = time.Hour
+		chStop := make(chan struct{})
+		close(chStop)
+		attempts, err := newDeliverer(cfg, chStop).deliver(s, n)
+		assert.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
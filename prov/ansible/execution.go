@@ -25,6 +25,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"syscall"
@@ -42,6 +43,7 @@ import (
 	"github.com/ystia/yorc/helper/consulutil"
 	"github.com/ystia/yorc/helper/executil"
 	"github.com/ystia/yorc/helper/provutil"
+	"github.com/ystia/yorc/helper/sshutil"
 	"github.com/ystia/yorc/helper/stringutil"
 	"github.com/ystia/yorc/log"
 	"github.com/ystia/yorc/prov"
@@ -57,6 +59,29 @@ stdout_callback = json
 retry_files_save_path = #PLAY_PATH#
 `
 
+// remoteCleanupTimeout is the maximum duration allowed to clean up remote hosts
+// after an operation was interrupted (cancelled or timed out)
+const remoteCleanupTimeout = 2 * time.Minute
+
+// cleanupPlaybook kills remote processes started by an interrupted operation. Those processes
+// are identified by the unique remote base directory of the execution which appears in their
+// command line either as the script location or as the Ansible remote temporary directory.
+const cleanupPlaybook = `
+- name: Kill processes of the interrupted operation
+  hosts: all
+  strategy: free
+  tasks:
+    - shell: |
+        pids=$(pgrep -d, -f '#PATTERN#') || exit 0
+        for pgid in $(ps -o pgid= -p "$pids" | sort -u); do kill -KILL -- -$pgid 2>/dev/null; done
+        exit 0
+      ignore_errors: yes
+#REMOVE_DIR#`
+
+const cleanupPlaybookRemoveDir = `    - file: path="{{ ansible_env.HOME}}/#BASE_DIR#" state=absent
+      ignore_errors: yes
+`
+
 type ansibleRetriableError struct {
 	root error
 }
@@ -92,6 +117,7 @@ type hostConnection struct {
 	instanceID string
 	privateKey string
 	password   string
+	bastions   []sshutil.Bastion
 }
 
 type execution interface {
@@ -125,6 +151,7 @@ type executionCommon struct {
 	NodePath                 string
 	NodeTypePath             string
 	Artifacts                map[string]string
+	ArtifactDeliveries       []deployments.ArtifactDelivery
 	OverlayPath              string
 	Context                  map[string]string
 	Outputs                  map[string]string
@@ -304,9 +331,43 @@ func (e *executionCommon) resolveArtifacts() error {
 		}
 	}
 	log.Debugf("Resolved artifacts: %v", e.Artifacts)
+	e.ArtifactDeliveries, err = deployments.GetOperationArtifactDeliveries(e.kv, e.deploymentID, e.NodeName, e.operation)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Resolved artifacts deliveries: %v", e.ArtifactDeliveries)
 	return nil
 }
 
+// ArtifactsToRemove returns the locations of artifacts delivered by get_artifact functions that should be removed
+// once the operation is done
+func (e *executionCommon) ArtifactsToRemove() []string {
+	locations := make([]string, 0)
+	for _, delivery := range e.ArtifactDeliveries {
+		if delivery.Remove && delivery.Location != "" {
+			locations = append(locations, delivery.Location)
+		}
+	}
+	return locations
+}
+
+// resolveDeliveredArtifactsInputs sets the value of inputs assigned by a get_artifact function without location to the
+// path of the artifact delivered in the operation remote directory
+//
+// Inputs using a get_artifact function in a more complex expression are left relative to this directory.
+func (e *executionCommon) resolveDeliveredArtifactsInputs() {
+	for _, delivery := range e.ArtifactDeliveries {
+		if delivery.Location != "" {
+			continue
+		}
+		for _, envInput := range e.EnvInputs {
+			if envInput.Name == delivery.InputName && (envInput.Value == delivery.File || strings.HasSuffix(envInput.Value, "/"+delivery.File)) {
+				envInput.Value = fmt.Sprintf("{{ ansible_env.HOME}}/%s/%s", e.OperationRemotePath, delivery.File)
+			}
+		}
+	}
+}
+
 func (e *executionCommon) setHostConnection(kv *api.KV, host, instanceID, capType string, conn *hostConnection) error {
 	hasEndpoint, err := deployments.IsTypeDerivedFrom(e.kv, e.deploymentID, capType, "yorc.capabilities.Endpoint.ProvisioningAdmin")
 	if err != nil {
@@ -349,6 +410,11 @@ func (e *executionCommon) setHostConnection(kv *api.KV, host, instanceID, capTyp
 				return errors.Wrapf(err, "Failed to convert port value:%q to int", port)
 			}
 		}
+
+		conn.bastions, err = deployments.GetInstanceBastions(kv, e.cfg, e.deploymentID, host, instanceID)
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -700,7 +766,7 @@ func (e *executionCommon) generateHostConnectionForOrchestratorOperation(ctx con
 	return nil
 }
 
-func (e *executionCommon) generateHostConnection(ctx context.Context, buffer *bytes.Buffer, host hostConnection) error {
+func (e *executionCommon) generateHostConnection(ctx context.Context, buffer *bytes.Buffer, host hostConnection, ansibleRecipePath string) error {
 	buffer.WriteString(host.host)
 	if e.isOrchestratorOperation {
 		err := e.generateHostConnectionForOrchestratorOperation(ctx, buffer)
@@ -720,7 +786,17 @@ func (e *executionCommon) generateHostConnection(ctx context.Context, buffer *by
 			sshPrivateKey = "~/.ssh/yorc.pem"
 			events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, e.deploymentID).RegisterAsString("Ansible provisioning: Missing ssh password or private key information, trying to use default private key ~/.ssh/yorc.pem.")
 		}
-		buffer.WriteString(fmt.Sprintf(" ansible_ssh_user=%s ansible_ssh_common_args=\"-o ConnectionAttempts=20\"", sshUser))
+		sshCommonArgs := "-o ConnectionAttempts=20"
+		if len(host.bastions) > 0 {
+			proxyCommand, err := sshutil.ProxyCommand(host.bastions, filepath.Join(ansibleRecipePath, "bastions", host.host))
+			if err != nil {
+				return err
+			}
+			sshCommonArgs += " -o " + sshutil.ShellQuote("ProxyCommand="+proxyCommand)
+		}
+		// Inventory values are double-quoted so escape backslashes and double quotes
+		sshCommonArgs = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(sshCommonArgs)
+		buffer.WriteString(fmt.Sprintf(" ansible_ssh_user=%s ansible_ssh_common_args=\"%s\"", sshUser, sshCommonArgs))
 		if sshPrivateKey != "" {
 			// TODO if not a path store it somewhere
 			// Note whould be better if we can use it directly https://github.com/ansible/ansible/issues/22382
@@ -778,7 +854,7 @@ func (e *executionCommon) executeWithCurrentInstance(ctx context.Context, retry
 	var buffer bytes.Buffer
 	buffer.WriteString("[all]\n")
 	for instanceName, host := range e.hosts {
-		err = e.generateHostConnection(ctx, &buffer, host)
+		err = e.generateHostConnection(ctx, &buffer, host, ansibleRecipePath)
 		if err != nil {
 			return err
 		}
@@ -874,8 +950,12 @@ func (e *executionCommon) executeWithCurrentInstance(ctx context.Context, retry
 		e.OperationRemotePath = path.Join(e.OperationRemoteBaseDir, e.NodeName, e.operation.Name)
 	}
 	log.Debugf("OperationRemotePath:%s", e.OperationRemotePath)
+	e.resolveDeliveredArtifactsInputs()
 	err = e.ansibleRunner.runAnsible(ctx, retry, currentInstance, ansibleRecipePath)
 	if err != nil {
+		if ctx.Err() != nil && !e.isOrchestratorOperation {
+			e.cleanupInterruptedOperation(ctx, ansibleRecipePath)
+		}
 		return err
 	}
 	if e.HaveOutput {
@@ -911,6 +991,51 @@ func (e *executionCommon) executeWithCurrentInstance(ctx context.Context, retry
 
 }
 
+// cleanupInterruptedOperation kills processes left on remote hosts by an operation that was
+// cancelled or that timed out and removes its remote directory.
+//
+// As the given context is already done, cleanup runs with its own bounded context.
+func (e *executionCommon) cleanupInterruptedOperation(ctx context.Context, ansibleRecipePath string) {
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, e.deploymentID).Registerf("Operation %q on node %q interrupted, cleaning up remote processes", e.operation.Name, e.NodeName)
+	playbook := strings.Replace(cleanupPlaybook, "#PATTERN#", remoteProcessesPattern(e.OperationRemoteBaseDir), -1)
+	removeDir := ""
+	if !e.KeepOperationRemotePath {
+		removeDir = strings.Replace(cleanupPlaybookRemoveDir, "#BASE_DIR#", e.OperationRemoteBaseDir, -1)
+	}
+	playbook = strings.Replace(playbook, "#REMOVE_DIR#", removeDir, -1)
+	if err := ioutil.WriteFile(filepath.Join(ansibleRecipePath, "cleanup.ansible.yml"), []byte(playbook), 0664); err != nil {
+		log.Printf("Failed to write cleanup playbook for node %q operation %q: %v", e.NodeName, e.operation.Name, err)
+		return
+	}
+	cleanupCtx, cancel := context.WithTimeout(context.Background(), remoteCleanupTimeout)
+	defer cancel()
+	cmd := executil.Command(cleanupCtx, "ansible-playbook", "-i", "hosts", "cleanup.ansible.yml")
+	if e.cfg.Ansible.UseOpenSSH {
+		cmd.Args = append(cmd.Args, "-c", "ssh")
+	} else {
+		cmd.Args = append(cmd.Args, "-c", "paramiko")
+	}
+	cmd.Dir = ansibleRecipePath
+	var outbuf bytes.Buffer
+	cmd.Stdout = &outbuf
+	cmd.Stderr = &outbuf
+	if err := cmd.Run(); err != nil {
+		log.Printf("Failed to cleanup remote hosts for node %q operation %q: %v", e.NodeName, e.operation.Name, err)
+		log.Debugf("Cleanup playbook output: %s", outbuf.String())
+	}
+}
+
+// remoteProcessesPattern returns a pgrep pattern matching the given directory.
+//
+// The first character is put in a bracket expression so the pattern doesn't match
+// the command line of the shell running pgrep.
+func remoteProcessesPattern(dir string) string {
+	if dir == "" {
+		return dir
+	}
+	return "[" + regexp.QuoteMeta(dir[:1]) + "]" + regexp.QuoteMeta(dir[1:])
+}
+
 func (e *executionCommon) checkAnsibleRetriableError(ctx context.Context, err error) error {
 	events.WithContextOptionalFields(ctx).NewLogEntry(events.ERROR, e.deploymentID).RegisterAsString(errors.Wrapf(err, "Ansible execution for operation %q on node %q failed", e.operation.Name, e.NodeName).Error())
 	log.Debugf(err.Error())
@@ -958,6 +1083,11 @@ func (e *executionCommon) executePlaybook(ctx context.Context, retry bool, ansib
 			cmd.Args = append(cmd.Args, "-c", "paramiko")
 		}
 	}
+	if !e.isOrchestratorOperation && e.OperationRemoteBaseDir != "" {
+		// Use a remote temporary directory specific to this execution so processes started by Ansible modules
+		// may be identified and killed if the operation is interrupted
+		cmd.Env = append(os.Environ(), "ANSIBLE_REMOTE_TEMP="+path.Join("~", e.OperationRemoteBaseDir, ".ansible_tmp"))
+	}
 	cmd.Dir = ansibleRecipePath
 	var outbuf bytes.Buffer
 	errbuf := events.NewBufferedLogEntryWriter()
@@ -37,7 +37,11 @@ const ansiblePlaybook = `
   tasks:
 [[[ range $artName, $art := .Artifacts ]]]    [[[printf "- file: path=\"{{ ansible_env.HOME}}/%s/%s\" state=directory mode=0755" $.OperationRemotePath (path $art)]]]
     [[[printf "- copy: src=\"%s/%s\" dest=\"{{ ansible_env.HOME}}/%s/%s\"" $.OverlayPath $art $.OperationRemotePath (path $art)]]]
-[[[end]]]
+[[[end]]][[[ range $delivery := .ArtifactDeliveries ]]][[[ if $delivery.Location ]]]    [[[printf "- file: path=\"%s\" state=directory mode=0755" (path $delivery.Location)]]]
+    [[[printf "- copy: src=\"%s/%s\" dest=\"%s\"" $.OverlayPath $delivery.File $delivery.Location]]]
+[[[else]]]    [[[printf "- file: path=\"{{ ansible_env.HOME}}/%s/%s\" state=directory mode=0755" $.OperationRemotePath (path $delivery.File)]]]
+    [[[printf "- copy: src=\"%s/%s\" dest=\"{{ ansible_env.HOME}}/%s/%s\"" $.OverlayPath $delivery.File $.OperationRemotePath $delivery.File]]]
+[[[end]]][[[end]]]
 - import_playbook: [[[.PlaybookPath]]]
 [[[if .HaveOutput]]]
 - name: Retrieving Operation outputs
@@ -48,6 +52,13 @@ const ansiblePlaybook = `
     [[[printf "- template: src=\"outputs.csv.j2\" dest=\"{{ ansible_env.HOME}}/%s/out.csv\"" $.OperationRemotePath]]]
     [[[printf "- fetch: src=\"{{ ansible_env.HOME}}/%s/out.csv\" dest={{dest_folder}}/{{ansible_host}}-out.csv flat=yes" $.OperationRemotePath]]]
 [[[end]]]
+[[[with .ArtifactsToRemove]]]
+- name: Remove delivered artifacts
+  hosts: all
+  strategy: free
+  tasks:
+[[[ range $location := . ]]]    [[[printf "- file: path=\"%s\" state=absent" $location]]]
+[[[end]]][[[end]]]
 [[[if not .KeepOperationRemotePath]]]
 - name: Cleanup temp directories
   hosts: all
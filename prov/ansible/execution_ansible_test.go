@@ -15,12 +15,13 @@
 package ansible
 
 import (
-	"os"
+	"bytes"
 	"path/filepath"
 	"testing"
 	"text/template"
 
 	"github.com/stretchr/testify/require"
+	"github.com/ystia/yorc/deployments"
 	"github.com/ystia/yorc/prov"
 )
 
@@ -33,6 +34,10 @@ func TestAnsibleTemplate(t *testing.T) {
 		OverlayPath:         "/some/local/path",
 		VarInputsNames:      []string{"INSTANCE", "PORT"},
 		OperationRemotePath: ".yorc/path/on/remote",
+		ArtifactDeliveries: []deployments.ArtifactDelivery{
+			{InputName: "WAR", NodeName: "Welcome", ArtifactName: "war", File: "files/app.war", Location: "/opt/app/app.war", Remove: true},
+			{InputName: "CONF", NodeName: "Welcome", ArtifactName: "conf", File: "files/app.conf"},
+		},
 	}
 
 	e := &executionAnsible{
@@ -48,8 +53,13 @@ func TestAnsibleTemplate(t *testing.T) {
 	tmpl = tmpl.Delims("[[[", "]]]")
 	tmpl, err := tmpl.Parse(ansiblePlaybook)
 	require.Nil(t, err)
-	err = tmpl.Execute(os.Stdout, e)
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, e)
 	t.Log(err)
 	require.Nil(t, err)
-
+	t.Log(buf.String())
+	require.Contains(t, buf.String(), `- file: path="/opt/app" state=directory mode=0755`)
+	require.Contains(t, buf.String(), `- copy: src="/some/local/path/files/app.war" dest="/opt/app/app.war"`)
+	require.Contains(t, buf.String(), `- copy: src="/some/local/path/files/app.conf" dest="{{ ansible_env.HOME}}/.yorc/path/on/remote/files/app.conf"`)
+	require.Contains(t, buf.String(), `- file: path="/opt/app/app.war" state=absent`)
 }
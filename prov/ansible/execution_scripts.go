@@ -129,7 +129,13 @@ const shellAnsiblePlaybook = `
     [[[ range $artName, $art := .Artifacts -]]]
     [[[printf "- file: path=\"{{ ansible_env.HOME}}/%s/%s\" state=directory mode=0755" $.OperationRemotePath (path $art)]]]
     [[[printf "- copy: src=\"%s/%s\" dest=\"{{ ansible_env.HOME}}/%s/%s\"" $.OverlayPath $art $.OperationRemotePath (path $art)]]]
-    [[[end]]]
+    [[[end]]][[[ range $delivery := .ArtifactDeliveries -]]][[[ if $delivery.Location -]]]
+    [[[printf "- file: path=\"%s\" state=directory mode=0755" (path $delivery.Location)]]]
+    [[[printf "- copy: src=\"%s/%s\" dest=\"%s\"" $.OverlayPath $delivery.File $delivery.Location]]]
+    [[[else -]]]
+    [[[printf "- file: path=\"{{ ansible_env.HOME}}/%s/%s\" state=directory mode=0755" $.OperationRemotePath (path $delivery.File)]]]
+    [[[printf "- copy: src=\"%s/%s\" dest=\"{{ ansible_env.HOME}}/%s/%s\"" $.OverlayPath $delivery.File $.OperationRemotePath $delivery.File]]]
+    [[[end]]][[[end]]]
     [[[printf "- shell: \"/bin/bash -l -c {{ ansible_env.HOME}}/%s/wrapper\"" $.OperationRemotePath]]]
       environment:
         [[[ range $key, $envInput := .EnvInputs -]]]
@@ -147,6 +153,9 @@ const shellAnsiblePlaybook = `
     [[[if .HaveOutput]]]
     [[[printf "- fetch: src={{ ansible_env.HOME}}/%s/out.csv dest=%s/{{ansible_host}}-out.csv flat=yes" $.OperationRemotePath $.DestFolder]]]
     [[[end]]]
+    [[[ range $location := .ArtifactsToRemove -]]]
+    [[[printf "- file: path=\"%s\" state=absent" $location]]]
+    [[[end]]]
     [[[if not .KeepOperationRemotePath ]]]
     - file: path="{{ ansible_env.HOME}}/[[[.OperationRemoteBaseDir]]]" state=absent
     [[[end]]]
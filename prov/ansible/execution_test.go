@@ -54,6 +54,10 @@ func TestTemplates(t *testing.T) {
 		OverlayPath:            "/some/local/path",
 		VarInputsNames:         []string{"INSTANCE", "PORT"},
 		OperationRemoteBaseDir: ".yorc/path/on/remote",
+		ArtifactDeliveries: []deployments.ArtifactDelivery{
+			{InputName: "WAR", NodeName: "Welcome", ArtifactName: "war", File: "files/app.war", Location: "/opt/app/app.war", Remove: true},
+			{InputName: "CONF", NodeName: "Welcome", ArtifactName: "conf", File: "files/app.conf"},
+		},
 	}
 
 	e := &executionScript{
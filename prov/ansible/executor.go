@@ -45,6 +45,10 @@ func NewExecutor() prov.OperationExecutor {
 	return &defaultExecutor{r: rand.New(rand.NewSource(time.Now().UnixNano())), cli: cli}
 }
 
+func (e *defaultExecutor) DefaultOperationTimeout(conf config.Configuration) time.Duration {
+	return conf.Ansible.OperationTimeout
+}
+
 func (e *defaultExecutor) ExecOperation(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation) error {
 	consulClient, err := conf.GetConsulClient()
 	if err != nil {
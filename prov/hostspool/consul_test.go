@@ -80,4 +80,25 @@ func TestRunConsulHostsPoolPackageTests(t *testing.T) {
 	t.Run("testConsulManagerAddLabelsWithAllocation", func(t *testing.T) {
 		testConsulManagerAddLabelsWithAllocation(t, client)
 	})
+	t.Run("testReapAllocations", func(t *testing.T) {
+		testReapAllocations(t, client)
+	})
+	t.Run("testForceReleaseAllocation", func(t *testing.T) {
+		testForceReleaseAllocation(t, client)
+	})
+	t.Run("testConsulManagerAllocateWithPlacement", func(t *testing.T) {
+		testConsulManagerAllocateWithPlacement(t, client)
+	})
+	t.Run("testProbeHosts", func(t *testing.T) {
+		testProbeHosts(t, client)
+	})
+	t.Run("testProbeHostsWithCommand", func(t *testing.T) {
+		testProbeHostsWithCommand(t, client)
+	})
+	t.Run("testProbeHostsTimeout", func(t *testing.T) {
+		testProbeHostsTimeout(t, client)
+	})
+	t.Run("testConsulManagerDiscover", func(t *testing.T) {
+		testConsulManagerDiscover(t, client)
+	})
 }
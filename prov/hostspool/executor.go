@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"github.com/ystia/yorc/helper/labelsutil"
+	"github.com/ystia/yorc/helper/sshutil"
 
 	"github.com/hashicorp/go-multierror"
 
@@ -33,6 +34,7 @@ import (
 	"github.com/ystia/yorc/tasks"
 	"github.com/ystia/yorc/tosca"
 	"strconv"
+	"time"
 )
 
 type defaultExecutor struct {
@@ -116,6 +118,11 @@ func (e *defaultExecutor) hostsPoolCreate(originalCtx context.Context, cc *api.C
 		}
 		filters = append(filters, f)
 	}
+	tenant, err := deployments.GetDeploymentTenant(cc.KV(), deploymentID)
+	if err != nil {
+		return err
+	}
+	filters = append(filters, NewTenantFilter(tenant))
 
 	shareable := false
 	if _, s, err := deployments.GetNodeProperty(cc.KV(), deploymentID, nodeName, "shareable"); err != nil {
@@ -127,6 +134,28 @@ func (e *defaultExecutor) hostsPoolCreate(originalCtx context.Context, cc *api.C
 		}
 	}
 
+	var leaseTTL time.Duration
+	if _, l, err := deployments.GetNodeProperty(cc.KV(), deploymentID, nodeName, "lease_ttl"); err != nil {
+		return err
+	} else if l != "" {
+		leaseTTL, err = time.ParseDuration(l)
+		if err != nil {
+			return errors.Wrapf(err, `failed to parse property "lease_ttl" for node %q as a duration`, nodeName)
+		}
+	}
+
+	var placement *PlacementPolicy
+	_, jsonProp, err = deployments.GetNodeProperty(cc.KV(), deploymentID, nodeName, "placement")
+	if err != nil {
+		return err
+	}
+	if jsonProp != "" {
+		placement, err = parsePlacementPolicy(jsonProp)
+		if err != nil {
+			return errors.Wrapf(err, `failed to parse property "placement" for node %q`, nodeName)
+		}
+	}
+
 	instances, err := tasks.GetInstances(cc.KV(), taskID, deploymentID, nodeName)
 	if err != nil {
 		return err
@@ -136,7 +165,7 @@ func (e *defaultExecutor) hostsPoolCreate(originalCtx context.Context, cc *api.C
 		logOptFields[events.InstanceID] = instance
 		ctx := events.NewContext(originalCtx, logOptFields)
 
-		allocation := &Allocation{NodeName: nodeName, Instance: instance, DeploymentID: deploymentID, Shareable: shareable, Resources: allocatedResources}
+		allocation := &Allocation{NodeName: nodeName, Instance: instance, DeploymentID: deploymentID, Shareable: shareable, Resources: allocatedResources, LeaseTTL: leaseTTL, PlacementPolicy: placement}
 		hostname, warnings, err := hpManager.Allocate(allocation, filters...)
 		for _, warn := range warnings {
 			events.WithContextOptionalFields(ctx).
@@ -168,6 +197,12 @@ func (e *defaultExecutor) hostsPoolCreate(originalCtx context.Context, cc *api.C
 		if err != nil {
 			return err
 		}
+		if len(host.Connection.Bastions) > 0 {
+			err = deployments.SetInstanceCapabilityAttributeComplex(deploymentID, nodeName, instance, "endpoint", "bastions", bastionsAsAttribute(host.Connection.Bastions))
+			if err != nil {
+				return err
+			}
+		}
 
 		privateAddress, ok := host.Labels["private_address"]
 		if !ok {
@@ -330,6 +365,27 @@ func (e *defaultExecutor) hostsPoolDelete(originalCtx context.Context, cc *api.C
 	return errors.Wrap(errs, "errors encountered during hosts pool node release. Some hosts maybe not properly released.")
 }
 
+// bastionsAsAttribute converts a connection bastions chain into the complex value
+// of the endpoint capability "bastions" attribute (a list of yorc.datatypes.BastionHost)
+func bastionsAsAttribute(bastions []sshutil.Bastion) []interface{} {
+	res := make([]interface{}, 0, len(bastions))
+	for _, b := range bastions {
+		credentials := map[string]interface{}{"user": b.User}
+		if b.Password != "" {
+			credentials["token"] = b.Password
+		}
+		if b.PrivateKey != "" {
+			credentials["keys"] = []string{b.PrivateKey}
+		}
+		bastion := map[string]interface{}{"host": b.Host, "credentials": credentials}
+		if b.Port != 0 {
+			bastion["port"] = strconv.Itoa(b.Port)
+		}
+		res = append(res, bastion)
+	}
+	return res
+}
+
 func setAttributeFromLabel(deploymentID, nodeName, instance, label string, value interface{}, prefix, suffix string) error {
 	if strings.HasPrefix(label, prefix+".") && strings.HasSuffix(label, "."+suffix) {
 		attrName := strings.Replace(strings.Replace(label, prefix+".", prefix+"/", -1), "."+suffix, "/"+suffix, -1)
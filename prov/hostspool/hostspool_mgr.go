@@ -56,6 +56,8 @@ type Manager interface {
 	GetHost(hostname string) (Host, error)
 	Allocate(allocation *Allocation, filters ...labelsutil.Filter) (string, []labelsutil.Warning, error)
 	Release(hostname string, allocation *Allocation) error
+	ForceRelease(hostname, allocationID string) error
+	Discover(hostname string) (map[string]string, error)
 }
 
 // SSHClientFactory is a that could be called to customize the client used to check the connection.
@@ -67,9 +69,10 @@ type SSHClientFactory func(config *ssh.ClientConfig, conn Connection) sshutil.Cl
 func NewManager(cc *api.Client) Manager {
 	return NewManagerWithSSHFactory(cc, func(config *ssh.ClientConfig, conn Connection) sshutil.Client {
 		return &sshutil.SSHClient{
-			Config: config,
-			Host:   conn.Host,
-			Port:   int(conn.Port),
+			Config:   config,
+			Host:     conn.Host,
+			Port:     int(conn.Port),
+			Bastions: conn.Bastions,
 		}
 	})
 }
@@ -195,6 +198,14 @@ func (cm *consulManager) getAddOperations(
 		},
 	}
 
+	if len(conn.Bastions) > 0 {
+		bastionsOp, err := getBastionsOperation(hostKVPrefix, conn.Bastions)
+		if err != nil {
+			return nil, err
+		}
+		addOps = append(addOps, bastionsOp)
+	}
+
 	if message != "" {
 
 		addOps = append(addOps, &api.KVTxnOp{
@@ -543,6 +554,10 @@ func (cm *consulManager) GetHost(hostname string) (Host, error) {
 	}
 
 	host.Labels, err = cm.GetHostLabels(hostname)
+	if err != nil {
+		return host, err
+	}
+	host.DiscoveredLabels, err = cm.getDiscoveredLabelsNames(hostname)
 	return host, err
 }
 
@@ -647,8 +662,15 @@ func (cm *consulManager) applyWait(
 
 			// Host already in pool, check if an update is needed
 			oldHost, _ := cm.GetHost(host.Name)
-			if oldHost.Connection == host.Connection &&
-				reflect.DeepEqual(oldHost.Labels, host.Labels) {
+			// Discovered labels are kept unless they are redefined
+			discovered, err := cm.getDiscoveredLabels(host.Name)
+			if err != nil {
+				return err
+			}
+			labels, remainingDiscovered := mergeDiscoveredLabels(host.Labels, discovered)
+			if reflect.DeepEqual(oldHost.Connection, host.Connection) &&
+				reflect.DeepEqual(oldHost.Labels, labels) &&
+				len(remainingDiscovered) == len(discovered) {
 
 				// No config change, no update needed, ignoring this host
 				delete(hostsToUnregisterCheckAllocatedStatus, host.Name)
@@ -686,12 +708,19 @@ func (cm *consulManager) applyWait(
 					message, _ = cm.getMessage(host.Name, true)
 				}
 			}
-			ops, err := cm.getAddOperations(host.Name, host.Connection, host.Labels,
+			ops, err := cm.getAddOperations(host.Name, host.Connection, labels,
 				status, message, allocations)
 			if err != nil {
 				return err
 			}
 			addOps = append(addOps, ops...)
+			if len(remainingDiscovered) > 0 {
+				op, err := getDiscoveredLabelsOperation(host.Name, remainingDiscovered)
+				if err != nil {
+					return err
+				}
+				addOps = append(addOps, op)
+			}
 		} else {
 			// Host is new, creating it
 			hostChanged = append(hostChanged, host.Name)
@@ -35,6 +35,10 @@ func (cm *consulManager) allocateWait(maxWaitTime time.Duration, allocation *All
 		return "", nil, err
 	}
 
+	if err := allocation.PlacementPolicy.validate(); err != nil {
+		return "", nil, err
+	}
+
 	lockCh, cleanupFn, err := cm.lockKey("", "allocation", maxWaitTime)
 	if err != nil {
 		return "", nil, err
@@ -47,7 +51,7 @@ func (cm *consulManager) allocateWait(maxWaitTime time.Duration, allocation *All
 	}
 	// Filters only free or allocated hosts in case of shareable allocation
 	var lastErr error
-	freeHosts := hosts[:0]
+	freeHosts := make([]Host, 0, len(hosts))
 	for _, h := range hosts {
 		select {
 		case <-lockCh:
@@ -59,47 +63,75 @@ func (cm *consulManager) allocateWait(maxWaitTime time.Duration, allocation *All
 			lastErr = err
 			continue
 		}
-		hs, err := cm.GetHostStatus(h)
+		host, err := cm.GetHost(h)
 		if err != nil {
 			lastErr = err
 		} else {
-			if hs == HostStatusFree {
-				freeHosts = append(freeHosts, h)
-			} else if hs == HostStatusAllocated && allocation.Shareable {
-				allocations, err := cm.GetAllocations(h)
-				if err != nil {
-					lastErr = err
-					continue
-				}
+			if host.Status == HostStatusFree {
+				freeHosts = append(freeHosts, host)
+			} else if host.Status == HostStatusAllocated && allocation.Shareable {
 				// Check the host allocation is not unshareable
-				if len(allocations) == 1 && !allocations[0].Shareable {
+				if len(host.Allocations) == 1 && !host.Allocations[0].Shareable {
 					continue
 				}
-				freeHosts = append(freeHosts, h)
+				freeHosts = append(freeHosts, host)
 			}
 		}
 	}
 
+	pool, err := cm.getPlacementPool(allocation.PlacementPolicy)
+	if err != nil {
+		return "", warnings, err
+	}
+	freeHosts, err = place(freeHosts, pool, allocation)
+	if err != nil {
+		return "", warnings, err
+	}
+
 	if len(freeHosts) == 0 {
 		if lastErr != nil {
 			return "", warnings, lastErr
 		}
 		return "", warnings, errors.WithStack(noMatchingHostFoundError{})
 	}
-	// Get the first host that match
-	hostname := freeHosts[0]
+	// Get the preferred host
+	hostname := freeHosts[0].Name
 	select {
 	case <-lockCh:
 		return "", warnings, errors.New("admin lock lost on hosts pool during host allocation")
 	default:
 	}
 
+	if allocation.LeaseTTL > 0 {
+		allocation.LeaseExpiration = time.Now().Add(allocation.LeaseTTL)
+	}
 	if err := cm.addAllocation(hostname, allocation); err != nil {
 		return "", warnings, errors.Wrapf(err, "failed to add allocation for hostname:%q", hostname)
 	}
 
 	return hostname, warnings, cm.setHostStatus(hostname, HostStatusAllocated)
 }
+
+// getPlacementPool returns all hosts of the pool if they are needed to evaluate affinity rules of a placement policy
+func (cm *consulManager) getPlacementPool(policy *PlacementPolicy) ([]Host, error) {
+	if policy == nil || (policy.Affinity == nil && policy.AntiAffinity == nil) {
+		return nil, nil
+	}
+	hostnames, _, _, err := cm.List()
+	if err != nil {
+		return nil, err
+	}
+	pool := make([]Host, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		host, err := cm.GetHost(hostname)
+		if err != nil {
+			return nil, err
+		}
+		pool = append(pool, host)
+	}
+	return pool, nil
+}
+
 func (cm *consulManager) Release(hostname string, allocation *Allocation) error {
 	return cm.releaseWait(hostname, allocation, maxWaitTimeSeconds*time.Second)
 }
@@ -171,6 +203,20 @@ func getAddAllocationsOperation(hostname string, allocations []Allocation) (api.
 				},
 			}
 
+			if alloc.LeaseTTL > 0 {
+				allocOps = append(allocOps,
+					&api.KVTxnOp{
+						Verb:  api.KVSet,
+						Key:   path.Join(allocKVPrefix, "lease_ttl"),
+						Value: []byte(alloc.LeaseTTL.String()),
+					},
+					&api.KVTxnOp{
+						Verb:  api.KVSet,
+						Key:   path.Join(allocKVPrefix, "lease_expiration"),
+						Value: []byte(alloc.LeaseExpiration.Format(time.RFC3339Nano)),
+					})
+			}
+
 			for k, v := range alloc.Resources {
 				k = url.PathEscape(k)
 				if k == "" {
@@ -277,6 +323,28 @@ func (cm *consulManager) GetAllocations(hostname string) ([]Allocation, error) {
 			}
 		}
 
+		kvp, _, err = cm.cc.KV().Get(path.Join(key, "lease_ttl"), nil)
+		if err != nil {
+			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if kvp != nil && len(kvp.Value) > 0 {
+			alloc.LeaseTTL, err = time.ParseDuration(string(kvp.Value))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse duration from value:%q", string(kvp.Value))
+			}
+		}
+
+		kvp, _, err = cm.cc.KV().Get(path.Join(key, "lease_expiration"), nil)
+		if err != nil {
+			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if kvp != nil && len(kvp.Value) > 0 {
+			alloc.LeaseExpiration, err = time.Parse(time.RFC3339Nano, string(kvp.Value))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse date from value:%q", string(kvp.Value))
+			}
+		}
+
 		kvps, _, err := cm.cc.KV().List(path.Join(key, "resources"), nil)
 		if err != nil {
 			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
@@ -291,3 +359,55 @@ func (cm *consulManager) GetAllocations(hostname string) ([]Allocation, error) {
 	}
 	return allocations, nil
 }
+
+// ForceRelease releases an allocation of a host given its ID and gives back the resources it consumed
+//
+// This is intended to clean up allocations whose owner will never release them.
+func (cm *consulManager) ForceRelease(hostname, allocationID string) error {
+	allocations, err := cm.GetAllocations(hostname)
+	if err != nil {
+		return err
+	}
+	for _, alloc := range allocations {
+		if alloc.ID != allocationID {
+			continue
+		}
+		if err = cm.Release(hostname, &alloc); err != nil {
+			return err
+		}
+		if len(alloc.Resources) == 0 {
+			return nil
+		}
+		return cm.UpdateResourcesLabels(hostname, alloc.Resources, add, updateResourcesLabels)
+	}
+	return errors.WithStack(allocationNotFoundError{})
+}
+
+// renewLease extends the lease of an allocation by its TTL from the given date
+//
+// The allocation is updated only if it was not released in the meantime.
+func (cm *consulManager) renewLease(hostname string, allocation Allocation, now time.Time) error {
+	allocKVPrefix := path.Join(consulutil.HostsPoolPrefix, hostname, "allocations", allocation.ID)
+	kvp, _, err := cm.cc.KV().Get(allocKVPrefix, nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil {
+		// Released in the meantime
+		return nil
+	}
+	ops := api.KVTxnOps{
+		&api.KVTxnOp{
+			Verb:  api.KVCheckIndex,
+			Key:   allocKVPrefix,
+			Index: kvp.ModifyIndex,
+		},
+		&api.KVTxnOp{
+			Verb:  api.KVSet,
+			Key:   path.Join(allocKVPrefix, "lease_expiration"),
+			Value: []byte(now.Add(allocation.LeaseTTL).Format(time.RFC3339Nano)),
+		},
+	}
+	_, _, _, err = cm.cc.KV().Txn(ops, nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
@@ -15,10 +15,14 @@
 package hostspool
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/hashicorp/consul/api"
 	"github.com/pkg/errors"
 	"github.com/ystia/yorc/config"
 	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/helper/sshutil"
 	"path"
 	"strconv"
 	"strings"
@@ -98,6 +102,19 @@ func (cm *consulManager) updateConnectionWait(hostname string, conn Connection,
 		})
 	}
 
+	if len(conn.Bastions) == 1 && conn.Bastions[0].Host == "-" {
+		ops = append(ops, &api.KVTxnOp{
+			Verb: api.KVDelete,
+			Key:  path.Join(hostKVPrefix, "connection", "bastions"),
+		})
+	} else if len(conn.Bastions) > 0 {
+		bastionsOp, err := getBastionsOperation(hostKVPrefix, conn.Bastions)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, bastionsOp)
+	}
+
 	_, cleanupFn, err := cm.lockKey(hostname, "update", maxWaitTime)
 	if err != nil {
 		return err
@@ -193,6 +210,16 @@ func (cm *consulManager) GetHostConnection(hostname string) (Connection, error)
 			return conn, errors.Wrapf(err, "failed to retrieve connection port for host %q", hostname)
 		}
 	}
+	kvp, _, err = kv.Get(path.Join(connKVPrefix, "bastions"), nil)
+	if err != nil {
+		return conn, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp != nil && len(kvp.Value) > 0 {
+		err = json.Unmarshal(kvp.Value, &conn.Bastions)
+		if err != nil {
+			return conn, errors.Wrapf(err, "failed to retrieve connection bastions for host %q", hostname)
+		}
+	}
 
 	return conn, nil
 }
@@ -202,24 +229,91 @@ func resolveTemplatesInConnection(conn *Connection) {
 	conn.Password = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("Connection.Password", conn.Password).(string)
 	conn.PrivateKey = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("Connection.PrivateKey", conn.PrivateKey).(string)
 	conn.Host = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("Connection.Host", conn.Host).(string)
+	for i := range conn.Bastions {
+		b := &conn.Bastions[i]
+		b.Host = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("Connection.Bastions.Host", b.Host).(string)
+		b.User = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("Connection.Bastions.User", b.User).(string)
+		b.Password = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("Connection.Bastions.Password", b.Password).(string)
+		b.PrivateKey = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("Connection.Bastions.PrivateKey", b.PrivateKey).(string)
+	}
+}
+
+// getBastionsOperation returns the operation storing the bastions of a host connection
+func getBastionsOperation(hostKVPrefix string, bastions []sshutil.Bastion) (*api.KVTxnOp, error) {
+	for i, b := range bastions {
+		if b.Host == "" {
+			return nil, errors.WithStack(badRequestError{fmt.Sprintf(`"host" missing for bastion number %d`, i+1)})
+		}
+		if b.Password == "" && b.PrivateKey == "" {
+			return nil, errors.WithStack(badRequestError{fmt.Sprintf(`at least "password" or "private_key" is required for bastion %q`, b.Host)})
+		}
+	}
+	value, err := json.Marshal(bastions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize connection bastions")
+	}
+	return &api.KVTxnOp{
+		Verb:  api.KVSet,
+		Key:   path.Join(hostKVPrefix, "connection", "bastions"),
+		Value: value,
+	}, nil
 }
 
 // Check if we can log into an host given a connection
 func (cm *consulManager) checkConnection(hostname string) error {
+	_, err := cm.runCommand(hostname, `echo "Connected!"`)
+	return errors.Wrapf(err, "failed to connect to host %q", hostname)
+}
+
+// runCommand runs a command on a host using its connection
+func (cm *consulManager) runCommand(hostname, command string) (string, error) {
+	return cm.runCommandContext(context.Background(), hostname, command)
+}
+
+// contextCommandRunner is implemented by SSH clients able to interrupt a running command
+type contextCommandRunner interface {
+	RunCommandWithContext(ctx context.Context, cmd string) (string, error)
+}
 
+// runCommandContext runs a command on a host using its connection, giving up when the context is done
+//
+// If the context has a deadline, connecting to the host should not take longer than it.
+func (cm *consulManager) runCommandContext(ctx context.Context, hostname, command string) (string, error) {
 	conn, err := cm.GetHostConnection(hostname)
 	if err != nil {
-		return errors.Wrapf(err, "failed to connect to host %q", hostname)
+		return "", err
 	}
 	resolveTemplatesInConnection(&conn)
 	conf, err := getSSHConfig(conn)
 	if err != nil {
-		return errors.Wrapf(err, "failed to connect to host %q", hostname)
+		return "", err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conf.Timeout = time.Until(deadline)
 	}
 
 	client := cm.getSSHClient(conf, conn)
-	_, err = client.RunCommand(`echo "Connected!"`)
-	return errors.Wrapf(err, "failed to connect to host %q", hostname)
+	if runner, ok := client.(contextCommandRunner); ok {
+		return runner.RunCommandWithContext(ctx, command)
+	}
+	if ctx.Done() == nil {
+		return client.RunCommand(command)
+	}
+	type result struct {
+		out string
+		err error
+	}
+	chResult := make(chan result, 1)
+	go func() {
+		out, err := client.RunCommand(command)
+		chResult <- result{out, err}
+	}()
+	select {
+	case r := <-chResult:
+		return r.out, r.err
+	case <-ctx.Done():
+		return "", errors.Wrap(ctx.Err(), "command interrupted")
+	}
 }
 
 // Go routine checking a Host connection and updating the Host status
@@ -0,0 +1,259 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostspool
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/helper/consulutil"
+)
+
+// discoveryCommand gathers hardware and OS facts of a host, one "fact=value" per line
+const discoveryCommand = `echo "num_cpus=$(getconf _NPROCESSORS_ONLN 2>/dev/null || nproc)"
+awk '/^MemTotal:/ {print "mem_kb=" $2}' /proc/meminfo 2>/dev/null
+df -Pk / 2>/dev/null | awk 'NR==2 {print "disk_kb=" $2}'
+awk -F: '/^cpu MHz/ {gsub(/ /, "", $2); print "cpu_mhz=" $2; exit}' /proc/cpuinfo 2>/dev/null
+echo "arch=$(uname -m)"
+echo "os_type=$(uname -s)"
+if [ -f /etc/os-release ]; then (. /etc/os-release; echo "distribution=$ID"; echo "version=$VERSION_ID"); fi
+if command -v nvidia-smi >/dev/null 2>&1; then
+  nvidia-smi --query-gpu=name --format=csv,noheader 2>/dev/null | awk '{n++; name=$0} END {if (n > 0) {print "gpus=" n; print "gpu_type=" name}}'
+fi
+exit 0`
+
+// discoveredLabelsKey is the host key storing labels maintained by the hardware discovery
+const discoveredLabelsKey = ".discoveredLabels"
+
+func (cm *consulManager) Discover(hostname string) (map[string]string, error) {
+	return cm.discoverWait(hostname, maxWaitTimeSeconds*time.Second)
+}
+
+func (cm *consulManager) discoverWait(hostname string, maxWaitTime time.Duration) (map[string]string, error) {
+	if hostname == "" {
+		return nil, errors.WithStack(badRequestError{`"hostname" missing`})
+	}
+	// Checks host existence
+	_, err := cm.GetHostStatus(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	// Facts are gathered before taking the lock as connecting to the host may take a while
+	out, err := cm.runCommand(hostname, discoveryCommand)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to discover hardware of host %q", hostname)
+	}
+	facts := parseDiscoveredFacts(out)
+
+	_, cleanupFn, err := cm.lockKey(hostname, "discovery", maxWaitTime)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupFn()
+
+	labels, err := cm.GetHostLabels(hostname)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := cm.getDiscoveredLabels(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	// Labels defined by users are never overridden by discovered ones
+	discovered := make(map[string]string, len(facts))
+	for k, v := range facts {
+		_, isLabel := labels[k]
+		_, wasDiscovered := previous[k]
+		if !isLabel || wasDiscovered {
+			discovered[k] = v
+		}
+	}
+
+	ops, err := cm.getAddUpdatedLabelsOperations(hostname, discovered)
+	if err != nil {
+		return nil, err
+	}
+	// Facts that are not discovered anymore are stale
+	hostKVPrefix := path.Join(consulutil.HostsPoolPrefix, hostname)
+	for k := range previous {
+		if _, ok := discovered[k]; !ok {
+			ops = append(ops, &api.KVTxnOp{
+				Verb: api.KVDelete,
+				Key:  path.Join(hostKVPrefix, "labels", url.PathEscape(k)),
+			})
+		}
+	}
+	op, err := getDiscoveredLabelsOperation(hostname, discovered)
+	if err != nil {
+		return nil, err
+	}
+	ops = append(ops, op)
+
+	ok, response, _, err := cm.cc.KV().Txn(ops, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if !ok {
+		// Check the response
+		errs := make([]string, 0)
+		for _, e := range response.Errors {
+			errs = append(errs, e.What)
+		}
+		return nil, errors.Errorf("Failed to update discovered labels of host %q: %s", hostname, strings.Join(errs, ", "))
+	}
+	return discovered, nil
+}
+
+// parseDiscoveredFacts converts the output of the discovery command into hosts pool labels
+//
+// Unknown, empty or malformed facts are ignored.
+func parseDiscoveredFacts(output string) map[string]string {
+	labels := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		if value == "" {
+			continue
+		}
+		switch parts[0] {
+		case "num_cpus":
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil && n > 0 {
+				labels["host.num_cpus"] = strconv.FormatUint(n, 10)
+			}
+		case "mem_kb":
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil && n > 0 {
+				labels["host.mem_size"] = humanize.IBytes(n * 1024)
+			}
+		case "disk_kb":
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil && n > 0 {
+				labels["host.disk_size"] = humanize.IBytes(n * 1024)
+			}
+		case "cpu_mhz":
+			if f, err := strconv.ParseFloat(value, 64); err == nil && f > 0 {
+				labels["host.cpu_frequency"] = humanize.SI(math.Round(f)*1e6, "Hz")
+			}
+		case "arch":
+			labels["os.architecture"] = value
+		case "os_type":
+			labels["os.type"] = strings.ToLower(value)
+		case "distribution":
+			labels["os.distribution"] = strings.ToLower(value)
+		case "version":
+			labels["os.version"] = value
+		case "gpus":
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil && n > 0 {
+				labels["host.num_gpus"] = strconv.FormatUint(n, 10)
+			}
+		case "gpu_type":
+			labels["host.gpu_type"] = value
+		}
+	}
+	return labels
+}
+
+// getDiscoveredLabels returns labels maintained by the hardware discovery of a host with their discovered values
+func (cm *consulManager) getDiscoveredLabels(hostname string) (map[string]string, error) {
+	discovered := make(map[string]string)
+	kvp, _, err := cm.cc.KV().Get(path.Join(consulutil.HostsPoolPrefix, hostname, discoveredLabelsKey), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil || len(kvp.Value) == 0 {
+		return discovered, nil
+	}
+	err = json.Unmarshal(kvp.Value, &discovered)
+	return discovered, errors.Wrapf(err, "failed to read discovered labels of host %q", hostname)
+}
+
+func (cm *consulManager) getDiscoveredLabelsNames(hostname string) ([]string, error) {
+	discovered, err := cm.getDiscoveredLabels(hostname)
+	if err != nil || len(discovered) == 0 {
+		return nil, err
+	}
+	names := make([]string, 0, len(discovered))
+	for k := range discovered {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func getDiscoveredLabelsOperation(hostname string, discovered map[string]string) (*api.KVTxnOp, error) {
+	b, err := json.Marshal(discovered)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to store discovered labels of host %q", hostname)
+	}
+	return &api.KVTxnOp{
+		Verb:  api.KVSet,
+		Key:   path.Join(consulutil.HostsPoolPrefix, hostname, discoveredLabelsKey),
+		Value: b,
+	}, nil
+}
+
+// getForgetDiscoveredLabelsOperation returns an operation making the given labels user-defined
+// so that they are not maintained anymore by the hardware discovery.
+//
+// It returns a nil operation if none of those labels were discovered.
+func (cm *consulManager) getForgetDiscoveredLabelsOperation(hostname string, labels []string) (*api.KVTxnOp, error) {
+	discovered, err := cm.getDiscoveredLabels(hostname)
+	if err != nil {
+		return nil, err
+	}
+	var changed bool
+	for _, k := range labels {
+		if _, ok := discovered[k]; ok {
+			delete(discovered, k)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	return getDiscoveredLabelsOperation(hostname, discovered)
+}
+
+// mergeDiscoveredLabels returns the given labels completed by previously discovered labels they do not redefine
+// and the discovered labels still maintained by the hardware discovery
+func mergeDiscoveredLabels(labels, discovered map[string]string) (map[string]string, map[string]string) {
+	merged := make(map[string]string, len(labels)+len(discovered))
+	remaining := make(map[string]string, len(discovered))
+	for k, v := range discovered {
+		if _, ok := labels[k]; !ok {
+			merged[k] = v
+			remaining[k] = v
+		}
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return merged, remaining
+}
@@ -0,0 +1,137 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostspool
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/ystia/yorc/helper/labelsutil"
+	"github.com/ystia/yorc/helper/sshutil"
+)
+
+const discoveryTestOutput = `num_cpus=4
+mem_kb=8009248
+disk_kb=50758760
+cpu_mhz=2593.906
+arch=x86_64
+os_type=Linux
+distribution=ubuntu
+version=18.04
+gpus=2
+gpu_type=Tesla K80
+`
+
+type mockDiscoverySSHClient struct {
+	output string
+}
+
+func (m *mockDiscoverySSHClient) RunCommand(string) (string, error) {
+	return m.output, nil
+}
+
+func TestParseDiscoveredFacts(t *testing.T) {
+	t.Parallel()
+	labels := parseDiscoveredFacts(discoveryTestOutput)
+	require.Equal(t, map[string]string{
+		"host.num_cpus":      "4",
+		"host.mem_size":      "7.6 GiB",
+		"host.disk_size":     "48 GiB",
+		"host.cpu_frequency": "2.594 GHz",
+		"os.architecture":    "x86_64",
+		"os.type":            "linux",
+		"os.distribution":    "ubuntu",
+		"os.version":         "18.04",
+		"host.num_gpus":      "2",
+		"host.gpu_type":      "Tesla K80",
+	}, labels)
+
+	labels = parseDiscoveredFacts("num_cpus=\nmem_kb=unknown\nConnected!\nunknown_fact=1\narch=aarch64")
+	require.Equal(t, map[string]string{"os.architecture": "aarch64"}, labels)
+}
+
+func TestMergeDiscoveredLabels(t *testing.T) {
+	t.Parallel()
+	merged, remaining := mergeDiscoveredLabels(
+		map[string]string{"os.type": "windows", "rack": "r1"},
+		map[string]string{"os.type": "linux", "host.num_cpus": "4"},
+	)
+	require.Equal(t, map[string]string{"os.type": "windows", "rack": "r1", "host.num_cpus": "4"}, merged)
+	require.Equal(t, map[string]string{"host.num_cpus": "4"}, remaining)
+}
+
+func testConsulManagerDiscover(t *testing.T, cc *api.Client) {
+	cleanupHostsPool(t, cc)
+	output := discoveryTestOutput
+	cm := &consulManager{cc, func(config *ssh.ClientConfig, conn Connection) sshutil.Client {
+		return &mockDiscoverySSHClient{output}
+	}}
+
+	// A user-defined label is never overridden
+	err := cm.Add("host0", Connection{PrivateKey: dummySSHkey}, map[string]string{"os.distribution": "debian"})
+	require.NoError(t, err)
+
+	labels, err := cm.Discover("host0")
+	require.NoError(t, err)
+	require.Len(t, labels, 9)
+	require.NotContains(t, labels, "os.distribution")
+
+	host, err := cm.GetHost("host0")
+	require.NoError(t, err)
+	require.Equal(t, "debian", host.Labels["os.distribution"])
+	require.Equal(t, "4", host.Labels["host.num_cpus"])
+	require.Equal(t, "7.6 GiB", host.Labels["host.mem_size"])
+	require.Len(t, host.DiscoveredLabels, 9)
+
+	// Resources labels take allocations into account
+	filter, err := labelsutil.CreateFilter("os.distribution == debian")
+	require.NoError(t, err)
+	allocation := &Allocation{NodeName: "Compute", Instance: "0", DeploymentID: "discoverDep", Resources: map[string]string{"host.num_cpus": "1"}}
+	_, _, err = cm.Allocate(allocation, filter)
+	require.NoError(t, err)
+	err = cm.UpdateResourcesLabels("host0", allocation.Resources, subtract, updateResourcesLabels)
+	require.NoError(t, err)
+
+	// Stale facts are removed and labels explicitly set become user-defined
+	output = "num_cpus=8\narch=x86_64"
+	err = cm.AddLabels("host0", map[string]string{"os.architecture": "amd64"})
+	require.NoError(t, err)
+	labels, err = cm.Discover("host0")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"host.num_cpus": "8"}, labels)
+
+	host, err = cm.GetHost("host0")
+	require.NoError(t, err)
+	require.Equal(t, []string{"host.num_cpus"}, host.DiscoveredLabels)
+	require.Equal(t, map[string]string{"host.num_cpus": "7", "os.distribution": "debian", "os.architecture": "amd64"}, host.Labels)
+
+	// Discovered labels are kept when applying a pool that doesn't define them
+	var checkpoint uint64
+	_, _, checkpoint, err = cm.List()
+	require.NoError(t, err)
+	err = cm.Apply([]Host{{Name: "host0", Connection: host.Connection, Labels: map[string]string{"os.distribution": "centos"}}}, &checkpoint)
+	require.NoError(t, err)
+	host, err = cm.GetHost("host0")
+	require.NoError(t, err)
+	require.Equal(t, []string{"host.num_cpus"}, host.DiscoveredLabels)
+	require.Equal(t, map[string]string{"host.num_cpus": "7", "os.distribution": "centos"}, host.Labels)
+
+	_, err = cm.Discover("unknownHost")
+	require.Error(t, err)
+	require.True(t, IsHostNotFoundError(err))
+}
@@ -62,6 +62,18 @@ func (cm *consulManager) addLabels(hostname string, labels map[string]string) er
 	if err != nil {
 		return err
 	}
+	// Labels explicitly set are not maintained by the hardware discovery anymore
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	op, err := cm.getForgetDiscoveredLabelsOperation(hostname, names)
+	if err != nil {
+		return err
+	}
+	if op != nil {
+		ops = append(ops, op)
+	}
 	ok, response, _, err := cm.cc.KV().Txn(ops, nil)
 	if err != nil {
 		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
@@ -130,6 +142,14 @@ func (cm *consulManager) removeLabelsWait(hostname string, labels []string, maxW
 
 	// We don't care about host status for updating labels
 
+	op, err := cm.getForgetDiscoveredLabelsOperation(hostname, labels)
+	if err != nil {
+		return err
+	}
+	if op != nil {
+		ops = append(ops, op)
+	}
+
 	ok, response, _, err := cm.cc.KV().Txn(ops, nil)
 	if err != nil {
 		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
@@ -68,6 +68,9 @@ func (m *mockSSHClient) RunCommand(string) (string, error) {
 	if m.config != nil && m.config.User == "fail" {
 		return "", errors.Errorf("Failed to connect")
 	}
+	if m.config != nil && m.config.User == "hang" {
+		time.Sleep(500 * time.Millisecond)
+	}
 
 	return "ok", nil
 }
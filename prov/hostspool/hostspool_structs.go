@@ -21,10 +21,13 @@ import (
 	"encoding/json"
 	"strconv"
 	"strings"
+	"time"
 
 	"fmt"
 	"github.com/pkg/errors"
 	"net/url"
+
+	"github.com/ystia/yorc/helper/sshutil"
 )
 
 // HostStatus x ENUM(
@@ -68,6 +71,8 @@ type Connection struct {
 	Host string `json:"host,omitempty" yaml:"host,omitempty"`
 	// The Port to connect to. Defaults to 22 if set to 0.
 	Port uint64 `json:"port,omitempty" yaml:"port,omitempty"`
+	// Bastions is an optional chain of bastions used to reach the Host.
+	Bastions []sshutil.Bastion `json:"bastions,omitempty" yaml:"bastions,omitempty"`
 }
 
 // String allows to stringify a connection
@@ -80,7 +85,16 @@ func (conn Connection) String() string {
 		key = "private key: " + conn.PrivateKey + ", "
 	}
 
-	return "user: " + conn.User + ", " + pass + key + "host: " + conn.Host + ", " + "port: " + strconv.FormatUint(conn.Port, 10)
+	var bastions string
+	if len(conn.Bastions) > 0 {
+		hops := make([]string, len(conn.Bastions))
+		for i, b := range conn.Bastions {
+			hops[i] = b.String()
+		}
+		bastions = ", bastions: " + strings.Join(hops, " > ")
+	}
+
+	return "user: " + conn.User + ", " + pass + key + "host: " + conn.Host + ", " + "port: " + strconv.FormatUint(conn.Port, 10) + bastions
 }
 
 // An Host holds information on an Host as it is known by the hostspool
@@ -91,6 +105,8 @@ type Host struct {
 	Message     string            `json:"reason,omitempty"`
 	Labels      map[string]string `json:"labels,omitempty"`
 	Allocations []Allocation      `json:"allocations,omitempty"`
+	// DiscoveredLabels are the names of labels maintained by the hardware discovery of the host
+	DiscoveredLabels []string `json:"discovered_labels,omitempty"`
 }
 
 // An Allocation describes the related allocation associated to a host pool
@@ -101,6 +117,13 @@ type Allocation struct {
 	DeploymentID string            `json:"deployment_id"`
 	Shareable    bool              `json:"shareable"`
 	Resources    map[string]string `json:"resource_labels,omitempty"`
+	// LeaseTTL is the optional duration of the allocation lease. A leased allocation is renewed while
+	// its deployment exists and released once its lease expired otherwise.
+	LeaseTTL time.Duration `json:"lease_ttl,omitempty"`
+	// LeaseExpiration is the date at which the allocation lease expires if not renewed
+	LeaseExpiration time.Time `json:"lease_expiration,omitempty"`
+	// PlacementPolicy is the optional policy used to choose the allocated host. It is not stored with the allocation.
+	PlacementPolicy *PlacementPolicy `json:"-"`
 }
 
 func (alloc *Allocation) String() string {
@@ -110,6 +133,9 @@ func (alloc *Allocation) String() string {
 			allocStr += "," + k + ": " + v
 		}
 	}
+	if alloc.LeaseTTL > 0 {
+		allocStr += ",lease expiration: " + alloc.LeaseExpiration.Format(time.RFC3339)
+	}
 
 	return allocStr
 }
@@ -0,0 +1,301 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostspool
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/dustin/go-humanize"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// Placement affinity scopes
+const (
+	// PlacementScopeNode restricts affinity rules to instances of the same node
+	PlacementScopeNode = "node"
+	// PlacementScopeDeployment extends affinity rules to instances of all nodes of the same deployment
+	PlacementScopeDeployment = "deployment"
+)
+
+// DefaultPlacementStrategy is the name of the placement strategy used when none is specified.
+// It keeps the first matching host.
+const DefaultPlacementStrategy = "default"
+
+// A PlacementStrategy orders the hosts that could satisfy an allocation by preference
+type PlacementStrategy interface {
+	// Sort sorts candidate hosts from the most to the least preferred one
+	Sort(candidates []Host, allocation *Allocation)
+}
+
+// PlacementStrategyFunc is an adapter allowing to use an ordinary function as a PlacementStrategy
+type PlacementStrategyFunc func(candidates []Host, allocation *Allocation)
+
+// Sort calls f(candidates, allocation)
+func (f PlacementStrategyFunc) Sort(candidates []Host, allocation *Allocation) {
+	f(candidates, allocation)
+}
+
+var placementStrategiesLock sync.RWMutex
+var placementStrategies = map[string]PlacementStrategy{
+	DefaultPlacementStrategy: PlacementStrategyFunc(func(candidates []Host, allocation *Allocation) {}),
+	"bin-pack":               PlacementStrategyFunc(binPack),
+	"least-allocated":        PlacementStrategyFunc(leastAllocated),
+	"spread":                 PlacementStrategyFunc(spread),
+}
+
+// RegisterPlacementStrategy registers a placement strategy under a given name
+//
+// Registering a strategy with the name of an existing one replaces it.
+func RegisterPlacementStrategy(name string, strategy PlacementStrategy) {
+	placementStrategiesLock.Lock()
+	defer placementStrategiesLock.Unlock()
+	placementStrategies[name] = strategy
+}
+
+func getPlacementStrategy(name string) (PlacementStrategy, error) {
+	if name == "" {
+		name = DefaultPlacementStrategy
+	}
+	placementStrategiesLock.RLock()
+	defer placementStrategiesLock.RUnlock()
+	s, ok := placementStrategies[name]
+	if !ok {
+		return nil, errors.WithStack(badRequestError{"unknown placement strategy " + strconv.Quote(name)})
+	}
+	return s, nil
+}
+
+// An AffinityRule groups or separates instances on hosts sharing the same topology
+type AffinityRule struct {
+	// TopologyLabel is the host label defining the topology domain (for instance a rack or a physical host).
+	// Hosts themselves are the topology domains if empty.
+	TopologyLabel string `json:"topology_label,omitempty"`
+	// Scope is either PlacementScopeNode (default) or PlacementScopeDeployment
+	Scope string `json:"scope,omitempty"`
+	// Required makes the rule mandatory, otherwise hosts satisfying it are only preferred
+	Required bool `json:"required,omitempty"`
+}
+
+// A PlacementPolicy describes how to choose a host among the ones matching an allocation request
+type PlacementPolicy struct {
+	// Strategy is the name of a registered PlacementStrategy
+	Strategy string `json:"strategy,omitempty"`
+	// Affinity places instances on hosts of the same topology domain than already allocated instances
+	Affinity *AffinityRule `json:"affinity,omitempty"`
+	// AntiAffinity places instances on hosts of other topology domains than already allocated instances
+	AntiAffinity *AffinityRule `json:"anti_affinity,omitempty"`
+}
+
+// parsePlacementPolicy decodes a placement policy from the JSON representation of a TOSCA property
+//
+// TOSCA literal values are represented as strings so values are weakly typed.
+func parsePlacementPolicy(jsonValue string) (*PlacementPolicy, error) {
+	var value map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonValue), &value); err != nil {
+		return nil, errors.Wrapf(err, "invalid placement policy %q", jsonValue)
+	}
+	policy := new(PlacementPolicy)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{WeaklyTypedInput: true, TagName: "json", Result: policy})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode placement policy")
+	}
+	return policy, errors.Wrapf(decoder.Decode(value), "invalid placement policy %q", jsonValue)
+}
+
+func (p *PlacementPolicy) validate() error {
+	if p == nil {
+		return nil
+	}
+	if _, err := getPlacementStrategy(p.Strategy); err != nil {
+		return err
+	}
+	for _, r := range []*AffinityRule{p.Affinity, p.AntiAffinity} {
+		if r != nil && r.Scope != "" && r.Scope != PlacementScopeNode && r.Scope != PlacementScopeDeployment {
+			return errors.WithStack(badRequestError{"unsupported placement scope " + strconv.Quote(r.Scope)})
+		}
+	}
+	return nil
+}
+
+// place sorts candidates from the most to the least preferred host for the given allocation
+//
+// Candidates that can't satisfy the allocation resources or a required affinity rule are removed. pool contains all
+// hosts of the pool and is used to find instances already allocated in the scope of affinity rules.
+func place(candidates, pool []Host, allocation *Allocation) ([]Host, error) {
+	candidates = filterHosts(candidates, func(h Host) bool { return hasEnoughResources(h, allocation.Resources) })
+
+	policy := allocation.PlacementPolicy
+	if policy == nil {
+		policy = &PlacementPolicy{}
+	}
+	strategy, err := getPlacementStrategy(policy.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	strategy.Sort(candidates, allocation)
+
+	// Apply affinity rules, required ones filter candidates while others only move preferred ones first
+	for _, rule := range []struct {
+		*AffinityRule
+		affinity bool
+	}{{policy.Affinity, true}, {policy.AntiAffinity, false}} {
+		if rule.AffinityRule == nil {
+			continue
+		}
+		domains := peersTopologyDomains(pool, allocation, rule.AffinityRule)
+		if len(domains) == 0 {
+			// First instance in scope, any host fits
+			continue
+		}
+		satisfies := func(h Host) bool {
+			return domains[topologyDomain(h, rule.TopologyLabel)] == rule.affinity
+		}
+		if rule.Required {
+			candidates = filterHosts(candidates, satisfies)
+		} else {
+			sort.SliceStable(candidates, func(i, j int) bool {
+				return satisfies(candidates[i]) && !satisfies(candidates[j])
+			})
+		}
+	}
+	return candidates, nil
+}
+
+func filterHosts(hosts []Host, keep func(Host) bool) []Host {
+	res := hosts[:0]
+	for _, h := range hosts {
+		if keep(h) {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+func topologyDomain(host Host, topologyLabel string) string {
+	if topologyLabel == "" {
+		return host.Name
+	}
+	return host.Labels[topologyLabel]
+}
+
+// peersTopologyDomains returns the topology domains of hosts having allocations in the scope of a rule
+func peersTopologyDomains(pool []Host, allocation *Allocation, rule *AffinityRule) map[string]bool {
+	domains := make(map[string]bool)
+	for _, h := range pool {
+		for _, a := range h.Allocations {
+			if a.DeploymentID != allocation.DeploymentID || a.ID == allocation.ID {
+				continue
+			}
+			if rule.Scope != PlacementScopeDeployment && a.NodeName != allocation.NodeName {
+				continue
+			}
+			if domain := topologyDomain(h, rule.TopologyLabel); domain != "" {
+				domains[domain] = true
+			}
+		}
+	}
+	return domains
+}
+
+// resourcesLabels are the labels maintained by hosts pool allocations to reflect the remaining resources of a host
+var resourcesLabels = []string{"host.num_cpus", "host.mem_size", "host.disk_size"}
+
+func parseResource(name, value string) (float64, error) {
+	if name == "host.num_cpus" {
+		v, err := strconv.Atoi(value)
+		return float64(v), errors.Wrapf(err, "invalid value %q for resource %q", value, name)
+	}
+	v, err := humanize.ParseBytes(value)
+	return float64(v), errors.Wrapf(err, "invalid value %q for resource %q", value, name)
+}
+
+// hasEnoughResources checks that the remaining resources of a host are greater or equal than the requested ones
+//
+// Resources not described on the host are not checked.
+func hasEnoughResources(host Host, requested map[string]string) bool {
+	for _, name := range resourcesLabels {
+		req, ok := requested[name]
+		if !ok {
+			continue
+		}
+		remaining, ok := host.Labels[name]
+		if !ok {
+			continue
+		}
+		r, err := parseResource(name, req)
+		if err != nil {
+			continue
+		}
+		v, err := parseResource(name, remaining)
+		if err != nil || v < r {
+			return false
+		}
+	}
+	return true
+}
+
+// freeResourcesScores returns for each host the sum of its remaining resources, each one being normalized
+// by the maximum value of this resource among hosts
+func freeResourcesScores(hosts []Host) map[string]float64 {
+	scores := make(map[string]float64, len(hosts))
+	for _, name := range resourcesLabels {
+		values := make(map[string]float64, len(hosts))
+		var max float64
+		for _, h := range hosts {
+			if s, ok := h.Labels[name]; ok {
+				if v, err := parseResource(name, s); err == nil {
+					values[h.Name] = v
+					if v > max {
+						max = v
+					}
+				}
+			}
+		}
+		if max <= 0 {
+			continue
+		}
+		for hostname, v := range values {
+			scores[hostname] += v / max
+		}
+	}
+	return scores
+}
+
+// binPack prefers hosts with the fewest remaining resources to keep other hosts free for larger allocations
+func binPack(candidates []Host, allocation *Allocation) {
+	scores := freeResourcesScores(candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i].Name] < scores[candidates[j].Name]
+	})
+}
+
+// leastAllocated prefers hosts with the most remaining resources
+func leastAllocated(candidates []Host, allocation *Allocation) {
+	scores := freeResourcesScores(candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i].Name] > scores[candidates[j].Name]
+	})
+}
+
+// spread prefers hosts with the fewest allocations
+func spread(candidates []Host, allocation *Allocation) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].Allocations) < len(candidates[j].Allocations)
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostspool
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func placementTestHosts() []Host {
+	return []Host{
+		{Name: "host0", Labels: map[string]string{"rack": "r1", "host.num_cpus": "8", "host.mem_size": "16 GB"}},
+		{Name: "host1", Labels: map[string]string{"rack": "r1", "host.num_cpus": "2", "host.mem_size": "4 GB"},
+			Allocations: []Allocation{{ID: "a1", DeploymentID: "dep", NodeName: "DB", Instance: "0"}, {ID: "a2", DeploymentID: "other", NodeName: "DB", Instance: "0"}}},
+		{Name: "host2", Labels: map[string]string{"rack": "r2", "host.num_cpus": "4", "host.mem_size": "8 GB"},
+			Allocations: []Allocation{{ID: "a3", DeploymentID: "dep", NodeName: "Web", Instance: "0"}}},
+	}
+}
+
+func hostsNames(hosts []Host) []string {
+	names := make([]string, len(hosts))
+	for i := range hosts {
+		names[i] = hosts[i].Name
+	}
+	return names
+}
+
+func TestPlacementStrategies(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		strategy string
+		expected []string
+	}{
+		{name: "Default", strategy: "", expected: []string{"host0", "host1", "host2"}},
+		{name: "BinPack", strategy: "bin-pack", expected: []string{"host1", "host2", "host0"}},
+		{name: "LeastAllocated", strategy: "least-allocated", expected: []string{"host0", "host2", "host1"}},
+		{name: "Spread", strategy: "spread", expected: []string{"host0", "host2", "host1"}},
+	}
+	for _, tt := range tests {
+		alloc := &Allocation{ID: "new", DeploymentID: "dep", NodeName: "DB", Instance: "1", PlacementPolicy: &PlacementPolicy{Strategy: tt.strategy}}
+		hosts, err := place(placementTestHosts(), nil, alloc)
+		require.NoError(t, err, tt.name)
+		require.Equal(t, tt.expected, hostsNames(hosts), tt.name)
+	}
+
+	_, err := place(placementTestHosts(), nil, &Allocation{PlacementPolicy: &PlacementPolicy{Strategy: "unknown"}})
+	require.Error(t, err)
+	require.True(t, IsBadRequestError(err))
+}
+
+func TestPlacementResources(t *testing.T) {
+	t.Parallel()
+	alloc := &Allocation{ID: "new", DeploymentID: "dep", NodeName: "DB", Instance: "1", Resources: map[string]string{"host.num_cpus": "4", "host.mem_size": "6 GB"}}
+	hosts, err := place(placementTestHosts(), nil, alloc)
+	require.NoError(t, err)
+	require.Equal(t, []string{"host0", "host2"}, hostsNames(hosts))
+}
+
+func TestPlacementAffinityRules(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		policy   *PlacementPolicy
+		expected []string
+	}{
+		{name: "RequiredNodeAntiAffinity", policy: &PlacementPolicy{AntiAffinity: &AffinityRule{Required: true}},
+			expected: []string{"host0", "host2"}},
+		{name: "RequiredRackAntiAffinity", policy: &PlacementPolicy{AntiAffinity: &AffinityRule{TopologyLabel: "rack", Required: true}},
+			expected: []string{"host2"}},
+		{name: "RequiredDeploymentRackAntiAffinity", policy: &PlacementPolicy{AntiAffinity: &AffinityRule{TopologyLabel: "rack", Scope: PlacementScopeDeployment, Required: true}},
+			expected: []string{}},
+		{name: "PreferredNodeAffinity", policy: &PlacementPolicy{Affinity: &AffinityRule{}},
+			expected: []string{"host1", "host0", "host2"}},
+		{name: "PreferredDeploymentAffinityWithSpread", policy: &PlacementPolicy{Strategy: "spread", Affinity: &AffinityRule{Scope: PlacementScopeDeployment}},
+			expected: []string{"host2", "host1", "host0"}},
+		{name: "RequiredRackAffinity", policy: &PlacementPolicy{Affinity: &AffinityRule{TopologyLabel: "rack", Required: true}},
+			expected: []string{"host0", "host1"}},
+	}
+	for _, tt := range tests {
+		pool := placementTestHosts()
+		alloc := &Allocation{ID: "new", DeploymentID: "dep", NodeName: "DB", Instance: "1", PlacementPolicy: tt.policy}
+		hosts, err := place(placementTestHosts(), pool, alloc)
+		require.NoError(t, err, tt.name)
+		require.Equal(t, tt.expected, hostsNames(hosts), tt.name)
+	}
+}
+
+func TestParsePlacementPolicy(t *testing.T) {
+	t.Parallel()
+	policy, err := parsePlacementPolicy(`{"strategy":"spread","anti_affinity":{"topology_label":"rack","scope":"deployment","required":"true"}}`)
+	require.NoError(t, err)
+	require.Equal(t, &PlacementPolicy{Strategy: "spread", AntiAffinity: &AffinityRule{TopologyLabel: "rack", Scope: PlacementScopeDeployment, Required: true}}, policy)
+
+	_, err = parsePlacementPolicy(`{"strategy":"spread","affinity":{"required":"maybe"}}`)
+	require.Error(t, err)
+}
+
+func testConsulManagerAllocateWithPlacement(t *testing.T, cc *api.Client) {
+	cleanupHostsPool(t, cc)
+	cm := &consulManager{cc, mockSSHClientFactory}
+
+	var checkpoint uint64
+	err := cm.Apply(createHosts(2), &checkpoint)
+	require.NoError(t, err)
+
+	policy := &PlacementPolicy{Strategy: "spread", AntiAffinity: &AffinityRule{Required: true}}
+	allocated := make(map[string]bool)
+	for _, instance := range []string{"0", "1"} {
+		hostname, _, err := cm.Allocate(&Allocation{NodeName: "DB", Instance: instance, DeploymentID: "placementDep", Shareable: true, PlacementPolicy: policy})
+		require.NoError(t, err)
+		allocated[hostname] = true
+	}
+	require.Len(t, allocated, 2, "instances should be allocated on different hosts")
+
+	_, _, err = cm.Allocate(&Allocation{NodeName: "DB", Instance: "2", DeploymentID: "placementDep", Shareable: true, PlacementPolicy: policy})
+	require.Error(t, err)
+	require.True(t, IsNoMatchingHostFoundError(err))
+}
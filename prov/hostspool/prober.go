@@ -0,0 +1,315 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostspool
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/log"
+)
+
+const (
+	// infrastructureName is the name of the hosts pool infrastructure in the Yorc configuration
+	infrastructureName = "hostspool"
+	// defaultHealthCheckInterval is the default delay between two health checks of the hosts pool
+	defaultHealthCheckInterval = time.Minute
+	// defaultHealthCheckFailureThreshold is the default number of consecutive failed health checks
+	// after which a host is considered in error
+	defaultHealthCheckFailureThreshold = 3
+	// healthCheckFailureKey marks hosts moved to the error status by the prober
+	healthCheckFailureKey = ".healthCheckFailure"
+)
+
+var defaultProber *prober
+
+// A prober regularly checks the health of hosts of the pool and updates their status accordingly
+type prober struct {
+	cm               *consulManager
+	interval         time.Duration
+	failureThreshold int
+	command          string
+	// failures counts consecutive failed health checks by host, it is only accessed by the running probe loop
+	failures      map[string]int
+	chShutdown    chan struct{}
+	chStopRunning chan struct{}
+	isRunning     bool
+	isRunningLock sync.Mutex
+	serviceKey    string
+}
+
+// StartProber allows to instantiate a default hosts pool prober and to start checking hosts health
+//
+// Only the leader of the Yorc cluster checks hosts. Health checks are configured by the "health_check_interval",
+// "health_check_failure_threshold" and "health_check_command" options of the "hostspool" infrastructure.
+// A negative or zero interval disables health checks.
+func StartProber(cfg config.Configuration, cc *api.Client) {
+	hpConfig := cfg.Infrastructures[infrastructureName]
+	interval := defaultHealthCheckInterval
+	if hpConfig.IsSet("health_check_interval") {
+		interval = hpConfig.GetDuration("health_check_interval")
+	}
+	if interval <= 0 {
+		log.Printf("Hosts pool health checks are disabled")
+		return
+	}
+	failureThreshold := hpConfig.GetInt("health_check_failure_threshold")
+	if failureThreshold <= 0 {
+		failureThreshold = defaultHealthCheckFailureThreshold
+	}
+	defaultProber = &prober{
+		cm:               NewManager(cc).(*consulManager),
+		interval:         interval,
+		failureThreshold: failureThreshold,
+		command:          hpConfig.GetString("health_check_command"),
+		failures:         make(map[string]int),
+		chShutdown:       make(chan struct{}),
+		serviceKey:       "service/hostspool_prober/leader",
+	}
+
+	// Watch leader election for the prober service
+	go consulutil.WatchLeaderElection(cc, defaultProber.serviceKey, defaultProber.chShutdown, defaultProber.startProbing, defaultProber.stopProbing)
+}
+
+// StopProber allows to stop checking hosts health
+func StopProber() {
+	if defaultProber == nil {
+		return
+	}
+	defaultProber.stopProbing()
+
+	// Stop watch leader election
+	close(defaultProber.chShutdown)
+}
+
+func (p *prober) startProbing() {
+	p.isRunningLock.Lock()
+	defer p.isRunningLock.Unlock()
+	if p.isRunning {
+		return
+	}
+	log.Debugf("Hosts pool prober is now running.")
+	p.isRunning = true
+	p.chStopRunning = make(chan struct{})
+	go p.run(p.chStopRunning)
+}
+
+func (p *prober) stopProbing() {
+	p.isRunningLock.Lock()
+	defer p.isRunningLock.Unlock()
+	if p.isRunning {
+		log.Debugf("Hosts pool prober is about to be stopped")
+		close(p.chStopRunning)
+		p.isRunning = false
+	}
+}
+
+func (p *prober) run(chStop chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-chStop:
+			log.Debugf("Ending hosts pool health checks has been requested: stop it now.")
+			return
+		case <-p.chShutdown:
+			log.Debugf("Shutdown has been sent: stop hosts pool health checks now.")
+			return
+		case <-ticker.C:
+			if err := p.probeHosts(); err != nil {
+				err = errors.Wrap(err, "[WARN] Error during hosts pool health checks")
+				log.Print(err)
+				log.Debugf("%+v", err)
+			}
+		}
+	}
+}
+
+// probeHosts checks all hosts of the pool concurrently then updates their status
+//
+// A host is moved to the error status after failureThreshold consecutive failed checks and gets back its previous
+// status as soon as a check succeeds. Checks not completed within the probing interval are considered as failed.
+func (p *prober) probeHosts() error {
+	hostnames, _, _, err := p.cm.List()
+	if err != nil {
+		return err
+	}
+	results := make([]error, len(hostnames))
+	var wg sync.WaitGroup
+	for i := range hostnames {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+			defer cancel()
+			results[i] = p.cm.probe(ctx, hostnames[i], p.command)
+		}(i)
+	}
+	wg.Wait()
+
+	failures := make(map[string]int, len(hostnames))
+	for i, hostname := range hostnames {
+		if results[i] == nil {
+			if err := p.cm.recoverHost(hostname); err != nil {
+				return err
+			}
+			continue
+		}
+		failures[hostname] = p.failures[hostname] + 1
+		log.Debugf("Health check %d/%d failed for host %q: %v", failures[hostname], p.failureThreshold, hostname, results[i])
+		if failures[hostname] >= p.failureThreshold {
+			msg := fmt.Sprintf("health check failed %d consecutive times: %v", failures[hostname], results[i])
+			if err := p.cm.markHostUnhealthy(hostname, msg); err != nil {
+				return err
+			}
+		}
+	}
+	// Forget hosts that are no longer in the pool
+	p.failures = failures
+	return nil
+}
+
+// probe checks that a host is reachable and that the optional health check command succeeds on it
+//
+// The check is interrupted when the context is done.
+func (cm *consulManager) probe(ctx context.Context, hostname, command string) error {
+	if _, err := cm.runCommandContext(ctx, hostname, `echo "Connected!"`); err != nil {
+		return errors.Wrapf(err, "failed to connect to host %q", hostname)
+	}
+	if command == "" {
+		return nil
+	}
+	out, err := cm.runCommandContext(ctx, hostname, command)
+	return errors.Wrapf(err, "health check command %q failed on host %q: %s", command, hostname, out)
+}
+
+// markHostUnhealthy moves a host to the error status, backing up its current status
+//
+// The host is marked so that only hosts moved to the error status by the prober are recovered by it.
+func (cm *consulManager) markHostUnhealthy(hostname, message string) error {
+	_, cleanupFn, err := cm.lockKey(hostname, "health check", maxWaitTimeSeconds*time.Second)
+	if err != nil {
+		return err
+	}
+	defer cleanupFn()
+
+	status, err := cm.GetHostStatus(hostname)
+	if err != nil {
+		if IsHostNotFoundError(err) {
+			// Removed in the meantime
+			return nil
+		}
+		return err
+	}
+	if status == HostStatusError {
+		return nil
+	}
+	if err = cm.backupHostStatus(hostname); err != nil {
+		return err
+	}
+	_, err = cm.cc.KV().Put(&api.KVPair{Key: path.Join(consulutil.HostsPoolPrefix, hostname, healthCheckFailureKey), Value: []byte(message)}, nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if err = cm.setHostStatusWithMessage(hostname, HostStatusError, message); err != nil {
+		return err
+	}
+	log.Printf("Host %q of the hosts pool is now in error: %s", hostname, message)
+	cm.notifyAllocations(hostname, events.WARN, fmt.Sprintf("Host %q of the hosts pool is now in error: %s", hostname, message))
+	return nil
+}
+
+// recoverHost restores the status of a host moved in error by the prober as it was before the failure
+//
+// Hosts in error for other reasons are left untouched. If the status was not backed up the host is considered
+// allocated if it has allocations or free otherwise.
+func (cm *consulManager) recoverHost(hostname string) error {
+	_, cleanupFn, err := cm.lockKey(hostname, "health check", maxWaitTimeSeconds*time.Second)
+	if err != nil {
+		return err
+	}
+	defer cleanupFn()
+
+	status, err := cm.GetHostStatus(hostname)
+	if err != nil {
+		if IsHostNotFoundError(err) {
+			// Removed in the meantime
+			return nil
+		}
+		return err
+	}
+	markerKey := path.Join(consulutil.HostsPoolPrefix, hostname, healthCheckFailureKey)
+	kvp, _, err := cm.cc.KV().Get(markerKey, nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil {
+		return nil
+	}
+	if status != HostStatusError {
+		// Status changed by someone else in the meantime, forget the mark
+		_, err = cm.cc.KV().Delete(markerKey, nil)
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	_, err = cm.getStatus(hostname, true)
+	switch {
+	case err == nil:
+		err = cm.restoreHostStatus(hostname)
+	case IsHostNotFoundError(err):
+		var allocations []Allocation
+		allocations, err = cm.GetAllocations(hostname)
+		if err != nil {
+			return err
+		}
+		status = HostStatusFree
+		if len(allocations) > 0 {
+			status = HostStatusAllocated
+		}
+		err = cm.setHostStatus(hostname, status)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = cm.cc.KV().Delete(markerKey, nil); err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	log.Printf("Host %q of the hosts pool recovered", hostname)
+	cm.notifyAllocations(hostname, events.INFO, fmt.Sprintf("Host %q of the hosts pool recovered", hostname))
+	return nil
+}
+
+// notifyAllocations emits a log event in deployments having allocations on a given host
+func (cm *consulManager) notifyAllocations(hostname string, level events.LogLevel, message string) {
+	allocations, err := cm.GetAllocations(hostname)
+	if err != nil {
+		log.Printf("[WARN] failed to notify deployments of an health change of host %q: %v", hostname, err)
+		return
+	}
+	for _, alloc := range allocations {
+		events.WithOptionalFields(events.LogOptionalFields{
+			events.NodeID:     alloc.NodeName,
+			events.InstanceID: alloc.Instance,
+		}).NewLogEntry(level, alloc.DeploymentID).RegisterAsString(message)
+	}
+}
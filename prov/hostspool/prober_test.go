@@ -0,0 +1,138 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostspool
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/helper/labelsutil"
+)
+
+func testProbeHosts(t *testing.T, cc *api.Client) {
+	cleanupHostsPool(t, cc)
+	cm := &consulManager{cc, mockSSHClientFactory}
+
+	var checkpoint uint64
+	err := cm.Apply(createHosts(2), &checkpoint)
+	require.NoError(t, err)
+
+	filter, err := labelsutil.CreateFilter("label1=value10")
+	require.NoError(t, err)
+	allocated, _, err := cm.Allocate(&Allocation{NodeName: "Compute", Instance: "0", DeploymentID: "probeDep"}, filter)
+	require.NoError(t, err)
+	require.Equal(t, "host0", allocated)
+
+	setUser := func(hostname, user string) {
+		_, err := cc.KV().Put(&api.KVPair{Key: path.Join(consulutil.HostsPoolPrefix, hostname, "connection", "user"), Value: []byte(user)}, nil)
+		require.NoError(t, err)
+	}
+	// The mock SSH client fails to connect to hosts whose user is "fail"
+	setUser("host0", "fail")
+	setUser("host1", "fail")
+
+	p := &prober{cm: cm, interval: time.Second, failureThreshold: 2, failures: make(map[string]int)}
+
+	err = p.probeHosts()
+	require.NoError(t, err)
+	for _, hostname := range []string{"host0", "host1"} {
+		status, err := cm.GetHostStatus(hostname)
+		require.NoError(t, err)
+		require.NotEqual(t, HostStatusError, status, "host %q should not be in error before reaching the failure threshold", hostname)
+	}
+
+	err = p.probeHosts()
+	require.NoError(t, err)
+	for _, hostname := range []string{"host0", "host1"} {
+		host, err := cm.GetHost(hostname)
+		require.NoError(t, err)
+		require.Equal(t, HostStatusError, host.Status)
+		require.Contains(t, host.Message, "health check failed 2 consecutive times")
+	}
+
+	// host0 recovers and gets back its previous status while host1 keeps failing
+	setUser("host0", "testuser0")
+	err = p.probeHosts()
+	require.NoError(t, err)
+
+	host, err := cm.GetHost("host0")
+	require.NoError(t, err)
+	require.Equal(t, HostStatusAllocated, host.Status)
+	require.Equal(t, "", host.Message)
+	require.Len(t, host.Allocations, 1)
+	require.Equal(t, 0, p.failures["host0"])
+
+	status, err := cm.GetHostStatus("host1")
+	require.NoError(t, err)
+	require.Equal(t, HostStatusError, status)
+	require.Equal(t, 3, p.failures["host1"])
+
+	setUser("host1", "testuser1")
+	err = p.probeHosts()
+	require.NoError(t, err)
+	status, err = cm.GetHostStatus("host1")
+	require.NoError(t, err)
+	require.Equal(t, HostStatusFree, status)
+}
+
+func testProbeHostsWithCommand(t *testing.T, cc *api.Client) {
+	cleanupHostsPool(t, cc)
+	cm := &consulManager{cc, mockSSHClientFactory}
+
+	var checkpoint uint64
+	err := cm.Apply(createHosts(1), &checkpoint)
+	require.NoError(t, err)
+
+	// Host in error for another reason than a failed health check (ex: set in error by an admin) is not recovered
+	err = cm.setHostStatusWithMessage("host0", HostStatusError, "broken")
+	require.NoError(t, err)
+
+	p := &prober{cm: cm, interval: time.Second, failureThreshold: 1, command: "systemctl is-system-running", failures: make(map[string]int)}
+	err = p.probeHosts()
+	require.NoError(t, err)
+
+	host, err := cm.GetHost("host0")
+	require.NoError(t, err)
+	require.Equal(t, HostStatusError, host.Status)
+	require.Equal(t, "broken", host.Message)
+}
+
+func testProbeHostsTimeout(t *testing.T, cc *api.Client) {
+	cleanupHostsPool(t, cc)
+	cm := &consulManager{cc, mockSSHClientFactory}
+
+	var checkpoint uint64
+	err := cm.Apply(createHosts(1), &checkpoint)
+	require.NoError(t, err)
+	// The mock SSH client hangs on hosts whose user is "hang"
+	_, err = cc.KV().Put(&api.KVPair{Key: path.Join(consulutil.HostsPoolPrefix, "host0", "connection", "user"), Value: []byte("hang")}, nil)
+	require.NoError(t, err)
+
+	p := &prober{cm: cm, interval: 50 * time.Millisecond, failureThreshold: 1, failures: make(map[string]int)}
+	start := time.Now()
+	err = p.probeHosts()
+	require.NoError(t, err)
+	require.True(t, time.Since(start) < 500*time.Millisecond, "probes should not last longer than the probing interval")
+
+	host, err := cm.GetHost("host0")
+	require.NoError(t, err)
+	require.Equal(t, HostStatusError, host.Status)
+	require.Contains(t, host.Message, "command interrupted")
+}
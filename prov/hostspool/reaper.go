@@ -0,0 +1,147 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostspool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/log"
+)
+
+// reapInterval is the delay between two checks of hosts pool allocations
+const reapInterval = 30 * time.Second
+
+var defaultReaper *reaper
+
+// A reaper renews the leases of allocations owned by existing deployments and releases orphaned allocations
+type reaper struct {
+	cm            *consulManager
+	chShutdown    chan struct{}
+	chStopRunning chan struct{}
+	isRunning     bool
+	isRunningLock sync.Mutex
+	serviceKey    string
+}
+
+// StartReaper allows to instantiate a default allocations reaper and to start checking hosts pool allocations
+//
+// Only the leader of the Yorc cluster checks allocations.
+func StartReaper(cc *api.Client) {
+	defaultReaper = &reaper{
+		cm:         NewManager(cc).(*consulManager),
+		chShutdown: make(chan struct{}),
+		serviceKey: "service/hostspool_reaper/leader",
+	}
+
+	// Watch leader election for the reaper service
+	go consulutil.WatchLeaderElection(cc, defaultReaper.serviceKey, defaultReaper.chShutdown, defaultReaper.startReaping, defaultReaper.stopReaping)
+}
+
+// StopReaper allows to stop checking hosts pool allocations
+func StopReaper() {
+	defaultReaper.stopReaping()
+
+	// Stop watch leader election
+	close(defaultReaper.chShutdown)
+}
+
+func (r *reaper) startReaping() {
+	r.isRunningLock.Lock()
+	defer r.isRunningLock.Unlock()
+	if r.isRunning {
+		return
+	}
+	log.Debugf("Hosts pool allocations reaper is now running.")
+	r.isRunning = true
+	r.chStopRunning = make(chan struct{})
+	go r.run(r.chStopRunning)
+}
+
+func (r *reaper) stopReaping() {
+	r.isRunningLock.Lock()
+	defer r.isRunningLock.Unlock()
+	if r.isRunning {
+		log.Debugf("Hosts pool allocations reaper is about to be stopped")
+		close(r.chStopRunning)
+		r.isRunning = false
+	}
+}
+
+func (r *reaper) run(chStop chan struct{}) {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-chStop:
+			log.Debugf("Ending hosts pool allocations reaping has been requested: stop it now.")
+			return
+		case <-r.chShutdown:
+			log.Debugf("Shutdown has been sent: stop hosts pool allocations reaping now.")
+			return
+		case now := <-ticker.C:
+			if err := reapAllocations(r.cm, now); err != nil {
+				err = errors.Wrap(err, "[WARN] Error during hosts pool allocations reaping")
+				log.Print(err)
+				log.Debugf("%+v", err)
+			}
+		}
+	}
+}
+
+// reapAllocations checks all allocations of the hosts pool
+//
+// Leases of allocations whose deployment still exists are renewed. Allocations whose deployment no longer exists
+// are released unless they have a lease that is not yet expired.
+func reapAllocations(cm *consulManager, now time.Time) error {
+	hostnames, _, _, err := cm.List()
+	if err != nil {
+		return err
+	}
+	kv := cm.cc.KV()
+	for _, hostname := range hostnames {
+		allocations, err := cm.GetAllocations(hostname)
+		if err != nil {
+			return err
+		}
+		for _, alloc := range allocations {
+			exist, err := deployments.DoesDeploymentExists(kv, alloc.DeploymentID)
+			if err != nil {
+				return err
+			}
+			if exist {
+				if alloc.LeaseTTL > 0 {
+					if err = cm.renewLease(hostname, alloc, now); err != nil {
+						return errors.Wrapf(err, "failed to renew lease of allocation %q on host %q", alloc.ID, hostname)
+					}
+				}
+				continue
+			}
+			if alloc.LeaseTTL > 0 && now.Before(alloc.LeaseExpiration) {
+				continue
+			}
+			log.Printf("Releasing orphaned allocation %q of host %q as deployment %q no longer exists", alloc.ID, hostname, alloc.DeploymentID)
+			if err = cm.ForceRelease(hostname, alloc.ID); err != nil {
+				return errors.Wrapf(err, "failed to release allocation %q on host %q", alloc.ID, hostname)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostspool
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/helper/labelsutil"
+)
+
+func testReapAllocations(t *testing.T, cc *api.Client) {
+	cleanupHostsPool(t, cc)
+	cm := &consulManager{cc, mockSSHClientFactory}
+
+	var checkpoint uint64
+	err := cm.Apply(createHosts(3), &checkpoint)
+	require.NoError(t, err)
+
+	_, err = cc.KV().Put(&api.KVPair{Key: path.Join(consulutil.DeploymentKVPrefix, "reapExistingDep", "status"), Value: []byte("DEPLOYED")}, nil)
+	require.NoError(t, err)
+	defer cc.KV().DeleteTree(path.Join(consulutil.DeploymentKVPrefix, "reapExistingDep"), nil)
+
+	filter := func(hostname string) labelsutil.Filter {
+		f, err := labelsutil.CreateFilter("label1=value1" + hostname[len("host"):])
+		require.NoError(t, err)
+		return f
+	}
+	allocations := map[string]*Allocation{
+		"host0": {NodeName: "Compute", Instance: "0", DeploymentID: "reapExistingDep", LeaseTTL: time.Minute},
+		"host1": {NodeName: "Compute", Instance: "0", DeploymentID: "reapPurgedDep"},
+		"host2": {NodeName: "Compute", Instance: "0", DeploymentID: "reapLeasedPurgedDep", LeaseTTL: time.Minute},
+	}
+	for hostname, alloc := range allocations {
+		allocated, _, err := cm.Allocate(alloc, filter(hostname))
+		require.NoError(t, err)
+		require.Equal(t, hostname, allocated)
+	}
+
+	now := time.Now()
+	err = reapAllocations(cm, now)
+	require.NoError(t, err)
+
+	host, err := cm.GetHost("host0")
+	require.NoError(t, err)
+	require.Equal(t, HostStatusAllocated, host.Status)
+	require.Len(t, host.Allocations, 1)
+	require.Equal(t, time.Minute, host.Allocations[0].LeaseTTL)
+	require.True(t, host.Allocations[0].LeaseExpiration.Equal(now.Add(time.Minute)), "lease should be renewed")
+
+	host, err = cm.GetHost("host1")
+	require.NoError(t, err)
+	require.Equal(t, HostStatusFree, host.Status)
+	require.Len(t, host.Allocations, 0)
+
+	// Lease of an allocation whose deployment was purged is not renewed but is kept until it expires
+	host, err = cm.GetHost("host2")
+	require.NoError(t, err)
+	require.Equal(t, HostStatusAllocated, host.Status)
+	require.Len(t, host.Allocations, 1)
+
+	err = reapAllocations(cm, now.Add(2*time.Minute))
+	require.NoError(t, err)
+
+	host, err = cm.GetHost("host0")
+	require.NoError(t, err)
+	require.Equal(t, HostStatusAllocated, host.Status)
+
+	host, err = cm.GetHost("host2")
+	require.NoError(t, err)
+	require.Equal(t, HostStatusFree, host.Status)
+	require.Len(t, host.Allocations, 0)
+}
+
+func testForceReleaseAllocation(t *testing.T, cc *api.Client) {
+	cleanupHostsPool(t, cc)
+	cm := &consulManager{cc, mockSSHClientFactory}
+
+	var checkpoint uint64
+	err := cm.Apply(createHostsWithLabels(1, map[string]string{"host.num_cpus": "8"}), &checkpoint)
+	require.NoError(t, err)
+
+	alloc := &Allocation{NodeName: "Compute", Instance: "0", DeploymentID: "forceReleaseDep", Resources: map[string]string{"host.num_cpus": "2"}}
+	hostname, _, err := cm.Allocate(alloc)
+	require.NoError(t, err)
+	err = cm.UpdateResourcesLabels(hostname, alloc.Resources, subtract, updateResourcesLabels)
+	require.NoError(t, err)
+
+	err = cm.ForceRelease(hostname, "unknownAllocation")
+	require.Error(t, err)
+	require.True(t, IsAllocationNotFoundError(err))
+
+	err = cm.ForceRelease(hostname, alloc.ID)
+	require.NoError(t, err)
+	host, err := cm.GetHost(hostname)
+	require.NoError(t, err)
+	require.Equal(t, HostStatusFree, host.Status)
+	require.Len(t, host.Allocations, 0)
+	require.Equal(t, "8", host.Labels["host.num_cpus"])
+}
@@ -0,0 +1,36 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostspool
+
+import "github.com/ystia/yorc/helper/labelsutil"
+
+// TenantLabel is the name of the label reserving a host to a tenant
+//
+// Hosts without this label are shared between all tenants.
+const TenantLabel = "tenant"
+
+type tenantFilter struct {
+	tenant string
+}
+
+func (f tenantFilter) Matches(labels map[string]string) (bool, error) {
+	hostTenant := labels[TenantLabel]
+	return hostTenant == "" || hostTenant == f.tenant, nil
+}
+
+// NewTenantFilter returns a filter matching hosts shared between all tenants or reserved to the given tenant
+func NewTenantFilter(tenant string) labelsutil.Filter {
+	return tenantFilter{tenant: tenant}
+}
@@ -398,7 +398,11 @@ func (e *executionCommon) checkNode(ctx context.Context) error {
 			}
 		}
 
-		time.Sleep(2 * time.Second)
+		if !deploymentReady {
+			if err = waitPollInterval(ctx); err != nil {
+				return errors.Wrapf(err, "interrupted while waiting for deployment of node %q", e.NodeName)
+			}
+		}
 	}
 
 	return nil
@@ -474,7 +478,9 @@ func (e *executionCommon) checkPod(ctx context.Context, podName string) error {
 		}
 
 		if status != v1.PodRunning {
-			time.Sleep(2 * time.Second)
+			if err = waitPollInterval(ctx); err != nil {
+				return errors.Wrapf(err, "interrupted while waiting for pod %q", podName)
+			}
 		}
 	}
 
@@ -526,7 +532,9 @@ func (e *executionCommon) uninstallNode(ctx context.Context) error {
 
 	log.Printf("Waiting for namespace to be fully deleted")
 	for err == nil {
-		time.Sleep(2 * time.Second)
+		if waitErr := waitPollInterval(ctx); waitErr != nil {
+			return errors.Wrapf(waitErr, "interrupted while waiting for namespace %q deletion", namespace)
+		}
 		_, err = (clientset.(*kubernetes.Clientset)).CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
 	}
 
@@ -534,6 +542,17 @@ func (e *executionCommon) uninstallNode(ctx context.Context) error {
 	return nil
 }
 
+// waitPollInterval waits for the polling interval used to check resources states
+// or returns the context error if it is cancelled in the meantime.
+func waitPollInterval(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(2 * time.Second):
+		return nil
+	}
+}
+
 func getNamespace(kv *api.KV, deploymentID, nodeName string) (string, error) {
 	return strings.ToLower(deploymentID), nil
 }
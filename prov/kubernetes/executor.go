@@ -16,6 +16,7 @@ package kubernetes
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	"k8s.io/client-go/kubernetes"
@@ -31,6 +32,10 @@ type defaultExecutor struct {
 	clientset *kubernetes.Clientset
 }
 
+func (e *defaultExecutor) DefaultOperationTimeout(conf config.Configuration) time.Duration {
+	return conf.Infrastructures["kubernetes"].GetDuration("operation_timeout")
+}
+
 func (e *defaultExecutor) ExecOperation(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation) error {
 	consulClient, err := conf.GetConsulClient()
 	if err != nil {
@@ -0,0 +1,385 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitoring
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/tasks"
+)
+
+const (
+	// autoScalingPolicyType is the TOSCA policy type of auto-scaling policies
+	autoScalingPolicyType = "yorc.policies.AutoScaling"
+	// checksCriticalRatioMetric is a metric computed from monitoring checks reports: it is the ratio
+	// of instances of a node which check is critical
+	checksCriticalRatioMetric = "checks_critical_ratio"
+	// autoScalingTickInterval is the interval at which policies are checked to know if they should be evaluated
+	autoScalingTickInterval = 10 * time.Second
+	// metricsMaxAgeFactor defines, as a factor of the policy evaluation interval, the age from which metrics are ignored
+	metricsMaxAgeFactor = 3
+)
+
+type autoScalingPolicy struct {
+	name               string
+	metric             string
+	scaleOutThreshold  *float64
+	scaleInThreshold   *float64
+	minInstances       uint32
+	maxInstances       uint32
+	increment          uint32
+	cooldown           time.Duration
+	evaluationInterval time.Duration
+}
+
+func readFloatPolicyProperty(kv *api.KV, deploymentID, policyName, propertyName string) (*float64, error) {
+	found, value, err := deployments.GetPolicyProperty(kv, deploymentID, policyName, propertyName)
+	if err != nil || !found || value == "" {
+		return nil, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s property for policy %q", propertyName, policyName)
+	}
+	return &f, nil
+}
+
+func readUintPolicyProperty(kv *api.KV, deploymentID, policyName, propertyName string) (uint32, error) {
+	found, value, err := deployments.GetPolicyProperty(kv, deploymentID, policyName, propertyName)
+	if err != nil || !found || value == "" {
+		return 0, err
+	}
+	i, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s property for policy %q", propertyName, policyName)
+	}
+	return uint32(i), nil
+}
+
+func readDurationPolicyProperty(kv *api.KV, deploymentID, policyName, propertyName string) (time.Duration, error) {
+	found, value, err := deployments.GetPolicyProperty(kv, deploymentID, policyName, propertyName)
+	if err != nil || !found || value == "" {
+		return 0, err
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s property for policy %q", propertyName, policyName)
+	}
+	return d, nil
+}
+
+func readAutoScalingPolicy(kv *api.KV, deploymentID, policyName string) (*autoScalingPolicy, error) {
+	p := &autoScalingPolicy{name: policyName}
+	found, metric, err := deployments.GetPolicyProperty(kv, deploymentID, policyName, "metric")
+	if err != nil {
+		return nil, err
+	}
+	if !found || metric == "" {
+		return nil, errors.Errorf("missing mandatory metric property for policy %q", policyName)
+	}
+	p.metric = metric
+	if p.scaleOutThreshold, err = readFloatPolicyProperty(kv, deploymentID, policyName, "scale_out_threshold"); err != nil {
+		return nil, err
+	}
+	if p.scaleInThreshold, err = readFloatPolicyProperty(kv, deploymentID, policyName, "scale_in_threshold"); err != nil {
+		return nil, err
+	}
+	if p.scaleOutThreshold != nil && p.scaleInThreshold != nil && *p.scaleInThreshold >= *p.scaleOutThreshold {
+		return nil, errors.Errorf("scale_in_threshold should be lower than scale_out_threshold for policy %q", policyName)
+	}
+	if p.minInstances, err = readUintPolicyProperty(kv, deploymentID, policyName, "min_instances"); err != nil {
+		return nil, err
+	}
+	if p.maxInstances, err = readUintPolicyProperty(kv, deploymentID, policyName, "max_instances"); err != nil {
+		return nil, err
+	}
+	if p.increment, err = readUintPolicyProperty(kv, deploymentID, policyName, "increment"); err != nil {
+		return nil, err
+	}
+	if p.increment == 0 {
+		p.increment = 1
+	}
+	if p.cooldown, err = readDurationPolicyProperty(kv, deploymentID, policyName, "cooldown"); err != nil {
+		return nil, err
+	}
+	if p.evaluationInterval, err = readDurationPolicyProperty(kv, deploymentID, policyName, "evaluation_interval"); err != nil {
+		return nil, err
+	}
+	if p.evaluationInterval <= 0 {
+		p.evaluationInterval = time.Minute
+	}
+	return p, nil
+}
+
+// instancesBounds returns the bounds of the number of instances of a node merging the policy bounds with
+// the node scalable capability bounds
+func (p *autoScalingPolicy) instancesBounds(nodeMin, nodeMax uint32) (uint32, uint32) {
+	min, max := nodeMin, nodeMax
+	if p.minInstances > min {
+		min = p.minInstances
+	}
+	if p.maxInstances > 0 && p.maxInstances < max {
+		max = p.maxInstances
+	}
+	return min, max
+}
+
+// scalingDelta computes the number of instances to add (positive result) or to remove (negative result)
+// given the metric value and the current number of instances
+func (p *autoScalingPolicy) scalingDelta(value float64, current, min, max uint32) int {
+	switch {
+	case p.scaleOutThreshold != nil && value > *p.scaleOutThreshold && current < max:
+		delta := p.increment
+		if current+delta > max {
+			delta = max - current
+		}
+		return int(delta)
+	case p.scaleInThreshold != nil && value < *p.scaleInThreshold && current > min:
+		delta := p.increment
+		if current < min+delta {
+			delta = current - min
+		}
+		return -int(delta)
+	}
+	return 0
+}
+
+func (mgr *monitoringMgr) runAutoScaling(chStop chan struct{}) {
+	lastEvaluations := make(map[string]time.Time)
+	ticker := time.NewTicker(autoScalingTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-chStop:
+			log.Debugf("Stopping auto-scaling policies evaluation")
+			return
+		case <-mgr.chShutdown:
+			log.Debugf("Shutdown has been sent: stop auto-scaling policies evaluation now.")
+			return
+		case <-ticker.C:
+			if err := mgr.evaluateAutoScalingPolicies(lastEvaluations); err != nil {
+				log.Printf("[WARN] Failed to evaluate auto-scaling policies: %v", err)
+				log.Debugf("%+v", err)
+			}
+		}
+	}
+}
+
+func (mgr *monitoringMgr) evaluateAutoScalingPolicies(lastEvaluations map[string]time.Time) error {
+	kv := mgr.cc.KV()
+	deploymentsIDs, err := deployments.GetDeploymentsIDs(kv)
+	if err != nil {
+		return err
+	}
+	for _, deploymentID := range deploymentsIDs {
+		status, err := deployments.GetDeploymentStatus(kv, deploymentID)
+		if err != nil || status != deployments.DEPLOYED {
+			// Only deployed applications are scaled, deployment may also have been removed meanwhile
+			continue
+		}
+		policies, err := deployments.GetPoliciesForType(kv, deploymentID, autoScalingPolicyType)
+		if err != nil {
+			return err
+		}
+		for _, policyName := range policies {
+			policy, err := readAutoScalingPolicy(kv, deploymentID, policyName)
+			if err != nil {
+				events.WithContextOptionalFields(context.Background()).NewLogEntry(events.WARN, deploymentID).Registerf("Auto-scaling policy %q is ignored: %v", policyName, err)
+				continue
+			}
+			key := path.Join(deploymentID, policyName)
+			if time.Since(lastEvaluations[key]) < policy.evaluationInterval {
+				continue
+			}
+			lastEvaluations[key] = time.Now()
+			if err = mgr.applyAutoScalingPolicy(deploymentID, policy); err != nil {
+				events.WithContextOptionalFields(context.Background()).NewLogEntry(events.WARN, deploymentID).Registerf("Failed to apply auto-scaling policy %q: %v", policyName, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (mgr *monitoringMgr) applyAutoScalingPolicy(deploymentID string, policy *autoScalingPolicy) error {
+	kv := mgr.cc.KV()
+	nodes, err := deployments.GetPolicyTargetsNodes(kv, deploymentID, policy.name)
+	if err != nil {
+		return err
+	}
+	for _, nodeName := range nodes {
+		scalable, err := deployments.HasScalableCapability(kv, deploymentID, nodeName)
+		if err != nil {
+			return err
+		}
+		if !scalable {
+			log.Debugf("Auto-scaling policy %q: node %q of deployment %q is not scalable, skipping it", policy.name, nodeName, deploymentID)
+			continue
+		}
+		lastScaling, err := getLastScalingTime(kv, deploymentID, nodeName)
+		if err != nil {
+			return err
+		}
+		if time.Since(lastScaling) < policy.cooldown {
+			log.Debugf("Auto-scaling policy %q: node %q of deployment %q is in cooldown period", policy.name, nodeName, deploymentID)
+			continue
+		}
+		instances, err := deployments.GetNodeInstancesIds(kv, deploymentID, nodeName)
+		if err != nil {
+			return err
+		}
+		found, value, err := mgr.getNodeMetricAverage(deploymentID, nodeName, instances, policy)
+		if err != nil {
+			return err
+		}
+		if !found {
+			log.Debugf("Auto-scaling policy %q: no value found for metric %q of node %q of deployment %q", policy.name, policy.metric, nodeName, deploymentID)
+			continue
+		}
+		nodeMin, err := deployments.GetMinNbInstancesForNode(kv, deploymentID, nodeName)
+		if err != nil {
+			return err
+		}
+		nodeMax, err := deployments.GetMaxNbInstancesForNode(kv, deploymentID, nodeName)
+		if err != nil {
+			return err
+		}
+		min, max := policy.instancesBounds(nodeMin, nodeMax)
+		delta := policy.scalingDelta(value, uint32(len(instances)), min, max)
+		if delta == 0 {
+			continue
+		}
+		if err = mgr.scaleNode(deploymentID, nodeName, policy, value, delta); err != nil {
+			return err
+		}
+		// A single scaling task may run at the same time for a deployment
+		return nil
+	}
+	return nil
+}
+
+// getNodeMetricAverage returns the average value of the policy metric over the given instances of a node
+//
+// Instances without a value for this metric or which metric value is too old are ignored.
+func (mgr *monitoringMgr) getNodeMetricAverage(deploymentID, nodeName string, instances []string, policy *autoScalingPolicy) (bool, float64, error) {
+	kv := mgr.cc.KV()
+	var sum float64
+	var count int
+	for _, instance := range instances {
+		var found bool
+		var value float64
+		if policy.metric == checksCriticalRatioMetric {
+			kvp, _, err := kv.Get(path.Join(consulutil.MonitoringKVPrefix, "reports", buildID(deploymentID, nodeName, instance), "status"), nil)
+			if err != nil {
+				return false, 0, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+			}
+			if kvp != nil && len(kvp.Value) > 0 {
+				found = true
+				if string(kvp.Value) == CheckStatusCRITICAL.String() {
+					value = 1
+				}
+			}
+		} else {
+			var timestamp time.Time
+			var err error
+			found, value, timestamp, err = GetInstanceMetric(kv, deploymentID, nodeName, instance, policy.metric)
+			if err != nil {
+				return false, 0, err
+			}
+			if found && time.Since(timestamp) > metricsMaxAgeFactor*policy.evaluationInterval {
+				log.Debugf("Ignoring outdated metric %q for node %q (instance %q) of deployment %q", policy.metric, nodeName, instance, deploymentID)
+				found = false
+			}
+		}
+		if found {
+			sum += value
+			count++
+		}
+	}
+	if count == 0 {
+		return false, 0, nil
+	}
+	return true, sum / float64(count), nil
+}
+
+// scaleNode registers a scaling task for a node
+//
+// The task is registered without queuing it, so that it is rejected if another task is living for this deployment.
+// Registering the task is the only guard against concurrent scaling: instances to create or to remove are computed
+// by the task itself when it runs.
+func (mgr *monitoringMgr) scaleNode(deploymentID, nodeName string, policy *autoScalingPolicy, value float64, delta int) error {
+	kv := mgr.cc.KV()
+	taskType := tasks.ScaleOut
+	instancesDelta := delta
+	if delta < 0 {
+		taskType = tasks.ScaleIn
+		instancesDelta = -delta
+	} else if err := deployments.CheckTenantQuotasForScaleOut(kv, mgr.cfg, deploymentID, nodeName, delta); err != nil {
+		if !deployments.IsTenantQuotaExceededError(err) {
+			return err
+		}
+		ctx := events.NewContext(context.Background(), events.LogOptionalFields{events.NodeID: nodeName})
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).Registerf("Auto-scaling policy %q: not scaling out node %q: %v", policy.name, nodeName, err)
+		return setLastScalingTime(kv, deploymentID, nodeName, time.Now())
+	}
+	data := map[string]string{
+		"scaling/nodeName":       nodeName,
+		"scaling/instancesDelta": strconv.Itoa(instancesDelta),
+	}
+	taskID, err := tasks.NewCollector(mgr.cc).RegisterTaskWithData(deploymentID, taskType, data)
+	if err != nil {
+		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); ok {
+			log.Debugf("Auto-scaling policy %q: another task is living on deployment %q, delaying scaling of node %q", policy.name, deploymentID, nodeName)
+			return nil
+		}
+		return err
+	}
+	ctx := events.NewContext(context.Background(), events.LogOptionalFields{events.NodeID: nodeName})
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, deploymentID).Registerf("Auto-scaling policy %q: metric %q average value is %v, registered task %q (%s) with a delta of %d instances for node %q", policy.name, policy.metric, value, taskID, taskType, delta, nodeName)
+	return setLastScalingTime(kv, deploymentID, nodeName, time.Now())
+}
+
+func lastScalingTimePath(deploymentID, nodeName string) string {
+	return path.Join(consulutil.MonitoringKVPrefix, "autoscaling", deploymentID, nodeName, "last_scaling")
+}
+
+// getLastScalingTime returns the last time a node was scaled by an auto-scaling policy
+//
+// This is stored in Consul to survive to a monitoring leader change.
+func getLastScalingTime(kv *api.KV, deploymentID, nodeName string) (time.Time, error) {
+	kvp, _, err := kv.Get(lastScalingTimePath(deploymentID, nodeName), nil)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil || len(kvp.Value) == 0 {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(kvp.Value))
+	return t, errors.Wrapf(err, "invalid last scaling time for node %q of deployment %q", nodeName, deploymentID)
+}
+
+func setLastScalingTime(kv *api.KV, deploymentID, nodeName string, t time.Time) error {
+	_, err := kv.Put(&api.KVPair{Key: lastScalingTimePath(deploymentID, nodeName), Value: []byte(t.Format(time.RFC3339Nano))}, nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
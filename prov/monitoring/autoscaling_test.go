@@ -0,0 +1,124 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoScalingPolicyScalingDelta(t *testing.T) {
+	t.Parallel()
+	out := 80.0
+	in := 20.0
+	p := &autoScalingPolicy{scaleOutThreshold: &out, scaleInThreshold: &in, increment: 2}
+	tests := []struct {
+		name     string
+		value    float64
+		current  uint32
+		min      uint32
+		max      uint32
+		expected int
+	}{
+		{"ScaleOut", 90, 2, 1, 10, 2},
+		{"ScaleOutBoundedByMax", 90, 9, 1, 10, 1},
+		{"ScaleOutMaxReached", 90, 10, 1, 10, 0},
+		{"ScaleIn", 10, 5, 1, 10, -2},
+		{"ScaleInBoundedByMin", 10, 2, 1, 10, -1},
+		{"ScaleInMinReached", 10, 1, 1, 10, 0},
+		{"WithinThresholds", 50, 5, 1, 10, 0},
+		{"OnThreshold", 80, 5, 1, 10, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, p.scalingDelta(tt.value, tt.current, tt.min, tt.max))
+		})
+	}
+
+	// Scale out only policy
+	p = &autoScalingPolicy{scaleOutThreshold: &out, increment: 1}
+	require.Equal(t, 0, p.scalingDelta(10, 5, 1, 10))
+	require.Equal(t, 1, p.scalingDelta(90, 5, 1, 10))
+}
+
+func TestAutoScalingPolicyInstancesBounds(t *testing.T) {
+	t.Parallel()
+	p := &autoScalingPolicy{}
+	min, max := p.instancesBounds(1, 10)
+	require.Equal(t, uint32(1), min)
+	require.Equal(t, uint32(10), max)
+
+	p = &autoScalingPolicy{minInstances: 2, maxInstances: 5}
+	min, max = p.instancesBounds(1, 10)
+	require.Equal(t, uint32(2), min)
+	require.Equal(t, uint32(5), max)
+
+	// Node bounds can't be exceeded
+	p = &autoScalingPolicy{minInstances: 0, maxInstances: 20}
+	min, max = p.instancesBounds(1, 10)
+	require.Equal(t, uint32(1), min)
+	require.Equal(t, uint32(10), max)
+}
+
+func testInstanceMetrics(t *testing.T, client *api.Client) {
+	kv := client.KV()
+	dep := "monitoring6"
+	node := "Compute1"
+
+	err := StoreInstanceMetric(kv, dep, node, "0", "cpu", 90)
+	require.Nil(t, err)
+	err = StoreInstanceMetric(kv, dep, node, "1", "cpu", 70.5)
+	require.Nil(t, err)
+
+	found, value, timestamp, err := GetInstanceMetric(kv, dep, node, "1", "cpu")
+	require.Nil(t, err)
+	require.True(t, found)
+	require.Equal(t, 70.5, value)
+	require.WithinDuration(t, time.Now(), timestamp, 10*time.Second)
+
+	found, _, _, err = GetInstanceMetric(kv, dep, node, "1", "memory")
+	require.Nil(t, err)
+	require.False(t, found)
+
+	policy := &autoScalingPolicy{name: "policy", metric: "cpu", evaluationInterval: time.Minute}
+	found, value, err = defaultMonManager.getNodeMetricAverage(dep, node, []string{"0", "1", "2"}, policy)
+	require.Nil(t, err)
+	require.True(t, found)
+	require.Equal(t, 80.25, value)
+
+	policy.metric = "memory"
+	found, _, err = defaultMonManager.getNodeMetricAverage(dep, node, []string{"0", "1", "2"}, policy)
+	require.Nil(t, err)
+	require.False(t, found)
+
+	err = removeInstanceMetrics(kv, dep, node, "0")
+	require.Nil(t, err)
+	found, _, _, err = GetInstanceMetric(kv, dep, node, "0", "cpu")
+	require.Nil(t, err)
+	require.False(t, found)
+
+	lastScaling, err := getLastScalingTime(kv, dep, node)
+	require.Nil(t, err)
+	require.True(t, lastScaling.IsZero())
+	now := time.Now()
+	err = setLastScalingTime(kv, dep, node, now)
+	require.Nil(t, err)
+	lastScaling, err = getLastScalingTime(kv, dep, node)
+	require.Nil(t, err)
+	require.True(t, now.Equal(lastScaling))
+}
@@ -15,7 +15,9 @@
 package monitoring
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"github.com/hashicorp/consul/api"
 	"github.com/pkg/errors"
@@ -24,12 +26,23 @@ import (
 	"github.com/ystia/yorc/helper/consulutil"
 	"github.com/ystia/yorc/log"
 	"github.com/ystia/yorc/tosca"
+	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"path"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
+// maxHTTPCheckBodySize is the maximum size of HTTP responses bodies read by HTTP checks
+const maxHTTPCheckBodySize = 1024 * 1024
+
+// maxCommandCheckOutputSize is the maximum size of the output of each stream of a command check kept to report a failure
+const maxCommandCheckOutputSize = 4096
+
 // NewCheck allows to instantiate a Check
 func NewCheck(deploymentID, nodeName, instance string) *Check {
 	return &Check{ID: buildID(deploymentID, nodeName, instance), Report: CheckReport{DeploymentID: deploymentID, NodeName: nodeName, Instance: instance}}
@@ -44,7 +57,7 @@ func NewCheckFromID(checkID string) (*Check, error) {
 	return &Check{ID: checkID, Report: CheckReport{DeploymentID: tab[0], NodeName: tab[1], Instance: tab[2]}}, nil
 }
 
-// Start allows to start running a TCP check
+// Start allows to start running a check
 func (c *Check) Start() {
 	c.stopLock.Lock()
 	defer c.stopLock.Unlock()
@@ -65,7 +78,7 @@ func (c *Check) Start() {
 	go c.run()
 }
 
-// Stop allows to stop a TCP check
+// Stop allows to stop a check
 func (c *Check) Stop() {
 	c.stopLock.Lock()
 	defer c.stopLock.Unlock()
@@ -92,14 +105,138 @@ func (c *Check) run() {
 }
 
 func (c *Check) check() {
+	status, err := c.execute()
+	c.lastError = err
+	c.updateStatus(status)
+	if status != CheckStatusCRITICAL {
+		c.consecutiveFailures = 0
+		c.healingLimitReported = false
+		return
+	}
+	c.consecutiveFailures++
+	if err = defaultMonManager.healInstance(c); err != nil {
+		log.Printf("[WARN] Failed to apply healing policy for check ID:%q due to error:%+v", c.ID, err)
+	}
+}
+
+// execute runs the check according to its type and returns the resulting status
+//
+// The returned error details the reason of a critical status.
+func (c *Check) execute() (CheckStatus, error) {
+	var err error
+	switch c.Type {
+	case CheckTypeHTTP, CheckTypeHTTPS:
+		err = c.checkHTTP()
+	case CheckTypeCOMMAND:
+		err = c.checkCommand()
+	default:
+		err = c.checkTCP()
+	}
+	if err != nil {
+		log.Debugf("[WARN] %s check (id:%q) failed: %v", c.Type, c.ID, err)
+		return CheckStatusCRITICAL, err
+	}
+	return CheckStatusPASSING, nil
+}
+
+func (c *Check) checkTCP() error {
 	conn, err := net.DialTimeout("tcp", c.TCPAddress, c.timeout)
 	if err != nil {
-		log.Debugf("[WARN] TCP check (id:%q) connection failed for address:%s", c.ID, c.TCPAddress)
-		c.updateStatus(CheckStatusCRITICAL)
-		return
+		return errors.Wrapf(err, "TCP connection failed for address:%s", c.TCPAddress)
 	}
 	conn.Close()
-	c.updateStatus(CheckStatusPASSING)
+	return nil
+}
+
+func (c *Check) checkHTTP() error {
+	scheme := "http"
+	transport := &http.Transport{}
+	if c.Type == CheckTypeHTTPS {
+		scheme = "https"
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: c.TLSSkipVerify}
+	}
+	client := &http.Client{Timeout: c.timeout, Transport: transport}
+	defer transport.CloseIdleConnections()
+	url := fmt.Sprintf("%s://%s/%s", scheme, c.TCPAddress, strings.TrimPrefix(c.HTTPPath, "/"))
+	resp, err := client.Get(url)
+	if err != nil {
+		return errors.Wrapf(err, "HTTP request failed for url:%s", url)
+	}
+	defer resp.Body.Close()
+
+	expectedStatus := c.HTTPExpectedStatus
+	if len(expectedStatus) == 0 {
+		expectedStatus = []int{http.StatusOK}
+	}
+	statusOK := false
+	for _, s := range expectedStatus {
+		if resp.StatusCode == s {
+			statusOK = true
+			break
+		}
+	}
+	if !statusOK {
+		return errors.Errorf("unexpected HTTP status %d for url:%s", resp.StatusCode, url)
+	}
+	if c.HTTPExpectedBody == "" {
+		return nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxHTTPCheckBodySize))
+	if err != nil {
+		return errors.Wrapf(err, "failed to read HTTP response body for url:%s", url)
+	}
+	matched, err := regexp.Match(c.HTTPExpectedBody, body)
+	if err != nil {
+		return errors.Wrapf(err, "invalid expected body pattern %q", c.HTTPExpectedBody)
+	}
+	if !matched {
+		return errors.Errorf("HTTP response body for url:%s doesn't match expected pattern %q", url, c.HTTPExpectedBody)
+	}
+	return nil
+}
+
+func (c *Check) checkCommand() error {
+	if c.sshClient == nil {
+		client, err := getInstanceSSHClient(defaultMonManager.cc.KV(), defaultMonManager.cfg, c.Report.DeploymentID, c.Report.NodeName, c.Report.Instance, c.TCPAddress)
+		if err != nil {
+			return err
+		}
+		c.sshClient = client
+	}
+	// Dial is done by the session pool, ensure it doesn't hang more than the check timeout
+	c.sshClient.Config.Timeout = c.timeout
+	sw, err := c.sshClient.GetSessionWrapper()
+	if err != nil {
+		// Force a new client to be created on next check
+		c.sshClient = nil
+		return errors.Wrapf(err, "failed to open SSH session on %s", c.TCPAddress)
+	}
+	// Session pipes should be drained for the command to complete
+	var stdout, stderr bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go drainCommandOutput(sw.Stdout, &stdout, &wg)
+	go drainCommandOutput(sw.Stderr, &stderr, &wg)
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	err = sw.RunCommand(ctx, c.Command)
+	// Pipes are closed with the session when the command returns
+	wg.Wait()
+	if err == nil {
+		return nil
+	}
+	output := strings.TrimSpace(strings.TrimSpace(stdout.String()) + "\n" + strings.TrimSpace(stderr.String()))
+	if output == "" {
+		return errors.Wrapf(err, "command %q failed on %s", c.Command, c.TCPAddress)
+	}
+	return errors.Wrapf(err, "command %q failed on %s with output %q", c.Command, c.TCPAddress, output)
+}
+
+// drainCommandOutput reads r until it is closed and keeps at most maxCommandCheckOutputSize bytes of it into buf
+func drainCommandOutput(r io.Reader, buf *bytes.Buffer, wg *sync.WaitGroup) {
+	defer wg.Done()
+	io.Copy(buf, io.LimitReader(r, maxCommandCheckOutputSize))
+	io.Copy(ioutil.Discard, r)
 }
 
 func (c *Check) exist() bool {
@@ -124,7 +261,7 @@ func (c *Check) updateStatus(status CheckStatus) {
 		log.Debugf("Update check status from %q to %q", c.Report.Status.String(), status.String())
 		key := &api.KVPair{Key: path.Join(consulutil.MonitoringKVPrefix, "reports", c.ID, "status"), Value: []byte(status.String())}
 		if _, err := defaultMonManager.cc.KV().Put(key, nil); err != nil {
-			log.Printf("[WARN] TCP check updating status failed for check ID:%q due to error:%+v", c.ID, err)
+			log.Printf("[WARN] Check updating status failed for check ID:%q due to error:%+v", c.ID, err)
 		}
 		c.Report.Status = status
 		c.notify()
@@ -141,7 +278,7 @@ func (c *Check) notify() {
 	} else if c.Report.Status == CheckStatusCRITICAL {
 		// Node in ERROR
 		nodeState = tosca.NodeStateError
-		events.WithContextOptionalFields(c.ctx).NewLogEntry(events.ERROR, c.Report.DeploymentID).Registerf("Monitoring Check returned a connection failure for node (%s-%s)", c.Report.NodeName, c.Report.Instance)
+		events.WithContextOptionalFields(c.ctx).NewLogEntry(events.ERROR, c.Report.DeploymentID).Registerf("Monitoring Check returned a failure for node (%s-%s): %v", c.Report.NodeName, c.Report.Instance, c.lastError)
 	}
 
 	// Update the node state
@@ -0,0 +1,139 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitoring
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckExecuteHTTP(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			fmt.Fprint(w, `{"status": "UP"}`)
+		case "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"status": "DOWN"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	address := strings.TrimPrefix(ts.URL, "http://")
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus []int
+		expectedBody   string
+		want           CheckStatus
+	}{
+		{"DefaultPathNotFound", "", nil, "", CheckStatusCRITICAL},
+		{"Healthy", "/health", nil, "", CheckStatusPASSING},
+		{"HealthyWithBody", "health", nil, `"status":\s*"UP"`, CheckStatusPASSING},
+		{"BodyMismatch", "/health", nil, `DOWN`, CheckStatusCRITICAL},
+		{"UnexpectedStatus", "/down", nil, "", CheckStatusCRITICAL},
+		{"ExpectedStatus", "/down", []int{200, 503}, "", CheckStatusPASSING},
+		{"NotFoundExpected", "/other", []int{404}, "", CheckStatusPASSING},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Check{ID: "dep:node:0", TCPAddress: address, Type: CheckTypeHTTP, HTTPPath: tt.path,
+				HTTPExpectedStatus: tt.expectedStatus, HTTPExpectedBody: tt.expectedBody, timeout: 2 * time.Second}
+			status, err := c.execute()
+			require.Equal(t, tt.want, status)
+			if tt.want == CheckStatusPASSING {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckExecuteHTTPS(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "OK")
+	}))
+	defer ts.Close()
+	address := strings.TrimPrefix(ts.URL, "https://")
+
+	c := &Check{ID: "dep:node:0", TCPAddress: address, Type: CheckTypeHTTPS, timeout: 2 * time.Second}
+	status, err := c.execute()
+	require.Error(t, err, "self-signed certificate should be rejected")
+	require.Equal(t, CheckStatusCRITICAL, status)
+
+	c.TLSSkipVerify = true
+	status, err = c.execute()
+	require.NoError(t, err)
+	require.Equal(t, CheckStatusPASSING, status)
+}
+
+func TestCheckExecuteTCP(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := ln.Addr().String()
+
+	c := &Check{ID: "dep:node:0", TCPAddress: address, Type: CheckTypeTCP, timeout: 2 * time.Second}
+	status, err := c.execute()
+	require.NoError(t, err)
+	require.Equal(t, CheckStatusPASSING, status)
+
+	ln.Close()
+	status, err = c.execute()
+	require.Error(t, err)
+	require.Equal(t, CheckStatusCRITICAL, status)
+}
+
+func TestParseAndFormatStatusCodes(t *testing.T) {
+	t.Parallel()
+	codes, err := parseStatusCodes("200, 201,204")
+	require.NoError(t, err)
+	require.Equal(t, []int{200, 201, 204}, codes)
+	require.Equal(t, "200,201,204", formatStatusCodes(codes))
+
+	codes, err = parseStatusCodes("")
+	require.NoError(t, err)
+	require.Nil(t, codes)
+	require.Equal(t, "", formatStatusCodes(nil))
+
+	_, err = parseStatusCodes("200,OK")
+	require.Error(t, err)
+}
+
+func TestDrainCommandOutput(t *testing.T) {
+	t.Parallel()
+	r := strings.NewReader(strings.Repeat("a", maxCommandCheckOutputSize+10))
+	var buf bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(1)
+	drainCommandOutput(r, &buf, &wg)
+	wg.Wait()
+	require.Equal(t, maxCommandCheckOutputSize, buf.Len())
+	require.Equal(t, 0, r.Len(), "output should be fully drained")
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitoring
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/helper/sshutil"
+)
+
+// defaultSSHPrivateKey is the private key used by command checks when no credentials are defined on the host
+const defaultSSHPrivateKey = "~/.ssh/yorc.pem"
+
+// getHostComputeInstance returns the compute node and instance hosting a given node instance
+//
+// For a compute node, the node instance itself is returned.
+func getHostComputeInstance(kv *api.KV, deploymentID, nodeName, instance string) (string, string, error) {
+	for nodeName != "" {
+		isCompute, err := deployments.IsNodeDerivedFrom(kv, deploymentID, nodeName, "tosca.nodes.Compute")
+		if err != nil || isCompute {
+			return nodeName, instance, err
+		}
+		nodeName, instance, err = deployments.GetHostedOnNodeInstance(kv, deploymentID, nodeName, instance)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return "", "", nil
+}
+
+// getInstanceIPAddress returns the IP address to use for checking a given node instance
+//
+// If an endpoint capability name is provided, its ip_address attribute is used if set.
+// Otherwise the ip_address attribute of the compute hosting the instance is used.
+func getInstanceIPAddress(kv *api.KV, deploymentID, nodeName, instance, endpoint string) (string, error) {
+	if endpoint != "" {
+		found, ipAddress, err := deployments.GetInstanceCapabilityAttribute(kv, deploymentID, nodeName, instance, endpoint, "ip_address")
+		if err != nil {
+			return "", err
+		}
+		if found && ipAddress != "" {
+			return ipAddress, nil
+		}
+	}
+	host, hostInstance, err := getHostComputeInstance(kv, deploymentID, nodeName, instance)
+	if err != nil {
+		return "", err
+	}
+	if host == "" {
+		return "", errors.Errorf("no compute hosting node %q (instance %q) found", nodeName, instance)
+	}
+	found, ipAddress, err := deployments.GetInstanceAttribute(kv, deploymentID, host, hostInstance, "ip_address")
+	if err != nil {
+		return "", err
+	}
+	if !found || ipAddress == "" {
+		return "", errors.Errorf("no attribute ip_address has been found for node %q (instance %q)", host, hostInstance)
+	}
+	return ipAddress, nil
+}
+
+// getInstanceSSHClient returns an SSH client connected to the given address using the credentials of the
+// endpoint capability of the compute hosting a given node instance
+func getInstanceSSHClient(kv *api.KV, cfg config.Configuration, deploymentID, nodeName, instance, address string) (*sshutil.SSHClient, error) {
+	host, hostInstance, err := getHostComputeInstance(kv, deploymentID, nodeName, instance)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" {
+		return nil, errors.Errorf("no compute hosting node %q (instance %q) found", nodeName, instance)
+	}
+	sshConfig := &ssh.ClientConfig{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+	found, user, err := deployments.GetInstanceCapabilityAttribute(kv, deploymentID, host, hostInstance, "endpoint", "credentials", "user")
+	if err != nil {
+		return nil, err
+	}
+	if !found || user == "" {
+		return nil, errors.Errorf("no user defined in endpoint credentials of node %q (instance %q)", host, hostInstance)
+	}
+	sshConfig.User = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("host.user", user).(string)
+
+	found, privateKey, err := deployments.GetInstanceCapabilityAttribute(kv, deploymentID, host, hostInstance, "endpoint", "credentials", "keys", "0")
+	if err != nil {
+		return nil, err
+	}
+	if found && privateKey != "" {
+		privateKey = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("host.privateKey", privateKey).(string)
+	}
+	found, password, err := deployments.GetInstanceCapabilityAttribute(kv, deploymentID, host, hostInstance, "endpoint", "credentials", "token")
+	if err != nil {
+		return nil, err
+	}
+	if found && password != "" {
+		sshConfig.Auth = append(sshConfig.Auth, ssh.Password(config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("host.password", password).(string)))
+	}
+	if privateKey == "" && len(sshConfig.Auth) == 0 {
+		privateKey = defaultSSHPrivateKey
+	}
+	if privateKey != "" {
+		keyAuth, err := sshutil.ReadPrivateKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		sshConfig.Auth = append(sshConfig.Auth, keyAuth)
+	}
+
+	ipAddress, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid address %q", address)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid port in address %q", address)
+	}
+	bastions, err := deployments.GetInstanceBastions(kv, cfg, deploymentID, host, hostInstance)
+	if err != nil {
+		return nil, err
+	}
+	return &sshutil.SSHClient{Config: sshConfig, Host: ipAddress, Port: port, Bastions: bastions}, nil
+}
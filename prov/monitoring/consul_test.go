@@ -87,5 +87,11 @@ func TestRunConsulMonitoringPackageTests(t *testing.T) {
 		t.Run("testAddAndRemoveCheck", func(t *testing.T) {
 			testAddAndRemoveCheck(t, client)
 		})
+		t.Run("testInstanceMetrics", func(t *testing.T) {
+			testInstanceMetrics(t, client)
+		})
+		t.Run("testHealingAttempts", func(t *testing.T) {
+			testHealingAttempts(t, client)
+		})
 	})
 }
@@ -0,0 +1,199 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitoring
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/collections"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/tasks"
+)
+
+// healingPolicyType is the TOSCA policy type of self-healing policies
+const healingPolicyType = "yorc.policies.Healing"
+
+type healingPolicy struct {
+	name              string
+	failuresThreshold uint32
+	workflow          string
+	maxAttempts       uint32
+	attemptsPeriod    time.Duration
+}
+
+func readHealingPolicy(kv *api.KV, deploymentID, policyName string) (*healingPolicy, error) {
+	p := &healingPolicy{name: policyName}
+	var err error
+	if p.failuresThreshold, err = readUintPolicyProperty(kv, deploymentID, policyName, "failures_threshold"); err != nil {
+		return nil, err
+	}
+	if p.failuresThreshold == 0 {
+		p.failuresThreshold = 3
+	}
+	if _, p.workflow, err = deployments.GetPolicyProperty(kv, deploymentID, policyName, "workflow"); err != nil {
+		return nil, err
+	}
+	if p.maxAttempts, err = readUintPolicyProperty(kv, deploymentID, policyName, "max_attempts"); err != nil {
+		return nil, err
+	}
+	if p.attemptsPeriod, err = readDurationPolicyProperty(kv, deploymentID, policyName, "attempts_period"); err != nil {
+		return nil, err
+	}
+	if p.attemptsPeriod <= 0 {
+		p.attemptsPeriod = time.Hour
+	}
+	return p, nil
+}
+
+// getNodeHealingPolicy returns the healing policy targeting a given node or nil if the node is not targeted by such a policy
+func getNodeHealingPolicy(kv *api.KV, deploymentID, nodeName string) (*healingPolicy, error) {
+	policies, err := deployments.GetPoliciesForType(kv, deploymentID, healingPolicyType)
+	if err != nil {
+		return nil, err
+	}
+	for _, policyName := range policies {
+		nodes, err := deployments.GetPolicyTargetsNodes(kv, deploymentID, policyName)
+		if err != nil {
+			return nil, err
+		}
+		if collections.ContainsString(nodes, nodeName) {
+			return readHealingPolicy(kv, deploymentID, policyName)
+		}
+	}
+	return nil, nil
+}
+
+// recentAttempts returns the healing attempts that occurred during the policy attempts period before now
+func (p *healingPolicy) recentAttempts(attempts []time.Time, now time.Time) []time.Time {
+	recent := attempts[:0]
+	for _, attempt := range attempts {
+		if now.Sub(attempt) < p.attemptsPeriod {
+			recent = append(recent, attempt)
+		}
+	}
+	return recent
+}
+
+// healInstance registers a task repairing the instance of a failing check if required by a healing policy
+func (mgr *monitoringMgr) healInstance(c *Check) error {
+	kv := mgr.cc.KV()
+	deploymentID, nodeName, instance := c.Report.DeploymentID, c.Report.NodeName, c.Report.Instance
+	policy, err := getNodeHealingPolicy(kv, deploymentID, nodeName)
+	if err != nil || policy == nil || c.consecutiveFailures < policy.failuresThreshold {
+		return err
+	}
+	status, err := deployments.GetDeploymentStatus(kv, deploymentID)
+	if err != nil {
+		return err
+	}
+	if status != deployments.DEPLOYED {
+		log.Debugf("Healing policy %q: deployment %q is not in DEPLOYED status, delaying repair of node %q (instance %q)", policy.name, deploymentID, nodeName, instance)
+		return nil
+	}
+
+	ctx := events.NewContext(context.Background(), events.LogOptionalFields{events.NodeID: nodeName, events.InstanceID: instance})
+	now := time.Now()
+	attempts, err := getHealingAttempts(kv, deploymentID, nodeName, instance)
+	if err != nil {
+		return err
+	}
+	attempts = policy.recentAttempts(attempts, now)
+	if uint32(len(attempts)) >= policy.maxAttempts {
+		// Rate limiting reached: do not loop on repairing this instance
+		if !c.healingLimitReported {
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).Registerf("Healing policy %q: %d repairs of node %q (instance %q) already done during the last %s, giving up until the end of this period", policy.name, len(attempts), nodeName, instance, policy.attemptsPeriod)
+			c.healingLimitReported = true
+		}
+		return nil
+	}
+
+	hasLivingTask, taskID, _, err := tasks.TargetHasLivingTasks(kv, deploymentID)
+	if err != nil {
+		return err
+	}
+	if hasLivingTask {
+		log.Debugf("Healing policy %q: task %q is running on deployment %q, delaying repair of node %q (instance %q)", policy.name, taskID, deploymentID, nodeName, instance)
+		return nil
+	}
+
+	instancesByNodes, err := deployments.SelectNodeInstanceStack(kv, deploymentID, nodeName, instance)
+	if err != nil {
+		return err
+	}
+	data := make(map[string]string)
+	for stackNode, nodeInstances := range instancesByNodes {
+		data[path.Join("nodes", stackNode)] = nodeInstances
+	}
+	if policy.workflow != "" {
+		data["workflowName"] = policy.workflow
+	}
+	taskID, err = tasks.NewCollector(mgr.cc).RegisterTaskWithData(deploymentID, tasks.Heal, data)
+	if err != nil {
+		return err
+	}
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, deploymentID).Registerf("Healing policy %q: check failed %d consecutive times for node %q (instance %q), registered repair task %q", policy.name, c.consecutiveFailures, nodeName, instance, taskID)
+	c.consecutiveFailures = 0
+	return storeHealingAttempts(kv, deploymentID, nodeName, instance, append(attempts, now))
+}
+
+func healingAttemptsPath(deploymentID, nodeName, instance string) string {
+	return path.Join(consulutil.MonitoringKVPrefix, "healing", deploymentID, nodeName, instance, "attempts")
+}
+
+// getHealingAttempts returns the times of previous repairs of a node instance
+//
+// This is stored in Consul to survive to a monitoring leader change and to the instance check removal during its repair.
+func getHealingAttempts(kv *api.KV, deploymentID, nodeName, instance string) ([]time.Time, error) {
+	kvp, _, err := kv.Get(healingAttemptsPath(deploymentID, nodeName, instance), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil || len(kvp.Value) == 0 {
+		return nil, nil
+	}
+	values := strings.Split(string(kvp.Value), ",")
+	attempts := make([]time.Time, len(values))
+	for i, value := range values {
+		attempts[i], err = time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid healing attempt time for node %q (instance %q) of deployment %q", nodeName, instance, deploymentID)
+		}
+	}
+	return attempts, nil
+}
+
+// removeHealingAttempts forgets previous repairs of a node instance
+func removeHealingAttempts(kv *api.KV, deploymentID, nodeName, instance string) error {
+	_, err := kv.Delete(healingAttemptsPath(deploymentID, nodeName, instance), nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
+
+func storeHealingAttempts(kv *api.KV, deploymentID, nodeName, instance string, attempts []time.Time) error {
+	values := make([]string, len(attempts))
+	for i, attempt := range attempts {
+		values[i] = attempt.Format(time.RFC3339Nano)
+	}
+	_, err := kv.Put(&api.KVPair{Key: healingAttemptsPath(deploymentID, nodeName, instance), Value: []byte(strings.Join(values, ","))}, nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
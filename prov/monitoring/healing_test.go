@@ -0,0 +1,65 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealingPolicyRecentAttempts(t *testing.T) {
+	t.Parallel()
+	p := &healingPolicy{attemptsPeriod: time.Hour}
+	now := time.Now()
+	attempts := []time.Time{now.Add(-2 * time.Hour), now.Add(-61 * time.Minute), now.Add(-59 * time.Minute), now.Add(-time.Minute)}
+	recent := p.recentAttempts(attempts, now)
+	require.Len(t, recent, 2)
+	require.Equal(t, now.Add(-59*time.Minute), recent[0])
+	require.Equal(t, now.Add(-time.Minute), recent[1])
+
+	require.Len(t, p.recentAttempts(nil, now), 0)
+}
+
+func testHealingAttempts(t *testing.T, client *api.Client) {
+	kv := client.KV()
+	dep := "monitoring7"
+	node := "Compute1"
+
+	attempts, err := getHealingAttempts(kv, dep, node, "0")
+	require.Nil(t, err)
+	require.Len(t, attempts, 0)
+
+	now := time.Now()
+	err = storeHealingAttempts(kv, dep, node, "0", []time.Time{now.Add(-time.Minute), now})
+	require.Nil(t, err)
+	attempts, err = getHealingAttempts(kv, dep, node, "0")
+	require.Nil(t, err)
+	require.Len(t, attempts, 2)
+	require.True(t, now.Equal(attempts[1]))
+	require.True(t, now.Add(-time.Minute).Equal(attempts[0]))
+
+	attempts, err = getHealingAttempts(kv, dep, node, "1")
+	require.Nil(t, err)
+	require.Len(t, attempts, 0)
+
+	err = removeHealingAttempts(kv, dep, node, "0")
+	require.Nil(t, err)
+	attempts, err = getHealingAttempts(kv, dep, node, "0")
+	require.Nil(t, err)
+	require.Len(t, attempts, 0)
+}
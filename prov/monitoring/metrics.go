@@ -0,0 +1,93 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitoring
+
+import (
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/helper/consulutil"
+)
+
+func metricsPath(deploymentID string, elems ...string) string {
+	return path.Join(append([]string{consulutil.MonitoringKVPrefix, "metrics", deploymentID}, elems...)...)
+}
+
+// StoreInstanceMetric stores the value of a metric for a given node instance
+//
+// Metrics are used by auto-scaling policies.
+func StoreInstanceMetric(kv *api.KV, deploymentID, nodeName, instance, metric string, value float64) error {
+	metricPath := metricsPath(deploymentID, nodeName, instance, metric)
+	ops := api.KVTxnOps{
+		&api.KVTxnOp{
+			Verb:  api.KVSet,
+			Key:   path.Join(metricPath, "value"),
+			Value: []byte(strconv.FormatFloat(value, 'f', -1, 64)),
+		},
+		&api.KVTxnOp{
+			Verb:  api.KVSet,
+			Key:   path.Join(metricPath, "timestamp"),
+			Value: []byte(time.Now().Format(time.RFC3339Nano)),
+		},
+	}
+	ok, response, _, err := kv.Txn(ops, nil)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to store metric %q for node %q (instance %q)", metric, nodeName, instance)
+	}
+	if !ok {
+		return errors.Errorf("Failed to store metric %q for node %q (instance %q): %+v", metric, nodeName, instance, response.Errors)
+	}
+	return nil
+}
+
+// GetInstanceMetric retrieves the last value of a metric for a given node instance and the time it was stored at
+//
+// It returns false as first return parameter if the metric has never been stored for this instance.
+func GetInstanceMetric(kv *api.KV, deploymentID, nodeName, instance, metric string) (bool, float64, time.Time, error) {
+	metricPath := metricsPath(deploymentID, nodeName, instance, metric)
+	kvp, _, err := kv.Get(path.Join(metricPath, "value"), nil)
+	if err != nil {
+		return false, 0, time.Time{}, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil || len(kvp.Value) == 0 {
+		return false, 0, time.Time{}, nil
+	}
+	value, err := strconv.ParseFloat(string(kvp.Value), 64)
+	if err != nil {
+		return false, 0, time.Time{}, errors.Wrapf(err, "invalid value for metric %q of node %q (instance %q)", metric, nodeName, instance)
+	}
+	var timestamp time.Time
+	kvp, _, err = kv.Get(path.Join(metricPath, "timestamp"), nil)
+	if err != nil {
+		return false, 0, time.Time{}, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp != nil && len(kvp.Value) > 0 {
+		timestamp, err = time.Parse(time.RFC3339Nano, string(kvp.Value))
+		if err != nil {
+			return false, 0, time.Time{}, errors.Wrapf(err, "invalid timestamp for metric %q of node %q (instance %q)", metric, nodeName, instance)
+		}
+	}
+	return true, value, timestamp, nil
+}
+
+// removeInstanceMetrics removes all metrics stored for a given node instance
+func removeInstanceMetrics(kv *api.KV, deploymentID, nodeName, instance string) error {
+	_, err := kv.DeleteTree(metricsPath(deploymentID, nodeName, instance)+"/", nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
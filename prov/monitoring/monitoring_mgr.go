@@ -16,7 +16,6 @@ package monitoring
 
 import (
 	"context"
-	"fmt"
 	"github.com/hashicorp/consul/api"
 	"github.com/pkg/errors"
 	"github.com/ystia/yorc/config"
@@ -27,7 +26,9 @@ import (
 	"github.com/ystia/yorc/tasks"
 	"github.com/ystia/yorc/tasks/workflow"
 	"github.com/ystia/yorc/tosca"
+	"net"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -108,6 +109,7 @@ func (mgr *monitoringMgr) startMonitoring() {
 	mgr.isMonitoringLock.Unlock()
 	mgr.chStopMonitoring = make(chan struct{})
 	mgr.checks = make(map[string]*Check)
+	go mgr.runAutoScaling(mgr.chStopMonitoring)
 	var waitIndex uint64
 	go func() {
 		for {
@@ -163,27 +165,10 @@ func (mgr *monitoringMgr) startMonitoring() {
 					continue
 				}
 
-				kvp, _, err = mgr.cc.KV().Get(path.Join(key, "interval"), nil)
-				if err != nil {
+				if err = readCheckConfig(mgr.cc.KV(), key, check); err != nil {
 					handleError(err)
 					continue
 				}
-				if kvp != nil && len(kvp.Value) > 0 {
-					d, err := time.ParseDuration(string(kvp.Value))
-					if err != nil {
-						handleError(err)
-						continue
-					}
-					check.TimeInterval = d
-				}
-				kvp, _, err = mgr.cc.KV().Get(path.Join(key, "address"), nil)
-				if err != nil {
-					handleError(err)
-					continue
-				}
-				if kvp != nil && len(kvp.Value) > 0 {
-					check.TCPAddress = string(kvp.Value)
-				}
 
 				reportPath := path.Join(consulutil.MonitoringKVPrefix, "reports", id)
 				kvp, _, err = mgr.cc.KV().Get(path.Join(reportPath, "status"), nil)
@@ -210,6 +195,42 @@ func (mgr *monitoringMgr) startMonitoring() {
 	}()
 }
 
+// readCheckConfig reads a registered check configuration stored under the given Consul key
+func readCheckConfig(kv *api.KV, key string, check *Check) error {
+	kvps, _, err := kv.List(key+"/", nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	for _, kvp := range kvps {
+		if len(kvp.Value) == 0 {
+			continue
+		}
+		value := string(kvp.Value)
+		switch strings.TrimPrefix(kvp.Key, key+"/") {
+		case "interval":
+			check.TimeInterval, err = time.ParseDuration(value)
+		case "address":
+			check.TCPAddress = value
+		case "type":
+			check.Type, err = ParseCheckType(value)
+		case "http_path":
+			check.HTTPPath = value
+		case "http_expected_status":
+			check.HTTPExpectedStatus, err = parseStatusCodes(value)
+		case "http_expected_body":
+			check.HTTPExpectedBody = value
+		case "tls_skip_verify":
+			check.TLSSkipVerify, err = strconv.ParseBool(value)
+		case "command":
+			check.Command = value
+		}
+		if err != nil {
+			return errors.Wrapf(err, "invalid configuration %q for check %q", kvp.Key, check.ID)
+		}
+	}
+	return nil
+}
+
 func addMonitoringHook(ctx context.Context, cfg config.Configuration, taskID, deploymentID, target string, activity workflow.Activity) {
 	// Monitoring check are added after (post-hook):
 	// - Delegate activity and install operation
@@ -220,13 +241,13 @@ func addMonitoringHook(ctx context.Context, cfg config.Configuration, taskID, de
 		activity.Type() == workflow.ActivityTypeSetState && activity.Value() == tosca.NodeStateStarted.String():
 
 		// Check if monitoring is required
-		isMonitorReq, monitoringInterval, err := defaultMonManager.isMonitoringRequired(deploymentID, target)
+		checkDef, err := defaultMonManager.readCheckDefinition(deploymentID, target)
 		if err != nil {
 			events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).
 				Registerf("Failed to check if monitoring is required for node name:%q due to: %v", target, err)
 			return
 		}
-		if !isMonitorReq {
+		if checkDef == nil {
 			return
 		}
 
@@ -238,19 +259,14 @@ func addMonitoringHook(ctx context.Context, cfg config.Configuration, taskID, de
 		}
 
 		for _, instance := range instances {
-			found, ipAddress, err := deployments.GetInstanceAttribute(defaultMonManager.cc.KV(), deploymentID, target, instance, "ip_address")
+			ipAddress, err := getInstanceIPAddress(defaultMonManager.cc.KV(), deploymentID, target, instance, checkDef.endpoint)
 			if err != nil {
 				events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).
-					Registerf("Failed to retrieve ip_address for node name:%q due to: %v", target, err)
-				return
-			}
-			if !found || ipAddress == "" {
-				events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).
-					Registerf("No attribute ip_address has been found for nodeName:%q, instance:%q with deploymentID", target, instance, deploymentID)
+					Registerf("Failed to retrieve ip_address for node name:%q, instance:%q due to: %v", target, instance, err)
 				return
 			}
 
-			if err := defaultMonManager.registerCheck(deploymentID, target, instance, ipAddress, 22, monitoringInterval); err != nil {
+			if err := defaultMonManager.registerCheckDefinition(deploymentID, target, instance, ipAddress, checkDef); err != nil {
 				events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).
 					Registerf("Failed to register check for node name:%q due to: %v", target, err)
 				return
@@ -267,21 +283,41 @@ func removeMonitoringHook(ctx context.Context, cfg config.Configuration, taskID,
 	case activity.Type() == workflow.ActivityTypeDelegate && strings.ToLower(activity.Value()) == "uninstall",
 		activity.Type() == workflow.ActivityTypeSetState && activity.Value() == tosca.NodeStateDeleted.String():
 
-		// Check if monitoring has been required
-		isMonitorReq, _, err := defaultMonManager.isMonitoringRequired(deploymentID, target)
+		instances, err := tasks.GetInstances(defaultMonManager.cc.KV(), taskID, deploymentID, target)
 		if err != nil {
 			events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).
-				Registerf("Failed to check if monitoring is required for node name:%q due to: %v", target, err)
+				Registerf("Failed to retrieve instances for node name:%q due to: %v", target, err)
 			return
 		}
-		if !isMonitorReq {
-			return
+		// Metrics posted for instances are no longer relevant
+		for _, instance := range instances {
+			if err := removeInstanceMetrics(defaultMonManager.cc.KV(), deploymentID, target, instance); err != nil {
+				events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).
+					Registerf("Failed to remove metrics for node name:%q due to: %v", target, err)
+			}
+		}
+		// Previous repairs are kept while an instance is healed, they are forgotten once it is deleted
+		taskType, err := tasks.GetTaskType(defaultMonManager.cc.KV(), taskID)
+		if err != nil {
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).
+				Registerf("Failed to retrieve type of task %q due to: %v", taskID, err)
+		} else if taskType != tasks.Heal {
+			for _, instance := range instances {
+				if err := removeHealingAttempts(defaultMonManager.cc.KV(), deploymentID, target, instance); err != nil {
+					events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).
+						Registerf("Failed to remove healing attempts for node name:%q due to: %v", target, err)
+				}
+			}
 		}
 
-		instances, err := tasks.GetInstances(defaultMonManager.cc.KV(), taskID, deploymentID, target)
+		// Check if monitoring has been required
+		isMonitorReq, _, err := defaultMonManager.isMonitoringRequired(deploymentID, target)
 		if err != nil {
 			events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).
-				Registerf("Failed to retrieve instances for node name:%q due to: %v", target, err)
+				Registerf("Failed to check if monitoring is required for node name:%q due to: %v", target, err)
+			return
+		}
+		if !isMonitorReq {
 			return
 		}
 
@@ -295,17 +331,20 @@ func removeMonitoringHook(ctx context.Context, cfg config.Configuration, taskID,
 	}
 }
 
-func (mgr *monitoringMgr) isMonitoringRequired(deploymentID, nodeName string) (bool, time.Duration, error) {
-	// Check if the node is a compute
-	var isCompute bool
-	isCompute, err := deployments.IsNodeDerivedFrom(mgr.cc.KV(), deploymentID, nodeName, "tosca.nodes.Compute")
-	if err != nil {
-		return false, 0, err
-	}
-	if !isCompute {
-		return false, 0, nil
-	}
+// checkDefinition holds the configuration of a check as defined in a node metadata
+type checkDefinition struct {
+	checkType          CheckType
+	interval           time.Duration
+	port               int
+	endpoint           string
+	httpPath           string
+	httpExpectedStatus []int
+	httpExpectedBody   string
+	tlsSkipVerify      bool
+	command            string
+}
 
+func (mgr *monitoringMgr) isMonitoringRequired(deploymentID, nodeName string) (bool, time.Duration, error) {
 	// monitoring_time_interval must be set to positive value
 	found, val, err := deployments.GetNodeMetadata(mgr.cc.KV(), deploymentID, nodeName, "monitoring_time_interval")
 	if err != nil {
@@ -329,11 +368,132 @@ func (mgr *monitoringMgr) isMonitoringRequired(deploymentID, nodeName string) (b
 	return false, 0, nil
 }
 
-// registerCheck allows to register a check
+// readCheckDefinition reads the monitoring check definition of a node from its metadata
+//
+// A nil definition is returned if the node is not monitored.
+func (mgr *monitoringMgr) readCheckDefinition(deploymentID, nodeName string) (*checkDefinition, error) {
+	isMonitorReq, interval, err := mgr.isMonitoringRequired(deploymentID, nodeName)
+	if err != nil || !isMonitorReq {
+		return nil, err
+	}
+	kv := mgr.cc.KV()
+	checkDef := &checkDefinition{interval: interval, checkType: CheckTypeTCP}
+	getMetadata := func(key string) (string, error) {
+		_, val, err := deployments.GetNodeMetadata(kv, deploymentID, nodeName, key)
+		return val, err
+	}
+
+	val, err := getMetadata("monitoring_check_type")
+	if err != nil {
+		return nil, err
+	}
+	if val != "" {
+		checkDef.checkType, err = ParseCheckType(strings.ToLower(val))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid monitoring_check_type for node %q", nodeName)
+		}
+	}
+	if checkDef.endpoint, err = getMetadata("monitoring_endpoint"); err != nil {
+		return nil, err
+	}
+
+	if val, err = getMetadata("monitoring_port"); err != nil {
+		return nil, err
+	}
+	if val == "" && checkDef.endpoint != "" {
+		_, val, err = deployments.GetCapabilityProperty(kv, deploymentID, nodeName, checkDef.endpoint, "port")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if val != "" {
+		checkDef.port, err = strconv.Atoi(val)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid monitoring port for node %q", nodeName)
+		}
+	} else {
+		switch checkDef.checkType {
+		case CheckTypeHTTP:
+			checkDef.port = 80
+		case CheckTypeHTTPS:
+			checkDef.port = 443
+		default:
+			checkDef.port = 22
+		}
+	}
+
+	switch checkDef.checkType {
+	case CheckTypeHTTP, CheckTypeHTTPS:
+		if checkDef.httpPath, err = getMetadata("monitoring_http_path"); err != nil {
+			return nil, err
+		}
+		if checkDef.httpExpectedBody, err = getMetadata("monitoring_http_expected_body"); err != nil {
+			return nil, err
+		}
+		if checkDef.httpExpectedBody != "" {
+			if _, err = regexp.Compile(checkDef.httpExpectedBody); err != nil {
+				return nil, errors.Wrapf(err, "invalid monitoring_http_expected_body for node %q", nodeName)
+			}
+		}
+		if val, err = getMetadata("monitoring_http_expected_status"); err != nil {
+			return nil, err
+		}
+		if checkDef.httpExpectedStatus, err = parseStatusCodes(val); err != nil {
+			return nil, errors.Wrapf(err, "invalid monitoring_http_expected_status for node %q", nodeName)
+		}
+		if val, err = getMetadata("monitoring_http_tls_skip_verify"); err != nil {
+			return nil, err
+		}
+		if val != "" {
+			if checkDef.tlsSkipVerify, err = strconv.ParseBool(val); err != nil {
+				return nil, errors.Wrapf(err, "invalid monitoring_http_tls_skip_verify for node %q", nodeName)
+			}
+		}
+	case CheckTypeCOMMAND:
+		if checkDef.command, err = getMetadata("monitoring_command"); err != nil {
+			return nil, err
+		}
+		if checkDef.command == "" {
+			return nil, errors.Errorf("missing monitoring_command metadata for command check of node %q", nodeName)
+		}
+	}
+	return checkDef, nil
+}
+
+func formatStatusCodes(codes []int) string {
+	strCodes := make([]string, len(codes))
+	for i, code := range codes {
+		strCodes[i] = strconv.Itoa(code)
+	}
+	return strings.Join(strCodes, ",")
+}
+
+func parseStatusCodes(val string) ([]int, error) {
+	if val == "" {
+		return nil, nil
+	}
+	codes := strings.Split(val, ",")
+	result := make([]int, len(codes))
+	for i, code := range codes {
+		var err error
+		result[i], err = strconv.Atoi(strings.TrimSpace(code))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// registerCheck allows to register a TCP check
 func (mgr *monitoringMgr) registerCheck(deploymentID, nodeName, instance, ipAddress string, port int, interval time.Duration) error {
+	return mgr.registerCheckDefinition(deploymentID, nodeName, instance, ipAddress, &checkDefinition{checkType: CheckTypeTCP, port: port, interval: interval})
+}
+
+// registerCheckDefinition allows to register a check
+func (mgr *monitoringMgr) registerCheckDefinition(deploymentID, nodeName, instance, ipAddress string, checkDef *checkDefinition) error {
 	id := buildID(deploymentID, nodeName, instance)
-	log.Debugf("Register check with id:%q, iPAddress:%q, port:%d, interval:%d", id, ipAddress, port, interval)
-	tcpAddr := fmt.Sprintf("%s:%d", ipAddress, port)
+	log.Debugf("Register %s check with id:%q, iPAddress:%q, port:%d, interval:%d", checkDef.checkType, id, ipAddress, checkDef.port, checkDef.interval)
+	tcpAddr := net.JoinHostPort(ipAddress, strconv.Itoa(checkDef.port))
 
 	// Check is registered in a transaction to ensure to be read in its wholeness
 	checkPath := path.Join(consulutil.MonitoringKVPrefix, "checks", id)
@@ -352,14 +512,33 @@ func (mgr *monitoringMgr) registerCheck(deploymentID, nodeName, instance, ipAddr
 		&api.KVTxnOp{
 			Verb:  api.KVSet,
 			Key:   path.Join(checkPath, "interval"),
-			Value: []byte(interval.String()),
+			Value: []byte(checkDef.interval.String()),
 		},
 		&api.KVTxnOp{
 			Verb:  api.KVSet,
-			Key:   path.Join(checkReportPath, "status"),
-			Value: []byte(CheckStatusPASSING.String()),
+			Key:   path.Join(checkPath, "type"),
+			Value: []byte(checkDef.checkType.String()),
 		},
 	}
+	optionalValues := map[string]string{
+		"http_path":            checkDef.httpPath,
+		"http_expected_body":   checkDef.httpExpectedBody,
+		"http_expected_status": formatStatusCodes(checkDef.httpExpectedStatus),
+		"command":              checkDef.command,
+	}
+	if checkDef.tlsSkipVerify {
+		optionalValues["tls_skip_verify"] = "true"
+	}
+	for k, v := range optionalValues {
+		if v != "" {
+			checkOps = append(checkOps, &api.KVTxnOp{Verb: api.KVSet, Key: path.Join(checkPath, k), Value: []byte(v)})
+		}
+	}
+	checkOps = append(checkOps, &api.KVTxnOp{
+		Verb:  api.KVSet,
+		Key:   path.Join(checkReportPath, "status"),
+		Value: []byte(CheckStatusPASSING.String()),
+	})
 
 	ok, response, _, err := mgr.cc.KV().Txn(checkOps, nil)
 	if err != nil {
@@ -18,6 +18,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"github.com/ystia/yorc/helper/sshutil"
 )
 
 //go:generate go-enum -f=monitoring_structs.go --lower
@@ -35,18 +37,42 @@ const (
 	CRITICAL
 )
 
+// CheckType x ENUM(
+// TCP,
+// HTTP,
+// HTTPS,
+// COMMAND
+// )
+type CheckType int
+
 // Check represents a registered check
 type Check struct {
 	ID           string
 	TCPAddress   string
 	TimeInterval time.Duration
 	Report       CheckReport
+	Type         CheckType
+	// HTTPPath is the path requested by HTTP(S) checks
+	HTTPPath string
+	// HTTPExpectedStatus is the list of accepted HTTP status codes for HTTP(S) checks (defaults to 200)
+	HTTPExpectedStatus []int
+	// HTTPExpectedBody is an optional regular expression the response body of HTTP(S) checks should match
+	HTTPExpectedBody string
+	// TLSSkipVerify allows to skip server certificate verification for HTTPS checks
+	TLSSkipVerify bool
+	// Command is the command run over SSH for COMMAND checks
+	Command string
 
-	stop     bool
-	stopLock sync.Mutex
-	chStop   chan struct{}
-	timeout  time.Duration
-	ctx      context.Context
+	stop      bool
+	stopLock  sync.Mutex
+	chStop    chan struct{}
+	timeout   time.Duration
+	ctx       context.Context
+	lastError error
+	sshClient *sshutil.SSHClient
+	// consecutiveFailures and healingLimitReported are used by healing policies
+	consecutiveFailures  uint32
+	healingLimitReported bool
 }
 
 // CheckReport represents a node check report including its status
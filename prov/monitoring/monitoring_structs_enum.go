@@ -57,3 +57,49 @@ func ParseCheckStatus(name string) (CheckStatus, error) {
 	}
 	return CheckStatus(0), fmt.Errorf("%s is not a valid CheckStatus", name)
 }
+
+const (
+	// CheckTypeTCP is a CheckType of type TCP
+	CheckTypeTCP CheckType = iota
+	// CheckTypeHTTP is a CheckType of type HTTP
+	CheckTypeHTTP
+	// CheckTypeHTTPS is a CheckType of type HTTPS
+	CheckTypeHTTPS
+	// CheckTypeCOMMAND is a CheckType of type COMMAND
+	CheckTypeCOMMAND
+)
+
+const _CheckTypeName = "TCPHTTPHTTPSCOMMAND"
+
+var _CheckTypeMap = map[CheckType]string{
+	0: _CheckTypeName[0:3],
+	1: _CheckTypeName[3:7],
+	2: _CheckTypeName[7:12],
+	3: _CheckTypeName[12:19],
+}
+
+func (i CheckType) String() string {
+	if str, ok := _CheckTypeMap[i]; ok {
+		return str
+	}
+	return fmt.Sprintf("CheckType(%d)", i)
+}
+
+var _CheckTypeValue = map[string]CheckType{
+	_CheckTypeName[0:3]:                    0,
+	strings.ToLower(_CheckTypeName[0:3]):   0,
+	_CheckTypeName[3:7]:                    1,
+	strings.ToLower(_CheckTypeName[3:7]):   1,
+	_CheckTypeName[7:12]:                   2,
+	strings.ToLower(_CheckTypeName[7:12]):  2,
+	_CheckTypeName[12:19]:                  3,
+	strings.ToLower(_CheckTypeName[12:19]): 3,
+}
+
+// ParseCheckType attempts to convert a string to a CheckType
+func ParseCheckType(name string) (CheckType, error) {
+	if x, ok := _CheckTypeValue[name]; ok {
+		return CheckType(x), nil
+	}
+	return CheckType(0), fmt.Errorf("%s is not a valid CheckType", name)
+}
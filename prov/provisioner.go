@@ -17,6 +17,7 @@ package prov
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/ystia/yorc/config"
 )
@@ -76,10 +77,20 @@ func (ro RelationshipOperation) String() string {
 // ExecOperation executes the given TOSCA operation for given nodeName on the given deploymentID.
 // The taskID identifies the task that requested to execute this operation.
 // The given ctx may be used to check for cancellation, conf is the server Configuration.
+// The ctx is also cancelled when the operation times out, executors should then stop and clean up any remote process
+// they started.
 type OperationExecutor interface {
 	ExecOperation(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation Operation) error
 }
 
+// OperationTimeoutProvider is an optional interface that an OperationExecutor may implement
+//
+// DefaultOperationTimeout returns the timeout applied to operations that do not define their own timeout.
+// A zero duration means that the server-wide default operation timeout applies.
+type OperationTimeoutProvider interface {
+	DefaultOperationTimeout(conf config.Configuration) time.Duration
+}
+
 // InfraUsageCollector is the interface for collecting information about infrastructure usage
 //
 // GetUsageInfo returns data about infrastructure usage for defined infrastructure
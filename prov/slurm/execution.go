@@ -391,12 +391,40 @@ func (e *executionCommon) runCommand(ctx context.Context) (string, error) {
 func (e *executionCommon) runInteractiveMode(ctx context.Context, opts, execFile string) (string, error) {
 	cmd := fmt.Sprintf("srun %s %s %s", opts, execFile, strings.Join(e.jobInfo.execArgs, " "))
 	events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, e.deploymentID).RegisterAsString(fmt.Sprintf("Run the command: %q", cmd))
-	output, err := e.client.RunCommand(cmd)
-	if err != nil {
-		log.Debugf("stderr:%q", output)
-		return "", errors.Wrap(err, output)
+	type cmdResult struct {
+		output string
+		err    error
+	}
+	// srun blocks until the job ends so we run it asynchronously to be able to cancel the job
+	// if the operation is cancelled or times out
+	resultCh := make(chan cmdResult, 1)
+	go func() {
+		output, err := e.client.RunCommand(cmd)
+		resultCh <- cmdResult{output: output, err: err}
+	}()
+	select {
+	case res := <-resultCh:
+		if res.err != nil {
+			log.Debugf("stderr:%q", res.output)
+			return "", errors.Wrap(res.err, res.output)
+		}
+		return res.output, nil
+	case <-ctx.Done():
+		e.cancelJob(ctx)
+		return "", errors.Wrapf(ctx.Err(), "interactive job %q interrupted", e.jobInfo.name)
+	}
+}
+
+// cancelJob cancels the current job using its ID if already known or its name otherwise
+func (e *executionCommon) cancelJob(ctx context.Context) {
+	jobID := e.jobInfo.ID
+	if jobID == "" {
+		jobID = "--name=" + e.jobInfo.name
+	}
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, e.deploymentID).Registerf("Cancelling job %q", e.jobInfo.name)
+	if err := cancelJobID(jobID, e.client); err != nil {
+		log.Printf("[Warning] failed to cancel job %q: %v", e.jobInfo.name, err)
 	}
-	return output, nil
 }
 
 func (e *executionCommon) runBatchMode(ctx context.Context, opts, execFile string) (string, error) {
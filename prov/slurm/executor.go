@@ -52,6 +52,10 @@ func newExecutor(generator defaultGenerator) prov.DelegateExecutor {
 	return &defaultExecutor{generator: generator}
 }
 
+func (e *defaultExecutor) DefaultOperationTimeout(conf config.Configuration) time.Duration {
+	return conf.Infrastructures[infrastructureName].GetDuration("operation_timeout")
+}
+
 func (e *defaultExecutor) ExecOperation(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation) error {
 	log.Debugf("Slurm defaultExecutor: Execute the operation:%+v", operation)
 	consulClient, err := conf.GetConsulClient()
@@ -71,10 +71,18 @@ func GetSSHClient(cfg config.Configuration) (*sshutil.SSHClient, error) {
 		return nil, err
 	}
 
+	bastions, err := sshutil.BastionsFromConfig(cfg.Infrastructures[infrastructureName].Get("bastions"))
+	if err != nil {
+		wrapErr := errors.Wrap(err, "slurm configuration bastions are invalid")
+		log.Printf("Unable to provide SSH client due to:%+v", wrapErr)
+		return nil, wrapErr
+	}
+
 	return &sshutil.SSHClient{
-		Config: SSHConfig,
-		Host:   cfg.Infrastructures[infrastructureName].GetString("url"),
-		Port:   port,
+		Config:   SSHConfig,
+		Host:     cfg.Infrastructures[infrastructureName].GetString("url"),
+		Port:     port,
+		Bastions: bastions,
 	}, nil
 }
 
@@ -0,0 +1,221 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/helper/collections"
+	"github.com/ystia/yorc/log"
+)
+
+// Roles granted to users of the REST API
+const (
+	// RoleAdmin grants every permission
+	RoleAdmin = "admin"
+	// RoleDeployer allows to deploy, update, scale and undeploy applications as well as operating them
+	RoleDeployer = "deployer"
+	// RoleOperator allows to run workflows and custom commands and to manage tasks
+	RoleOperator = "operator"
+	// RoleHostsPoolManager allows to manage the hosts pool
+	RoleHostsPoolManager = "hosts_pool_manager"
+	// RoleReader allows to read any resource
+	RoleReader = "reader"
+)
+
+// impliedRoles defines roles granted by other roles
+var impliedRoles = map[string][]string{
+	RoleAdmin:            {RoleDeployer, RoleOperator, RoleHostsPoolManager, RoleReader},
+	RoleDeployer:         {RoleOperator, RoleReader},
+	RoleOperator:         {RoleReader},
+	RoleHostsPoolManager: {RoleReader},
+}
+
+// Identity represents an authenticated user of the REST API
+type Identity struct {
+	Name  string
+	Roles []string
+	// Tenant is the tenant the user belongs to, it may be empty for administrators managing every tenant
+	Tenant string
+}
+
+// HasRole checks if an identity is granted a given role either directly or through another role
+func (i *Identity) HasRole(role string) bool {
+	for _, r := range i.Roles {
+		if r == role || collections.ContainsString(impliedRoles[r], role) {
+			return true
+		}
+	}
+	return false
+}
+
+// An Authenticator authenticates REST API requests
+type Authenticator interface {
+	// Authenticate returns the identity of the user issuing a request.
+	//
+	// A nil identity and a nil error are returned if the request does not hold any credentials
+	// handled by this authenticator. An error is returned if credentials are invalid.
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+const identityLookupKey contextKey = 2
+
+// IdentityFromContext returns the identity of the user issuing a request if any
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityLookupKey).(*Identity)
+	return identity, ok
+}
+
+// newAuthenticators creates authenticators configured for the REST API
+func newAuthenticators(cfg config.Configuration) ([]Authenticator, error) {
+	authenticators := make([]Authenticator, 0)
+	if len(cfg.Auth.Tokens) > 0 {
+		authenticators = append(authenticators, newTokenAuthenticator(cfg.Auth))
+	}
+	if cfg.Auth.OIDC.IsEnabled() {
+		jwtAuth, err := newJWTAuthenticator(cfg.Auth)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, jwtAuth)
+	}
+	if cfg.Auth.ClientCertificates {
+		if cfg.CertFile == "" || cfg.KeyFile == "" || !cfg.SSLVerify {
+			return nil, errors.New("authentication using client certificates requires TLS to be enabled with ssl_verify")
+		}
+		authenticators = append(authenticators, &certificateAuthenticator{usersRoles: cfg.Auth.UsersRoles, usersTenants: cfg.Auth.UsersTenants})
+	}
+	return authenticators, nil
+}
+
+// authenticate returns the identity of the user issuing a request using the first authenticator handling its credentials
+func authenticate(authenticators []Authenticator, r *http.Request) (*Identity, error) {
+	for _, a := range authenticators {
+		identity, err := a.Authenticate(r)
+		if err != nil || identity != nil {
+			return identity, err
+		}
+	}
+	return nil, nil
+}
+
+// authHandler returns a middleware rejecting requests of users not granted the given role
+//
+// Every request is accepted if authentication is not enabled.
+func (s *Server) authHandler(role string) func(http.Handler) http.Handler {
+	m := func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if len(s.authenticators) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			identity, err := authenticate(s.authenticators, r)
+			if err != nil {
+				log.Debugf("[%s] %q authentication failed: %v", r.Method, r.URL.String(), err)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="yorc"`)
+				writeError(w, r, newUnauthorizedError("Invalid credentials."))
+				return
+			}
+			if identity == nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="yorc"`)
+				writeError(w, r, newUnauthorizedError("Authentication required."))
+				return
+			}
+			if !identity.HasRole(role) {
+				log.Debugf("[%s] %q rejected for user %q: role %q required", r.Method, r.URL.String(), identity.Name, role)
+				writeError(w, r, newForbiddenError(role))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityLookupKey, identity)))
+		}
+		return http.HandlerFunc(fn)
+	}
+	return m
+}
+
+// getBearerToken returns the bearer token of a request Authorization header if any
+func getBearerToken(r *http.Request) string {
+	authHeader := r.Header.Get("Authorization")
+	if len(authHeader) > 7 && strings.EqualFold(authHeader[:7], "bearer ") {
+		return strings.TrimSpace(authHeader[7:])
+	}
+	return ""
+}
+
+// mergeRoles returns given roles merged with the configured roles of a user
+func mergeRoles(usersRoles map[string][]string, user string, roles []string) []string {
+	result := make([]string, 0, len(roles))
+	for _, r := range append(roles, usersRoles[user]...) {
+		if !collections.ContainsString(result, r) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// userTenant returns the given tenant if not empty or the configured tenant of a user
+func userTenant(usersTenants map[string]string, user, tenant string) string {
+	if tenant != "" {
+		return tenant
+	}
+	return usersTenants[user]
+}
+
+// tokenAuthenticator authenticates users by static bearer tokens
+type tokenAuthenticator struct {
+	tokens       []config.AuthToken
+	usersRoles   map[string][]string
+	usersTenants map[string]string
+}
+
+func newTokenAuthenticator(cfg config.Auth) *tokenAuthenticator {
+	return &tokenAuthenticator{tokens: cfg.Tokens, usersRoles: cfg.UsersRoles, usersTenants: cfg.UsersTenants}
+}
+
+func (a *tokenAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token := getBearerToken(r)
+	if token == "" || isJWT(token) {
+		return nil, nil
+	}
+	for _, t := range a.tokens {
+		if t.Token != "" && subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return &Identity{Name: t.User, Roles: mergeRoles(a.usersRoles, t.User, t.Roles), Tenant: userTenant(a.usersTenants, t.User, t.Tenant)}, nil
+		}
+	}
+	return nil, errors.New("unknown token")
+}
+
+// certificateAuthenticator authenticates users by the common name of their verified TLS client certificate
+type certificateAuthenticator struct {
+	usersRoles   map[string][]string
+	usersTenants map[string]string
+}
+
+func (a *certificateAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	user := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	if user == "" {
+		return nil, errors.New("client certificate has no common name")
+	}
+	return &Identity{Name: user, Roles: mergeRoles(a.usersRoles, user, nil), Tenant: userTenant(a.usersTenants, user, "")}, nil
+}
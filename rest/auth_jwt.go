@@ -0,0 +1,370 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/log"
+)
+
+const (
+	defaultJWTUserClaim   = "sub"
+	defaultJWTRolesClaim  = "roles"
+	defaultJWTTenantClaim = "tenant"
+	// jwksMinRefreshInterval is the minimum interval between two downloads of a remote key set
+	jwksMinRefreshInterval = time.Minute
+	// jwtClockSkew is the tolerated clock difference when checking tokens validity dates
+	jwtClockSkew = 30 * time.Second
+)
+
+// jsonWebKey is a public key of a JSON Web Key Set as defined by RFC 7517
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type publicKey struct {
+	kid string
+	key crypto.PublicKey
+}
+
+// jwtAuthenticator authenticates users by JSON Web Tokens signed by keys of a given key set
+type jwtAuthenticator struct {
+	cfg          config.OIDC
+	usersRoles   map[string][]string
+	usersTenants map[string]string
+	keysLock     sync.RWMutex
+	keys         []publicKey
+	lastRefresh  time.Time
+	httpClient   *http.Client
+}
+
+func newJWTAuthenticator(cfg config.Auth) (*jwtAuthenticator, error) {
+	a := &jwtAuthenticator{cfg: cfg.OIDC, usersRoles: cfg.UsersRoles, usersTenants: cfg.UsersTenants, httpClient: &http.Client{Timeout: 30 * time.Second}}
+	if a.cfg.UserClaim == "" {
+		a.cfg.UserClaim = defaultJWTUserClaim
+	}
+	if a.cfg.RolesClaim == "" {
+		a.cfg.RolesClaim = defaultJWTRolesClaim
+	}
+	if a.cfg.TenantClaim == "" {
+		a.cfg.TenantClaim = defaultJWTTenantClaim
+	}
+	if err := a.refreshKeys(); err != nil {
+		if a.cfg.JWKSURL == "" {
+			return nil, err
+		}
+		// Remote key set may be temporary unavailable, it will be downloaded again later
+		log.Printf("[WARN] Failed to load OIDC key set: %v", err)
+	}
+	return a, nil
+}
+
+func (a *jwtAuthenticator) refreshKeys() error {
+	var content []byte
+	var err error
+	if a.cfg.JWKSFile != "" {
+		content, err = ioutil.ReadFile(a.cfg.JWKSFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read key set file %q", a.cfg.JWKSFile)
+		}
+	} else {
+		a.lastRefresh = time.Now()
+		resp, err := a.httpClient.Get(a.cfg.JWKSURL)
+		if err != nil {
+			return errors.Wrapf(err, "failed to download key set from %q", a.cfg.JWKSURL)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("failed to download key set from %q: unexpected status %q", a.cfg.JWKSURL, resp.Status)
+		}
+		content, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrapf(err, "failed to download key set from %q", a.cfg.JWKSURL)
+		}
+	}
+	keys, err := parseJSONWebKeySet(content)
+	if err != nil {
+		return err
+	}
+	a.keys = keys
+	return nil
+}
+
+func parseJSONWebKeySet(content []byte) ([]publicKey, error) {
+	var jwks jsonWebKeySet
+	if err := json.Unmarshal(content, &jwks); err != nil {
+		return nil, errors.Wrap(err, "invalid key set")
+	}
+	keys := make([]publicKey, 0, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key %q in key set", jwk.Kid)
+		}
+		keys = append(keys, publicKey{kid: jwk.Kid, key: key})
+	}
+	return keys, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, errors.Errorf("unsupported key type %q", k.Kty)
+}
+
+// isJWT checks if a token looks like a JSON Web Token
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token := getBearerToken(r)
+	if token == "" || !isJWT(token) {
+		return nil, nil
+	}
+	claims, err := a.validate(token)
+	if err != nil {
+		return nil, err
+	}
+	user, _ := claims[a.cfg.UserClaim].(string)
+	if user == "" {
+		return nil, errors.Errorf("missing %q claim in token", a.cfg.UserClaim)
+	}
+	tenant, _ := claims[a.cfg.TenantClaim].(string)
+	return &Identity{Name: user, Roles: mergeRoles(a.usersRoles, user, getRolesClaim(claims, a.cfg.RolesClaim)), Tenant: userTenant(a.usersTenants, user, tenant)}, nil
+}
+
+// getRolesClaim returns roles defined in a claim either as an array of strings or as a space separated string
+func getRolesClaim(claims map[string]interface{}, claim string) []string {
+	switch v := claims[claim].(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		roles := make([]string, 0, len(v))
+		for _, r := range v {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	}
+	return nil
+}
+
+// validate checks a token signature and validity and returns its claims
+func (a *jwtAuthenticator) validate(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, errors.Wrap(err, "invalid token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid token signature")
+	}
+	if err = a.verifySignature(header, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+	var claims map[string]interface{}
+	if err = decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, errors.Wrap(err, "invalid token claims")
+	}
+	return claims, a.validateClaims(claims, time.Now())
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (a *jwtAuthenticator) validateClaims(claims map[string]interface{}, now time.Time) error {
+	if exp, ok := claims["exp"].(float64); !ok {
+		return errors.New("missing exp claim in token")
+	} else if now.After(time.Unix(int64(exp), 0).Add(jwtClockSkew)) {
+		return errors.New("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token is not valid yet")
+	}
+	if a.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.cfg.Issuer {
+			return errors.Errorf("unexpected token issuer %q", iss)
+		}
+	}
+	if a.cfg.Audience != "" {
+		var audiences []string
+		switch aud := claims["aud"].(type) {
+		case string:
+			audiences = []string{aud}
+		case []interface{}:
+			for _, v := range aud {
+				if s, ok := v.(string); ok {
+					audiences = append(audiences, s)
+				}
+			}
+		}
+		found := false
+		for _, aud := range audiences {
+			if aud == a.cfg.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("token audience doesn't contain %q", a.cfg.Audience)
+		}
+	}
+	return nil
+}
+
+func (a *jwtAuthenticator) getKeys(kid string) []publicKey {
+	a.keysLock.RLock()
+	keys := a.selectKeys(kid)
+	a.keysLock.RUnlock()
+	if len(keys) > 0 || a.cfg.JWKSURL == "" {
+		return keys
+	}
+	// Unknown key: the remote key set may have been rotated
+	a.keysLock.Lock()
+	defer a.keysLock.Unlock()
+	if time.Since(a.lastRefresh) > jwksMinRefreshInterval {
+		if err := a.refreshKeys(); err != nil {
+			log.Printf("[WARN] Failed to refresh OIDC key set: %v", err)
+		}
+	}
+	return a.selectKeys(kid)
+}
+
+func (a *jwtAuthenticator) selectKeys(kid string) []publicKey {
+	keys := make([]publicKey, 0)
+	for _, k := range a.keys {
+		if kid == "" || k.kid == kid {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (a *jwtAuthenticator) verifySignature(header jwtHeader, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch header.Alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return errors.Errorf("unsupported token signing algorithm %q", header.Alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+	for _, k := range a.getKeys(header.Kid) {
+		switch key := k.key.(type) {
+		case *rsa.PublicKey:
+			if strings.HasPrefix(header.Alg, "RS") && rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil {
+				return nil
+			}
+		case *ecdsa.PublicKey:
+			size := (key.Curve.Params().BitSize + 7) / 8
+			if strings.HasPrefix(header.Alg, "ES") && len(signature) == 2*size {
+				r := new(big.Int).SetBytes(signature[:size])
+				s := new(big.Int).SetBytes(signature[size:])
+				if ecdsa.Verify(key, digest, r, s) {
+					return nil
+				}
+			}
+		}
+	}
+	return errors.New("invalid token signature")
+}
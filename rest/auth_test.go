@@ -0,0 +1,275 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/config"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func signJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := b64(header) + "." + b64(payload)
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(signed))
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest.Sum(nil))
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest.Sum(nil))
+		require.NoError(t, err)
+		signature = make([]byte, 64)
+		copy(signature[32-len(r.Bytes()):32], r.Bytes())
+		copy(signature[64-len(s.Bytes()):], s.Bytes())
+	}
+	return signed + "." + b64(signature)
+}
+
+func writeJWKS(t *testing.T, dir string, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(ecKey.X.Bytes()), "y": b64(ecKey.Y.Bytes())},
+		},
+	}
+	content, err := json.Marshal(jwks)
+	require.NoError(t, err)
+	jwksFile := filepath.Join(dir, "jwks.json")
+	require.NoError(t, ioutil.WriteFile(jwksFile, content, 0600))
+	return jwksFile
+}
+
+func TestIdentityHasRole(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		roles []string
+		role  string
+		want  bool
+	}{
+		{"AdminIsDeployer", []string{RoleAdmin}, RoleDeployer, true},
+		{"AdminIsHostsPoolManager", []string{RoleAdmin}, RoleHostsPoolManager, true},
+		{"DeployerIsOperator", []string{RoleDeployer}, RoleOperator, true},
+		{"DeployerIsReader", []string{RoleDeployer}, RoleReader, true},
+		{"DeployerIsNotHostsPoolManager", []string{RoleDeployer}, RoleHostsPoolManager, false},
+		{"OperatorIsNotDeployer", []string{RoleOperator}, RoleDeployer, false},
+		{"ReaderIsNotOperator", []string{RoleReader}, RoleOperator, false},
+		{"HostsPoolManagerIsReader", []string{RoleHostsPoolManager}, RoleReader, true},
+		{"MultipleRoles", []string{RoleOperator, RoleHostsPoolManager}, RoleHostsPoolManager, true},
+		{"NoRoles", nil, RoleReader, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &Identity{Name: "user", Roles: tt.roles}
+			require.Equal(t, tt.want, i.HasRole(tt.role))
+		})
+	}
+}
+
+func TestAuthHandler(t *testing.T) {
+	t.Parallel()
+	authCfg := config.Auth{
+		Tokens: []config.AuthToken{
+			{Token: "readerToken", User: "bob", Roles: []string{RoleReader}},
+			{Token: "adminToken", User: "alice"},
+			{Token: "deployerToken", User: "dave", Roles: []string{RoleDeployer}, Tenant: "team1"},
+		},
+		UsersRoles:   map[string][]string{"alice": {RoleAdmin}},
+		UsersTenants: map[string]string{"dave": "team2"},
+	}
+	authenticators, err := newAuthenticators(config.Configuration{Auth: authCfg})
+	require.NoError(t, err)
+	s := &Server{authenticators: authenticators}
+
+	var identity *Identity
+	handler := s.authHandler(RoleDeployer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ = IdentityFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		authHeader string
+		wantStatus int
+	}{
+		{"NoCredentials", "", http.StatusUnauthorized},
+		{"UnknownToken", "Bearer unknown", http.StatusUnauthorized},
+		{"MissingRole", "Bearer readerToken", http.StatusForbidden},
+		{"TenantFromToken", "Bearer deployerToken", http.StatusOK},
+		{"RoleFromUsersRoles", "bearer adminToken", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/deployments", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tt.wantStatus, resp.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				require.NotEmpty(t, resp.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+	require.NotNil(t, identity)
+	require.Equal(t, "alice", identity.Name)
+	require.Empty(t, identity.Tenant)
+
+	req := httptest.NewRequest("POST", "/deployments", nil)
+	req.Header.Set("Authorization", "Bearer deployerToken")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, "dave", identity.Name)
+	require.Equal(t, "team1", identity.Tenant)
+
+	// Authentication disabled
+	s = &Server{}
+	req = httptest.NewRequest("DELETE", "/deployments/dep", nil)
+	resp := httptest.NewRecorder()
+	s.authHandler(RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "yorc-jwt")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	authCfg := config.Auth{
+		OIDC: config.OIDC{
+			Issuer:   "https://idp.example.com",
+			Audience: "yorc",
+			JWKSFile: writeJWKS(t, dir, rsaKey, ecKey),
+		},
+		UsersRoles:   map[string][]string{"carol": {RoleHostsPoolManager}},
+		UsersTenants: map[string]string{"carol": "team1"},
+	}
+	a, err := newJWTAuthenticator(authCfg)
+	require.NoError(t, err)
+
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":    "https://idp.example.com",
+			"aud":    []string{"other", "yorc"},
+			"sub":    "carol",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"roles":  []string{RoleOperator},
+			"tenant": "team2",
+		}
+	}
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"RSAValid", signJWT(t, "RS256", "rsa1", rsaKey, validClaims()), false},
+		{"ECValid", signJWT(t, "ES256", "ec1", ecKey, validClaims()), false},
+		{"NoKid", signJWT(t, "RS256", "", rsaKey, validClaims()), false},
+		{"StringAudience", signJWT(t, "RS256", "rsa1", rsaKey, withClaim("aud", "yorc")), false},
+		{"TenantFromUsersTenants", signJWT(t, "RS256", "rsa1", rsaKey, withClaim("tenant", nil)), false},
+		{"UnknownKey", signJWT(t, "RS256", "rsa1", otherKey, validClaims()), true},
+		{"UnknownKid", signJWT(t, "RS256", "rsa2", rsaKey, validClaims()), true},
+		{"WrongAlgorithm", signJWT(t, "ES256", "rsa1", ecKey, validClaims()), true},
+		{"Expired", signJWT(t, "RS256", "rsa1", rsaKey, withClaim("exp", time.Now().Add(-time.Hour).Unix())), true},
+		{"NoExpiration", signJWT(t, "RS256", "rsa1", rsaKey, withClaim("exp", nil)), true},
+		{"NotYetValid", signJWT(t, "RS256", "rsa1", rsaKey, withClaim("nbf", time.Now().Add(time.Hour).Unix())), true},
+		{"WrongIssuer", signJWT(t, "RS256", "rsa1", rsaKey, withClaim("iss", "https://other.example.com")), true},
+		{"WrongAudience", signJWT(t, "RS256", "rsa1", rsaKey, withClaim("aud", "other")), true},
+		{"NoSubject", signJWT(t, "RS256", "rsa1", rsaKey, withClaim("sub", nil)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/deployments", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			identity, err := a.Authenticate(req)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, identity)
+			require.Equal(t, "carol", identity.Name)
+			require.True(t, identity.HasRole(RoleOperator))
+			require.True(t, identity.HasRole(RoleHostsPoolManager))
+			require.False(t, identity.HasRole(RoleDeployer))
+			if tt.name == "TenantFromUsersTenants" {
+				require.Equal(t, "team1", identity.Tenant)
+			} else {
+				require.Equal(t, "team2", identity.Tenant)
+			}
+		})
+	}
+
+	// Static tokens are not handled by this authenticator
+	req := httptest.NewRequest("GET", "/deployments", nil)
+	req.Header.Set("Authorization", "Bearer staticToken")
+	identity, err := a.Authenticate(req)
+	require.NoError(t, err)
+	require.Nil(t, identity)
+}
+
+func TestNewAuthenticatorsClientCertificatesRequireTLS(t *testing.T) {
+	t.Parallel()
+	_, err := newAuthenticators(config.Configuration{Auth: config.Auth{ClientCertificates: true}})
+	require.Error(t, err)
+
+	authenticators, err := newAuthenticators(config.Configuration{CertFile: "cert.pem", KeyFile: "key.pem", SSLVerify: true, Auth: config.Auth{ClientCertificates: true}})
+	require.NoError(t, err)
+	require.Len(t, authenticators, 1)
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/log"
+)
+
+func (s *Server) listGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	deploymentID := params.ByName("id")
+	kv := s.consulClient.KV()
+
+	dExits, err := deployments.DoesDeploymentExists(kv, deploymentID)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+	if !dExits {
+		writeError(w, r, errNotFound)
+		return
+	}
+
+	groups, err := deployments.GetGroups(kv, deploymentID)
+	if err != nil {
+		log.Panic(err)
+	}
+	groupsCol := GroupsCollection{Groups: make([]AtomLink, len(groups))}
+	for i, group := range groups {
+		groupsCol.Groups[i] = newAtomLink(LinkRelGroup, path.Join("/deployments", deploymentID, "groups", group))
+	}
+	encodeJSONResponse(w, r, groupsCol)
+}
+
+func (s *Server) getGroupHandler(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	deploymentID := params.ByName("id")
+	groupName := params.ByName("groupName")
+	kv := s.consulClient.KV()
+
+	exists, err := deployments.DoesGroupExist(kv, deploymentID, groupName)
+	if err != nil {
+		log.Panic(err)
+	}
+	if !exists {
+		writeError(w, r, newContentNotFoundError(fmt.Sprintf("Group %q", groupName)))
+		return
+	}
+
+	group := Group{Name: groupName, Properties: make(map[string]string)}
+	group.Type, err = deployments.GetGroupType(kv, deploymentID, groupName)
+	if err != nil {
+		log.Panic(err)
+	}
+	group.Description, err = deployments.GetGroupDescription(kv, deploymentID, groupName)
+	if err != nil {
+		log.Panic(err)
+	}
+	group.Members, err = deployments.GetGroupMembers(kv, deploymentID, groupName)
+	if err != nil {
+		log.Panic(err)
+	}
+	propNames, err := deployments.GetGroupPropertiesNames(kv, deploymentID, groupName)
+	if err != nil {
+		log.Panic(err)
+	}
+	for _, propName := range propNames {
+		found, value, err := deployments.GetGroupProperty(kv, deploymentID, groupName, propName)
+		if err != nil {
+			writeError(w, r, newInternalServerError(err))
+			return
+		}
+		if found {
+			group.Properties[propName] = value
+		}
+	}
+	group.Links = []AtomLink{newAtomLink(LinkRelSelf, r.URL.Path), newAtomLink(LinkRelDeployment, path.Join("/deployments", deploymentID))}
+	for _, member := range group.Members {
+		group.Links = append(group.Links, newAtomLink(LinkRelNode, path.Join("/deployments", deploymentID, "nodes", member)))
+	}
+	encodeJSONResponse(w, r, group)
+}
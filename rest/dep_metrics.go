@@ -0,0 +1,64 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/helper/collections"
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/prov/monitoring"
+)
+
+func (s *Server) postNodeInstanceMetricHandler(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	id := params.ByName("id")
+	nodeName := params.ByName("nodeName")
+	instanceID := params.ByName("instanceId")
+	metricName := params.ByName("metricName")
+	kv := s.consulClient.KV()
+
+	instances, err := deployments.GetNodeInstancesIds(kv, id, nodeName)
+	if err != nil {
+		log.Panic(err)
+	}
+	if !collections.ContainsString(instances, instanceID) {
+		writeError(w, r, newContentNotFoundError(fmt.Sprintf("Instance %q for node %q", instanceID, nodeName)))
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Panic(err)
+	}
+	var metric MetricRequest
+	if err = json.Unmarshal(body, &metric); err != nil {
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
+
+	if err = monitoring.StoreInstanceMetric(kv, id, nodeName, instanceID, metricName, metric.Value); err != nil {
+		log.Panic(err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/log"
+)
+
+func (s *Server) listPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	deploymentID := params.ByName("id")
+	kv := s.consulClient.KV()
+
+	dExits, err := deployments.DoesDeploymentExists(kv, deploymentID)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+	if !dExits {
+		writeError(w, r, errNotFound)
+		return
+	}
+
+	policies, err := deployments.GetPolicies(kv, deploymentID)
+	if err != nil {
+		log.Panic(err)
+	}
+	policiesCol := PoliciesCollection{Policies: make([]AtomLink, len(policies))}
+	for i, policy := range policies {
+		policiesCol.Policies[i] = newAtomLink(LinkRelPolicy, path.Join("/deployments", deploymentID, "policies", policy))
+	}
+	encodeJSONResponse(w, r, policiesCol)
+}
+
+func (s *Server) getPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	deploymentID := params.ByName("id")
+	policyName := params.ByName("policyName")
+	kv := s.consulClient.KV()
+
+	exists, err := deployments.DoesPolicyExist(kv, deploymentID, policyName)
+	if err != nil {
+		log.Panic(err)
+	}
+	if !exists {
+		writeError(w, r, newContentNotFoundError(fmt.Sprintf("Policy %q", policyName)))
+		return
+	}
+
+	policy := Policy{Name: policyName, Properties: make(map[string]string)}
+	policy.Type, err = deployments.GetPolicyType(kv, deploymentID, policyName)
+	if err != nil {
+		log.Panic(err)
+	}
+	policy.Description, err = deployments.GetPolicyDescription(kv, deploymentID, policyName)
+	if err != nil {
+		log.Panic(err)
+	}
+	policy.Targets, err = deployments.GetPolicyTargets(kv, deploymentID, policyName)
+	if err != nil {
+		log.Panic(err)
+	}
+	propNames, err := deployments.GetPolicyPropertiesNames(kv, deploymentID, policyName)
+	if err != nil {
+		log.Panic(err)
+	}
+	for _, propName := range propNames {
+		found, value, err := deployments.GetPolicyProperty(kv, deploymentID, policyName, propName)
+		if err != nil {
+			writeError(w, r, newInternalServerError(err))
+			return
+		}
+		if found {
+			policy.Properties[propName] = value
+		}
+	}
+	policy.Links = []AtomLink{newAtomLink(LinkRelSelf, r.URL.Path), newAtomLink(LinkRelDeployment, path.Join("/deployments", deploymentID))}
+	for _, target := range policy.Targets {
+		isGroup, err := deployments.DoesGroupExist(kv, deploymentID, target)
+		if err != nil {
+			log.Panic(err)
+		}
+		if isGroup {
+			policy.Links = append(policy.Links, newAtomLink(LinkRelGroup, path.Join("/deployments", deploymentID, "groups", target)))
+		} else {
+			policy.Links = append(policy.Links, newAtomLink(LinkRelNode, path.Join("/deployments", deploymentID, "nodes", target)))
+		}
+	}
+	encodeJSONResponse(w, r, policy)
+}
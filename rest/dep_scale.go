@@ -81,8 +81,11 @@ func (s *Server) scaleHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Debugf("Scaling %d instances of node %q", instancesDelta, nodeName)
 	var taskID string
-	if instancesDelta > 0 {
-		taskID, err = s.scaleOut(id, nodeName, uint32(instancesDelta))
+	_, rollback := r.URL.Query()["rollback"]
+	if _, queue := r.URL.Query()["queue"]; queue {
+		taskID, err = s.queueScaling(id, nodeName, instancesDelta, rollback)
+	} else if instancesDelta > 0 {
+		taskID, err = s.scaleOut(id, nodeName, uint32(instancesDelta), rollback)
 	} else {
 		taskID, err = s.scaleIn(id, nodeName, uint32(-instancesDelta))
 	}
@@ -101,7 +104,27 @@ func (s *Server) scaleHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusAccepted)
 }
 
-func (s *Server) scaleOut(id, nodeName string, instancesDelta uint32) (string, error) {
+// queueScaling queues a scaling task
+//
+// Instances to create or to remove are computed by the task itself when it runs as previous tasks
+// may change the deployment in the meantime.
+func (s *Server) queueScaling(id, nodeName string, instancesDelta int, rollback bool) (string, error) {
+	taskType := tasks.ScaleOut
+	if instancesDelta < 0 {
+		taskType = tasks.ScaleIn
+		instancesDelta = -instancesDelta
+	}
+	data := map[string]string{
+		"scaling/nodeName":       nodeName,
+		"scaling/instancesDelta": strconv.Itoa(instancesDelta),
+	}
+	if rollback && taskType == tasks.ScaleOut {
+		data["rollbackOnFailure"] = strconv.FormatBool(true)
+	}
+	return s.tasksCollector.QueueTaskWithData(id, taskType, data)
+}
+
+func (s *Server) scaleOut(id, nodeName string, instancesDelta uint32, rollback bool) (string, error) {
 	kv := s.consulClient.KV()
 	maxInstances, err := deployments.GetMaxNbInstancesForNode(kv, id, nodeName)
 	if err != nil {
@@ -120,6 +143,13 @@ func (s *Server) scaleOut(id, nodeName string, instancesDelta uint32) (string, e
 		}
 	}
 
+	if err = deployments.CheckTenantQuotasForScaleOut(kv, s.config, id, nodeName, int(instancesDelta)); err != nil {
+		if deployments.IsTenantQuotaExceededError(err) {
+			return "", newQuotaExceededError(err)
+		}
+		return "", err
+	}
+
 	instancesByNodes, err := deployments.CreateNewNodeStackInstances(kv, id, nodeName, int(instancesDelta))
 	if err != nil {
 		return "", err
@@ -129,6 +159,9 @@ func (s *Server) scaleOut(id, nodeName string, instancesDelta uint32) (string, e
 	for scalableNode, nodeInstances := range instancesByNodes {
 		data[path.Join("nodes", scalableNode)] = nodeInstances
 	}
+	if rollback {
+		data["rollbackOnFailure"] = strconv.FormatBool(true)
+	}
 	return s.tasksCollector.RegisterTaskWithData(id, tasks.ScaleOut, data)
 }
 
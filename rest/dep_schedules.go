@@ -0,0 +1,177 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/helper/collections"
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/scheduling"
+)
+
+// readScheduleRequest decodes a schedule definition from a request body and checks that its target exists
+//
+// An error response is written if the schedule is not valid.
+func (s *Server) readScheduleRequest(w http.ResponseWriter, r *http.Request, deploymentID string) (scheduling.Schedule, bool) {
+	var schedule scheduling.Schedule
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Panic(err)
+	}
+	if err = json.Unmarshal(body, &schedule); err != nil {
+		writeError(w, r, newBadRequestError(err))
+		return schedule, false
+	}
+	schedule.DeploymentID = deploymentID
+	if err = schedule.Validate(); err != nil {
+		writeError(w, r, newBadRequestError(err))
+		return schedule, false
+	}
+	kv := s.consulClient.KV()
+	if schedule.WorkflowName != "" {
+		workflows, err := deployments.GetWorkflows(kv, deploymentID)
+		if err != nil {
+			log.Panic(err)
+		}
+		if !collections.ContainsString(workflows, schedule.WorkflowName) {
+			writeError(w, r, newBadRequestMessage(fmt.Sprintf("workflow %q not found", schedule.WorkflowName)))
+			return schedule, false
+		}
+		return schedule, true
+	}
+	exists, err := deployments.DoesNodeExist(kv, deploymentID, schedule.NodeName)
+	if err != nil {
+		log.Panic(err)
+	}
+	if !exists {
+		writeError(w, r, newBadRequestMessage(fmt.Sprintf("node %q not found", schedule.NodeName)))
+		return schedule, false
+	}
+	nodeType, err := deployments.GetNodeType(kv, deploymentID, schedule.NodeName)
+	if err != nil {
+		log.Panic(err)
+	}
+	_, err = deployments.GetTypeImplementingAnOperation(kv, deploymentID, nodeType, "custom."+schedule.CustomCommandName)
+	if err != nil {
+		if deployments.IsOperationNotImplemented(err) {
+			writeError(w, r, newBadRequestMessage(fmt.Sprintf("custom command %q not found for node %q", schedule.CustomCommandName, schedule.NodeName)))
+			return schedule, false
+		}
+		log.Panic(err)
+	}
+	return schedule, true
+}
+
+// checkDeploymentExists writes a not found error response if the deployment of the request does not exist
+func (s *Server) checkDeploymentExists(w http.ResponseWriter, r *http.Request, deploymentID string) bool {
+	exists, err := deployments.DoesDeploymentExists(s.consulClient.KV(), deploymentID)
+	if err != nil {
+		log.Panic(err)
+	}
+	if !exists {
+		writeError(w, r, errNotFound)
+	}
+	return exists
+}
+
+func (s *Server) newScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	deploymentID := params.ByName("id")
+	if !s.checkDeploymentExists(w, r, deploymentID) {
+		return
+	}
+	schedule, ok := s.readScheduleRequest(w, r, deploymentID)
+	if !ok {
+		return
+	}
+	id, err := scheduling.CreateSchedule(s.consulClient.KV(), schedule)
+	if err != nil {
+		log.Panic(err)
+	}
+	w.Header().Set("Location", fmt.Sprintf("/deployments/%s/schedules/%s", deploymentID, id))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) updateScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	deploymentID := params.ByName("id")
+	if !s.checkDeploymentExists(w, r, deploymentID) {
+		return
+	}
+	schedule, ok := s.readScheduleRequest(w, r, deploymentID)
+	if !ok {
+		return
+	}
+	schedule.ID = params.ByName("scheduleId")
+	err := scheduling.UpdateSchedule(s.consulClient.KV(), schedule)
+	if err != nil {
+		if scheduling.IsScheduleNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return
+		}
+		log.Panic(err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) getScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	schedule, err := scheduling.GetSchedule(s.consulClient.KV(), params.ByName("id"), params.ByName("scheduleId"))
+	if err != nil {
+		if scheduling.IsScheduleNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return
+		}
+		log.Panic(err)
+	}
+	encodeJSONResponse(w, r, schedule)
+}
+
+func (s *Server) listSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	deploymentID := params.ByName("id")
+	if !s.checkDeploymentExists(w, r, deploymentID) {
+		return
+	}
+	schedules, err := scheduling.ListSchedules(s.consulClient.KV(), deploymentID)
+	if err != nil {
+		log.Panic(err)
+	}
+	if len(schedules) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	encodeJSONResponse(w, r, SchedulesCollection{Schedules: schedules})
+}
+
+func (s *Server) deleteScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	err := scheduling.DeleteSchedule(s.consulClient.KV(), params.ByName("id"), params.ByName("scheduleId"))
+	if err != nil {
+		if scheduling.IsScheduleNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return
+		}
+		log.Panic(err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -17,6 +17,7 @@ package rest
 import (
 	"log"
 	"net/http"
+	"sort"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
@@ -51,6 +52,56 @@ func (s *Server) tasksPreChecks(w http.ResponseWriter, r *http.Request, id, task
 	return true
 }
 
+// registerTask registers a new task for a deployment
+//
+// If the queue parameter is set and other tasks are living for this deployment, the task is queued
+// instead of being rejected.
+func (s *Server) registerTask(r *http.Request, deploymentID string, taskType tasks.TaskType, data map[string]string) (string, error) {
+	if _, ok := r.URL.Query()["queue"]; ok {
+		return s.tasksCollector.QueueTaskWithData(deploymentID, taskType, data)
+	}
+	return s.tasksCollector.RegisterTaskWithData(deploymentID, taskType, data)
+}
+
+func (s *Server) listTasksHandler(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	id := params.ByName("id")
+	kv := s.consulClient.KV()
+	if !s.checkDeploymentExists(w, r, id) {
+		return
+	}
+
+	tasksIDs, err := tasks.GetTasksIdsForTarget(kv, id)
+	if err != nil {
+		log.Panic(err)
+	}
+	tasksCol := DeploymentTasksCollection{Tasks: make([]DeploymentTask, 0, len(tasksIDs))}
+	for _, taskID := range tasksIDs {
+		task := DeploymentTask{Task: Task{ID: taskID, TargetID: id}}
+		status, err := tasks.GetTaskStatus(kv, taskID)
+		if err != nil {
+			log.Panic(err)
+		}
+		task.Status = status.String()
+		taskType, err := tasks.GetTaskType(kv, taskID)
+		if err != nil {
+			log.Panic(err)
+		}
+		task.Type = taskType.String()
+		task.CreationDate, err = tasks.GetTaskCreationDate(kv, taskID)
+		if err != nil {
+			log.Panic(err)
+		}
+		tasksCol.Tasks = append(tasksCol.Tasks, task)
+	}
+	sort.SliceStable(tasksCol.Tasks, func(i, j int) bool {
+		return tasksCol.Tasks[i].CreationDate.Before(tasksCol.Tasks[j].CreationDate)
+	})
+	encodeJSONResponse(w, r, tasksCol)
+}
+
 func (s *Server) cancelTaskHandler(w http.ResponseWriter, r *http.Request) {
 	var params httprouter.Params
 	ctx := r.Context()
@@ -64,7 +115,7 @@ func (s *Server) cancelTaskHandler(w http.ResponseWriter, r *http.Request) {
 
 	if taskStatus, err := tasks.GetTaskStatus(kv, taskID); err != nil {
 		log.Panic(err)
-	} else if taskStatus != tasks.RUNNING && taskStatus != tasks.INITIAL {
+	} else if taskStatus != tasks.RUNNING && taskStatus != tasks.INITIAL && taskStatus != tasks.QUEUED {
 		writeError(w, r, newBadRequestError(errors.Errorf("Cannot cancel a task with status %q", taskStatus.String())))
 		return
 	}
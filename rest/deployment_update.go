@@ -0,0 +1,133 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/tasks"
+)
+
+// updateDeployment handles the upload of a new version of an existing deployment
+//
+// The new archive is stored in a dedicated update directory next to the current one and an update task applying the
+// differences between both topologies is created.
+func (s *Server) updateDeployment(w http.ResponseWriter, r *http.Request, id string) {
+	kv := s.consulClient.KV()
+	status, err := deployments.GetDeploymentStatus(kv, id)
+	if err != nil {
+		log.Panic(err)
+	}
+	if status != deployments.DEPLOYED && status != deployments.UPDATE_FAILED {
+		writeError(w, r, newConflictRequest(fmt.Sprintf("Deployment with id %q can't be updated in status %q", id, status.String())))
+		return
+	}
+	// Reject the update before touching the deployment directory if another task is running or queued
+	hasLivingTask, livingTaskID, livingTaskStatus, err := tasks.TargetHasLivingTasks(kv, id)
+	if err != nil {
+		log.Panic(err)
+	}
+	if hasLivingTask {
+		writeError(w, r, newConflictRequest(fmt.Sprintf("Deployment with id %q can't be updated while task %q is in status %q", id, livingTaskID, livingTaskStatus)))
+		return
+	}
+	updateOperation := r.URL.Query().Get("update_operation")
+	if updateOperation == "" {
+		updateOperation = deployments.DefaultUpdateOperation
+	}
+	log.Printf("Analyzing update of deployment %s\n", id)
+
+	uploadPath := filepath.Join(s.config.WorkingDirectory, "deployments", id)
+	// Each update is staged in its own directory so that concurrent requests can't interfere with a queued update
+	updateDirectory := "update-" + fmt.Sprint(uuid.NewV4())
+	updatePath := filepath.Join(uploadPath, updateDirectory)
+	removeUpdate := func() {
+		if err := os.RemoveAll(updatePath); err != nil {
+			log.Panic(err)
+		}
+	}
+	updatedDefPath, inputs, err := readDeploymentRequest(r, updatePath)
+	if err != nil {
+		removeUpdate()
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
+
+	updated, err := deployments.ReadTopology(updatedDefPath)
+	if err != nil {
+		removeUpdate()
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
+	previousInputs, err := deployments.GetInputsWithValue(kv, id)
+	if err != nil {
+		log.Panic(err)
+	}
+	if err = deployments.ValidateInputValues(updated, inputs, previousInputs...); err != nil {
+		removeUpdate()
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
+	diff, err := deployments.DiffDeploymentDefinitions(rootDefinitionPath(filepath.Join(uploadPath, "overlay")), updatedDefPath, inputs)
+	if err != nil {
+		removeUpdate()
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
+	if diff.IsEmpty() {
+		log.Debugf("No changes in the topology nor in the inputs of deployment %q, nothing to update", id)
+		removeUpdate()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	diffData, err := json.Marshal(diff)
+	if err != nil {
+		log.Panic(err)
+	}
+	data := map[string]string{
+		"topologyDiff":    string(diffData),
+		"updateOperation": updateOperation,
+		"definitionFile":  filepath.Base(updatedDefPath),
+		"updateDirectory": updateDirectory,
+	}
+	if len(inputs) > 0 {
+		inputsData, err := deployments.MarshalInputValues(inputs)
+		if err != nil {
+			log.Panic(err)
+		}
+		data["inputs"] = string(inputsData)
+	}
+	setRollbackOnFailure(r, data)
+	taskID, err := s.tasksCollector.RegisterTaskWithData(id, tasks.Update, data)
+	if err != nil {
+		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); ok {
+			removeUpdate()
+			writeError(w, r, newConflictRequest(err.Error()))
+			return
+		}
+		log.Panic(err)
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/deployments/%s/tasks/%s", id, taskID))
+	w.WriteHeader(http.StatusCreated)
+}
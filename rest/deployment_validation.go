@@ -0,0 +1,61 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/julienschmidt/httprouter"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/log"
+)
+
+// deploymentValidationID is the reserved deployment id segment of the validation endpoint
+//
+// httprouter does not allow a static path segment to share its position with the :id wildcard, so
+// POST /deployments/validate is routed as POST /deployments/:id. This id can't be used to submit a deployment.
+const deploymentValidationID = "validate"
+
+// validateDeploymentHandler validates a CSAR without storing it
+//
+// The archive is extracted into a temporary directory which is removed once the validation report is computed.
+func (s *Server) validateDeploymentHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	if params.ByName("id") != deploymentValidationID {
+		// Only the validation endpoint accepts POST on a deployment resource
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	validationPath := filepath.Join(s.config.WorkingDirectory, "validations", fmt.Sprint(uuid.NewV4()))
+	defer func() {
+		if err := os.RemoveAll(validationPath); err != nil {
+			log.Printf("Failed to remove validation directory %q: %v", validationPath, err)
+		}
+	}()
+	rootDefPath, inputs, err := readDeploymentRequest(r, validationPath)
+	if err != nil {
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
+	report := deployments.ValidateDeploymentDefinition(rootDefPath, inputs)
+	encodeJSONResponse(w, r, report)
+}
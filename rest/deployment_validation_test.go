@@ -0,0 +1,111 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/deployments"
+)
+
+func zipDirectory(t *testing.T, dir string) []byte {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		f, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(content)
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestValidateDeploymentHandler(t *testing.T) {
+	t.Parallel()
+	workDir, err := ioutil.TempDir("", "yorc-validation-")
+	require.NoError(t, err)
+	defer os.RemoveAll(workDir)
+	s := &Server{config: config.Configuration{WorkingDirectory: workDir}}
+
+	// Build an archive missing the imported types
+	csarDir, err := ioutil.TempDir("", "yorc-validation-csar-")
+	require.NoError(t, err)
+	defer os.RemoveAll(csarDir)
+	content, err := ioutil.ReadFile("../deployments/testdata/validation/invalid.yaml")
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(csarDir, "topology.yaml"), content, 0644))
+
+	newRequest := func(id string) *http.Request {
+		req := httptest.NewRequest("POST", "/deployments/"+id, bytes.NewReader(zipDirectory(t, csarDir)))
+		req.Header.Set("Content-Type", "application/zip")
+		return req.WithContext(context.WithValue(req.Context(), paramsLookupKey, httprouter.Params{{Key: "id", Value: id}}))
+	}
+
+	rec := httptest.NewRecorder()
+	s.validateDeploymentHandler(rec, newRequest("myDeployment"))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	// The validation endpoint can't be shadowed by a deployment
+	req := httptest.NewRequest("PUT", "/deployments/"+deploymentValidationID, bytes.NewReader(zipDirectory(t, csarDir)))
+	req.Header.Set("Content-Type", "application/zip")
+	req = req.WithContext(context.WithValue(req.Context(), paramsLookupKey, httprouter.Params{{Key: "id", Value: deploymentValidationID}}))
+	rec = httptest.NewRecorder()
+	s.newDeploymentHandler(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.validateDeploymentHandler(rec, newRequest(deploymentValidationID))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var report deployments.ValidationReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	require.False(t, report.Valid)
+	require.Contains(t, report.Errors, deployments.ValidationIssue{
+		Location: "imports/imports/types.yaml",
+		Message:  `imported definition "imports/types.yaml" not found in archive`,
+	})
+
+	// Nothing should be kept once validated
+	validations, err := ioutil.ReadDir(filepath.Join(workDir, "validations"))
+	require.NoError(t, err)
+	require.Len(t, validations, 0)
+}
@@ -18,10 +18,13 @@ import (
 	"archive/zip"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"regexp"
@@ -32,9 +35,11 @@ import (
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/helper/collections"
 	"github.com/ystia/yorc/helper/consulutil"
 	"github.com/ystia/yorc/log"
 	"github.com/ystia/yorc/tasks"
+	"github.com/ystia/yorc/tosca"
 )
 
 func extractFile(f *zip.File, path string) {
@@ -55,50 +60,11 @@ func extractFile(f *zip.File, path string) {
 	}
 }
 
-func (s *Server) newDeploymentHandler(w http.ResponseWriter, r *http.Request) {
-
-	var uid string
-	if r.Method == http.MethodPut {
-		var params httprouter.Params
-		ctx := r.Context()
-		params = ctx.Value(paramsLookupKey).(httprouter.Params)
-		id := params.ByName("id")
-		id, err := url.QueryUnescape(id)
-		if err != nil {
-			log.Panicf("%v", errors.Wrapf(err, "Failed to unescape given deployment id %q", id))
-		}
-		matched, err := regexp.MatchString(YorcDeploymentIDPattern, id)
-		if err != nil {
-			log.Panicf("%v", errors.Wrapf(err, "Failed to parse given deployment id %q", id))
-		}
-		if !matched {
-			writeError(w, r, newBadRequestError(errors.Errorf("Deployment id should respect the following format: %q", YorcDeploymentIDPattern)))
-			return
-		}
-		// Do not impose a max id length as it doesn't have a concrete impact for now
-		// if len(id) > YorcDeploymentIDMaxLength {
-		// 	writeError(w, r, newBadRequestError(errors.Errorf("Deployment id should be less than %d characters (actual size %d)", YorcDeploymentIDMaxLength, len(id))))
-		// 	return
-		// }
-		dExits, err := deployments.DoesDeploymentExists(s.consulClient.KV(), id)
-		if err != nil {
-			log.Panicf("%v", err)
-		}
-		if dExits {
-			mess := fmt.Sprintf("Deployment with id %q already exists", id)
-			log.Debugf("[ERROR]: %s", mess)
-			writeError(w, r, newConflictRequest(mess))
-			return
-		}
-		uid = id
-	} else {
-		uid = fmt.Sprint(uuid.NewV4())
-	}
-	log.Printf("Analyzing deployment %s\n", uid)
-
+// extractDeploymentArchive stores a CSAR as deployment.zip into the given directory, extracts it into an overlay
+// sub-directory and returns the path of the root YAML definition
+func extractDeploymentArchive(archive io.Reader, uploadPath string) string {
 	var err error
 	var file *os.File
-	uploadPath := filepath.Join(s.config.WorkingDirectory, "deployments", uid)
 	if err = os.MkdirAll(uploadPath, 0775); err != nil {
 		log.Panicf("%+v", err)
 	}
@@ -108,8 +74,9 @@ func (s *Server) newDeploymentHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Panicf("%+v", err)
 	}
+	defer file.Close()
 
-	_, err = io.Copy(file, r.Body)
+	_, err = io.Copy(file, archive)
 	if err != nil {
 		log.Panicf("%+v", err)
 	}
@@ -138,6 +105,76 @@ func (s *Server) newDeploymentHandler(w http.ResponseWriter, r *http.Request) {
 		extractFile(f, fPath)
 	}
 
+	return rootDefinitionPath(destDir)
+}
+
+// deploymentInputsFiles are the names of the optional documents at the root of a deployment archive defining inputs values
+var deploymentInputsFiles = []string{"inputs.yaml", "inputs.yml"}
+
+// readDeploymentRequest extracts the CSAR of a deployment request into uploadPath and returns the path of its root
+// YAML definition and the input values supplied at deployment time
+//
+// The CSAR is either the request body or the "csar" part of a multipart request. Input values are read from an inputs
+// document at the root of the archive and from the "inputs" part of a multipart request which takes precedence.
+func readDeploymentRequest(r *http.Request, uploadPath string) (string, map[string]*tosca.ValueAssignment, error) {
+	var rootDefPath string
+	var requestInputs map[string]*tosca.ValueAssignment
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return "", nil, errors.Wrap(err, "invalid multipart request")
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", nil, errors.Wrap(err, "invalid multipart request")
+			}
+			switch part.FormName() {
+			case "csar":
+				rootDefPath = extractDeploymentArchive(part, uploadPath)
+			case "inputs":
+				data, err := ioutil.ReadAll(part)
+				if err != nil {
+					return "", nil, errors.Wrap(err, "failed to read inputs")
+				}
+				if requestInputs, err = deployments.ParseInputValues(data); err != nil {
+					return "", nil, err
+				}
+			}
+			part.Close()
+		}
+		if rootDefPath == "" {
+			return "", nil, errors.New(`missing "csar" part in multipart request`)
+		}
+	} else {
+		rootDefPath = extractDeploymentArchive(r.Body, uploadPath)
+	}
+
+	inputs := make(map[string]*tosca.ValueAssignment)
+	for _, fileName := range deploymentInputsFiles {
+		data, err := ioutil.ReadFile(filepath.Join(filepath.Dir(rootDefPath), fileName))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			log.Panic(err)
+		}
+		if inputs, err = deployments.ParseInputValues(data); err != nil {
+			return "", nil, errors.Wrapf(err, "invalid inputs document %q", fileName)
+		}
+		break
+	}
+	for name, value := range requestInputs {
+		inputs[name] = value
+	}
+	return rootDefPath, inputs, nil
+}
+
+// rootDefinitionPath returns the path of the single YAML definition at the root of an extracted deployment archive
+func rootDefinitionPath(overlayPath string) string {
 	patterns := []struct {
 		pattern string
 	}{
@@ -146,23 +183,104 @@ func (s *Server) newDeploymentHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	var yamlList []string
 	for _, pattern := range patterns {
-		if yamls, err := filepath.Glob(filepath.Join(destDir, pattern.pattern)); err != nil {
+		if yamls, err := filepath.Glob(filepath.Join(overlayPath, pattern.pattern)); err != nil {
 			log.Panicf("%+v", err)
 		} else {
-			yamlList = append(yamlList, yamls...)
+			for _, yamlPath := range yamls {
+				if !collections.ContainsString(deploymentInputsFiles, filepath.Base(yamlPath)) {
+					yamlList = append(yamlList, yamlPath)
+				}
+			}
 		}
 	}
 	if len(yamlList) != 1 {
 		log.Panic("One and only one YAML (.yml or .yaml) file should be present at the root of deployment archive")
 	}
+	return yamlList[0]
+}
 
-	if err := deployments.StoreDeploymentDefinition(r.Context(), s.consulClient.KV(), uid, yamlList[0]); err != nil {
-		log.Debugf("ERROR: %+v", err)
+func (s *Server) newDeploymentHandler(w http.ResponseWriter, r *http.Request) {
+
+	var uid string
+	if r.Method == http.MethodPut {
+		var params httprouter.Params
+		ctx := r.Context()
+		params = ctx.Value(paramsLookupKey).(httprouter.Params)
+		id := params.ByName("id")
+		id, err := url.QueryUnescape(id)
+		if err != nil {
+			log.Panicf("%v", errors.Wrapf(err, "Failed to unescape given deployment id %q", id))
+		}
+		matched, err := regexp.MatchString(YorcDeploymentIDPattern, id)
+		if err != nil {
+			log.Panicf("%v", errors.Wrapf(err, "Failed to parse given deployment id %q", id))
+		}
+		if !matched {
+			writeError(w, r, newBadRequestError(errors.Errorf("Deployment id should respect the following format: %q", YorcDeploymentIDPattern)))
+			return
+		}
+		if id == deploymentValidationID {
+			writeError(w, r, newBadRequestError(errors.Errorf("Deployment id %q is reserved", id)))
+			return
+		}
+		// Do not impose a max id length as it doesn't have a concrete impact for now
+		// if len(id) > YorcDeploymentIDMaxLength {
+		// 	writeError(w, r, newBadRequestError(errors.Errorf("Deployment id should be less than %d characters (actual size %d)", YorcDeploymentIDMaxLength, len(id))))
+		// 	return
+		// }
+		dExits, err := deployments.DoesDeploymentExists(s.consulClient.KV(), id)
+		if err != nil {
+			log.Panicf("%v", err)
+		}
+		if dExits {
+			s.updateDeployment(w, r, id)
+			return
+		}
+		uid = id
+	} else {
+		uid = fmt.Sprint(uuid.NewV4())
+	}
+	log.Printf("Analyzing deployment %s\n", uid)
+
+	uploadPath := filepath.Join(s.config.WorkingDirectory, "deployments", uid)
+	rootDefPath, inputs, err := readDeploymentRequest(r, uploadPath)
+	if err == nil {
+		err = deployments.StoreDeploymentDefinitionWithInputs(r.Context(), s.consulClient.KV(), uid, rootDefPath, inputs)
+	}
+	if err != nil {
+		if !deployments.IsInvalidInputsError(err) && rootDefPath != "" {
+			log.Debugf("ERROR: %+v", err)
+			log.Panic(err)
+		}
+		log.Printf("Rejecting deployment %s: %v", uid, err)
+		if err := os.RemoveAll(uploadPath); err != nil {
+			log.Panic(err)
+		}
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
+	tenant := requestTenantOrDefault(r)
+	if err := deployments.SetDeploymentTenant(s.consulClient.KV(), uid, tenant); err != nil {
 		log.Panic(err)
 	}
+	if err := deployments.CheckTenantQuotas(s.consulClient.KV(), s.config, tenant, 0); err != nil {
+		if !deployments.IsTenantQuotaExceededError(err) {
+			log.Panic(err)
+		}
+		log.Printf("Rejecting deployment %s: %v", uid, err)
+		if _, err := s.consulClient.KV().DeleteTree(path.Join(consulutil.DeploymentKVPrefix, uid), nil); err != nil {
+			log.Panic(err)
+		}
+		if err := os.RemoveAll(uploadPath); err != nil {
+			log.Panic(err)
+		}
+		writeError(w, r, newQuotaExceededError(err))
+		return
+	}
 	data := map[string]string{
 		"workflowName": "install",
 	}
+	setRollbackOnFailure(r, data)
 	taskID, err := s.tasksCollector.RegisterTaskWithData(uid, tasks.Deploy, data)
 	if err != nil {
 		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); ok {
@@ -176,6 +294,13 @@ func (s *Server) newDeploymentHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
+// setRollbackOnFailure requests a task to be rolled back on failure if the rollback parameter is set
+func setRollbackOnFailure(r *http.Request, data map[string]string) {
+	if _, ok := r.URL.Query()["rollback"]; ok {
+		data["rollbackOnFailure"] = strconv.FormatBool(true)
+	}
+}
+
 func (s *Server) deleteDeploymentHandler(w http.ResponseWriter, r *http.Request) {
 	var params httprouter.Params
 	ctx := r.Context()
@@ -212,7 +337,7 @@ func (s *Server) deleteDeploymentHandler(w http.ResponseWriter, r *http.Request)
 	data := map[string]string{
 		"workflowName": "uninstall",
 	}
-	if taskID, err := s.tasksCollector.RegisterTaskWithData(id, taskType, data); err != nil {
+	if taskID, err := s.registerTask(r, id, taskType, data); err != nil {
 		log.Debugln("register task err" + err.Error())
 		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); ok {
 			log.Debugln("another task is living")
@@ -277,10 +402,13 @@ func (s *Server) listDeploymentsHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	depCol := DeploymentsCollection{Deployments: make([]Deployment, len(depPaths))}
+	depCol := DeploymentsCollection{Deployments: make([]Deployment, 0, len(depPaths))}
 	depPrefix := consulutil.DeploymentKVPrefix + "/"
-	for depIndex, depPath := range depPaths {
+	for _, depPath := range depPaths {
 		deploymentID := strings.TrimRight(strings.TrimPrefix(depPath, depPrefix), "/ ")
+		if !s.isDeploymentAccessible(r, deploymentID) {
+			continue
+		}
 		status, err := deployments.GetDeploymentStatus(kv, deploymentID)
 		if err != nil {
 			if deployments.IsDeploymentNotFoundError(err) {
@@ -290,11 +418,15 @@ func (s *Server) listDeploymentsHandler(w http.ResponseWriter, r *http.Request)
 				log.Panic(err)
 			}
 		}
-		depCol.Deployments[depIndex] = Deployment{
+		depCol.Deployments = append(depCol.Deployments, Deployment{
 			ID:     deploymentID,
 			Status: status.String(),
 			Links:  []AtomLink{newAtomLink(LinkRelDeployment, "/deployments/"+deploymentID)},
-		}
+		})
+	}
+	if len(depCol.Deployments) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
 	}
 	encodeJSONResponse(w, r, depCol)
 }
@@ -42,8 +42,9 @@ func writeError(w http.ResponseWriter, r *http.Request, err *Error) {
 }
 
 var (
-	errNotFound  = &Error{"not_found", 404, "Not Found", "Requested content not found."}
-	errForbidden = &Error{"forbidden", 401, "Forbidden", "This operation is forbidden."}
+	errNotFound         = &Error{"not_found", 404, "Not Found", "Requested content not found."}
+	errForbidden        = &Error{"forbidden", 401, "Forbidden", "This operation is forbidden."}
+	errMethodNotAllowed = &Error{"method_not_allowed", 405, "Method Not Allowed", "Requested method is not allowed on this resource."}
 )
 
 func newContentNotFoundError(contentName string) *Error {
@@ -77,3 +78,19 @@ func newBadRequestMessage(message string) *Error {
 func newConflictRequest(message string) *Error {
 	return &Error{"conflict", http.StatusConflict, "Conflict", message}
 }
+
+func newUnauthorizedError(message string) *Error {
+	return &Error{"unauthorized", http.StatusUnauthorized, "Unauthorized", message}
+}
+
+func newForbiddenError(role string) *Error {
+	return &Error{"forbidden", http.StatusForbidden, "Forbidden", fmt.Sprintf("This operation requires the %q role.", role)}
+}
+
+func newForbiddenMessage(message string) *Error {
+	return &Error{"forbidden", http.StatusForbidden, "Forbidden", message}
+}
+
+func newQuotaExceededError(err error) *Error {
+	return &Error{"quota_exceeded", http.StatusForbidden, "Quota Exceeded", fmt.Sprint(err)}
+}
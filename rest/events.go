@@ -16,7 +16,9 @@ package rest
 
 import (
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"encoding/json"
@@ -62,6 +64,11 @@ func (s *Server) pollEvents(w http.ResponseWriter, r *http.Request) {
 			timeout = 10 * time.Minute
 		}
 	}
+	filter, err := parseEventsFilter(values)
+	if err != nil {
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
 
 	// If id parameter not set (id == ""), StatusEvents returns events for all the deployments
 	evts, lastIdx, err := events.StatusEvents(kv, id, waitIndex, timeout)
@@ -69,6 +76,8 @@ func (s *Server) pollEvents(w http.ResponseWriter, r *http.Request) {
 		log.Panicf("Can't retrieve events: %v", err)
 	}
 
+	evts = s.filterStatusEvents(r, id, filter, evts)
+
 	eventsCollection := EventsCollection{Events: evts, LastIndex: lastIdx}
 	w.Header().Add(YorcIndexHeader, strconv.FormatUint(lastIdx, 10))
 	encodeJSONResponse(w, r, eventsCollection)
@@ -108,6 +117,11 @@ func (s *Server) pollLogs(w http.ResponseWriter, r *http.Request) {
 			timeout = 10 * time.Minute
 		}
 	}
+	filter, err := parseEventsFilter(values)
+	if err != nil {
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
 
 	var logs []json.RawMessage
 	var lastIdx uint64
@@ -119,6 +133,8 @@ func (s *Server) pollLogs(w http.ResponseWriter, r *http.Request) {
 	}
 	lastIdx = idx
 
+	logs = s.filterLogs(r, id, filter, logs)
+
 	logCollection := LogsCollection{Logs: logs, LastIndex: lastIdx}
 	w.Header().Add(YorcIndexHeader, strconv.FormatUint(lastIdx, 10))
 	encodeJSONResponse(w, r, logCollection)
@@ -167,3 +183,82 @@ func (s *Server) headLogsEventsIndex(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add(YorcIndexHeader, strconv.FormatUint(lastIdx, 10))
 	w.WriteHeader(http.StatusOK)
 }
+
+// queryValues returns the values of a query parameter either repeated or given as a comma-separated list
+func queryValues(values url.Values, name string) []string {
+	result := make([]string, 0)
+	for _, value := range values[name] {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				result = append(result, v)
+			}
+		}
+	}
+	return result
+}
+
+// parseEventsFilter returns the filter of status updates and log entries defined by the query parameters of a request
+func parseEventsFilter(values url.Values) (events.Filter, error) {
+	filter := events.Filter{
+		Nodes:     queryValues(values, "node"),
+		Instances: queryValues(values, "instance"),
+		Tasks:     queryValues(values, "task"),
+		Levels:    queryValues(values, "level"),
+		Types:     queryValues(values, "type"),
+	}
+	return filter, filter.Validate()
+}
+
+// filterStatusEvents returns status updates matching the given filter and accessible to the issuer of a request
+func (s *Server) filterStatusEvents(r *http.Request, deploymentID string, filter events.Filter, evts []events.StatusUpdate) []events.StatusUpdate {
+	checkTenant := deploymentID == "" && requestTenant(r) != ""
+	if !checkTenant && filter.IsEmpty() {
+		return evts
+	}
+	accessibleDeps := make(map[string]bool)
+	filtered := evts[:0]
+	for _, evt := range evts {
+		if checkTenant && !s.isDeploymentAccessibleCached(r, evt.DeploymentID, accessibleDeps) {
+			continue
+		}
+		if filter.MatchesStatusUpdate(evt) {
+			filtered = append(filtered, evt)
+		}
+	}
+	return filtered
+}
+
+// filterLogs returns log entries matching the given filter and accessible to the issuer of a request
+func (s *Server) filterLogs(r *http.Request, deploymentID string, filter events.Filter, logs []json.RawMessage) []json.RawMessage {
+	checkTenant := deploymentID == "" && requestTenant(r) != ""
+	if !checkTenant && filter.IsEmpty() {
+		return logs
+	}
+	accessibleDeps := make(map[string]bool)
+	filtered := logs[:0]
+	for _, logEntry := range logs {
+		var flat map[string]interface{}
+		if err := json.Unmarshal(logEntry, &flat); err != nil {
+			log.Panicf("Can't decode log entry: %v", err)
+		}
+		depID, _ := flat["deploymentId"].(string)
+		if checkTenant && !s.isDeploymentAccessibleCached(r, depID, accessibleDeps) {
+			continue
+		}
+		if filter.MatchesLogEntry(flat) {
+			filtered = append(filtered, logEntry)
+		}
+	}
+	return filtered
+}
+
+// isDeploymentAccessibleCached checks if a deployment is accessible to the issuer of a request
+// caching results into the given map
+func (s *Server) isDeploymentAccessibleCached(r *http.Request, deploymentID string, cache map[string]bool) bool {
+	accessible, ok := cache[deploymentID]
+	if !ok {
+		accessible = s.isDeploymentAccessible(r, deploymentID)
+		cache[deploymentID] = accessible
+	}
+	return accessible
+}
@@ -0,0 +1,198 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/log"
+)
+
+// streamWaitTime is the maximum duration of the Consul blocking queries of a stream.
+//
+// A keep-alive comment is sent to clients if nothing happened during this period, this allows to detect closed connections.
+const streamWaitTime = 30 * time.Second
+
+// serverSentEvent is a message of a text/event-stream response
+type serverSentEvent struct {
+	name string
+	data []byte
+}
+
+// streamFetchFunc returns the messages to send to a stream client after a given index and the new index
+type streamFetchFunc func(index uint64) ([]serverSentEvent, uint64, error)
+
+// writeServerSentEvents writes messages in the text/event-stream format
+//
+// All messages share the same id which is the index to use to resume the stream after them.
+// If there is no messages to send but the index changed, an id field alone is sent to update the client last event id.
+func writeServerSentEvents(w io.Writer, id uint64, indexChanged bool, sses []serverSentEvent) error {
+	var err error
+	if len(sses) == 0 {
+		if indexChanged {
+			_, err = fmt.Fprintf(w, "id: %d\n\n", id)
+		} else {
+			_, err = io.WriteString(w, ": keep-alive\n\n")
+		}
+		return err
+	}
+	for _, sse := range sses {
+		if _, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, sse.name, sse.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamStartIndex returns the index after which messages should be streamed
+//
+// It is given by the 'index' query parameter or by the Last-Event-ID header set by clients on reconnection.
+func streamStartIndex(r *http.Request) (uint64, error) {
+	idx := r.URL.Query().Get("index")
+	if idx == "" {
+		idx = r.Header.Get("Last-Event-ID")
+	}
+	if idx == "" {
+		return 0, nil
+	}
+	index, err := strconv.ParseUint(idx, 10, 64)
+	return index, errors.Wrapf(err, "invalid index %q", idx)
+}
+
+// stream sends messages returned by a fetch function to a client until it disconnects or the server shuts down
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, fetch streamFetchFunc) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Panic("streaming is not supported by the HTTP response writer")
+	}
+	index, err := streamStartIndex(r)
+	if err != nil {
+		writeError(w, r, newBadRequestParameter("index", err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Disable buffering of reverse proxies such as nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.shutdownCh:
+			return
+		default:
+		}
+		sses, lastIdx, err := fetch(index)
+		if err != nil {
+			// Headers are already sent, report the error as a message before closing the stream
+			log.Printf("[ERROR] [%s] %q streaming failed: %+v", r.Method, r.URL.String(), err)
+			data, _ := json.Marshal(err.Error())
+			writeServerSentEvents(w, index, false, []serverSentEvent{{name: "error", data: data}})
+			flusher.Flush()
+			return
+		}
+		indexChanged := lastIdx != 0 && lastIdx != index
+		if indexChanged {
+			index = lastIdx
+		}
+		if err = writeServerSentEvents(w, index, indexChanged, sses); err != nil {
+			log.Debugf("[%s] %q stream closed: %v", r.Method, r.URL.String(), err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// checkStreamedDeployment checks the deployment of a stream if any and parses the stream filter.
+//
+// An error response is written if the deployment doesn't exist or if the filter is invalid.
+func (s *Server) checkStreamedDeployment(w http.ResponseWriter, r *http.Request) (string, events.Filter, bool) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	id := params.ByName("id")
+	if id != "" {
+		if depExist, err := deployments.DoesDeploymentExists(s.consulClient.KV(), id); err != nil {
+			log.Panic(err)
+		} else if !depExist {
+			writeError(w, r, errNotFound)
+			return id, events.Filter{}, false
+		}
+	}
+	filter, err := parseEventsFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, r, newBadRequestError(err))
+		return id, filter, false
+	}
+	return id, filter, true
+}
+
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	id, filter, ok := s.checkStreamedDeployment(w, r)
+	if !ok {
+		return
+	}
+	kv := s.consulClient.KV()
+	s.stream(w, r, func(index uint64) ([]serverSentEvent, uint64, error) {
+		// If id is not set (id == ""), StatusEvents returns events for all the deployments
+		evts, lastIdx, err := events.StatusEvents(kv, id, index, streamWaitTime)
+		if err != nil {
+			return nil, index, err
+		}
+		evts = s.filterStatusEvents(r, id, filter, evts)
+		sses := make([]serverSentEvent, len(evts))
+		for i, evt := range evts {
+			data, err := json.Marshal(evt)
+			if err != nil {
+				return nil, index, errors.Wrap(err, "failed to encode event")
+			}
+			sses[i] = serverSentEvent{name: "status", data: data}
+		}
+		return sses, lastIdx, nil
+	})
+}
+
+func (s *Server) streamLogs(w http.ResponseWriter, r *http.Request) {
+	id, filter, ok := s.checkStreamedDeployment(w, r)
+	if !ok {
+		return
+	}
+	kv := s.consulClient.KV()
+	s.stream(w, r, func(index uint64) ([]serverSentEvent, uint64, error) {
+		// If id is not set (id == ""), LogsEvents returns logs for all the deployments
+		logs, lastIdx, err := events.LogsEvents(kv, id, index, streamWaitTime)
+		if err != nil {
+			return nil, index, err
+		}
+		logs = s.filterLogs(r, id, filter, logs)
+		sses := make([]serverSentEvent, len(logs))
+		for i, logEntry := range logs {
+			sses[i] = serverSentEvent{name: "log", data: logEntry}
+		}
+		return sses, lastIdx, nil
+	})
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamStartIndex(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest("GET", "/events/stream", nil)
+	index, err := streamStartIndex(req)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), index)
+
+	req.Header.Set("Last-Event-ID", "42")
+	index, err = streamStartIndex(req)
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), index)
+
+	// Explicit index takes precedence
+	req = httptest.NewRequest("GET", "/events/stream?index=12", nil)
+	req.Header.Set("Last-Event-ID", "42")
+	index, err = streamStartIndex(req)
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), index)
+
+	req = httptest.NewRequest("GET", "/events/stream?index=abc", nil)
+	_, err = streamStartIndex(req)
+	require.Error(t, err)
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+	s := &Server{shutdownCh: make(chan struct{})}
+	type batch struct {
+		sses    []serverSentEvent
+		lastIdx uint64
+		err     error
+	}
+	batches := []batch{
+		{[]serverSentEvent{{"status", []byte(`{"status":"started"}`)}, {"status", []byte(`{"status":"stopped"}`)}}, 10, nil},
+		{nil, 10, nil},
+		{nil, 12, nil},
+		{nil, 0, errors.New("consul unreachable")},
+	}
+	var indexes []uint64
+	fetch := func(index uint64) ([]serverSentEvent, uint64, error) {
+		indexes = append(indexes, index)
+		b := batches[0]
+		batches = batches[1:]
+		return b.sses, b.lastIdx, b.err
+	}
+
+	req := httptest.NewRequest("GET", "/events/stream?index=5", nil)
+	resp := httptest.NewRecorder()
+	s.stream(resp, req, fetch)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "text/event-stream", resp.Header().Get("Content-Type"))
+	require.Equal(t, []uint64{5, 10, 10, 12}, indexes)
+	require.Equal(t, "id: 10\nevent: status\ndata: {\"status\":\"started\"}\n\n"+
+		"id: 10\nevent: status\ndata: {\"status\":\"stopped\"}\n\n"+
+		": keep-alive\n\n"+
+		"id: 12\n\n"+
+		"id: 12\nevent: error\ndata: \"consul unreachable\"\n\n", resp.Body.String())
+
+	// Closed client connection
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req = httptest.NewRequest("GET", "/events/stream", nil).WithContext(ctx)
+	resp = httptest.NewRecorder()
+	s.stream(resp, req, func(index uint64) ([]serverSentEvent, uint64, error) {
+		t.Fatal("no fetch expected on closed connections")
+		return nil, 0, nil
+	})
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	req = httptest.NewRequest("GET", "/events/stream?index=-1", nil)
+	resp = httptest.NewRecorder()
+	s.stream(resp, req, fetch)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestParseEventsFilter(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest("GET", "/events?node=Compute,Soft&node=Network&level=warn&type=instance&task=", nil)
+	filter, err := parseEventsFilter(req.URL.Query())
+	require.NoError(t, err)
+	require.Equal(t, []string{"Compute", "Soft", "Network"}, filter.Nodes)
+	require.Equal(t, []string{"warn"}, filter.Levels)
+	require.Equal(t, []string{"instance"}, filter.Types)
+	require.Empty(t, filter.Tasks)
+
+	req = httptest.NewRequest("GET", "/events?type=unknown", nil)
+	_, err = parseEventsFilter(req.URL.Query())
+	require.Error(t, err)
+}
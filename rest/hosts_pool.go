@@ -33,6 +33,9 @@ func (s *Server) deleteHostInPool(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	params = ctx.Value(paramsLookupKey).(httprouter.Params)
 	hostname := params.ByName("host")
+	if !s.checkHostManageable(w, r, hostname) {
+		return
+	}
 	err := s.hostsPoolMgr.Remove(hostname)
 	if err != nil {
 		if hostspool.IsHostNotFoundError(err) {
@@ -48,6 +51,54 @@ func (s *Server) deleteHostInPool(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+func (s *Server) releaseHostAllocation(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	hostname := params.ByName("host")
+	allocationID := params.ByName("allocationId")
+	if !s.checkHostManageable(w, r, hostname) {
+		return
+	}
+	err := s.hostsPoolMgr.ForceRelease(hostname, allocationID)
+	if err != nil {
+		if hostspool.IsHostNotFoundError(err) || hostspool.IsAllocationNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return
+		}
+		if hostspool.IsBadRequestError(err) {
+			writeError(w, r, newBadRequestError(err))
+			return
+		}
+		log.Panic(err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) discoverHostInPool(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	hostname := params.ByName("host")
+	if !s.checkHostManageable(w, r, hostname) {
+		return
+	}
+	labels, err := s.hostsPoolMgr.Discover(hostname)
+	if err != nil {
+		if hostspool.IsHostNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return
+		}
+		if hostspool.IsBadRequestError(err) {
+			writeError(w, r, newBadRequestError(err))
+			return
+		}
+		log.Panic(err)
+	}
+	encodeJSONResponse(w, r, HostDiscovery{Labels: labels})
+}
+
 func (s *Server) newHostInPool(w http.ResponseWriter, r *http.Request) {
 	var params httprouter.Params
 	ctx := r.Context()
@@ -78,6 +129,13 @@ func (s *Server) newHostInPool(w http.ResponseWriter, r *http.Request) {
 		}
 		labels[entry.Name] = entry.Value
 	}
+	if tenant := requestTenant(r); tenant != "" {
+		if labels[hostspool.TenantLabel] != "" && labels[hostspool.TenantLabel] != tenant {
+			writeError(w, r, newForbiddenMessage(fmt.Sprintf("Hosts can only be added to tenant %q", tenant)))
+			return
+		}
+		labels[hostspool.TenantLabel] = tenant
+	}
 
 	err = s.hostsPoolMgr.Add(hostname, *host.Connection, labels)
 	if err != nil {
@@ -109,6 +167,18 @@ func (s *Server) updateHostInPool(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !s.checkHostManageable(w, r, hostname) {
+		return
+	}
+	if tenant := requestTenant(r); tenant != "" {
+		for _, entry := range host.Labels {
+			if entry.Name == hostspool.TenantLabel && (entry.Op == MapEntryOperationRemove || entry.Value != tenant) {
+				writeError(w, r, newForbiddenMessage(fmt.Sprintf("Label %q of hosts reserved to tenant %q can't be changed", hostspool.TenantLabel, tenant)))
+				return
+			}
+		}
+	}
+
 	if host.Connection != nil {
 		err = s.hostsPoolMgr.UpdateConnection(hostname, *host.Connection)
 		if err != nil {
@@ -182,6 +252,10 @@ func (s *Server) getHostInPool(w http.ResponseWriter, r *http.Request) {
 		}
 		log.Panic(err)
 	}
+	if !isHostAccessible(r, host.Labels) {
+		writeError(w, r, errNotFound)
+		return
+	}
 
 	restHost := Host{Host: host, Links: make([]AtomLink, 1)}
 	restHost.Links[0] = newAtomLink(LinkRelSelf, fmt.Sprintf("/hosts_pool/%s", hostname))
@@ -200,6 +274,9 @@ func (s *Server) listHostsInPool(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if tenant := requestTenant(r); tenant != "" {
+		filters = append(filters, hostspool.NewTenantFilter(tenant))
+	}
 
 	hostsNames, warnings, checkpoint, err := s.hostsPoolMgr.List(filters...)
 	if err != nil {
@@ -230,6 +307,10 @@ func (s *Server) listHostsInPool(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) applyHostsPool(w http.ResponseWriter, r *http.Request) {
+	if tenant := requestTenant(r); tenant != "" {
+		writeError(w, r, newForbiddenMessage(fmt.Sprintf("Users of tenant %q can't apply a whole hosts pool configuration", tenant)))
+		return
+	}
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		log.Panic(err)
@@ -281,3 +362,32 @@ func (s *Server) applyHostsPool(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+// checkHostManageable checks if a host of the pool may be modified by the issuer of a request
+// and writes an error response otherwise
+func (s *Server) checkHostManageable(w http.ResponseWriter, r *http.Request, hostname string) bool {
+	if requestTenant(r) == "" {
+		return true
+	}
+	host, err := s.hostsPoolMgr.GetHost(hostname)
+	if err != nil {
+		if hostspool.IsHostNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return false
+		}
+		if hostspool.IsBadRequestError(err) {
+			writeError(w, r, newBadRequestError(err))
+			return false
+		}
+		log.Panic(err)
+	}
+	if !isHostAccessible(r, host.Labels) {
+		writeError(w, r, errNotFound)
+		return false
+	}
+	if !isHostManageable(r, host.Labels) {
+		writeError(w, r, newForbiddenMessage(fmt.Sprintf("Host %q is shared between tenants and can't be modified", hostname)))
+		return false
+	}
+	return true
+}
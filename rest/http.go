@@ -83,6 +83,8 @@ type Server struct {
 	tasksCollector *tasks.Collector
 	config         config.Configuration
 	hostsPoolMgr   hostspool.Manager
+	authenticators []Authenticator
+	shutdownCh     chan struct{}
 }
 
 // Shutdown stops the HTTP server
@@ -106,6 +108,10 @@ func NewServer(configuration config.Configuration, client *api.Client, shutdownC
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to bind on %s", addr)
 	}
+	authenticators, err := newAuthenticators(configuration)
+	if err != nil {
+		return nil, err
+	}
 	sslEnabled := (configuration.CertFile != "" && configuration.KeyFile != "")
 	if sslEnabled {
 		listener, err = wrapListenerTLS(listener, configuration)
@@ -121,6 +127,8 @@ func NewServer(configuration config.Configuration, client *api.Client, shutdownC
 		tasksCollector: tasks.NewCollector(client),
 		config:         configuration,
 		hostsPoolMgr:   hostspool.NewManager(client),
+		authenticators: authenticators,
+		shutdownCh:     shutdownCh,
 	}
 
 	httpServer.registerHandlers()
@@ -135,6 +143,9 @@ func NewServer(configuration config.Configuration, client *api.Client, shutdownC
 	} else {
 		log.Printf("Starting HTTPServer on address %s", listener.Addr())
 	}
+	if len(authenticators) == 0 {
+		log.Printf("[WARN] REST API authentication is disabled, any client may access it")
+	}
 	go http.Serve(httpServer.listener, httpServer.router)
 
 	return httpServer, nil
@@ -142,57 +153,86 @@ func NewServer(configuration config.Configuration, client *api.Client, shutdownC
 
 func (s *Server) registerHandlers() {
 	commonHandlers := alice.New(telemetryHandler, loggingHandler, recoverHandler)
-	s.router.Post("/deployments", commonHandlers.Append(contentTypeHandler("application/zip")).ThenFunc(s.newDeploymentHandler))
-	s.router.Put("/deployments/:id", commonHandlers.Append(contentTypeHandler("application/zip")).ThenFunc(s.newDeploymentHandler))
-	s.router.Delete("/deployments/:id", commonHandlers.ThenFunc(s.deleteDeploymentHandler))
-	s.router.Get("/deployments/:id", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getDeploymentHandler))
-	s.router.Get("/deployments", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listDeploymentsHandler))
-	s.router.Get("/deployments/:id/events", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.pollEvents))
-	s.router.Get("/events", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.pollEvents))
-	s.router.Head("/deployments/:id/events", commonHandlers.ThenFunc(s.headEventsIndex))
-	s.router.Head("/events", commonHandlers.ThenFunc(s.headEventsIndex))
-	s.router.Get("/deployments/:id/logs", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.pollLogs))
-	s.router.Get("/logs", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.pollLogs))
-	s.router.Head("/deployments/:id/logs", commonHandlers.ThenFunc(s.headLogsEventsIndex))
-	s.router.Head("/logs", commonHandlers.ThenFunc(s.headLogsEventsIndex))
-	s.router.Get("/deployments/:id/nodes/:nodeName", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getNodeHandler))
-	s.router.Get("/deployments/:id/nodes/:nodeName/instances/:instanceId", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getNodeInstanceHandler))
-	s.router.Get("/deployments/:id/outputs", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listOutputsHandler))
-	s.router.Get("/deployments/:id/outputs/:opt", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getOutputHandler))
-	s.router.Get("/deployments/:id/tasks/:taskId", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getTaskHandler))
-	s.router.Get("/deployments/:id/tasks/:taskId/steps", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getTaskStepsHandler))
-	s.router.Delete("/deployments/:id/tasks/:taskId", commonHandlers.ThenFunc(s.cancelTaskHandler))
-	s.router.Put("/deployments/:id/tasks/:taskId", commonHandlers.ThenFunc(s.resumeTaskHandler))
-	s.router.Put("/deployments/:id/tasks/:taskId/steps/:stepId", commonHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.updateTaskStepStatusHandler))
-	s.router.Post("/deployments/:id/scale/:nodeName", commonHandlers.ThenFunc(s.scaleHandler))
-	s.router.Get("/deployments/:id/nodes/:nodeName/instances/:instanceId/attributes", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getNodeInstanceAttributesListHandler))
-	s.router.Get("/deployments/:id/nodes/:nodeName/instances/:instanceId/attributes/:attributeName", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getNodeInstanceAttributeHandler))
-	s.router.Post("/deployments/:id/custom", commonHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.newCustomCommandHandler))
-	s.router.Post("/deployments/:id/workflows/:workflowName", commonHandlers.ThenFunc(s.newWorkflowHandler))
-	s.router.Get("/deployments/:id/workflows/:workflowName", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getWorkflowHandler))
-	s.router.Get("/deployments/:id/workflows", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listWorkflowsHandler))
-
-	s.router.Get("/registry/delegates", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listRegistryDelegatesHandler))
-	s.router.Get("/registry/implementations", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listRegistryImplementationsHandler))
-	s.router.Get("/registry/definitions", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listRegistryDefinitionsHandler))
-	s.router.Get("/registry/vaults", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listVaultsBuilderHandler))
-	s.router.Get("/registry/infra_usage_collectors", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listInfraHandler))
-
-	s.router.Post("/infra_usage/:infraName", commonHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.postInfraUsageHandler))
-	s.router.Get("/infra_usage/:infraName/tasks/:taskId", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getTaskQueryHandler))
-	s.router.Delete("/infra_usage/:infraName/tasks/:taskId", commonHandlers.ThenFunc(s.deleteTaskQueryHandler))
-	s.router.Get("/infra_usage", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listTaskQueryHandler))
-
-	s.router.Put("/hosts_pool/:host", commonHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.newHostInPool))
-	s.router.Patch("/hosts_pool/:host", commonHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.updateHostInPool))
-	s.router.Delete("/hosts_pool/:host", commonHandlers.ThenFunc(s.deleteHostInPool))
-	s.router.Post("/hosts_pool", commonHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.applyHostsPool))
-	s.router.Put("/hosts_pool", commonHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.applyHostsPool))
-	s.router.Get("/hosts_pool", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listHostsInPool))
-	s.router.Get("/hosts_pool/:host", commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getHostInPool))
+	readHandlers := commonHandlers.Append(s.authHandler(RoleReader), s.tenantHandler)
+	deployHandlers := commonHandlers.Append(s.authHandler(RoleDeployer), s.tenantHandler)
+	operateHandlers := commonHandlers.Append(s.authHandler(RoleOperator), s.tenantHandler)
+	hostsPoolHandlers := commonHandlers.Append(s.authHandler(RoleHostsPoolManager), s.tenantHandler)
+	s.router.Post("/deployments", deployHandlers.Append(contentTypeHandler("application/zip", "multipart/form-data")).ThenFunc(s.newDeploymentHandler))
+	s.router.Put("/deployments/:id", deployHandlers.Append(contentTypeHandler("application/zip", "multipart/form-data")).ThenFunc(s.newDeploymentHandler))
+	s.router.Post("/deployments/:id", deployHandlers.Append(contentTypeHandler("application/zip", "multipart/form-data")).ThenFunc(s.validateDeploymentHandler))
+	s.router.Delete("/deployments/:id", deployHandlers.ThenFunc(s.deleteDeploymentHandler))
+	s.router.Get("/deployments/:id", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getDeploymentHandler))
+	s.router.Get("/deployments", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listDeploymentsHandler))
+	s.router.Get("/deployments/:id/events", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.pollEvents))
+	s.router.Get("/events", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.pollEvents))
+	s.router.Get("/deployments/:id/events/stream", readHandlers.Append(acceptHandler("text/event-stream")).ThenFunc(s.streamEvents))
+	s.router.Get("/events/stream", readHandlers.Append(acceptHandler("text/event-stream")).ThenFunc(s.streamEvents))
+	s.router.Head("/deployments/:id/events", readHandlers.ThenFunc(s.headEventsIndex))
+	s.router.Head("/events", readHandlers.ThenFunc(s.headEventsIndex))
+	s.router.Get("/deployments/:id/logs", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.pollLogs))
+	s.router.Get("/logs", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.pollLogs))
+	s.router.Get("/deployments/:id/logs/stream", readHandlers.Append(acceptHandler("text/event-stream")).ThenFunc(s.streamLogs))
+	s.router.Get("/logs/stream", readHandlers.Append(acceptHandler("text/event-stream")).ThenFunc(s.streamLogs))
+	s.router.Head("/deployments/:id/logs", readHandlers.ThenFunc(s.headLogsEventsIndex))
+	s.router.Head("/logs", readHandlers.ThenFunc(s.headLogsEventsIndex))
+	s.router.Get("/deployments/:id/nodes/:nodeName", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getNodeHandler))
+	s.router.Get("/deployments/:id/nodes/:nodeName/instances/:instanceId", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getNodeInstanceHandler))
+	s.router.Get("/deployments/:id/outputs", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listOutputsHandler))
+	s.router.Get("/deployments/:id/outputs/:opt", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getOutputHandler))
+	s.router.Get("/deployments/:id/tasks", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listTasksHandler))
+	s.router.Get("/deployments/:id/tasks/:taskId", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getTaskHandler))
+	s.router.Get("/deployments/:id/tasks/:taskId/steps", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getTaskStepsHandler))
+	s.router.Delete("/deployments/:id/tasks/:taskId", operateHandlers.ThenFunc(s.cancelTaskHandler))
+	s.router.Put("/deployments/:id/tasks/:taskId", operateHandlers.ThenFunc(s.resumeTaskHandler))
+	s.router.Put("/deployments/:id/tasks/:taskId/steps/:stepId", operateHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.updateTaskStepStatusHandler))
+	s.router.Post("/deployments/:id/scale/:nodeName", deployHandlers.ThenFunc(s.scaleHandler))
+	s.router.Get("/deployments/:id/nodes/:nodeName/instances/:instanceId/attributes", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getNodeInstanceAttributesListHandler))
+	s.router.Get("/deployments/:id/nodes/:nodeName/instances/:instanceId/attributes/:attributeName", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getNodeInstanceAttributeHandler))
+	s.router.Put("/deployments/:id/nodes/:nodeName/instances/:instanceId/metrics/:metricName", operateHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.postNodeInstanceMetricHandler))
+	s.router.Post("/deployments/:id/custom", operateHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.newCustomCommandHandler))
+	s.router.Post("/deployments/:id/workflows/:workflowName", operateHandlers.ThenFunc(s.newWorkflowHandler))
+	s.router.Get("/deployments/:id/workflows/:workflowName", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getWorkflowHandler))
+	s.router.Get("/deployments/:id/workflows", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listWorkflowsHandler))
+	s.router.Get("/deployments/:id/groups", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listGroupsHandler))
+	s.router.Get("/deployments/:id/groups/:groupName", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getGroupHandler))
+	s.router.Get("/deployments/:id/policies", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listPoliciesHandler))
+	s.router.Get("/deployments/:id/policies/:policyName", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getPolicyHandler))
+	s.router.Post("/deployments/:id/schedules", operateHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.newScheduleHandler))
+	s.router.Get("/deployments/:id/schedules", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listSchedulesHandler))
+	s.router.Get("/deployments/:id/schedules/:scheduleId", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getScheduleHandler))
+	s.router.Put("/deployments/:id/schedules/:scheduleId", operateHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.updateScheduleHandler))
+	s.router.Delete("/deployments/:id/schedules/:scheduleId", operateHandlers.ThenFunc(s.deleteScheduleHandler))
+
+	s.router.Get("/registry/delegates", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listRegistryDelegatesHandler))
+	s.router.Get("/registry/implementations", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listRegistryImplementationsHandler))
+	s.router.Get("/registry/definitions", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listRegistryDefinitionsHandler))
+	s.router.Get("/registry/vaults", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listVaultsBuilderHandler))
+	s.router.Get("/registry/infra_usage_collectors", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listInfraHandler))
+
+	s.router.Post("/infra_usage/:infraName", operateHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.postInfraUsageHandler))
+	s.router.Get("/infra_usage/:infraName/tasks/:taskId", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getTaskQueryHandler))
+	s.router.Delete("/infra_usage/:infraName/tasks/:taskId", operateHandlers.ThenFunc(s.deleteTaskQueryHandler))
+	s.router.Get("/infra_usage", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listTaskQueryHandler))
+
+	s.router.Put("/hosts_pool/:host", hostsPoolHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.newHostInPool))
+	s.router.Patch("/hosts_pool/:host", hostsPoolHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.updateHostInPool))
+	s.router.Delete("/hosts_pool/:host", hostsPoolHandlers.ThenFunc(s.deleteHostInPool))
+	s.router.Delete("/hosts_pool/:host/allocations/:allocationId", hostsPoolHandlers.ThenFunc(s.releaseHostAllocation))
+	s.router.Post("/hosts_pool/:host/discover", hostsPoolHandlers.Append(acceptHandler("application/json")).ThenFunc(s.discoverHostInPool))
+	s.router.Post("/hosts_pool", hostsPoolHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.applyHostsPool))
+	s.router.Put("/hosts_pool", hostsPoolHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.applyHostsPool))
+	s.router.Get("/hosts_pool", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listHostsInPool))
+	s.router.Get("/hosts_pool/:host", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getHostInPool))
+
+	s.router.Post("/notifications/subscriptions", operateHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.newSubscriptionHandler))
+	s.router.Get("/notifications/subscriptions", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listSubscriptionsHandler))
+	s.router.Get("/notifications/subscriptions/:id", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getSubscriptionHandler))
+	s.router.Delete("/notifications/subscriptions/:id", operateHandlers.ThenFunc(s.deleteSubscriptionHandler))
+	s.router.Get("/notifications/subscriptions/:id/dead_letters", readHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listDeadLettersHandler))
+	s.router.Delete("/notifications/subscriptions/:id/dead_letters", operateHandlers.ThenFunc(s.deleteDeadLettersHandler))
 
 	if s.config.Telemetry.PrometheusEndpoint {
-		s.router.Get("/metrics", commonHandlers.Then(promhttp.Handler()))
+		s.router.Get("/metrics", readHandlers.Then(promhttp.Handler()))
 	}
 }
 
@@ -16,10 +16,13 @@ package rest
 
 import (
 	"fmt"
+	"mime"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/ystia/yorc/helper/collections"
 	"github.com/ystia/yorc/helper/metricsutil"
 	"github.com/ystia/yorc/log"
 )
@@ -66,11 +69,13 @@ func acceptHandler(cType string) func(http.Handler) http.Handler {
 	return m
 }
 
-func contentTypeHandler(cType string) func(http.Handler) http.Handler {
+func contentTypeHandler(cTypes ...string) func(http.Handler) http.Handler {
 	m := func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			if r.Header.Get("Content-Type") != cType {
-				writeError(w, r, newUnsupportedMediaTypeError(cType))
+			// Parameters like the multipart boundary are ignored
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if !collections.ContainsString(cTypes, mediaType) {
+				writeError(w, r, newUnsupportedMediaTypeError(strings.Join(cTypes, "' or '")))
 				return
 			}
 
@@ -92,6 +97,13 @@ func (w *statusRecorderResponseWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
+// Flush implements http.Flusher to allow streaming responses
+func (w *statusRecorderResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func telemetryHandler(next http.Handler) http.Handler {
 
 	fn := func(w http.ResponseWriter, r *http.Request) {
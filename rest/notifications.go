@@ -0,0 +1,148 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/notifications"
+)
+
+func (s *Server) newSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Panic(err)
+	}
+	var subscription notifications.Subscription
+	if err = json.Unmarshal(body, &subscription); err != nil {
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
+	if tenant := requestTenant(r); tenant != "" {
+		if subscription.Tenant != "" && subscription.Tenant != tenant {
+			writeError(w, r, newForbiddenMessage(fmt.Sprintf("Subscriptions can only be created for tenant %q", tenant)))
+			return
+		}
+		subscription.Tenant = tenant
+	}
+	if err = subscription.Validate(); err != nil {
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
+	id, err := notifications.CreateSubscription(s.consulClient.KV(), subscription)
+	if err != nil {
+		log.Panic(err)
+	}
+	w.Header().Set("Location", fmt.Sprintf("/notifications/subscriptions/%s", id))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// getAccessibleSubscription returns the subscription of the request parameters
+//
+// An error response is written if the subscription does not exist or belongs to another tenant.
+func (s *Server) getAccessibleSubscription(w http.ResponseWriter, r *http.Request) (notifications.Subscription, bool) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	subscription, err := notifications.GetSubscription(s.consulClient.KV(), params.ByName("id"))
+	if err != nil {
+		if notifications.IsSubscriptionNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return subscription, false
+		}
+		log.Panic(err)
+	}
+	if !isTenantAccessible(r, subscription.Tenant) {
+		writeError(w, r, errNotFound)
+		return subscription, false
+	}
+	return subscription, true
+}
+
+func (s *Server) getSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := s.getAccessibleSubscription(w, r)
+	if !ok {
+		return
+	}
+	// Never disclose secrets
+	subscription.Secret = ""
+	encodeJSONResponse(w, r, subscription)
+}
+
+func (s *Server) listSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := notifications.ListSubscriptions(s.consulClient.KV())
+	if err != nil {
+		log.Panic(err)
+	}
+	col := SubscriptionsCollection{Subscriptions: make([]notifications.Subscription, 0, len(subscriptions))}
+	for _, subscription := range subscriptions {
+		if isTenantAccessible(r, subscription.Tenant) {
+			subscription.Secret = ""
+			col.Subscriptions = append(col.Subscriptions, subscription)
+		}
+	}
+	if len(col.Subscriptions) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	encodeJSONResponse(w, r, col)
+}
+
+func (s *Server) deleteSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := s.getAccessibleSubscription(w, r)
+	if !ok {
+		return
+	}
+	err := notifications.DeleteSubscription(s.consulClient.KV(), subscription.ID)
+	if err != nil {
+		if notifications.IsSubscriptionNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return
+		}
+		log.Panic(err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) listDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := s.getAccessibleSubscription(w, r)
+	if !ok {
+		return
+	}
+	deadLetters, err := notifications.ListDeadLetters(s.consulClient.KV(), subscription.ID)
+	if err != nil {
+		log.Panic(err)
+	}
+	if len(deadLetters) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	encodeJSONResponse(w, r, DeadLettersCollection{DeadLetters: deadLetters})
+}
+
+func (s *Server) deleteDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := s.getAccessibleSubscription(w, r)
+	if !ok {
+		return
+	}
+	if err := notifications.DeleteDeadLetters(s.consulClient.KV(), subscription.ID); err != nil {
+		log.Panic(err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
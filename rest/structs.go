@@ -19,10 +19,13 @@ package rest
 import (
 	"bytes"
 	"encoding/json"
+	"time"
 
 	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/notifications"
 	"github.com/ystia/yorc/prov/hostspool"
 	"github.com/ystia/yorc/registry"
+	"github.com/ystia/yorc/scheduling"
 	"github.com/ystia/yorc/tosca"
 )
 
@@ -43,6 +46,10 @@ const (
 	LinkRelAttribute string = "attribute"
 	// LinkRelWorkflow defines the AtomLink Rel attribute for relationships of the "attribute"
 	LinkRelWorkflow string = "workflow"
+	// LinkRelGroup defines the AtomLink Rel attribute for relationships of the "group"
+	LinkRelGroup string = "group"
+	// LinkRelPolicy defines the AtomLink Rel attribute for relationships of the "policy"
+	LinkRelPolicy string = "policy"
 	// LinkRelHost defines the AtomLink Rel attribute for relationships of the "host" (for hostspool)
 	LinkRelHost string = "host"
 )
@@ -107,6 +114,21 @@ type LogsCollection struct {
 	LastIndex uint64            `json:"last_index"`
 }
 
+// SubscriptionsCollection is a collection of webhooks notifications subscriptions
+type SubscriptionsCollection struct {
+	Subscriptions []notifications.Subscription `json:"subscriptions"`
+}
+
+// DeadLettersCollection is a collection of notifications that could not be delivered
+type DeadLettersCollection struct {
+	DeadLetters []notifications.DeadLetter `json:"dead_letters"`
+}
+
+// SchedulesCollection is a collection of scheduled workflows and custom commands of a deployment
+type SchedulesCollection struct {
+	Schedules []scheduling.Schedule `json:"schedules"`
+}
+
 // Node is the representation of a TOSCA node
 //
 // Node's links are of type LinkRelSelf, LinkRelDeployment and LinkRelInstance.
@@ -138,6 +160,17 @@ type Task struct {
 	ResultSet json.RawMessage `json:"result_set,omitempty"`
 }
 
+// DeploymentTask is the representation of a task in the list of tasks of a deployment
+type DeploymentTask struct {
+	Task
+	CreationDate time.Time `json:"creation_date"`
+}
+
+// DeploymentTasksCollection is the collection of the tasks of a deployment ordered by creation date
+type DeploymentTasksCollection struct {
+	Tasks []DeploymentTask `json:"tasks"`
+}
+
 // TasksCollection is the collection of task's links
 type TasksCollection struct {
 	Tasks []AtomLink `json:"tasks,omitempty"`
@@ -159,6 +192,11 @@ type Attribute struct {
 	Value string `json:"value"`
 }
 
+// MetricRequest is the representation of a request to post a metric value for a node instance
+type MetricRequest struct {
+	Value float64 `json:"value"`
+}
+
 // CustomCommandRequest is the representation of a request to process a Custom Command
 type CustomCommandRequest struct {
 	NodeName          string                            `json:"node"`
@@ -179,6 +217,44 @@ type Workflow struct {
 	tosca.Workflow
 }
 
+// GroupsCollection is a collection of groups links
+//
+// Links are all of type LinkRelGroup.
+type GroupsCollection struct {
+	Groups []AtomLink `json:"groups"`
+}
+
+// Group is the representation of a TOSCA group
+//
+// Group's links are of type LinkRelSelf, LinkRelDeployment and LinkRelNode for its members.
+type Group struct {
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	Description string            `json:"description,omitempty"`
+	Properties  map[string]string `json:"properties,omitempty"`
+	Members     []string          `json:"members,omitempty"`
+	Links       []AtomLink        `json:"links"`
+}
+
+// PoliciesCollection is a collection of policies links
+//
+// Links are all of type LinkRelPolicy.
+type PoliciesCollection struct {
+	Policies []AtomLink `json:"policies"`
+}
+
+// Policy is the representation of a TOSCA policy
+//
+// Policy's links are of type LinkRelSelf, LinkRelDeployment and LinkRelNode or LinkRelGroup for its targets.
+type Policy struct {
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	Description string            `json:"description,omitempty"`
+	Properties  map[string]string `json:"properties,omitempty"`
+	Targets     []string          `json:"targets,omitempty"`
+	Links       []AtomLink        `json:"links"`
+}
+
 // MapEntryOperation is an enumeration of valid values for a MapEntry.Op field
 // ENUM(
 // Add,
@@ -234,6 +310,12 @@ type HostRequest struct {
 	Labels     []MapEntry            `json:"labels,omitempty"`
 }
 
+// HostDiscovery is the result of the hardware discovery of a host in the hosts pool
+type HostDiscovery struct {
+	// Labels are the labels maintained by the discovery with their discovered values
+	Labels map[string]string `json:"labels"`
+}
+
 // HostsCollection is a collection of hosts registered in the host pool links
 //
 // Links are all of type LinkRelHost.
@@ -0,0 +1,112 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/prov/hostspool"
+)
+
+// requestTenant returns the tenant whose resources are accessible to the issuer of a request
+//
+// Users attached to a tenant only access this tenant. Administrators not attached to a tenant and
+// anonymous users when authentication is disabled may restrict a request to a tenant using the
+// 'tenant' query parameter, otherwise an empty string is returned meaning that every tenant is accessible.
+// Other users belong to the default tenant.
+func requestTenant(r *http.Request) string {
+	if identity, ok := IdentityFromContext(r.Context()); ok && identity != nil {
+		if identity.Tenant != "" {
+			return identity.Tenant
+		}
+		if !identity.HasRole(RoleAdmin) {
+			return deployments.DefaultTenant
+		}
+	}
+	return r.URL.Query().Get("tenant")
+}
+
+// requestTenantOrDefault returns the tenant of resources created by a request
+func requestTenantOrDefault(r *http.Request) string {
+	if tenant := requestTenant(r); tenant != "" {
+		return tenant
+	}
+	return deployments.DefaultTenant
+}
+
+// isTenantAccessible checks if resources of a given tenant are accessible to the issuer of a request
+func isTenantAccessible(r *http.Request, tenant string) bool {
+	reqTenant := requestTenant(r)
+	return reqTenant == "" || reqTenant == tenant
+}
+
+// isDeploymentAccessible checks if a deployment belongs to a tenant accessible to the issuer of a request
+func (s *Server) isDeploymentAccessible(r *http.Request, deploymentID string) bool {
+	if requestTenant(r) == "" {
+		return true
+	}
+	tenant, err := deployments.GetDeploymentTenant(s.consulClient.KV(), deploymentID)
+	if err != nil {
+		log.Panic(err)
+	}
+	return isTenantAccessible(r, tenant)
+}
+
+// tenantHandler is a middleware hiding deployments of other tenants than the one of the request issuer
+//
+// Such deployments are reported as not found to not disclose their existence.
+func (s *Server) tenantHandler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		params, _ := r.Context().Value(paramsLookupKey).(httprouter.Params)
+		id := params.ByName("id")
+		if id != "" && strings.HasPrefix(r.URL.Path, "/deployments/") && !s.isDeploymentAccessible(r, id) {
+			exists, err := deployments.DoesDeploymentExists(s.consulClient.KV(), id)
+			if err != nil {
+				log.Panic(err)
+			}
+			if exists {
+				writeError(w, r, errNotFound)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// isHostAccessible checks if a host of the pool is visible to the issuer of a request
+//
+// Hosts reserved to a tenant are only visible to this tenant while other hosts are shared.
+func isHostAccessible(r *http.Request, labels map[string]string) bool {
+	reqTenant := requestTenant(r)
+	if reqTenant == "" {
+		return true
+	}
+	m, _ := hostspool.NewTenantFilter(reqTenant).Matches(labels)
+	return m
+}
+
+// isHostManageable checks if a host of the pool may be modified by the issuer of a request
+//
+// Users restricted to a tenant can only manage hosts reserved to their tenant.
+func isHostManageable(r *http.Request, labels map[string]string) bool {
+	reqTenant := requestTenant(r)
+	return reqTenant == "" || labels[hostspool.TenantLabel] == reqTenant
+}
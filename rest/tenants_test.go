@@ -0,0 +1,75 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/prov/hostspool"
+)
+
+func TestRequestTenant(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		identity *Identity
+		url      string
+		want     string
+	}{
+		{"AuthDisabled", nil, "/deployments", ""},
+		{"AuthDisabledWithTenant", nil, "/deployments?tenant=team1", "team1"},
+		{"UserOfTenant", &Identity{Name: "bob", Roles: []string{RoleDeployer}, Tenant: "team1"}, "/deployments?tenant=team2", "team1"},
+		{"UserWithoutTenant", &Identity{Name: "bob", Roles: []string{RoleDeployer}}, "/deployments?tenant=team2", deployments.DefaultTenant},
+		{"AdminOfTenant", &Identity{Name: "alice", Roles: []string{RoleAdmin}, Tenant: "team1"}, "/deployments?tenant=team2", "team1"},
+		{"GlobalAdmin", &Identity{Name: "alice", Roles: []string{RoleAdmin}}, "/deployments", ""},
+		{"GlobalAdminWithTenant", &Identity{Name: "alice", Roles: []string{RoleAdmin}}, "/deployments?tenant=team2", "team2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			if tt.identity != nil {
+				req = req.WithContext(context.WithValue(req.Context(), identityLookupKey, tt.identity))
+			}
+			require.Equal(t, tt.want, requestTenant(req))
+		})
+	}
+}
+
+func TestHostsTenantAccess(t *testing.T) {
+	t.Parallel()
+	tenantReq := httptest.NewRequest("GET", "/hosts_pool", nil)
+	tenantReq = tenantReq.WithContext(context.WithValue(tenantReq.Context(), identityLookupKey, &Identity{Name: "bob", Roles: []string{RoleHostsPoolManager}, Tenant: "team1"}))
+	globalReq := httptest.NewRequest("GET", "/hosts_pool", nil)
+
+	shared := map[string]string{"os.type": "linux"}
+	team1 := map[string]string{hostspool.TenantLabel: "team1"}
+	team2 := map[string]string{hostspool.TenantLabel: "team2"}
+
+	require.True(t, isHostAccessible(tenantReq, shared))
+	require.True(t, isHostAccessible(tenantReq, team1))
+	require.False(t, isHostAccessible(tenantReq, team2))
+	require.False(t, isHostManageable(tenantReq, shared))
+	require.True(t, isHostManageable(tenantReq, team1))
+	require.False(t, isHostManageable(tenantReq, team2))
+
+	require.True(t, isHostAccessible(globalReq, team2))
+	require.True(t, isHostManageable(globalReq, shared))
+	require.True(t, isHostManageable(globalReq, team2))
+}
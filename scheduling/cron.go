@@ -0,0 +1,234 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduling
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// minEveryDelay is the minimum delay between occurrences of an "@every <duration>" schedule
+const minEveryDelay = time.Minute
+
+// maxLookAhead bounds the search of the next occurrence of cron expressions that may never match like "0 0 30 2 *"
+const maxLookAhead = 5
+
+// recurrence computes occurrences of a schedule
+type recurrence interface {
+	// next returns the first occurrence strictly after the given time or a zero time if there is no such occurrence
+	next(t time.Time) time.Time
+}
+
+// cronField defines the allowed range of values of a cron expression field
+type cronField struct {
+	name  string
+	min   uint
+	max   uint
+	names map[string]uint
+}
+
+var (
+	minutesField = cronField{name: "minutes", min: 0, max: 59}
+	hoursField   = cronField{name: "hours", min: 0, max: 23}
+	domField     = cronField{name: "day of month", min: 1, max: 31}
+	monthsField  = cronField{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Both 0 and 7 stand for sunday
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronRecurrence is a standard 5 fields cron expression, each field is a bit set of matching values
+type cronRecurrence struct {
+	minutes, hours, doms, months, dows uint64
+	// domRestricted and dowRestricted are used to implement the cron rule stating that if both days fields are
+	// restricted then a day matches if any of them matches
+	domRestricted, dowRestricted bool
+	loc                          *time.Location
+}
+
+// everyRecurrence fires at a fixed delay after the previous occurrence
+type everyRecurrence struct {
+	delay time.Duration
+}
+
+// parseRecurrence parses a cron expression, a descriptor like "@daily" or an "@every <duration>" expression
+//
+// Occurrences of cron expressions and descriptors are computed in the given location.
+func parseRecurrence(expr string, loc *time.Location) (recurrence, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every") {
+		delay, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every")))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid schedule %q", expr)
+		}
+		if delay < minEveryDelay {
+			return nil, errors.Errorf("invalid schedule %q, delay should be at least %v", expr, minEveryDelay)
+		}
+		return everyRecurrence{delay: delay}, nil
+	}
+	if strings.HasPrefix(expr, "@") {
+		d, ok := descriptors[strings.ToLower(expr)]
+		if !ok {
+			return nil, errors.Errorf("invalid schedule %q, unknown descriptor", expr)
+		}
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("invalid cron expression %q, expecting 5 fields (minutes, hours, day of month, month and day of week) got %d", expr, len(fields))
+	}
+	c := &cronRecurrence{loc: loc}
+	var err error
+	if c.minutes, err = parseCronField(fields[0], minutesField); err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q", expr)
+	}
+	if c.hours, err = parseCronField(fields[1], hoursField); err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q", expr)
+	}
+	if c.doms, err = parseCronField(fields[2], domField); err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q", expr)
+	}
+	if c.months, err = parseCronField(fields[3], monthsField); err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q", expr)
+	}
+	if c.dows, err = parseCronField(fields[4], dowField); err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q", expr)
+	}
+	if c.dows&(1<<7) != 0 {
+		c.dows |= 1
+	}
+	c.domRestricted = !strings.HasPrefix(fields[2], "*")
+	c.dowRestricted = !strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps like "1,10-20/2,*/15" into a bit set
+func parseCronField(expr string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr := part
+		step := uint(1)
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || s == 0 {
+				return 0, errors.Errorf("invalid step in %s field %q", f.name, part)
+			}
+			step = uint(s)
+			rangeExpr = part[:i]
+		}
+		var low, high uint
+		switch {
+		case rangeExpr == "*":
+			low, high = f.min, f.max
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err error
+			if low, err = parseCronValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if high, err = parseCronValue(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, errors.Errorf("invalid range in %s field %q", f.name, part)
+			}
+		default:
+			var err error
+			if low, err = parseCronValue(rangeExpr, f); err != nil {
+				return 0, err
+			}
+			high = low
+			if step > 1 {
+				// "a/n" means from a to the maximum value every n
+				high = f.max
+			}
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, f cronField) (uint, error) {
+	if v, ok := f.names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.ParseUint(value, 10, 8)
+	if err != nil {
+		return 0, errors.Errorf("invalid value %q in %s field", value, f.name)
+	}
+	if uint(v) < f.min || uint(v) > f.max {
+		return 0, errors.Errorf("value %d out of range [%d-%d] in %s field", v, f.min, f.max, f.name)
+	}
+	return uint(v), nil
+}
+
+func (c *cronRecurrence) next(t time.Time) time.Time {
+	t = t.In(c.loc)
+	// Start at the beginning of the next minute
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, c.loc)
+	yearLimit := t.Year() + maxLookAhead
+	for t.Year() <= yearLimit {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+			continue
+		}
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *cronRecurrence) dayMatches(t time.Time) bool {
+	domMatch := c.doms&(1<<uint(t.Day())) != 0
+	dowMatch := c.dows&(1<<uint(t.Weekday())) != 0
+	if c.domRestricted && c.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+func (e everyRecurrence) next(t time.Time) time.Time {
+	return t.Add(e.delay)
+}
@@ -0,0 +1,103 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	ref := time.Date(2018, time.June, 15, 10, 32, 45, 0, time.UTC) // a friday
+	tests := []struct {
+		name    string
+		expr    string
+		loc     *time.Location
+		want    []time.Time
+		wantErr bool
+	}{
+		{"EveryMinute", "* * * * *", time.UTC, []time.Time{
+			time.Date(2018, time.June, 15, 10, 33, 0, 0, time.UTC),
+			time.Date(2018, time.June, 15, 10, 34, 0, 0, time.UTC),
+		}, false},
+		{"Steps", "*/20 * * * *", time.UTC, []time.Time{
+			time.Date(2018, time.June, 15, 10, 40, 0, 0, time.UTC),
+			time.Date(2018, time.June, 15, 11, 0, 0, 0, time.UTC),
+		}, false},
+		{"Nightly", "30 2 * * *", time.UTC, []time.Time{
+			time.Date(2018, time.June, 16, 2, 30, 0, 0, time.UTC),
+			time.Date(2018, time.June, 17, 2, 30, 0, 0, time.UTC),
+		}, false},
+		{"NightlyInTimeZone", "0 2 * * *", paris, []time.Time{
+			time.Date(2018, time.June, 16, 0, 0, 0, 0, time.UTC),
+		}, false},
+		{"WorkingDays", "0 8 * * mon-fri", time.UTC, []time.Time{
+			time.Date(2018, time.June, 18, 8, 0, 0, 0, time.UTC),
+			time.Date(2018, time.June, 19, 8, 0, 0, 0, time.UTC),
+		}, false},
+		{"SundayAsSeven", "0 0 * * 7", time.UTC, []time.Time{
+			time.Date(2018, time.June, 17, 0, 0, 0, 0, time.UTC),
+		}, false},
+		{"ListsAndRanges", "0,30 9-10 1 jan,jul *", time.UTC, []time.Time{
+			time.Date(2018, time.July, 1, 9, 0, 0, 0, time.UTC),
+			time.Date(2018, time.July, 1, 9, 30, 0, 0, time.UTC),
+			time.Date(2018, time.July, 1, 10, 0, 0, 0, time.UTC),
+		}, false},
+		{"DayOfMonthOrDayOfWeek", "0 0 1 * sun", time.UTC, []time.Time{
+			time.Date(2018, time.June, 17, 0, 0, 0, 0, time.UTC),
+			time.Date(2018, time.June, 24, 0, 0, 0, 0, time.UTC),
+			time.Date(2018, time.July, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2018, time.July, 8, 0, 0, 0, 0, time.UTC),
+		}, false},
+		{"LeapDay", "0 0 29 2 *", time.UTC, []time.Time{
+			time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC),
+		}, false},
+		{"Monthly", "@monthly", time.UTC, []time.Time{
+			time.Date(2018, time.July, 1, 0, 0, 0, 0, time.UTC),
+		}, false},
+		{"Every", "@every 90m", time.UTC, []time.Time{
+			time.Date(2018, time.June, 15, 12, 2, 45, 0, time.UTC),
+			time.Date(2018, time.June, 15, 13, 32, 45, 0, time.UTC),
+		}, false},
+		{"NeverMatches", "0 0 30 2 *", time.UTC, []time.Time{{}}, false},
+		{"EveryTooShort", "@every 10s", time.UTC, nil, true},
+		{"UnknownDescriptor", "@fortnightly", time.UTC, nil, true},
+		{"MissingField", "0 2 * *", time.UTC, nil, true},
+		{"OutOfRange", "60 * * * *", time.UTC, nil, true},
+		{"InvalidRange", "0 10-2 * * *", time.UTC, nil, true},
+		{"InvalidStep", "*/0 * * * *", time.UTC, nil, true},
+		{"InvalidName", "0 0 * * monday", time.UTC, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseRecurrence(tt.expr, tt.loc)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			current := ref
+			for _, want := range tt.want {
+				current = r.next(current)
+				assert.True(t, want.Equal(current), "expecting %v got %v", want, current)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduling
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/tasks"
+)
+
+// checkInterval is the delay between two checks of schedules occurrences
+const checkInterval = 10 * time.Second
+
+var defaultScheduler *scheduler
+
+type scheduler struct {
+	cc            *api.Client
+	cfg           config.Configuration
+	chShutdown    chan struct{}
+	chStopRunning chan struct{}
+	isRunning     bool
+	isRunningLock sync.Mutex
+	serviceKey    string
+}
+
+// Start allows to instantiate a default Scheduler and to start firing schedules occurrences
+//
+// Only the leader of the Yorc cluster fires occurrences.
+func Start(cfg config.Configuration, cc *api.Client) {
+	defaultScheduler = &scheduler{
+		cc:         cc,
+		cfg:        cfg,
+		chShutdown: make(chan struct{}),
+		serviceKey: "service/scheduling/leader",
+	}
+
+	// Watch leader election for scheduling service
+	go consulutil.WatchLeaderElection(cc, defaultScheduler.serviceKey, defaultScheduler.chShutdown, defaultScheduler.startScheduling, defaultScheduler.stopScheduling)
+}
+
+// Stop allows to stop firing schedules occurrences
+func Stop() {
+	defaultScheduler.stopScheduling()
+
+	// Stop watch leader election
+	close(defaultScheduler.chShutdown)
+}
+
+func handleError(err error) {
+	err = errors.Wrap(err, "[WARN] Error during schedules processing")
+	log.Print(err)
+	log.Debugf("%+v", err)
+}
+
+func (s *scheduler) startScheduling() {
+	s.isRunningLock.Lock()
+	defer s.isRunningLock.Unlock()
+	if s.isRunning {
+		return
+	}
+	log.Debugf("Scheduling service is now running.")
+	s.isRunning = true
+	s.chStopRunning = make(chan struct{})
+	go s.run(s.chStopRunning)
+}
+
+func (s *scheduler) stopScheduling() {
+	s.isRunningLock.Lock()
+	defer s.isRunningLock.Unlock()
+	if s.isRunning {
+		log.Debugf("Scheduling service is about to be stopped")
+		close(s.chStopRunning)
+		s.isRunning = false
+	}
+}
+
+func (s *scheduler) run(chStop chan struct{}) {
+	kv := s.cc.KV()
+	collector := tasks.NewCollector(s.cc)
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-chStop:
+			log.Debugf("Ending scheduling has been requested: stop it now.")
+			return
+		case <-s.chShutdown:
+			log.Debugf("Shutdown has been sent: stop scheduling now.")
+			return
+		case now := <-ticker.C:
+			schedules, err := ListSchedules(kv, "")
+			if err != nil {
+				handleError(err)
+				continue
+			}
+			for _, schedule := range schedules {
+				if err = handleSchedule(kv, collector, schedule, now); err != nil {
+					handleError(errors.Wrapf(err, "failed to process schedule %q of deployment %q", schedule.ID, schedule.DeploymentID))
+				}
+			}
+		}
+	}
+}
+
+// handleSchedule registers a task for a schedule if one of its occurrences is due at the given time
+//
+// Missed occurrences (for instance while no leader was elected) are coalesced into a single one.
+// The occurrence is claimed by updating the schedule status before registering the task so that it is never
+// fired twice even if the leadership changes. If another task is running on the deployment the occurrence is
+// either skipped or kept pending depending on the schedule overlap policy.
+func handleSchedule(kv *api.KV, collector *tasks.Collector, schedule Schedule, now time.Time) error {
+	r, err := schedule.recurrence()
+	if err != nil {
+		return err
+	}
+	status, index, err := getStatus(kv, schedule.DeploymentID, schedule.ID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		status = &Status{}
+	}
+	reference := schedule.Created
+	if !status.LastOccurrence.IsZero() {
+		reference = status.LastOccurrence
+	}
+
+	newStatus := *status
+	fire := status.Pending
+	if occurrence := r.next(reference); !occurrence.IsZero() && !occurrence.After(now) {
+		for n := r.next(occurrence); !n.IsZero() && !n.After(now); n = r.next(n) {
+			occurrence = n
+		}
+		newStatus.LastOccurrence = occurrence
+		reference = occurrence
+		fire = true
+	}
+	newStatus.NextOccurrence = r.next(reference)
+	if !fire {
+		if status.NextOccurrence.Equal(newStatus.NextOccurrence) {
+			return nil
+		}
+		_, err = casStatus(kv, schedule.DeploymentID, schedule.ID, newStatus, index)
+		return err
+	}
+
+	exists, err := deployments.DoesDeploymentExists(kv, schedule.DeploymentID)
+	if err != nil || !exists {
+		return err
+	}
+	newStatus.Pending = false
+	ok, err := casStatus(kv, schedule.DeploymentID, schedule.ID, newStatus, index)
+	if err != nil || !ok {
+		return err
+	}
+
+	taskID, err := registerTask(kv, collector, schedule)
+	if err != nil {
+		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); !ok {
+			events.SimpleLogEntry(events.ERROR, schedule.DeploymentID).Registerf("Failed to register task for schedule %q: %v", schedule.ID, err)
+			return err
+		}
+		if schedule.OverlapPolicy != OverlapQueue {
+			events.SimpleLogEntry(events.WARN, schedule.DeploymentID).Registerf("Occurrence of schedule %q skipped as another task is running: %v", schedule.ID, err)
+			return nil
+		}
+		if !status.Pending {
+			events.SimpleLogEntry(events.INFO, schedule.DeploymentID).Registerf("Occurrence of schedule %q delayed until running tasks end: %v", schedule.ID, err)
+		}
+		newStatus.Pending = true
+	} else {
+		events.SimpleLogEntry(events.INFO, schedule.DeploymentID).Registerf("Task %q registered for schedule %q", taskID, schedule.ID)
+		newStatus.LastTaskID = taskID
+	}
+	// The occurrence was claimed just before, we are still the owner of the status
+	_, index, err = getStatus(kv, schedule.DeploymentID, schedule.ID)
+	if err != nil {
+		return err
+	}
+	_, err = casStatus(kv, schedule.DeploymentID, schedule.ID, newStatus, index)
+	return err
+}
+
+// registerTask registers a custom workflow or a custom command task for a schedule
+func registerTask(kv *api.KV, collector *tasks.Collector, schedule Schedule) (string, error) {
+	data := make(map[string]string)
+	data["scheduleID"] = schedule.ID
+	for name, value := range schedule.Inputs {
+		data[path.Join("inputs", name)] = value
+	}
+	if schedule.WorkflowName != "" {
+		data["workflowName"] = schedule.WorkflowName
+		data["continueOnError"] = strconv.FormatBool(schedule.ContinueOnError)
+		return collector.RegisterTaskWithData(schedule.DeploymentID, tasks.CustomWorkflow, data)
+	}
+	instances, err := deployments.GetNodeInstancesIds(kv, schedule.DeploymentID, schedule.NodeName)
+	if err != nil {
+		return "", err
+	}
+	data[path.Join("nodes", schedule.NodeName)] = strings.Join(instances, ",")
+	data["commandName"] = schedule.CustomCommandName
+	return collector.RegisterTaskWithData(schedule.DeploymentID, tasks.CustomCommand, data)
+}
@@ -0,0 +1,177 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduling
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+
+	"github.com/ystia/yorc/helper/consulutil"
+)
+
+var schedulesPrefix = path.Join(consulutil.SchedulingPrefix, "schedules")
+var statusesPrefix = path.Join(consulutil.SchedulingPrefix, "statuses")
+
+type scheduleNotFound struct {
+	deploymentID string
+	id           string
+}
+
+func (e scheduleNotFound) Error() string {
+	return fmt.Sprintf("schedule %q not found for deployment %q", e.id, e.deploymentID)
+}
+
+// IsScheduleNotFoundError checks if an error is due to a schedule that does not exist
+func IsScheduleNotFoundError(err error) bool {
+	_, ok := errors.Cause(err).(scheduleNotFound)
+	return ok
+}
+
+// CreateSchedule validates and stores a new schedule and returns its generated ID
+func CreateSchedule(kv *api.KV, schedule Schedule) (string, error) {
+	schedule.ID = fmt.Sprint(uuid.NewV4())
+	return schedule.ID, storeSchedule(kv, schedule)
+}
+
+// UpdateSchedule validates and replaces the definition of an existing schedule
+//
+// Occurrences of the updated schedule are computed from the update time, a pending occurrence is dropped.
+func UpdateSchedule(kv *api.KV, schedule Schedule) error {
+	if _, err := GetSchedule(kv, schedule.DeploymentID, schedule.ID); err != nil {
+		return err
+	}
+	if err := storeSchedule(kv, schedule); err != nil {
+		return err
+	}
+	_, err := kv.Delete(path.Join(statusesPrefix, schedule.DeploymentID, schedule.ID), nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
+
+func storeSchedule(kv *api.KV, schedule Schedule) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	if schedule.OverlapPolicy == "" {
+		schedule.OverlapPolicy = OverlapSkip
+	}
+	schedule.Created = time.Now().UTC()
+	schedule.Status = nil
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode schedule")
+	}
+	_, err = kv.Put(&api.KVPair{Key: path.Join(schedulesPrefix, schedule.DeploymentID, schedule.ID), Value: data}, nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
+
+// GetSchedule returns a schedule of a deployment given its ID
+//
+// An error that could be checked with IsScheduleNotFoundError is returned if the schedule does not exist.
+func GetSchedule(kv *api.KV, deploymentID, id string) (Schedule, error) {
+	var schedule Schedule
+	kvp, _, err := kv.Get(path.Join(schedulesPrefix, deploymentID, id), nil)
+	if err != nil {
+		return schedule, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil {
+		return schedule, errors.WithStack(scheduleNotFound{deploymentID: deploymentID, id: id})
+	}
+	if err = json.Unmarshal(kvp.Value, &schedule); err != nil {
+		return schedule, errors.Wrapf(err, "failed to decode schedule %q", id)
+	}
+	schedule.Status, _, err = getStatus(kv, deploymentID, id)
+	return schedule, err
+}
+
+// ListSchedules returns the schedules of a deployment or of all deployments if deploymentID is empty
+func ListSchedules(kv *api.KV, deploymentID string) ([]Schedule, error) {
+	prefix := schedulesPrefix + "/"
+	if deploymentID != "" {
+		prefix = path.Join(schedulesPrefix, deploymentID) + "/"
+	}
+	kvps, _, err := kv.List(prefix, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	schedules := make([]Schedule, 0, len(kvps))
+	for _, kvp := range kvps {
+		var schedule Schedule
+		if err = json.Unmarshal(kvp.Value, &schedule); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode schedule %q", kvp.Key)
+		}
+		if schedule.Status, _, err = getStatus(kv, schedule.DeploymentID, schedule.ID); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// DeleteSchedule removes a schedule of a deployment
+func DeleteSchedule(kv *api.KV, deploymentID, id string) error {
+	if _, err := GetSchedule(kv, deploymentID, id); err != nil {
+		return err
+	}
+	_, err := kv.Delete(path.Join(schedulesPrefix, deploymentID, id), nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	_, err = kv.Delete(path.Join(statusesPrefix, deploymentID, id), nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
+
+// DeleteDeploymentSchedules removes all the schedules of a deployment
+func DeleteDeploymentSchedules(kv *api.KV, deploymentID string) error {
+	_, err := kv.DeleteTree(path.Join(schedulesPrefix, deploymentID)+"/", nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	_, err = kv.DeleteTree(path.Join(statusesPrefix, deploymentID)+"/", nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
+
+// getStatus returns the status of a schedule and the modify index of its key
+//
+// A nil status and a zero index are returned if the schedule never fired.
+func getStatus(kv *api.KV, deploymentID, id string) (*Status, uint64, error) {
+	kvp, _, err := kv.Get(path.Join(statusesPrefix, deploymentID, id), nil)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil {
+		return nil, 0, nil
+	}
+	status := new(Status)
+	err = json.Unmarshal(kvp.Value, status)
+	return status, kvp.ModifyIndex, errors.Wrapf(err, "failed to decode status of schedule %q", id)
+}
+
+// casStatus stores the status of a schedule if its key was not modified since the given index
+//
+// A zero index means that the status should not exist yet.
+func casStatus(kv *api.KV, deploymentID, id string, status Status, index uint64) (bool, error) {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to encode schedule status")
+	}
+	ok, _, err := kv.CAS(&api.KVPair{Key: path.Join(statusesPrefix, deploymentID, id), Value: data, ModifyIndex: index}, nil)
+	return ok, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
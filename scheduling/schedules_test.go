@@ -0,0 +1,162 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduling
+
+import (
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/tasks"
+)
+
+func testSchedules(t *testing.T, kv *api.KV) {
+	_, err := CreateSchedule(kv, Schedule{DeploymentID: "schedApp", Cron: "@daily"})
+	require.Error(t, err)
+
+	schedule := Schedule{DeploymentID: "schedApp", Cron: "0 2 * * *", WorkflowName: "backup", Inputs: map[string]string{"target": "s3"}}
+	id, err := CreateSchedule(kv, schedule)
+	require.NoError(t, err)
+	require.NotEmpty(t, id)
+
+	got, err := GetSchedule(kv, "schedApp", id)
+	require.NoError(t, err)
+	require.False(t, got.Created.IsZero())
+	require.Nil(t, got.Status)
+	schedule.ID = id
+	schedule.OverlapPolicy = OverlapSkip
+	schedule.Created = got.Created
+	require.Equal(t, schedule, got)
+
+	_, err = GetSchedule(kv, "otherApp", id)
+	require.True(t, IsScheduleNotFoundError(err))
+
+	ok, err := casStatus(kv, "schedApp", id, Status{LastTaskID: "t1", Pending: true}, 0)
+	require.NoError(t, err)
+	require.True(t, ok)
+	schedules, err := ListSchedules(kv, "schedApp")
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	require.Equal(t, &Status{LastTaskID: "t1", Pending: true}, schedules[0].Status)
+
+	// Updating a schedule resets its status
+	schedule.Cron = "@weekly"
+	require.NoError(t, UpdateSchedule(kv, schedule))
+	got, err = GetSchedule(kv, "schedApp", id)
+	require.NoError(t, err)
+	require.Equal(t, "@weekly", got.Cron)
+	require.Nil(t, got.Status)
+	err = UpdateSchedule(kv, Schedule{ID: "unknown", DeploymentID: "schedApp", Cron: "@daily", WorkflowName: "backup"})
+	require.True(t, IsScheduleNotFoundError(err))
+
+	otherID, err := CreateSchedule(kv, Schedule{DeploymentID: "otherApp", Cron: "@hourly", NodeName: "Cert", CustomCommandName: "renew"})
+	require.NoError(t, err)
+	schedules, err = ListSchedules(kv, "")
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+
+	require.NoError(t, DeleteSchedule(kv, "schedApp", id))
+	_, err = GetSchedule(kv, "schedApp", id)
+	require.True(t, IsScheduleNotFoundError(err))
+	require.True(t, IsScheduleNotFoundError(DeleteSchedule(kv, "schedApp", id)))
+
+	require.NoError(t, DeleteDeploymentSchedules(kv, "otherApp"))
+	_, err = GetSchedule(kv, "otherApp", otherID)
+	require.True(t, IsScheduleNotFoundError(err))
+}
+
+func testHandleSchedule(t *testing.T, client *api.Client) {
+	kv := client.KV()
+	collector := tasks.NewCollector(client)
+	deploymentID := "handleSchedApp"
+	_, err := kv.Put(&api.KVPair{Key: path.Join(consulutil.DeploymentKVPrefix, deploymentID, "status"), Value: []byte("DEPLOYED")}, nil)
+	require.NoError(t, err)
+	_, err = kv.Put(&api.KVPair{Key: path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances/Cert/0/attributes/state"), Value: []byte("started")}, nil)
+	require.NoError(t, err)
+
+	id, err := CreateSchedule(kv, Schedule{DeploymentID: deploymentID, Cron: "@every 1h", WorkflowName: "backup", Inputs: map[string]string{"target": "s3"}})
+	require.NoError(t, err)
+	schedule, err := GetSchedule(kv, deploymentID, id)
+	require.NoError(t, err)
+	created := schedule.Created
+
+	// No occurrence due yet
+	require.NoError(t, handleSchedule(kv, collector, schedule, created.Add(30*time.Minute)))
+	schedule, err = GetSchedule(kv, deploymentID, id)
+	require.NoError(t, err)
+	require.NotNil(t, schedule.Status)
+	require.True(t, schedule.Status.LastOccurrence.IsZero())
+	require.True(t, created.Add(time.Hour).Equal(schedule.Status.NextOccurrence))
+
+	// Missed occurrences are coalesced
+	require.NoError(t, handleSchedule(kv, collector, schedule, created.Add(3*time.Hour+30*time.Minute)))
+	schedule, err = GetSchedule(kv, deploymentID, id)
+	require.NoError(t, err)
+	require.True(t, created.Add(3*time.Hour).Equal(schedule.Status.LastOccurrence))
+	require.True(t, created.Add(4*time.Hour).Equal(schedule.Status.NextOccurrence))
+	taskID := schedule.Status.LastTaskID
+	require.NotEmpty(t, taskID)
+	taskType, err := tasks.GetTaskType(kv, taskID)
+	require.NoError(t, err)
+	require.Equal(t, tasks.CustomWorkflow, taskType)
+	wfName, err := tasks.GetTaskData(kv, taskID, "workflowName")
+	require.NoError(t, err)
+	require.Equal(t, "backup", wfName)
+	input, err := tasks.GetTaskInput(kv, taskID, "target")
+	require.NoError(t, err)
+	require.Equal(t, "s3", input)
+
+	// The task is still running: occurrences are skipped by default
+	require.NoError(t, handleSchedule(kv, collector, schedule, created.Add(4*time.Hour+10*time.Minute)))
+	schedule, err = GetSchedule(kv, deploymentID, id)
+	require.NoError(t, err)
+	require.True(t, created.Add(4*time.Hour).Equal(schedule.Status.LastOccurrence))
+	require.Equal(t, taskID, schedule.Status.LastTaskID)
+	require.False(t, schedule.Status.Pending)
+
+	// or queued until the running task ends
+	queuedID, err := CreateSchedule(kv, Schedule{DeploymentID: deploymentID, Cron: "@every 1h", NodeName: "Cert", CustomCommandName: "renew", OverlapPolicy: OverlapQueue})
+	require.NoError(t, err)
+	queued, err := GetSchedule(kv, deploymentID, queuedID)
+	require.NoError(t, err)
+	now := queued.Created.Add(time.Hour + time.Minute)
+	require.NoError(t, handleSchedule(kv, collector, queued, now))
+	queued, err = GetSchedule(kv, deploymentID, queuedID)
+	require.NoError(t, err)
+	require.True(t, queued.Status.Pending)
+	require.Empty(t, queued.Status.LastTaskID)
+
+	_, err = kv.Put(&api.KVPair{Key: path.Join(consulutil.TasksPrefix, taskID, "status"), Value: []byte(strconv.Itoa(int(tasks.DONE)))}, nil)
+	require.NoError(t, err)
+	require.NoError(t, handleSchedule(kv, collector, queued, now))
+	queued, err = GetSchedule(kv, deploymentID, queuedID)
+	require.NoError(t, err)
+	require.False(t, queued.Status.Pending)
+	require.NotEmpty(t, queued.Status.LastTaskID)
+	taskType, err = tasks.GetTaskType(kv, queued.Status.LastTaskID)
+	require.NoError(t, err)
+	require.Equal(t, tasks.CustomCommand, taskType)
+	commandName, err := tasks.GetTaskData(kv, queued.Status.LastTaskID, "commandName")
+	require.NoError(t, err)
+	require.Equal(t, "renew", commandName)
+	instances, err := tasks.GetTaskData(kv, queued.Status.LastTaskID, "nodes/Cert")
+	require.NoError(t, err)
+	require.Equal(t, "0", instances)
+}
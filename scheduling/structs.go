@@ -0,0 +1,96 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduling
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OverlapPolicy defines what happens to an occurrence of a schedule when another task is running on the deployment
+type OverlapPolicy string
+
+const (
+	// OverlapSkip skips the occurrence, this is the default policy
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue delays the occurrence until the deployment has no more running task
+	OverlapQueue OverlapPolicy = "queue"
+)
+
+// Schedule registers recurring executions of a workflow or of a custom command of a deployment
+//
+// Exactly one of WorkflowName or CustomCommandName should be set.
+type Schedule struct {
+	ID           string `json:"id"`
+	DeploymentID string `json:"deployment_id"`
+	// Cron is a 5 fields cron expression, a descriptor like "@daily" or an "@every <duration>" expression
+	Cron string `json:"cron"`
+	// TimeZone is the IANA name of the time zone used to compute occurrences, defaults to UTC
+	TimeZone          string            `json:"time_zone,omitempty"`
+	WorkflowName      string            `json:"workflow_name,omitempty"`
+	ContinueOnError   bool              `json:"continue_on_error,omitempty"`
+	NodeName          string            `json:"node_name,omitempty"`
+	CustomCommandName string            `json:"custom_command_name,omitempty"`
+	Inputs            map[string]string `json:"inputs,omitempty"`
+	OverlapPolicy     OverlapPolicy     `json:"overlap_policy,omitempty"`
+	// Created is the time of the creation or of the last update of the schedule, first occurrence follows it
+	Created time.Time `json:"created"`
+	// Status is computed by the scheduler, it is ignored when creating or updating a schedule
+	Status *Status `json:"status,omitempty"`
+}
+
+// Status reports the executions of a schedule
+type Status struct {
+	// LastOccurrence is the last occurrence handled by the scheduler, missed occurrences are not caught up
+	LastOccurrence time.Time `json:"last_occurrence,omitempty"`
+	NextOccurrence time.Time `json:"next_occurrence,omitempty"`
+	// LastTaskID is the ID of the last task registered for this schedule
+	LastTaskID string `json:"last_task_id,omitempty"`
+	// Pending is true when an occurrence waits for running tasks of the deployment to end
+	Pending bool `json:"pending,omitempty"`
+}
+
+// Validate checks that a schedule has a valid recurrence and target
+func (s Schedule) Validate() error {
+	if (s.WorkflowName == "") == (s.CustomCommandName == "") {
+		return errors.New("either a workflow name or a custom command name should be defined")
+	}
+	if s.CustomCommandName != "" && s.NodeName == "" {
+		return errors.New("a node name is required to schedule a custom command")
+	}
+	switch s.OverlapPolicy {
+	case "", OverlapSkip, OverlapQueue:
+	default:
+		return errors.Errorf("invalid overlap policy %q, expecting %q or %q", s.OverlapPolicy, OverlapSkip, OverlapQueue)
+	}
+	r, err := s.recurrence()
+	if err != nil {
+		return err
+	}
+	if r.next(time.Now()).IsZero() {
+		return errors.Errorf("cron expression %q never matches", s.Cron)
+	}
+	return nil
+}
+
+// recurrence returns the parsed recurrence of a schedule
+func (s Schedule) recurrence() (recurrence, error) {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid time zone %q", s.TimeZone)
+	}
+	return parseRecurrence(s.Cron, loc)
+}
@@ -0,0 +1,45 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduling
+
+import (
+	"testing"
+)
+
+func TestScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		wantErr  bool
+	}{
+		{"Workflow", Schedule{Cron: "@daily", WorkflowName: "backup"}, false},
+		{"CustomCommand", Schedule{Cron: "0 3 * * sun", NodeName: "Cert", CustomCommandName: "renew", OverlapPolicy: OverlapQueue}, false},
+		{"TimeZone", Schedule{Cron: "0 2 * * *", TimeZone: "Europe/Paris", WorkflowName: "backup"}, false},
+		{"NoTarget", Schedule{Cron: "@daily"}, true},
+		{"BothTargets", Schedule{Cron: "@daily", WorkflowName: "backup", NodeName: "Cert", CustomCommandName: "renew"}, true},
+		{"CustomCommandWithoutNode", Schedule{Cron: "@daily", CustomCommandName: "renew"}, true},
+		{"InvalidCron", Schedule{Cron: "every day", WorkflowName: "backup"}, true},
+		{"NeverMatches", Schedule{Cron: "0 0 31 4 *", WorkflowName: "backup"}, true},
+		{"InvalidTimeZone", Schedule{Cron: "@daily", TimeZone: "Mars/Olympus", WorkflowName: "backup"}, true},
+		{"InvalidPolicy", Schedule{Cron: "@daily", WorkflowName: "backup", OverlapPolicy: "replace"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.schedule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Schedule.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -27,8 +27,11 @@ import (
 	"github.com/ystia/yorc/config"
 	"github.com/ystia/yorc/helper/consulutil"
 	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/notifications"
+	"github.com/ystia/yorc/prov/hostspool"
 	"github.com/ystia/yorc/prov/monitoring"
 	"github.com/ystia/yorc/rest"
+	"github.com/ystia/yorc/scheduling"
 	"github.com/ystia/yorc/tasks/workflow"
 )
 
@@ -87,6 +90,18 @@ func RunServer(configuration config.Configuration, shutdownCh chan struct{}) err
 	// Start monitoring
 	monitoring.Start(configuration, client)
 	defer monitoring.Stop()
+	// Start webhooks notifications
+	notifications.Start(configuration, client)
+	defer notifications.Stop()
+	// Start scheduled workflows
+	scheduling.Start(configuration, client)
+	defer scheduling.Stop()
+	// Start hosts pool allocations reaper
+	hostspool.StartReaper(client)
+	defer hostspool.StopReaper()
+	// Start hosts pool health checks
+	hostspool.StartProber(configuration, client)
+	defer hostspool.StopProber()
 
 WAIT:
 	signalCh := make(chan os.Signal, 4)
@@ -42,7 +42,22 @@ func NewCollector(consulClient *api.Client) *Collector {
 //
 // The task id is returned.
 func (c *Collector) RegisterTaskWithData(targetID string, taskType TaskType, data map[string]string) (string, error) {
-	destroy, lock, taskID, err := c.registerTaskWithoutDestroyLock(targetID, taskType, data)
+	return c.registerTaskWithData(targetID, taskType, data, false)
+}
+
+// QueueTaskWithData register a new Task of a given type with some data
+//
+// Contrary to RegisterTaskWithData, if other tasks are living for the given target the
+// task is not rejected but created with the QUEUED status. Queued tasks are dispatched
+// one at a time in their creation order once previous tasks are completed.
+//
+// The task id is returned.
+func (c *Collector) QueueTaskWithData(targetID string, taskType TaskType, data map[string]string) (string, error) {
+	return c.registerTaskWithData(targetID, taskType, data, true)
+}
+
+func (c *Collector) registerTaskWithData(targetID string, taskType TaskType, data map[string]string, queue bool) (string, error) {
+	destroy, lock, taskID, err := c.registerTaskWithoutDestroyLock(targetID, taskType, data, queue)
 	if destroy != nil {
 		defer destroy(lock, taskID, targetID)
 	}
@@ -60,13 +75,17 @@ func (c *Collector) RegisterTask(targetID string, taskType TaskType) (string, er
 	return c.RegisterTaskWithData(targetID, taskType, nil)
 }
 
-func (c *Collector) registerTaskWithoutDestroyLock(targetID string, taskType TaskType, data map[string]string) (func(taskLockCreate *api.Lock, taskId, targetId string), *api.Lock, string, error) { // First check if other tasks are running for this target before creating a new one
+func (c *Collector) registerTaskWithoutDestroyLock(targetID string, taskType TaskType, data map[string]string, queue bool) (func(taskLockCreate *api.Lock, taskId, targetId string), *api.Lock, string, error) { // First check if other tasks are running for this target before creating a new one
 	hasLivingTask, livingTaskID, livingTaskStatus, err := TargetHasLivingTasks(c.consulClient.KV(), targetID)
 	if err != nil {
 		return nil, nil, "", err
-	} else if hasLivingTask {
+	} else if hasLivingTask && !queue {
 		return nil, nil, "", anotherLivingTaskAlreadyExistsError{taskID: livingTaskID, targetID: targetID, status: livingTaskStatus}
 	}
+	status := INITIAL
+	if hasLivingTask {
+		status = QUEUED
+	}
 	taskID := fmt.Sprint(uuid.NewV4())
 	kv := c.consulClient.KV()
 	taskPrefix := consulutil.TasksPrefix + "/" + taskID
@@ -91,7 +110,7 @@ func (c *Collector) registerTaskWithoutDestroyLock(targetID string, taskType Tas
 	if _, err := kv.Put(key, nil); err != nil {
 		return nil, nil, taskID, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
 	}
-	key = &api.KVPair{Key: taskPrefix + "/status", Value: []byte(strconv.Itoa(int(INITIAL)))}
+	key = &api.KVPair{Key: taskPrefix + "/status", Value: []byte(strconv.Itoa(int(status)))}
 	if _, err := kv.Put(key, nil); err != nil {
 		return nil, nil, taskID, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
 	}
@@ -116,7 +135,7 @@ func (c *Collector) registerTaskWithoutDestroyLock(targetID string, taskType Tas
 		}
 	}
 
-	EmitTaskEvent(kv, targetID, taskID, taskType, INITIAL.String())
+	EmitTaskEvent(kv, targetID, taskID, taskType, status.String())
 
 	destroy := func(taskLockCreate *api.Lock, taskId, targetId string) {
 		log.Debugf("Unlocking newly created task with id %q (target id %q)", taskId, targetId)
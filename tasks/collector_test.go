@@ -0,0 +1,113 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/ystia/yorc/helper/consulutil"
+)
+
+func setTaskStatus(t *testing.T, kv *api.KV, taskID string, status TaskStatus) {
+	_, err := kv.Put(&api.KVPair{Key: path.Join(consulutil.TasksPrefix, taskID, "status"), Value: []byte(strconv.Itoa(int(status)))}, nil)
+	if err != nil {
+		t.Fatalf("Failed to update status of task %q: %v", taskID, err)
+	}
+}
+
+func checkTaskStatus(t *testing.T, kv *api.KV, taskID string, expected TaskStatus) {
+	status, err := GetTaskStatus(kv, taskID)
+	if err != nil {
+		t.Fatalf("GetTaskStatus() unexpected error: %v", err)
+	}
+	if status != expected {
+		t.Fatalf("GetTaskStatus() = %v, want %v", status, expected)
+	}
+}
+
+func checkStartNextQueuedTask(t *testing.T, kv *api.KV, targetID, expected string) {
+	started, err := StartNextQueuedTask(kv, targetID)
+	if err != nil {
+		t.Fatalf("StartNextQueuedTask() unexpected error: %v", err)
+	}
+	if started != expected {
+		t.Fatalf("StartNextQueuedTask() = %q, want %q", started, expected)
+	}
+}
+
+func testQueueTasks(t *testing.T, client *api.Client) {
+	kv := client.KV()
+	collector := NewCollector(client)
+	targetID := "queueTarget"
+
+	first, err := collector.QueueTaskWithData(targetID, CustomWorkflow, map[string]string{"workflowName": "wf1"})
+	if err != nil {
+		t.Fatalf("QueueTaskWithData() unexpected error: %v", err)
+	}
+	checkTaskStatus(t, kv, first, INITIAL)
+
+	second, err := collector.QueueTaskWithData(targetID, ScaleOut, map[string]string{"scaling/nodeName": "Compute", "scaling/instancesDelta": "1"})
+	if err != nil {
+		t.Fatalf("QueueTaskWithData() unexpected error: %v", err)
+	}
+	checkTaskStatus(t, kv, second, QUEUED)
+	third, err := collector.QueueTaskWithData(targetID, CustomWorkflow, map[string]string{"workflowName": "wf2"})
+	if err != nil {
+		t.Fatalf("QueueTaskWithData() unexpected error: %v", err)
+	}
+	checkTaskStatus(t, kv, third, QUEUED)
+	fourth, err := collector.QueueTaskWithData(targetID, CustomWorkflow, map[string]string{"workflowName": "wf3"})
+	if err != nil {
+		t.Fatalf("QueueTaskWithData() unexpected error: %v", err)
+	}
+
+	_, err = collector.RegisterTaskWithData(targetID, CustomWorkflow, map[string]string{"workflowName": "wf4"})
+	if ok, _ := IsAnotherLivingTaskAlreadyExistsError(err); !ok {
+		t.Fatalf("RegisterTaskWithData() expecting another living task error, got: %v", err)
+	}
+
+	// The first task is still living
+	checkStartNextQueuedTask(t, kv, targetID, "")
+	setTaskStatus(t, kv, first, RUNNING)
+	checkStartNextQueuedTask(t, kv, targetID, "")
+
+	setTaskStatus(t, kv, first, DONE)
+	checkStartNextQueuedTask(t, kv, targetID, second)
+	checkTaskStatus(t, kv, second, INITIAL)
+	checkTaskStatus(t, kv, third, QUEUED)
+
+	// Canceling a queued task doesn't wait for it to be dispatched
+	if err = CancelTask(kv, third); err != nil {
+		t.Fatalf("CancelTask() unexpected error: %v", err)
+	}
+	checkTaskStatus(t, kv, third, CANCELED)
+
+	// Rollback tasks are started before other queued tasks
+	setTaskStatus(t, kv, second, FAILED)
+	rollback, err := collector.QueueTaskWithData(targetID, Rollback, map[string]string{"rollbackOf": second})
+	if err != nil {
+		t.Fatalf("QueueTaskWithData() unexpected error: %v", err)
+	}
+	checkTaskStatus(t, kv, rollback, QUEUED)
+	checkStartNextQueuedTask(t, kv, targetID, rollback)
+
+	setTaskStatus(t, kv, rollback, DONE)
+	checkStartNextQueuedTask(t, kv, targetID, fourth)
+	setTaskStatus(t, kv, fourth, DONE)
+	checkStartNextQueuedTask(t, kv, targetID, "")
+}
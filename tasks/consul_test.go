@@ -87,5 +87,8 @@ func TestRunConsulTasksPackageTests(t *testing.T) {
 		t.Run("testGetQueryTaskIDs", func(t *testing.T) {
 			testGetQueryTaskIDs(t, kv)
 		})
+		t.Run("testQueueTasks", func(t *testing.T) {
+			testQueueTasks(t, client)
+		})
 	})
 }
@@ -41,7 +41,13 @@ const (
 	CustomWorkflow
 	// Query defines a Task of type "Query"
 	Query
-	// NOTE: if a new task type should be added then change validity check on GetTaskType
+	// Heal defines a Task of type "Heal"
+	Heal
+	// Update defines a Task of type "Update"
+	Update
+	// Rollback defines a Task of type "Rollback"
+	Rollback
+	// NOTE: if a new task type should be added then change validity check on GetTaskType and ParseTaskType
 )
 
 // TaskStatus represents the status of a Task
@@ -52,7 +58,9 @@ type TaskStatus int
 // RUNNING,
 // DONE,
 // ERROR,
-// CANCELED
+// CANCELED,
+// TIMEOUT,
+// WAITING
 // )
 type TaskStepStatus int
 
@@ -67,6 +75,8 @@ const (
 	FAILED
 	// CANCELED is the status of a canceled task
 	CANCELED
+	// QUEUED is the status of a task waiting for previous tasks on the same target to complete before being dispatched
+	QUEUED
 	// NOTE: if a new status should be added then change validity check on GetTaskStatus
 )
 
@@ -33,9 +33,13 @@ const (
 	TaskStepStatusERROR
 	// TaskStepStatusCANCELED is a TaskStepStatus of type CANCELED
 	TaskStepStatusCANCELED
+	// TaskStepStatusTIMEOUT is a TaskStepStatus of type TIMEOUT
+	TaskStepStatusTIMEOUT
+	// TaskStepStatusWAITING is a TaskStepStatus of type WAITING
+	TaskStepStatusWAITING
 )
 
-const _TaskStepStatusName = "INITIALRUNNINGDONEERRORCANCELED"
+const _TaskStepStatusName = "INITIALRUNNINGDONEERRORCANCELEDTIMEOUTWAITING"
 
 var _TaskStepStatusMap = map[TaskStepStatus]string{
 	0: _TaskStepStatusName[0:7],
@@ -43,6 +47,8 @@ var _TaskStepStatusMap = map[TaskStepStatus]string{
 	2: _TaskStepStatusName[14:18],
 	3: _TaskStepStatusName[18:23],
 	4: _TaskStepStatusName[23:31],
+	5: _TaskStepStatusName[31:38],
+	6: _TaskStepStatusName[38:45],
 }
 
 func (i TaskStepStatus) String() string {
@@ -63,6 +69,10 @@ var _TaskStepStatusValue = map[string]TaskStepStatus{
 	strings.ToLower(_TaskStepStatusName[18:23]): 3,
 	_TaskStepStatusName[23:31]:                  4,
 	strings.ToLower(_TaskStepStatusName[23:31]): 4,
+	_TaskStepStatusName[31:38]:                  5,
+	strings.ToLower(_TaskStepStatusName[31:38]): 5,
+	_TaskStepStatusName[38:45]:                  6,
+	strings.ToLower(_TaskStepStatusName[38:45]): 6,
 }
 
 // ParseTaskStepStatus attempts to convert a string to a TaskStepStatus
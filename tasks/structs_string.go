@@ -18,9 +18,9 @@ package tasks
 
 import "strconv"
 
-const _TaskStatus_name = "INITIALRUNNINGDONEFAILEDCANCELED"
+const _TaskStatus_name = "INITIALRUNNINGDONEFAILEDCANCELEDQUEUED"
 
-var _TaskStatus_index = [...]uint8{0, 7, 14, 18, 24, 32}
+var _TaskStatus_index = [...]uint8{0, 7, 14, 18, 24, 32, 38}
 
 func (i TaskStatus) String() string {
 	if i < 0 || i >= TaskStatus(len(_TaskStatus_index)-1) {
@@ -29,9 +29,9 @@ func (i TaskStatus) String() string {
 	return _TaskStatus_name[_TaskStatus_index[i]:_TaskStatus_index[i+1]]
 }
 
-const _TaskType_name = "DeployUnDeployScaleOutScaleInPurgeCustomCommandCustomWorkflowQuery"
+const _TaskType_name = "DeployUnDeployScaleOutScaleInPurgeCustomCommandCustomWorkflowQueryHealUpdateRollback"
 
-var _TaskType_index = [...]uint8{0, 6, 14, 22, 29, 34, 47, 61, 66}
+var _TaskType_index = [...]uint8{0, 6, 14, 22, 29, 34, 47, 61, 66, 70, 76, 84}
 
 func (i TaskType) String() string {
 	if i < 0 || i >= TaskType(len(_TaskType_index)-1) {
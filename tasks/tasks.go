@@ -17,6 +17,7 @@ package tasks
 import (
 	"fmt"
 	"path"
+	"sort"
 	"strconv"
 
 	"strings"
@@ -93,7 +94,7 @@ func GetTaskStatus(kv *api.KV, taskID string) (TaskStatus, error) {
 	if err != nil {
 		return FAILED, errors.Wrapf(err, "Invalid task status:")
 	}
-	if statusInt < 0 || statusInt > int(CANCELED) {
+	if statusInt < 0 || statusInt > int(QUEUED) {
 		return FAILED, errors.Errorf("Invalid status for task with id %q: %q", taskID, string(kvp.Value))
 	}
 	return TaskStatus(statusInt), nil
@@ -112,12 +113,22 @@ func GetTaskType(kv *api.KV, taskID string) (TaskType, error) {
 	if err != nil {
 		return Deploy, errors.Wrapf(err, "Invalid task type:")
 	}
-	if typeInt < 0 || typeInt > int(Query) {
+	if typeInt < 0 || typeInt > int(Rollback) {
 		return Deploy, errors.Errorf("Invalid type for task with id %q: %q", taskID, string(kvp.Value))
 	}
 	return TaskType(typeInt), nil
 }
 
+// ParseTaskType returns the TaskType matching the given case insensitive name
+func ParseTaskType(name string) (TaskType, error) {
+	for taskType := Deploy; taskType <= Rollback; taskType++ {
+		if strings.EqualFold(taskType.String(), name) {
+			return taskType, nil
+		}
+	}
+	return Deploy, errors.Errorf("Unknown task type %q", name)
+}
+
 // GetTaskTarget retrieves the targetID of a task
 func GetTaskTarget(kv *api.KV, taskID string) (string, error) {
 	kvp, _, err := kv.Get(path.Join(consulutil.TasksPrefix, taskID, "targetId"), nil)
@@ -160,12 +171,125 @@ func TaskExists(kv *api.KV, taskID string) (bool, error) {
 }
 
 // CancelTask marks a task as Canceled
+//
+// A QUEUED task is directly switched to the CANCELED status as it will never be dispatched.
 func CancelTask(kv *api.KV, taskID string) error {
-	kvp := &api.KVPair{Key: path.Join(consulutil.TasksPrefix, taskID, ".canceledFlag"), Value: []byte("true")}
-	_, err := kv.Put(kvp, nil)
+	kvp, _, err := kv.Get(path.Join(consulutil.TasksPrefix, taskID, "status"), nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp != nil && string(kvp.Value) == strconv.Itoa(int(QUEUED)) {
+		ok, err := setTaskStatusCAS(kv, taskID, kvp, CANCELED)
+		if err != nil || ok {
+			return err
+		}
+		// Status changed in the meantime (the task was probably dispatched) fallback to the regular cancellation
+	}
+	kvp = &api.KVPair{Key: path.Join(consulutil.TasksPrefix, taskID, ".canceledFlag"), Value: []byte("true")}
+	_, err = kv.Put(kvp, nil)
 	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
 }
 
+// setTaskStatusCAS atomically updates a task status using the ModifyIndex of the given status KVPair
+// and emits the related task event.
+//
+// It returns false if the status was modified by someone else in the meantime.
+func setTaskStatusCAS(kv *api.KV, taskID string, statusKVP *api.KVPair, status TaskStatus) (bool, error) {
+	p := &api.KVPair{Key: statusKVP.Key, Value: []byte(strconv.Itoa(int(status))), ModifyIndex: statusKVP.ModifyIndex}
+	ok, _, err := kv.CAS(p, nil)
+	if err != nil {
+		return false, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if !ok {
+		return false, nil
+	}
+	targetID, err := GetTaskTarget(kv, taskID)
+	if err != nil {
+		return true, err
+	}
+	taskType, err := GetTaskType(kv, taskID)
+	if err != nil {
+		return true, err
+	}
+	_, err = EmitTaskEvent(kv, targetID, taskID, taskType, status.String())
+	return true, err
+}
+
+// StartNextQueuedTask makes the oldest QUEUED task of a given target ready to be dispatched
+// by switching its status to INITIAL.
+//
+// Rollback tasks are started first as they should not be delayed by tasks queued after the failed task.
+// Nothing is done if the target still has a task in status INITIAL or RUNNING.
+// The id of the started task is returned or an empty string if no task was started.
+func StartNextQueuedTask(kv *api.KV, targetID string) (string, error) {
+	tasksKeys, _, err := kv.Keys(consulutil.TasksPrefix+"/", "/", nil)
+	if err != nil {
+		return "", errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	type queuedTask struct {
+		id           string
+		taskType     TaskType
+		creationDate time.Time
+		statusKVP    *api.KVPair
+	}
+	queued := make([]queuedTask, 0)
+	for _, taskKey := range tasksKeys {
+		kvp, _, err := kv.Get(path.Join(taskKey, "targetId"), nil)
+		if err != nil {
+			return "", errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if kvp == nil || string(kvp.Value) != targetID {
+			continue
+		}
+		taskID := path.Base(taskKey)
+		statusKVP, _, err := kv.Get(path.Join(taskKey, "status"), nil)
+		if err != nil {
+			return "", errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if statusKVP == nil || len(statusKVP.Value) == 0 {
+			return "", errors.Errorf("Missing status for task with id %q", taskID)
+		}
+		statusInt, err := strconv.Atoi(string(statusKVP.Value))
+		if err != nil {
+			return "", errors.Wrap(err, "Invalid task status")
+		}
+		switch TaskStatus(statusInt) {
+		case INITIAL, RUNNING:
+			return "", nil
+		case QUEUED:
+			taskType, err := GetTaskType(kv, taskID)
+			if err != nil {
+				return "", err
+			}
+			creationDate, err := GetTaskCreationDate(kv, taskID)
+			if err != nil {
+				return "", err
+			}
+			queued = append(queued, queuedTask{id: taskID, taskType: taskType, creationDate: creationDate, statusKVP: statusKVP})
+		}
+	}
+	if len(queued) == 0 {
+		return "", nil
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		if (queued[i].taskType == Rollback) != (queued[j].taskType == Rollback) {
+			return queued[i].taskType == Rollback
+		}
+		if queued[i].creationDate.Equal(queued[j].creationDate) {
+			return queued[i].id < queued[j].id
+		}
+		return queued[i].creationDate.Before(queued[j].creationDate)
+	})
+	next := queued[0]
+	ok, err := setTaskStatusCAS(kv, next.id, next.statusKVP, INITIAL)
+	if err != nil || !ok {
+		// Not started by us
+		return "", err
+	}
+	log.Debugf("Queued task %q started for target %q", next.id, targetID)
+	return next.id, nil
+}
+
 // ResumeTask marks a task as Initial to allow it being resumed
 func ResumeTask(kv *api.KV, taskID string) error {
 	kvp := &api.KVPair{Key: path.Join(consulutil.TasksPrefix, taskID, "status"), Value: []byte(strconv.Itoa(int(INITIAL)))}
@@ -182,7 +306,7 @@ func DeleteTask(kv *api.KV, taskID string) error {
 	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
 }
 
-// TargetHasLivingTasks checks if a targetID has associated tasks in status INITIAL or RUNNING and returns the id and status of the first one found
+// TargetHasLivingTasks checks if a targetID has associated tasks in status INITIAL, RUNNING or QUEUED and returns the id and status of the first one found
 func TargetHasLivingTasks(kv *api.KV, targetID string) (bool, string, string, error) {
 	tasksKeys, _, err := kv.Keys(consulutil.TasksPrefix+"/", "/", nil)
 	if err != nil {
@@ -207,7 +331,7 @@ func TargetHasLivingTasks(kv *api.KV, targetID string) (bool, string, string, er
 				return false, "", "", errors.Wrap(err, "Invalid task status")
 			}
 			switch TaskStatus(statusInt) {
-			case INITIAL, RUNNING:
+			case INITIAL, RUNNING, QUEUED:
 				return true, taskID, TaskStatus(statusInt).String(), nil
 			}
 		}
@@ -249,7 +373,7 @@ func GetInstances(kv *api.KV, taskID, deploymentID, nodeName string) ([]string,
 
 // GetTaskRelatedNodes returns the list of nodes that are specifically targeted by this task
 //
-// Currently it only appens for scaling tasks
+// Currently it only appens for scaling and healing tasks
 func GetTaskRelatedNodes(kv *api.KV, taskID string) ([]string, error) {
 	nodes, _, err := kv.Keys(path.Join(consulutil.TasksPrefix, taskID, "nodes")+"/", "/", nil)
 	if err != nil {
@@ -335,7 +459,7 @@ func CheckTaskStepStatusChange(before, after string) (bool, error) {
 		return false, err
 	}
 
-	if stBefore != TaskStepStatusERROR || stAfter != TaskStepStatusDONE {
+	if (stBefore != TaskStepStatusERROR && stBefore != TaskStepStatusTIMEOUT) || stAfter != TaskStepStatusDONE {
 		return false, nil
 	}
 	return true, nil
@@ -27,6 +27,7 @@ import (
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testutil"
 	"strconv"
+	"strings"
 )
 
 func populateKV(t *testing.T, srv *testutil.TestServer) {
@@ -54,12 +55,25 @@ func populateKV(t *testing.T, srv *testutil.TestServer) {
 		consulutil.TasksPrefix + "/tCustomWF/targetId": []byte("id"),
 		consulutil.TasksPrefix + "/tCustomWF/status":   []byte("0"),
 		consulutil.TasksPrefix + "/tCustomWF/type":     []byte("6"),
+
+		consulutil.TasksPrefix + "/tHeal/targetId":     []byte("id"),
+		consulutil.TasksPrefix + "/tHeal/status":       []byte("0"),
+		consulutil.TasksPrefix + "/tHeal/type":         []byte("8"),
+		consulutil.TasksPrefix + "/tUpdate/targetId":   []byte("id"),
+		consulutil.TasksPrefix + "/tUpdate/status":     []byte("0"),
+		consulutil.TasksPrefix + "/tUpdate/type":       []byte("9"),
+		consulutil.TasksPrefix + "/tRollback/targetId": []byte("id"),
+		consulutil.TasksPrefix + "/tRollback/status":   []byte("0"),
+		consulutil.TasksPrefix + "/tRollback/type":     []byte("10"),
 		consulutil.TasksPrefix + "/t6/targetId":        []byte("id"),
-		consulutil.TasksPrefix + "/t6/status":          []byte("5"),
+		consulutil.TasksPrefix + "/t6/status":          []byte("6"),
 		consulutil.TasksPrefix + "/t6/type":            []byte("5"),
 		consulutil.TasksPrefix + "/t7/targetId":        []byte("id"),
-		consulutil.TasksPrefix + "/t7/status":          []byte("5"),
+		consulutil.TasksPrefix + "/t7/status":          []byte("6"),
 		consulutil.TasksPrefix + "/t7/type":            []byte("6666"),
+		consulutil.TasksPrefix + "/tQueued/targetId":   []byte("idQueued"),
+		consulutil.TasksPrefix + "/tQueued/status":     []byte("5"),
+		consulutil.TasksPrefix + "/tQueued/type":       []byte("6"),
 		consulutil.TasksPrefix + "/tNotInt/targetId":   []byte("targetNotInt"),
 		consulutil.TasksPrefix + "/tNotInt/status":     []byte("not a status"),
 		consulutil.TasksPrefix + "/tNotInt/type":       []byte("not a type"),
@@ -99,6 +113,21 @@ func populateKV(t *testing.T, srv *testutil.TestServer) {
 	})
 }
 
+func TestParseTaskType(t *testing.T) {
+	t.Parallel()
+	for taskType := Deploy; taskType <= Rollback; taskType++ {
+		got, err := ParseTaskType(strings.ToLower(taskType.String()))
+		if err != nil {
+			t.Errorf("ParseTaskType(%q) unexpected error: %v", taskType.String(), err)
+		} else if got != taskType {
+			t.Errorf("ParseTaskType(%q) = %v, want %v", taskType.String(), got, taskType)
+		}
+	}
+	if _, err := ParseTaskType("unknown"); err == nil {
+		t.Error("ParseTaskType(\"unknown\") expecting an error")
+	}
+}
+
 func buildResultset() []byte {
 	m := make(map[string]interface{})
 	m["key1"] = "value1"
@@ -157,6 +186,7 @@ func testGetTaskStatus(t *testing.T, kv *api.KV) {
 		{"StatusDONE", args{kv, "t3"}, DONE, false},
 		{"StatusFAILED", args{kv, "t4"}, FAILED, false},
 		{"StatusCANCELED", args{kv, "t5"}, CANCELED, false},
+		{"StatusQUEUED", args{kv, "tQueued"}, QUEUED, false},
 		{"StatusDoesntExist", args{kv, "t6"}, FAILED, true},
 		{"StatusNotInt", args{kv, "tNotInt"}, FAILED, true},
 		{"TaskDoesntExist", args{kv, "TaskDoesntExist"}, FAILED, true},
@@ -196,6 +226,9 @@ func testGetTaskType(t *testing.T, kv *api.KV) {
 		{"TypeNotInt", args{kv, "tNotInt"}, Deploy, true},
 		{"TaskDoesntExist", args{kv, "TaskDoesntExist"}, Deploy, true},
 		{"TypeCustomWorkflow", args{kv, "tCustomWF"}, CustomWorkflow, false},
+		{"TypeHeal", args{kv, "tHeal"}, Heal, false},
+		{"TypeUpdate", args{kv, "tUpdate"}, Update, false},
+		{"TypeRollback", args{kv, "tRollback"}, Rollback, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -316,6 +349,7 @@ func testTargetHasLivingTasks(t *testing.T, kv *api.KV) {
 		wantErr bool
 	}{
 		{"TargetHasRunningTasks", args{kv, "id1"}, true, "t1", "INITIAL", false},
+		{"TargetHasQueuedTasks", args{kv, "idQueued"}, true, "tQueued", "QUEUED", false},
 		{"TargetHasNoRunningTasks", args{kv, "id2"}, false, "", "", false},
 		{"TargetDoesntExist", args{kv, "TargetDoesntExist"}, false, "", "", false},
 		{"TargetNotInt", args{kv, "targetNotInt"}, false, "", "", true},
@@ -537,6 +571,7 @@ func TestCheckTaskStepStatusChange(t *testing.T) {
 		wantErr bool
 	}{
 		{"ChangeOK", args{"error", "done"}, true, false},
+		{"ChangeFromTimeoutOK", args{"timeout", "done"}, true, false},
 		{"NotAllowed", args{"initial", "done"}, false, false},
 		{"NotAllowed", args{"initial", "running"}, false, false},
 		{"Error", args{"fake", "fake"}, false, true},
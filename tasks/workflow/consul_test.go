@@ -30,6 +30,9 @@ func TestRunConsulWorkflowPackageTests(t *testing.T) {
 		t.Run("testReadStepWithNext", func(t *testing.T) {
 			testReadStepWithNext(t, srv, kv)
 		})
+		t.Run("testReadStepWithOnFailureAndFilter", func(t *testing.T) {
+			testReadStepWithOnFailureAndFilter(t, srv, kv)
+		})
 		t.Run("testReadStepFromConsul", func(t *testing.T) {
 			testReadStepFromConsul(t, srv, kv)
 		})
@@ -42,5 +45,20 @@ func TestRunConsulWorkflowPackageTests(t *testing.T) {
 		t.Run("testRunWorkflow", func(t *testing.T) {
 			testRunWorkflow(t, kv)
 		})
+		t.Run("testRunStepWithRetries", func(t *testing.T) {
+			testRunStepWithRetries(t, kv)
+		})
+		t.Run("testRunStepWithOperationTimeout", func(t *testing.T) {
+			testRunStepWithOperationTimeout(t, kv)
+		})
+		t.Run("testRunUpdateOperationWithTimeout", func(t *testing.T) {
+			testRunUpdateOperationWithTimeout(t, client)
+		})
+		t.Run("testGetExecutionSlots", func(t *testing.T) {
+			testGetExecutionSlots(t, srv, kv)
+		})
+		t.Run("testRunStepWaitingForExecutionSlot", func(t *testing.T) {
+			testRunStepWaitingForExecutionSlot(t, client)
+		})
 	})
 }
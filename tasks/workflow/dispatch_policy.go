@@ -0,0 +1,108 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/tasks"
+)
+
+// dispatchPolicy holds the rules used to choose the next task to hand to an idle worker
+type dispatchPolicy struct {
+	priorities         map[tasks.TaskType]int
+	maxConcurrentTasks map[tasks.TaskType]int
+	fairSharing        bool
+}
+
+// dispatchCandidate is a task waiting to be dispatched to a worker
+type dispatchCandidate struct {
+	taskKey      string
+	taskID       string
+	targetID     string
+	taskType     tasks.TaskType
+	status       tasks.TaskStatus
+	creationDate time.Time
+}
+
+// processingTasks counts tasks currently processed by all Yorc servers
+type processingTasks struct {
+	byType   map[tasks.TaskType]int
+	byTarget map[string]int
+}
+
+func newProcessingTasks() processingTasks {
+	return processingTasks{byType: make(map[tasks.TaskType]int), byTarget: make(map[string]int)}
+}
+
+func (p processingTasks) add(taskType tasks.TaskType, targetID string) {
+	p.byType[taskType]++
+	p.byTarget[targetID]++
+}
+
+// newDispatchPolicy creates a dispatchPolicy from the tasks configuration
+//
+// Unknown task types are ignored with a warning.
+func newDispatchPolicy(cfg config.Tasks) dispatchPolicy {
+	return dispatchPolicy{
+		priorities:         taskTypesMap(cfg.Priorities, "priorities"),
+		maxConcurrentTasks: taskTypesMap(cfg.MaxConcurrentTasks, "max_concurrent_tasks"),
+		fairSharing:        !cfg.DisableFairSharing,
+	}
+}
+
+func taskTypesMap(values map[string]int, option string) map[tasks.TaskType]int {
+	res := make(map[tasks.TaskType]int, len(values))
+	for name, value := range values {
+		taskType, err := tasks.ParseTaskType(name)
+		if err != nil {
+			log.Printf("[WARNING] Ignoring tasks %s configuration: %v", option, err)
+			continue
+		}
+		res[taskType] = value
+	}
+	return res
+}
+
+// isThrottled checks if the maximum number of concurrent tasks of the given type is reached
+func (p dispatchPolicy) isThrottled(taskType tasks.TaskType, processing processingTasks) bool {
+	max := p.maxConcurrentTasks[taskType]
+	return max > 0 && processing.byType[taskType] >= max
+}
+
+// sortCandidates orders candidates in their dispatching order
+//
+// Tasks with the highest priority come first. Then if fair sharing is enabled, tasks of deployments having
+// the fewest tasks being processed come first. Finally tasks are ordered by creation date.
+func (p dispatchPolicy) sortCandidates(candidates []dispatchCandidate, processing processingTasks) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if pi, pj := p.priorities[ci.taskType], p.priorities[cj.taskType]; pi != pj {
+			return pi > pj
+		}
+		if p.fairSharing {
+			if ni, nj := processing.byTarget[ci.targetID], processing.byTarget[cj.targetID]; ni != nj {
+				return ni < nj
+			}
+		}
+		if !ci.creationDate.Equal(cj.creationDate) {
+			return ci.creationDate.Before(cj.creationDate)
+		}
+		return ci.taskID < cj.taskID
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/tasks"
+)
+
+func candidatesIDs(candidates []dispatchCandidate) []string {
+	ids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.taskID)
+	}
+	return ids
+}
+
+func TestDispatchPolicySortCandidates(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	newCandidates := func() []dispatchCandidate {
+		return []dispatchCandidate{
+			{taskID: "deployT1", targetID: "app1", taskType: tasks.Deploy, creationDate: now},
+			{taskID: "deployT1Bis", targetID: "app1", taskType: tasks.Deploy, creationDate: now.Add(time.Second)},
+			{taskID: "deployT2", targetID: "app2", taskType: tasks.Deploy, creationDate: now.Add(2 * time.Second)},
+			{taskID: "undeployT1", targetID: "app1", taskType: tasks.UnDeploy, creationDate: now.Add(3 * time.Second)},
+			{taskID: "query", targetID: "infra_usage:slurm", taskType: tasks.Query, creationDate: now.Add(-time.Second)},
+		}
+	}
+	processing := newProcessingTasks()
+	processing.add(tasks.Deploy, "app1")
+
+	policy := newDispatchPolicy(config.Tasks{DisableFairSharing: true})
+	candidates := newCandidates()
+	policy.sortCandidates(candidates, processing)
+	require.Equal(t, []string{"query", "deployT1", "deployT1Bis", "deployT2", "undeployT1"}, candidatesIDs(candidates))
+
+	policy = newDispatchPolicy(config.Tasks{})
+	candidates = newCandidates()
+	policy.sortCandidates(candidates, processing)
+	require.Equal(t, []string{"query", "deployT2", "deployT1", "deployT1Bis", "undeployT1"}, candidatesIDs(candidates))
+
+	policy = newDispatchPolicy(config.Tasks{Priorities: map[string]int{"UNDEPLOY": 10, "query": -1, "unknown": 5}})
+	candidates = newCandidates()
+	policy.sortCandidates(candidates, processing)
+	require.Equal(t, []string{"undeployT1", "deployT2", "deployT1", "deployT1Bis", "query"}, candidatesIDs(candidates))
+}
+
+func TestDispatchPolicyIsThrottled(t *testing.T) {
+	t.Parallel()
+	policy := newDispatchPolicy(config.Tasks{MaxConcurrentTasks: map[string]int{"Query": 2, "deploy": 0}})
+	processing := newProcessingTasks()
+	require.False(t, policy.isThrottled(tasks.Query, processing))
+	processing.add(tasks.Query, "app1")
+	require.False(t, policy.isThrottled(tasks.Query, processing))
+	processing.add(tasks.Query, "app2")
+	require.True(t, policy.isThrottled(tasks.Query, processing))
+
+	processing.add(tasks.Deploy, "app1")
+	processing.add(tasks.Deploy, "app1")
+	require.False(t, policy.isThrottled(tasks.Deploy, processing))
+	require.False(t, policy.isThrottled(tasks.UnDeploy, processing))
+}
@@ -15,7 +15,6 @@
 package workflow
 
 import (
-	"strconv"
 	"time"
 
 	"sync"
@@ -41,40 +40,64 @@ type Dispatcher struct {
 	maxWorkers int
 	cfg        config.Configuration
 	wg         *sync.WaitGroup
+	policy     dispatchPolicy
+	// tasksInfo caches the immutable information of dispatchable tasks indexed by task ID.
+	// It is only accessed by the Run goroutine.
+	tasksInfo map[string]dispatchCandidate
 }
 
 // NewDispatcher create a new Dispatcher with a given number of workers
 func NewDispatcher(cfg config.Configuration, shutdownCh chan struct{}, client *api.Client, wg *sync.WaitGroup) *Dispatcher {
 	pool := make(chan chan *task, cfg.WorkersNumber)
-	dispatcher := &Dispatcher{WorkerPool: pool, client: client, shutdownCh: shutdownCh, maxWorkers: cfg.WorkersNumber, cfg: cfg, wg: wg}
+	dispatcher := &Dispatcher{WorkerPool: pool, client: client, shutdownCh: shutdownCh, maxWorkers: cfg.WorkersNumber, cfg: cfg, wg: wg, policy: newDispatchPolicy(cfg.Tasks), tasksInfo: make(map[string]dispatchCandidate)}
 	dispatcher.emitTasksMetrics()
 	return dispatcher
 }
 
-func getNbAndMaxTasksWaitTimeMs(kv *api.KV) (float32, float64, error) {
+// waitingTasksStats holds the number of tasks waiting for being processed and the highest duration since their creation
+type waitingTasksStats struct {
+	nb    float32
+	maxMs float64
+}
+
+func (s *waitingTasksStats) add(waitMs float64) {
+	s.nb++
+	s.maxMs = math.Max(s.maxMs, waitMs)
+}
+
+// getWaitingTasksStats returns statistics on tasks waiting for being processed globally and by task type
+func getWaitingTasksStats(kv *api.KV) (waitingTasksStats, map[tasks.TaskType]*waitingTasksStats, error) {
 	now := time.Now()
-	var max float64
-	var nb float32
+	var global waitingTasksStats
+	byType := make(map[tasks.TaskType]*waitingTasksStats)
+	for taskType := tasks.Deploy; taskType <= tasks.Rollback; taskType++ {
+		byType[taskType] = &waitingTasksStats{}
+	}
 	tasksKeys, _, err := kv.Keys(consulutil.TasksPrefix+"/", "/", nil)
 	if err != nil {
-		return nb, max, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		return global, byType, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
 	}
 	for _, taskKey := range tasksKeys {
 		taskID := path.Base(taskKey)
 		status, err := tasks.GetTaskStatus(kv, taskID)
 		if err != nil {
-			return nb, max, err
+			return global, byType, err
 		}
 		if status == tasks.INITIAL {
-			nb++
-			createDate, err := tasks.GetTaskCreationDate(kv, path.Base(taskKey))
+			createDate, err := tasks.GetTaskCreationDate(kv, taskID)
 			if err != nil {
-				return nb, max, err
+				return global, byType, err
 			}
-			max = math.Max(max, float64(now.Sub(createDate)/time.Millisecond))
+			taskType, err := tasks.GetTaskType(kv, taskID)
+			if err != nil {
+				return global, byType, err
+			}
+			waitMs := float64(now.Sub(createDate) / time.Millisecond)
+			global.add(waitMs)
+			byType[taskType].add(waitMs)
 		}
 	}
-	return nb, max, nil
+	return global, byType, nil
 }
 
 func (d *Dispatcher) emitTasksMetrics() {
@@ -87,7 +110,7 @@ func (d *Dispatcher) emitTasksMetrics() {
 			select {
 			case <-time.After(time.Second):
 				metrics.SetGauge([]string{"workers", "free"}, float32(len(d.WorkerPool)))
-				nb, maxWait, err := getNbAndMaxTasksWaitTimeMs(kv)
+				global, byType, err := getWaitingTasksStats(kv)
 				if err != nil {
 					now := time.Now()
 					if now.Sub(lastWarn) > 5*time.Minute {
@@ -97,8 +120,12 @@ func (d *Dispatcher) emitTasksMetrics() {
 					}
 					continue
 				}
-				metrics.AddSample([]string{"tasks", "maxBlockTimeMs"}, float32(maxWait))
-				metrics.SetGauge([]string{"tasks", "nbWaiting"}, nb)
+				metrics.AddSample([]string{"tasks", "maxBlockTimeMs"}, float32(global.maxMs))
+				metrics.SetGauge([]string{"tasks", "nbWaiting"}, global.nb)
+				for taskType, stats := range byType {
+					metrics.AddSample([]string{"tasks", taskType.String(), "maxBlockTimeMs"}, float32(stats.maxMs))
+					metrics.SetGauge([]string{"tasks", taskType.String(), "nbWaiting"}, stats.nb)
+				}
 			case <-d.shutdownCh:
 				return
 			}
@@ -106,7 +133,193 @@ func (d *Dispatcher) emitTasksMetrics() {
 	}()
 }
 
+// startNextQueuedTask checks if the target of a queued task is ready to run a new task and if so
+// starts the oldest queued task of this target.
+// The started task will be dispatched as any other task on the next iteration of the dispatcher loop.
+func (d *Dispatcher) startNextQueuedTask(kv *api.KV, taskID string) {
+	targetID, err := tasks.GetTaskTarget(kv, taskID)
+	if err != nil {
+		log.Print(err)
+		log.Debugf("%+v", err)
+		return
+	}
+	if _, err = tasks.StartNextQueuedTask(kv, targetID); err != nil {
+		log.Printf("Failed to start next queued task for target %q: %v", targetID, err)
+		log.Debugf("%+v", err)
+	}
+}
+
+// getDispatchCandidate returns the dispatch candidate of a task.
+//
+// The target, type and creation date of a task never change so they are read only once and then cached.
+func (d *Dispatcher) getDispatchCandidate(kv *api.KV, taskKey, taskID string, status tasks.TaskStatus) (dispatchCandidate, error) {
+	c, ok := d.tasksInfo[taskID]
+	if !ok {
+		c = dispatchCandidate{taskKey: taskKey, taskID: taskID}
+		var err error
+		c.targetID, err = tasks.GetTaskTarget(kv, taskID)
+		if err != nil {
+			return c, err
+		}
+		c.taskType, err = tasks.GetTaskType(kv, taskID)
+		if err != nil {
+			return c, err
+		}
+		c.creationDate, err = tasks.GetTaskCreationDate(kv, taskID)
+		if err != nil {
+			return c, err
+		}
+		d.tasksInfo[taskID] = c
+	}
+	c.status = status
+	return c, nil
+}
+
+// listDispatchCandidates returns the tasks waiting for being dispatched ordered according to the dispatch policy
+// and counts the tasks currently processed by all Yorc servers.
+//
+// Queued tasks which targets are ready to run a new task are started on the way.
+func (d *Dispatcher) listDispatchCandidates(kv *api.KV, tasksKeys []string) ([]dispatchCandidate, processingTasks) {
+	processing := newProcessingTasks()
+	candidates := make([]dispatchCandidate, 0)
+	dispatchable := make(map[string]struct{})
+	for _, taskKey := range tasksKeys {
+		taskID := path.Base(taskKey)
+		log.Debugf("Check if createLock exists for task %s", taskKey)
+		for {
+			if createLock, _, _ := kv.Get(taskKey+".createLock", nil); createLock != nil {
+				// Locked in creation let's it finish
+				log.Debugf("CreateLock exists for task %s wait for few ms", taskKey)
+				time.Sleep(100 * time.Millisecond)
+			} else {
+				break
+			}
+		}
+		status, err := tasks.GetTaskStatus(kv, taskID)
+		if err != nil {
+			log.Print(err)
+			log.Debugf("%+v", err)
+			continue
+		}
+
+		if status == tasks.QUEUED {
+			d.startNextQueuedTask(kv, taskID)
+			continue
+		}
+
+		if status != tasks.INITIAL && status != tasks.RUNNING {
+			log.Debugf("Skipping task with status %q", status)
+			continue
+		}
+
+		c, err := d.getDispatchCandidate(kv, taskKey, taskID, status)
+		if err != nil {
+			log.Printf("Failed to get task %q information: %v", taskID, err)
+			log.Debugf("%+v", err)
+			continue
+		}
+		dispatchable[taskID] = struct{}{}
+		processingLock, _, err := kv.Get(taskKey+".processingLock", nil)
+		if err != nil {
+			log.Printf("Failed to get processing lock for key %s: %+v", taskKey, err)
+			continue
+		}
+		if processingLock != nil && processingLock.Session != "" {
+			processing.add(c.taskType, c.targetID)
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	// Forget tasks that are done or removed
+	for taskID := range d.tasksInfo {
+		if _, ok := dispatchable[taskID]; !ok {
+			delete(d.tasksInfo, taskID)
+		}
+	}
+	d.policy.sortCandidates(candidates, processing)
+	return candidates, processing
+}
+
+// lockNextTask acquires the processing lock of the first candidate that could be dispatched
+// according to the dispatch policy and returns the corresponding task.
+//
+// It returns nil if no task could be locked.
+func (d *Dispatcher) lockNextTask(kv *api.KV, candidates []dispatchCandidate, processing processingTasks, nodeName string) *task {
+	for _, c := range candidates {
+		if d.policy.isThrottled(c.taskType, processing) {
+			log.Debugf("Maximum number of concurrent %q tasks reached, task %q will wait", c.taskType, c.taskID)
+			continue
+		}
+
+		log.Debugf("Try to acquire processing lock for task %s", c.taskKey)
+		opts := &api.LockOptions{
+			Key:          c.taskKey + ".processingLock",
+			Value:        []byte(nodeName),
+			LockTryOnce:  true,
+			LockWaitTime: 10 * time.Millisecond,
+		}
+		lock, err := d.client.LockOpts(opts)
+		if err != nil {
+			log.Printf("Can't create processing lock for key %s: %+v", c.taskKey, err)
+			continue
+		}
+		leaderChan, err := lock.Lock(nil)
+		if err != nil {
+			log.Printf("Can't create acquire lock for key %s: %+v", c.taskKey, err)
+			continue
+		}
+		if leaderChan == nil {
+			log.Debugf("Another instance got the lock for key %s", c.taskKey)
+			continue
+		}
+
+		status, err := tasks.GetTaskStatus(kv, c.taskID)
+		if err != nil {
+			log.Print(err)
+			log.Debugf("%+v", err)
+			lock.Unlock()
+			lock.Destroy()
+			continue
+		}
+
+		if status != tasks.INITIAL && status != tasks.RUNNING {
+			log.Debugf("Skipping task with status %q", status)
+			lock.Unlock()
+			lock.Destroy()
+			continue
+		}
+
+		log.Debugf("Got processing lock for task %s", c.taskKey)
+		return &task{
+			ID:           c.taskID,
+			status:       status,
+			TargetID:     c.targetID,
+			taskLock:     lock,
+			kv:           kv,
+			creationDate: c.creationDate,
+			TaskType:     c.taskType,
+		}
+	}
+	return nil
+}
+
+// removeCandidate returns the given candidates without the one of the given task
+func removeCandidate(candidates []dispatchCandidate, taskID string) []dispatchCandidate {
+	for i, c := range candidates {
+		if c.taskID == taskID {
+			return append(candidates[:i], candidates[i+1:]...)
+		}
+	}
+	return candidates
+}
+
 // Run creates workers and waits for new tasks
+//
+// Each time a worker is idle the waiting task to process is chosen according to the configured
+// tasks priorities, fair sharing between deployments and concurrency limits.
+//
+// Candidates are computed once per change of the tasks list and then updated locally
+// as tasks are dispatched.
 func (d *Dispatcher) Run() {
 
 	for i := 0; i < d.maxWorkers; i++ {
@@ -144,146 +357,60 @@ func (d *Dispatcher) Run() {
 		}
 		waitIndex = rMeta.LastIndex
 		log.Debugf("Got response new wait index is %d", waitIndex)
-		for _, taskKey := range tasksKeys {
-			taskID := path.Base(taskKey)
-			log.Debugf("Check if createLock exists for task %s", taskKey)
-			for {
-				if createLock, _, _ := kv.Get(taskKey+".createLock", nil); createLock != nil {
-					// Locked in creation let's it finish
-					log.Debugf("CreateLock exists for task %s wait for few ms", taskKey)
-					time.Sleep(100 * time.Millisecond)
-				} else {
-					break
-				}
-			}
-			status, err := tasks.GetTaskStatus(kv, taskID)
 
-			if err != nil {
-				log.Print(err)
-				log.Debugf("%+v", err)
-				continue
-			}
-
-			if status != tasks.INITIAL && status != tasks.RUNNING {
-				log.Debugf("Skipping task with status %q", status)
-				continue
-			}
-
-			log.Debugf("Try to acquire processing lock for task %s", taskKey)
-			opts := &api.LockOptions{
-				Key:          taskKey + ".processingLock",
-				Value:        []byte(nodeName),
-				LockTryOnce:  true,
-				LockWaitTime: 10 * time.Millisecond,
-			}
-			lock, err := d.client.LockOpts(opts)
-			if err != nil {
-				log.Printf("Can't create processing lock for key %s: %+v", taskKey, err)
-				continue
-			}
-			leaderChan, err := lock.Lock(nil)
-			if err != nil {
-				log.Printf("Can't create acquire lock for key %s: %+v", taskKey, err)
-				continue
-			}
-			if leaderChan == nil {
-				log.Debugf("Another instance got the lock for key %s", taskKey)
-				continue
-			}
-
-			status, err = tasks.GetTaskStatus(kv, taskID)
-
-			if err != nil {
-				log.Print(err)
-				log.Debugf("%+v", err)
-				lock.Unlock()
-				lock.Destroy()
-				continue
-			}
-
-			if status != tasks.INITIAL && status != tasks.RUNNING {
-				log.Debugf("Skipping task with status %q", status)
-				lock.Unlock()
-				lock.Destroy()
-				continue
-			}
-
-			log.Debugf("Got processing lock for task %s", taskKey)
-			// Got lock
-			kvPairContent, _, err := kv.Get(taskKey+"targetId", nil)
-			if err != nil {
-				log.Printf("Failed to get targetId for key %s: %+v", taskKey, err)
-				continue
-			}
-			if kvPairContent == nil {
-				log.Printf("Failed to get targetId for key %s: nil value", taskKey)
-				continue
-			}
-			targetID := string(kvPairContent.Value)
-
-			kvPairContent, _, err = kv.Get(taskKey+"type", nil)
-			if err != nil {
-				log.Printf("Failed to get task type for key %s: %+v", taskKey, err)
-				continue
-			}
-			if kvPairContent == nil {
-				log.Printf("Failed to get task type for key %s: nil value", taskKey)
-				continue
-			}
-			taskType, err := strconv.Atoi(string(kvPairContent.Value))
-			if err != nil {
-				log.Printf("Failed to get task type for key %s: %+v", taskKey, err)
-				continue
-			}
-			kvPairContent, _, err = kv.Get(taskKey+"creationDate", nil)
-			if err != nil {
-				log.Printf("Failed to get task creationDate for key %s: %+v", taskKey, err)
-				continue
-			}
-			if kvPairContent == nil {
-				log.Printf("Failed to get task creationDate for key %s: nil value", taskKey)
-				continue
-			}
-			creationDate := time.Time{}
-			err = creationDate.UnmarshalBinary(kvPairContent.Value)
-			if err != nil {
-				log.Printf("Failed to get task creationDate for key %s: %+v", taskKey, err)
-				continue
+		// Dispatch tasks as long as there are idle workers and tasks that could be dispatched
+		candidates, processing := d.listDispatchCandidates(kv, tasksKeys)
+		candidatesIndex := waitIndex
+	dispatchLoop:
+		for len(candidates) > 0 {
+			var taskChannel chan *task
+			select {
+			case taskChannel = <-d.WorkerPool:
+			default:
+				// this will block until a worker is idle
+				select {
+				case taskChannel = <-d.WorkerPool:
+				case <-d.shutdownCh:
+					log.Printf("Dispatcher received shutdown signal. Exiting...")
+					return
+				}
+				// Tasks may have changed while waiting for an idle worker
+				tasksKeys, rMeta, err = kv.Keys(consulutil.TasksPrefix+"/", "/", nil)
+				if err != nil {
+					d.WorkerPool <- taskChannel
+					err = errors.Wrap(err, "Error getting tasks list")
+					log.Print(err)
+					log.Debugf("%+v", err)
+					break dispatchLoop
+				}
+				if rMeta.LastIndex != candidatesIndex {
+					candidates, processing = d.listDispatchCandidates(kv, tasksKeys)
+					candidatesIndex = rMeta.LastIndex
+					if len(candidates) == 0 {
+						d.WorkerPool <- taskChannel
+						break dispatchLoop
+					}
+				}
 			}
 
-			log.Printf("Processing task %q linked to deployment %q", taskID, targetID)
-			t := &task{
-				ID:           taskID,
-				status:       status,
-				TargetID:     targetID,
-				taskLock:     lock,
-				kv:           kv,
-				creationDate: creationDate,
-				TaskType:     tasks.TaskType(taskType),
+			t := d.lockNextTask(kv, candidates, processing, nodeName)
+			if t == nil {
+				// Give back the worker, nothing can be dispatched until some tasks change
+				d.WorkerPool <- taskChannel
+				break
 			}
+			candidates = removeCandidate(candidates, t.ID)
+			processing.add(t.TaskType, t.TargetID)
+			d.policy.sortCandidates(candidates, processing)
+			log.Printf("Processing task %q linked to deployment %q", t.ID, t.TargetID)
 			log.Debugf("New task created %+v: pushing it to a work channel", t)
-			// try to obtain a worker task channel that is available.
-			// this will block until a worker is idle
 			select {
-			case taskChannel := <-d.WorkerPool:
-				taskChannel <- t
-			case <-leaderChan:
-				// lock lost
-				continue
+			case taskChannel <- t:
 			case <-d.shutdownCh:
-				lock.Unlock()
-				lock.Destroy()
+				t.releaseLock()
 				log.Printf("Dispatcher received shutdown signal. Exiting...")
 				return
-			case <-time.After(5 * time.Second):
-				// Timeout let another instance a chance to consume this
-				// task
-				lock.Unlock()
-				lock.Destroy()
-				time.Sleep(100 * time.Millisecond)
-				break
 			}
-
 		}
 	}
 
@@ -0,0 +1,147 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/log"
+)
+
+// executionsWaitNotificationDelay is the delay after which an execution that didn't get its execution slot is reported as waiting
+const executionsWaitNotificationDelay = time.Second
+
+// executionSlot is a slot among a limited number of executions allowed to run at the same time on all Yorc servers
+type executionSlot struct {
+	kind  string
+	name  string
+	limit int
+}
+
+func (s executionSlot) String() string {
+	return fmt.Sprintf("an execution slot of %s %q (limit of %d concurrent executions reached)", s.kind, s.name, s.limit)
+}
+
+func (s executionSlot) semaphorePrefix() string {
+	return path.Join(consulutil.ExecutionsSemaphoresPrefix, s.kind+"s", s.name)
+}
+
+// getExecutionSlots returns the execution slots to acquire before running a delegate or an operation on the given node
+//
+// Slots are always returned in the same order (deployment then infrastructure) to prevent deadlocks between executions.
+func getExecutionSlots(kv *api.KV, limits config.ExecutionsLimits, deploymentID, nodeName string) ([]executionSlot, error) {
+	slots := make([]executionSlot, 0)
+	if limits.PerDeployment > 0 {
+		slots = append(slots, executionSlot{kind: "deployment", name: deploymentID, limit: limits.PerDeployment})
+	}
+	if len(limits.PerInfrastructure) == 0 {
+		return slots, nil
+	}
+	infra, err := deployments.GetNodeInfrastructure(kv, deploymentID, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	if limit := limits.PerInfrastructure[infra]; infra != "" && limit > 0 {
+		slots = append(slots, executionSlot{kind: "infrastructure", name: infra, limit: limit})
+	}
+	return slots, nil
+}
+
+// acquireExecutionSlots blocks until the execution slots limiting the concurrent executions on the given node are acquired
+//
+// onWait is called for each slot not acquired after executionsWaitNotificationDelay.
+// The returned function should be called to release the acquired slots once the execution is done.
+func (w worker) acquireExecutionSlots(ctx context.Context, deploymentID, nodeName string, onWait func(slot executionSlot)) (func(), error) {
+	slots, err := getExecutionSlots(w.consulClient.KV(), w.cfg.ExecutionsLimits, deploymentID, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	semaphores := make([]*api.Semaphore, 0, len(slots))
+	release := func() {
+		for i := len(semaphores) - 1; i >= 0; i-- {
+			if err := semaphores[i].Release(); err != nil {
+				log.Printf("Deployment %q: failed to release execution slot for node %q: %+v", deploymentID, nodeName, err)
+			}
+		}
+	}
+	for _, slot := range slots {
+		sema, err := w.consulClient.SemaphoreOpts(&api.SemaphoreOptions{
+			Prefix:         slot.semaphorePrefix(),
+			Limit:          slot.limit,
+			Value:          []byte(path.Join(deploymentID, nodeName)),
+			SessionName:    fmt.Sprintf("execution slot of %s %q", slot.kind, slot.name),
+			MonitorRetries: 2,
+		})
+		if err != nil {
+			release()
+			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		err = acquireSemaphore(ctx, sema, func() { onWait(slot) })
+		if err != nil {
+			release()
+			return nil, errors.Wrapf(err, "failed to acquire execution slot of %s %q", slot.kind, slot.name)
+		}
+		semaphores = append(semaphores, sema)
+	}
+	return release, nil
+}
+
+// acquireOperationExecutionSlots blocks until an operation running outside of a workflow step is allowed to run according to executions limits
+func (w worker) acquireOperationExecutionSlots(ctx context.Context, deploymentID, nodeName, operationName string) (func(), error) {
+	return w.acquireExecutionSlots(ctx, deploymentID, nodeName, func(slot executionSlot) {
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, deploymentID).Registerf("Operation %q on node %q waiting for %s", operationName, nodeName, slot)
+	})
+}
+
+func acquireSemaphore(ctx context.Context, sema *api.Semaphore, onWait func()) error {
+	type acquireResult struct {
+		lostCh <-chan struct{}
+		err    error
+	}
+	stopCh := make(chan struct{})
+	resultCh := make(chan acquireResult, 1)
+	go func() {
+		lostCh, err := sema.Acquire(stopCh)
+		resultCh <- acquireResult{lostCh, err}
+	}()
+
+	timer := time.NewTimer(executionsWaitNotificationDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case res := <-resultCh:
+			return res.err
+		case <-timer.C:
+			onWait()
+		case <-ctx.Done():
+			close(stopCh)
+			res := <-resultCh
+			if res.err == nil && res.lostCh != nil {
+				sema.Release()
+			}
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/registry"
+)
+
+func testGetExecutionSlots(t *testing.T, srv *testutil.TestServer, kv *api.KV) {
+	deploymentID := strings.Replace(t.Name(), "/", "_", -1)
+	topoPrefix := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology")
+	srv.PopulateKV(t, map[string][]byte{
+		topoPrefix + "/types/yorc.nodes.openstack.Compute/derived_from": []byte("yorc.nodes.Compute"),
+		topoPrefix + "/types/yorc.nodes.Compute/derived_from":           []byte("tosca.nodes.Compute"),
+		topoPrefix + "/types/yorc.nodes.slurm.Job/derived_from":         []byte("tosca.nodes.Root"),
+		topoPrefix + "/types/my.nodes.Job/derived_from":                 []byte("yorc.nodes.slurm.Job"),
+		topoPrefix + "/types/my.nodes.Soft/derived_from":                []byte("tosca.nodes.SoftwareComponent"),
+
+		topoPrefix + "/nodes/Compute/type":                     []byte("yorc.nodes.openstack.Compute"),
+		topoPrefix + "/nodes/Soft/type":                        []byte("my.nodes.Soft"),
+		topoPrefix + "/nodes/Soft/requirements/0/relationship": []byte("tosca.relationships.HostedOn"),
+		topoPrefix + "/nodes/Soft/requirements/0/node":         []byte("Compute"),
+		topoPrefix + "/nodes/Job/type":                         []byte("my.nodes.Job"),
+		topoPrefix + "/nodes/Standalone/type":                  []byte("my.nodes.Soft"),
+	})
+
+	limits := config.ExecutionsLimits{PerDeployment: 20, PerInfrastructure: map[string]int{"openstack": 5, "slurm": 0}}
+	slots, err := getExecutionSlots(kv, limits, deploymentID, "Soft")
+	require.NoError(t, err)
+	require.Equal(t, []executionSlot{{kind: "deployment", name: deploymentID, limit: 20}, {kind: "infrastructure", name: "openstack", limit: 5}}, slots)
+	require.Equal(t, path.Join(consulutil.ExecutionsSemaphoresPrefix, "infrastructures", "openstack"), slots[1].semaphorePrefix())
+
+	slots, err = getExecutionSlots(kv, limits, deploymentID, "Job")
+	require.NoError(t, err)
+	require.Equal(t, []executionSlot{{kind: "deployment", name: deploymentID, limit: 20}}, slots)
+
+	slots, err = getExecutionSlots(kv, config.ExecutionsLimits{}, deploymentID, "Soft")
+	require.NoError(t, err)
+	require.Len(t, slots, 0)
+}
+
+func testRunStepWaitingForExecutionSlot(t *testing.T, client *api.Client) {
+	kv := client.KV()
+	deploymentID := strings.Replace(t.Name(), "/", "_", -1)
+	err := deployments.StoreDeploymentDefinition(context.Background(), kv, deploymentID, "testdata/workflow.yaml")
+	require.Nil(t, err)
+
+	mockExecutor := &mockExecutor{}
+	registry.GetRegistry().RegisterOperationExecutor([]string{"ystia.yorc.tests.artifacts.Implementation.Custom"}, mockExecutor, "tests")
+	clearActivityHooks()
+
+	stepsPrefix := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "workflows", "retry", "steps") + "/"
+	s, err := readStep(kv, stepsPrefix, "WFNode_create_retry", make(map[string]*visitStep))
+	require.NoError(t, err)
+	s.Timeout = 100 * time.Millisecond
+
+	cfg := config.Configuration{ExecutionsLimits: config.ExecutionsLimits{PerDeployment: 1}}
+	w := worker{consulClient: client, cfg: cfg}
+
+	// Hold the only execution slot of the deployment for longer than the step timeout
+	release, err := w.acquireExecutionSlots(context.Background(), deploymentID, s.Target, func(executionSlot) {})
+	require.NoError(t, err)
+	time.AfterFunc(time.Second, release)
+
+	// Time spent waiting for the slot doesn't count against the step timeout
+	s.SetTaskID(&task{ID: "taskSlotWaitID", TargetID: deploymentID})
+	err = s.run(context.Background(), deploymentID, kv, make(chan error, 10), make(chan struct{}), cfg, false, "retry", w)
+	require.NoError(t, err)
+	require.Equal(t, 1, mockExecutor.callOpsCount)
+}
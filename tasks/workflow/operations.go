@@ -15,7 +15,11 @@
 package workflow
 
 import (
+	"context"
+	"time"
+
 	"github.com/hashicorp/consul/api"
+	"github.com/ystia/yorc/config"
 	"github.com/ystia/yorc/deployments"
 	"github.com/ystia/yorc/prov"
 	"github.com/ystia/yorc/registry"
@@ -41,3 +45,42 @@ func getOperationExecutor(kv *api.KV, deploymentID, artifact string) (prov.Opera
 	}
 	return nil, originalErr
 }
+
+// getOperationTimeout returns the maximum duration allowed for an operation execution
+//
+// The timeout defined on the operation implementation takes precedence over the default timeout
+// of the operation executor which takes precedence over the server-wide default.
+// A zero duration means that the operation is not time limited.
+func getOperationTimeout(kv *api.KV, cfg config.Configuration, deploymentID string, exec prov.OperationExecutor, op prov.Operation) (time.Duration, error) {
+	timeout, err := deployments.GetOperationTimeout(kv, deploymentID, op.ImplementedInType, op.Name)
+	if err != nil || timeout > 0 {
+		return timeout, err
+	}
+	if tp, ok := exec.(prov.OperationTimeoutProvider); ok {
+		if timeout = tp.DefaultOperationTimeout(cfg); timeout > 0 {
+			return timeout, nil
+		}
+	}
+	return cfg.OperationTimeout, nil
+}
+
+// execOperation executes an operation on a node using the given executor within the operation timeout
+//
+// An operation exceeding its timeout is canceled and a timeout error is returned. Cancellation of the given
+// context is reported as is.
+func execOperation(ctx context.Context, kv *api.KV, cfg config.Configuration, taskID, deploymentID, nodeName string, exec prov.OperationExecutor, op prov.Operation) error {
+	timeout, err := getOperationTimeout(kv, cfg, deploymentID, exec, op)
+	if err != nil {
+		return err
+	}
+	if timeout <= 0 {
+		return exec.ExecOperation(ctx, cfg, taskID, deploymentID, nodeName, op)
+	}
+	opCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err = exec.ExecOperation(opCtx, cfg, taskID, deploymentID, nodeName, op)
+	if err != nil && opCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return newTimeoutError(err, "operation %q timed out after %s", op.Name, timeout)
+	}
+	return err
+}
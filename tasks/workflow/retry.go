@@ -0,0 +1,170 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/consulutil"
+)
+
+// defaultRetryDelay is the delay between two attempts of an activity when not specified in the step retry policy
+const defaultRetryDelay = 10 * time.Second
+
+// timeoutError is returned when an activity or an operation didn't complete within its allowed duration
+type timeoutError struct {
+	msg   string
+	cause error
+}
+
+func (te timeoutError) Error() string {
+	return te.msg + ": " + te.cause.Error()
+}
+
+func newTimeoutError(cause error, format string, a ...interface{}) error {
+	return timeoutError{msg: fmt.Sprintf(format, a...), cause: cause}
+}
+
+// isTimeoutError checks if a given error is an error indicating that an activity or an operation timed out
+func isTimeoutError(err error) bool {
+	_, ok := errors.Cause(err).(timeoutError)
+	return ok
+}
+
+type retryPolicy struct {
+	retries  int
+	delay    time.Duration
+	backoff  float64
+	maxDelay time.Duration
+}
+
+// nextDelay computes the delay to wait before the next attempt given the current delay
+func (rp retryPolicy) nextDelay(current time.Duration) time.Duration {
+	if rp.backoff <= 0 {
+		return current
+	}
+	next := time.Duration(float64(current) * rp.backoff)
+	if rp.maxDelay > 0 && next > rp.maxDelay {
+		return rp.maxDelay
+	}
+	return next
+}
+
+func readDuration(kv *api.KV, key string) (time.Duration, error) {
+	kvp, _, err := kv.Get(key, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil || len(kvp.Value) == 0 {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(string(kvp.Value))
+	return d, errors.Wrapf(err, "invalid duration for key %q", key)
+}
+
+// readRetryPolicy reads the retry policy and timeout of a step
+func readRetryPolicy(kv *api.KV, stepPrefix string) (retryPolicy, time.Duration, error) {
+	rp := retryPolicy{delay: defaultRetryDelay}
+	timeout, err := readDuration(kv, stepPrefix+"/timeout")
+	if err != nil {
+		return rp, 0, err
+	}
+	kvp, _, err := kv.Get(stepPrefix+"/retry/retries", nil)
+	if err != nil {
+		return rp, 0, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil || len(kvp.Value) == 0 {
+		return rp, timeout, nil
+	}
+	rp.retries, err = strconv.Atoi(string(kvp.Value))
+	if err != nil {
+		return rp, 0, errors.Wrapf(err, "invalid retries number for step %q", stepPrefix)
+	}
+	delay, err := readDuration(kv, stepPrefix+"/retry/delay")
+	if err != nil {
+		return rp, 0, err
+	}
+	if delay > 0 {
+		rp.delay = delay
+	}
+	rp.maxDelay, err = readDuration(kv, stepPrefix+"/retry/max_delay")
+	if err != nil {
+		return rp, 0, err
+	}
+	kvp, _, err = kv.Get(stepPrefix+"/retry/backoff", nil)
+	if err != nil {
+		return rp, 0, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp != nil && len(kvp.Value) != 0 {
+		rp.backoff, err = strconv.ParseFloat(string(kvp.Value), 64)
+		if err != nil {
+			return rp, 0, errors.Wrapf(err, "invalid retry backoff for step %q", stepPrefix)
+		}
+	}
+	return rp, timeout, nil
+}
+
+// runActivityWithRetries runs an activity applying the step timeout to each attempt and retrying
+// delegate and call-operation activities according to the step retry policy
+func (s *step) runActivityWithRetries(wfCtx context.Context, kv *api.KV, cfg config.Configuration, deploymentID string, bypassErrors bool, w worker, activity Activity) error {
+	attempts := 1
+	if activity.Type() == ActivityTypeDelegate || activity.Type() == ActivityTypeCallOperation {
+		attempts += s.Retry.retries
+	}
+	delay := s.Retry.delay
+	for attempt := 1; ; attempt++ {
+		if attempts > 1 {
+			events.WithContextOptionalFields(wfCtx).NewLogEntry(events.INFO, deploymentID).Registerf("Step %q: running attempt %d/%d of %s activity %q", s.Name, attempt, attempts, activity.Type(), activity.Value())
+		}
+		err := s.runActivity(wfCtx, kv, cfg, deploymentID, bypassErrors, w, activity)
+		if err == nil || attempt >= attempts || wfCtx.Err() != nil {
+			return err
+		}
+		events.WithContextOptionalFields(wfCtx).NewLogEntry(events.WARN, deploymentID).Registerf("Step %q: attempt %d/%d of %s activity %q failed: %v. Retrying in %s", s.Name, attempt, attempts, activity.Type(), activity.Value(), err, delay)
+		select {
+		case <-wfCtx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay = s.Retry.nextDelay(delay)
+	}
+}
+
+// withStepTimeout returns a context applying the step timeout to an activity attempt
+//
+// It should be called once the activity is ready to run so that time spent waiting for
+// execution slots doesn't count against the step timeout.
+func (s *step) withStepTimeout(wfCtx context.Context) (context.Context, context.CancelFunc) {
+	if s.Timeout <= 0 {
+		return context.WithCancel(wfCtx)
+	}
+	return context.WithTimeout(wfCtx, s.Timeout)
+}
+
+// checkStepTimeout returns a timeout error if an activity failed because the step timeout was reached
+func (s *step) checkStepTimeout(stepCtx, wfCtx context.Context, err error, activity Activity) error {
+	if err != nil && stepCtx.Err() == context.DeadlineExceeded && wfCtx.Err() == nil {
+		return newTimeoutError(err, "%s activity %q timed out after %s", activity.Type(), activity.Value(), s.Timeout)
+	}
+	return err
+}
@@ -0,0 +1,281 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/tasks"
+	"github.com/ystia/yorc/tosca"
+)
+
+// instancesStates maps nodes names to the states of their instances
+type instancesStates map[string]map[string]string
+
+// isRollbackEnabled checks if a task should be rolled back on failure
+func isRollbackEnabled(kv *api.KV, taskID string) (bool, error) {
+	rollback, err := tasks.GetTaskData(kv, taskID, "rollbackOnFailure")
+	if err != nil {
+		if tasks.IsTaskDataNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return strconv.ParseBool(rollback)
+}
+
+// setupRollback checks if a task should be rolled back on failure and if so records the state needed to do it
+func (w worker) setupRollback(ctx context.Context, t *task) bool {
+	rollback, err := isRollbackEnabled(w.consulClient.KV(), t.ID)
+	if err == nil && rollback {
+		err = w.prepareRollback(ctx, t)
+	}
+	if err != nil {
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, t.TargetID).Registerf("Task %q will not be rolled back on failure: %v", t.ID, err)
+		log.Printf("Deployment id: %q, Task id: %q, Failed to prepare rollback: %+v", t.TargetID, t.ID, err)
+		return false
+	}
+	return rollback
+}
+
+// getInstancesStates returns the current state of every node instance of a deployment
+func getInstancesStates(kv *api.KV, deploymentID string) (instancesStates, error) {
+	nodes, err := deployments.GetNodes(kv, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	states := make(instancesStates, len(nodes))
+	for _, nodeName := range nodes {
+		instances, err := deployments.GetNodeInstancesIds(kv, deploymentID, nodeName)
+		if err != nil {
+			return nil, err
+		}
+		states[nodeName] = make(map[string]string, len(instances))
+		for _, instance := range instances {
+			state, err := deployments.GetInstanceState(kv, deploymentID, nodeName, instance)
+			if err != nil {
+				return nil, err
+			}
+			states[nodeName][instance] = state.String()
+		}
+	}
+	return states, nil
+}
+
+// prepareRollback records the deployment status and the instances states before a task runs
+//
+// For an update, the deployment definition is also recorded and the directory where the current deployment archive
+// will be kept is set.
+// This is a no-op if they were already recorded by a previous run of this task (resumed task).
+func (w worker) prepareRollback(ctx context.Context, t *task) error {
+	kv := w.consulClient.KV()
+	rollbackPrefix := path.Join(consulutil.TasksPrefix, t.ID, "rollback")
+	kvp, _, err := kv.Get(path.Join(rollbackPrefix, "previousStatus"), nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp != nil {
+		return nil
+	}
+	status, err := deployments.GetDeploymentStatus(kv, t.TargetID)
+	if err != nil {
+		return err
+	}
+	states, err := getInstancesStates(kv, t.TargetID)
+	if err != nil {
+		return err
+	}
+	statesData, err := json.Marshal(states)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode instances states")
+	}
+	_, err = kv.Put(&api.KVPair{Key: path.Join(rollbackPrefix, "instancesStates"), Value: statesData}, nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if t.TaskType == tasks.Update {
+		err = deployments.SnapshotDeploymentDefinition(ctx, kv, t.TargetID, path.Join(rollbackPrefix, "definition"))
+		if err != nil {
+			return err
+		}
+		_, err = kv.Put(&api.KVPair{Key: path.Join(rollbackPrefix, "archiveDirectory"), Value: []byte("rollback-" + t.ID)}, nil)
+		if err != nil {
+			return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+	}
+	_, err = kv.Put(&api.KVPair{Key: path.Join(rollbackPrefix, "previousStatus"), Value: []byte(status.String())}, nil)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
+
+// computeRollbackScope returns the instances to uninstall and the instances to delete to rollback a task
+//
+// Instances to uninstall are those created or modified by the task that left the initial state.
+// Instances to delete are those that didn't exist before the task or that were created for it
+// like instances added by a scale-out.
+func computeRollbackScope(before, after instancesStates, createdForTask map[string][]string) (map[string][]string, map[string][]string) {
+	toUninstall := make(map[string][]string)
+	toDelete := make(map[string][]string)
+	for nodeName, instances := range after {
+		for instance, state := range instances {
+			previousState, existed := before[nodeName][instance]
+			created := !existed
+			for _, id := range createdForTask[nodeName] {
+				created = created || id == instance
+			}
+			if created {
+				toDelete[nodeName] = append(toDelete[nodeName], instance)
+			}
+			if (!existed && state != tosca.NodeStateInitial.String()) || (existed && state != previousState) {
+				toUninstall[nodeName] = append(toUninstall[nodeName], instance)
+			}
+		}
+	}
+	for _, instances := range toUninstall {
+		sort.Strings(instances)
+	}
+	for _, instances := range toDelete {
+		sort.Strings(instances)
+	}
+	return toUninstall, toDelete
+}
+
+// registerRollback creates a rollback task for a failed task if rollback was requested for it
+func (w worker) registerRollback(ctx context.Context, t *task) {
+	if t.Status() != tasks.FAILED {
+		return
+	}
+	err := func() error {
+		kv := w.consulClient.KV()
+		previousStatus, err := tasks.GetTaskData(kv, t.ID, "rollback/previousStatus")
+		if err != nil {
+			return err
+		}
+		statesData, err := tasks.GetTaskData(kv, t.ID, "rollback/instancesStates")
+		if err != nil {
+			return err
+		}
+		var before instancesStates
+		if err = json.Unmarshal([]byte(statesData), &before); err != nil {
+			return errors.Wrap(err, "failed to decode instances states")
+		}
+		after, err := getInstancesStates(kv, t.TargetID)
+		if err != nil {
+			return err
+		}
+		createdForTask := make(map[string][]string)
+		if t.TaskType == tasks.ScaleOut {
+			nodes, err := tasks.GetTaskRelatedNodes(kv, t.ID)
+			if err != nil {
+				return err
+			}
+			for _, nodeName := range nodes {
+				if createdForTask[nodeName], err = tasks.GetInstances(kv, t.ID, t.TargetID, nodeName); err != nil {
+					return err
+				}
+			}
+		}
+		toUninstall, toDelete := computeRollbackScope(before, after, createdForTask)
+		data := map[string]string{
+			"workflowName":   "uninstall",
+			"rollbackOf":     t.ID,
+			"previousStatus": previousStatus,
+		}
+		for nodeName, instances := range toUninstall {
+			data[path.Join("nodes", nodeName)] = strings.Join(instances, ",")
+		}
+		for nodeName, instances := range toDelete {
+			data[path.Join("createdInstances", nodeName)] = strings.Join(instances, ",")
+		}
+		// Queue the rollback task as other tasks may have been queued on this deployment while this one was running
+		rollbackTaskID, err := tasks.NewCollector(w.consulClient).QueueTaskWithData(t.TargetID, tasks.Rollback, data)
+		if err != nil {
+			return err
+		}
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, t.TargetID).Registerf("Task %q failed, rolling it back with task %q", t.ID, rollbackTaskID)
+		return nil
+	}()
+	if err != nil {
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.ERROR, t.TargetID).Registerf("Failed to rollback task %q: %v", t.ID, err)
+		log.Printf("Deployment id: %q, Task id: %q, Failed to register rollback task: %+v", t.TargetID, t.ID, err)
+	}
+}
+
+// runRollback uninstalls the instances created or modified by a failed task, deletes the instances
+// it created and restores the deployment status it had before this task
+//
+// The definition and the archive of the deployment are also restored if the failed task is an update.
+func (w worker) runRollback(ctx context.Context, t *task) error {
+	kv := w.consulClient.KV()
+	nodes, err := tasks.GetTaskRelatedNodes(kv, t.ID)
+	if err != nil {
+		return err
+	}
+	if len(nodes) > 0 {
+		if err = w.runWorkflows(ctx, t, []string{"uninstall"}, true); err != nil {
+			return err
+		}
+	}
+	createdPrefix := path.Join(consulutil.TasksPrefix, t.ID, "createdInstances")
+	kvps, _, err := kv.List(createdPrefix+"/", nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	for _, kvp := range kvps {
+		nodeName := path.Base(kvp.Key)
+		for _, instance := range strings.Split(string(kvp.Value), ",") {
+			if err = deployments.DeleteInstance(kv, t.TargetID, nodeName, instance); err != nil {
+				return err
+			}
+			if err = deployments.DeleteRelationshipInstance(kv, t.TargetID, nodeName, instance); err != nil {
+				return err
+			}
+		}
+	}
+	rollbackOf, err := tasks.GetTaskData(kv, t.ID, "rollbackOf")
+	if err != nil {
+		return err
+	}
+	rollbackOfType, err := tasks.GetTaskType(kv, rollbackOf)
+	if err != nil {
+		return err
+	}
+	if rollbackOfType == tasks.Update {
+		if err = w.rollbackUpdate(ctx, t, rollbackOf); err != nil {
+			return err
+		}
+	}
+	previousStatus, err := tasks.GetTaskData(kv, t.ID, "previousStatus")
+	if err != nil {
+		return err
+	}
+	status, err := deployments.DeploymentStatusFromString(previousStatus, true)
+	if err != nil {
+		return err
+	}
+	w.setDeploymentStatus(t.TargetID, status)
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeRollbackScope(t *testing.T) {
+	t.Parallel()
+	before := instancesStates{
+		"Compute": {"0": "started", "1": "initial"},
+		"App":     {"0": "initial"},
+		"Unused":  {"0": "started"},
+	}
+	after := instancesStates{
+		"Compute": {"0": "started", "1": "started", "2": "initial"},
+		"App":     {"0": "error"},
+		"Added":   {"0": "created", "1": "initial"},
+		"Unused":  {"0": "started"},
+	}
+	toUninstall, toDelete := computeRollbackScope(before, after, map[string][]string{"Compute": {"1", "2"}})
+	require.Equal(t, map[string][]string{
+		"Compute": {"1"},
+		"App":     {"0"},
+		"Added":   {"0"},
+	}, toUninstall)
+	require.Equal(t, map[string][]string{
+		"Compute": {"1", "2"},
+		"Added":   {"0", "1"},
+	}, toDelete)
+}
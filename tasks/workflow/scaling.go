@@ -0,0 +1,111 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"path"
+	"strconv"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/tasks"
+)
+
+// resolveScalingInstances creates or selects the instances of a queued or auto-scaling task.
+//
+// Instances impacted by such a scaling task are not computed when the task is submitted but when it actually
+// runs as previous tasks may change the deployment in the meantime.
+// This is a no-op for scaling tasks having their instances already computed.
+func (w worker) resolveScalingInstances(t *task) error {
+	kv := w.consulClient.KV()
+	nodes, err := tasks.GetTaskRelatedNodes(kv, t.ID)
+	if err != nil || len(nodes) > 0 {
+		return err
+	}
+	nodeName, err := tasks.GetTaskData(kv, t.ID, "scaling/nodeName")
+	if err != nil {
+		return err
+	}
+	deltaData, err := tasks.GetTaskData(kv, t.ID, "scaling/instancesDelta")
+	if err != nil {
+		return err
+	}
+	instancesDelta, err := strconv.Atoi(deltaData)
+	if err != nil {
+		return errors.Wrapf(err, "invalid instances delta %q for scaling task %q", deltaData, t.ID)
+	}
+	currentNbInstances, err := deployments.GetNbInstancesForNode(kv, t.TargetID, nodeName)
+	if err != nil {
+		return err
+	}
+
+	var instancesByNodes map[string]string
+	if t.TaskType == tasks.ScaleOut {
+		var maxInstances uint32
+		maxInstances, err = deployments.GetMaxNbInstancesForNode(kv, t.TargetID, nodeName)
+		if err != nil {
+			return err
+		}
+		if int(currentNbInstances)+instancesDelta > int(maxInstances) {
+			log.Debug("The delta is too high, the max instances number is chosen")
+			instancesDelta = int(maxInstances) - int(currentNbInstances)
+			if instancesDelta <= 0 {
+				return errors.Errorf("Maximum number of instances reached for node %q", nodeName)
+			}
+		}
+		if err = deployments.CheckTenantQuotasForScaleOut(kv, w.cfg, t.TargetID, nodeName, instancesDelta); err != nil {
+			return err
+		}
+		instancesByNodes, err = deployments.CreateNewNodeStackInstances(kv, t.TargetID, nodeName, instancesDelta)
+	} else {
+		var minInstances uint32
+		minInstances, err = deployments.GetMinNbInstancesForNode(kv, t.TargetID, nodeName)
+		if err != nil {
+			return err
+		}
+		if int(currentNbInstances)-instancesDelta < int(minInstances) {
+			log.Debug("The delta is too low, the min instances number is chosen")
+			instancesDelta = int(currentNbInstances) - int(minInstances)
+			if instancesDelta <= 0 {
+				return errors.Errorf("Minimum number of instances reached for node %q", nodeName)
+			}
+		}
+		instancesByNodes, err = deployments.SelectNodeStackInstances(kv, t.TargetID, nodeName, instancesDelta)
+	}
+	if err != nil {
+		return err
+	}
+
+	for scalableNode, nodeInstances := range instancesByNodes {
+		kvp := &api.KVPair{Key: path.Join(consulutil.TasksPrefix, t.ID, "nodes", scalableNode), Value: []byte(nodeInstances)}
+		if _, err = kv.Put(kvp, nil); err != nil {
+			return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+	}
+	return nil
+}
+
+// failScaling marks a scaling task as failed when its instances can't be computed
+func (w worker) failScaling(ctx context.Context, t *task, err error) {
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.ERROR, t.TargetID).Registerf("Failed to compute instances for scaling task %q: %v", t.ID, err)
+	log.Printf("Deployment id: %q, Task id: %q, Failed to compute scaling instances: %+v", t.TargetID, t.ID, err)
+	t.WithStatus(tasks.FAILED)
+}
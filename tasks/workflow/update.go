@@ -0,0 +1,341 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/events"
+	"github.com/ystia/yorc/helper/consulutil"
+	"github.com/ystia/yorc/helper/metricsutil"
+	"github.com/ystia/yorc/log"
+	"github.com/ystia/yorc/prov/operations"
+	"github.com/ystia/yorc/tasks"
+)
+
+// runUpdate applies a topology update to a deployment
+//
+// Removed nodes are uninstalled first, then the deployment definition is replaced by the updated one,
+// added nodes are installed and finally the update operation is run on changed nodes.
+func (w worker) runUpdate(ctx context.Context, t *task) error {
+	kv := w.consulClient.KV()
+	diff, updateOperation, err := getUpdateDiff(kv, t.ID)
+	if err != nil {
+		return err
+	}
+	definitionFile, err := tasks.GetTaskData(kv, t.ID, "definitionFile")
+	if err != nil {
+		return err
+	}
+	updateDirectory, err := tasks.GetTaskData(kv, t.ID, "updateDirectory")
+	if err != nil && !tasks.IsTaskDataNotFoundError(err) {
+		return err
+	}
+	if updateDirectory == "" {
+		updateDirectory = "update"
+	}
+	inputsData, err := tasks.GetTaskData(kv, t.ID, "inputs")
+	if err != nil && !tasks.IsTaskDataNotFoundError(err) {
+		return err
+	}
+	inputs, err := deployments.ParseInputValues([]byte(inputsData))
+	if err != nil {
+		return err
+	}
+	// The current archive is kept if the update may be rolled back
+	backupDirectory, err := tasks.GetTaskData(kv, t.ID, "rollback/archiveDirectory")
+	if err != nil && !tasks.IsTaskDataNotFoundError(err) {
+		return err
+	}
+
+	if len(diff.RemovedNodes) > 0 {
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, t.TargetID).Registerf("Removing nodes %s", strings.Join(diff.RemovedNodes, ", "))
+		if err = setTaskRelatedNodes(kv, t, diff.RemovedNodes); err != nil {
+			return err
+		}
+		if err = w.runWorkflows(ctx, t, []string{"uninstall"}, true); err != nil {
+			return err
+		}
+		if err = w.cleanupScaledDownNodes(t); err != nil {
+			return err
+		}
+	}
+
+	deploymentPath := filepath.Join(w.cfg.WorkingDirectory, "deployments", t.TargetID)
+	if err = swapDeploymentArchive(deploymentPath, updateDirectory, backupDirectory); err != nil {
+		return err
+	}
+	err = deployments.UpdateDeploymentDefinition(ctx, kv, t.TargetID, filepath.Join(deploymentPath, "overlay", definitionFile), inputs)
+	if err != nil {
+		return err
+	}
+
+	if len(diff.AddedNodes) > 0 {
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, t.TargetID).Registerf("Adding nodes %s", strings.Join(diff.AddedNodes, ", "))
+		if err = setTaskRelatedNodes(kv, t, diff.AddedNodes); err != nil {
+			return err
+		}
+		if err = w.runWorkflows(ctx, t, []string{"install"}, false); err != nil {
+			return err
+		}
+	}
+
+	return w.runChangedNodesUpdateOperation(ctx, t, diff, updateOperation)
+}
+
+// getUpdateDiff returns the topology diff and the update operation of an update task
+func getUpdateDiff(kv *api.KV, taskID string) (deployments.TopologyDiff, string, error) {
+	var diff deployments.TopologyDiff
+	diffData, err := tasks.GetTaskData(kv, taskID, "topologyDiff")
+	if err != nil {
+		return diff, "", err
+	}
+	if err = json.Unmarshal([]byte(diffData), &diff); err != nil {
+		return diff, "", errors.Wrap(err, "failed to decode topology diff")
+	}
+	updateOperation, err := tasks.GetTaskData(kv, taskID, "updateOperation")
+	if err != nil && !tasks.IsTaskDataNotFoundError(err) {
+		return diff, "", err
+	}
+	if updateOperation == "" {
+		updateOperation = deployments.DefaultUpdateOperation
+	}
+	return diff, updateOperation, nil
+}
+
+// runChangedNodesUpdateOperation runs the update operation on the nodes changed by an update
+func (w worker) runChangedNodesUpdateOperation(ctx context.Context, t *task, diff deployments.TopologyDiff, updateOperation string) error {
+	changedNodes := make([]string, 0, len(diff.ChangedNodes))
+	for _, nodeDiff := range diff.ChangedNodes {
+		changedNodes = append(changedNodes, nodeDiff.Name)
+	}
+	if err := setTaskRelatedNodes(w.consulClient.KV(), t, changedNodes); err != nil {
+		return err
+	}
+	for _, nodeName := range changedNodes {
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, t.TargetID).Registerf("Running operation %q on updated node %q", updateOperation, nodeName)
+		if err := w.runUpdateOperation(ctx, t, nodeName, updateOperation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollbackUpdate restores the definition and the archive a deployment had before a failed update, installs again
+// the instances of nodes removed by this update and runs the update operation on nodes it changed
+//
+// Instances of nodes added by the update are expected to be uninstalled and deleted before.
+func (w worker) rollbackUpdate(ctx context.Context, t *task, updateTaskID string) error {
+	kv := w.consulClient.KV()
+	diff, updateOperation, err := getUpdateDiff(kv, updateTaskID)
+	if err != nil {
+		return err
+	}
+	statesData, err := tasks.GetTaskData(kv, updateTaskID, "rollback/instancesStates")
+	if err != nil {
+		return err
+	}
+	var before instancesStates
+	if err = json.Unmarshal([]byte(statesData), &before); err != nil {
+		return errors.Wrap(err, "failed to decode instances states")
+	}
+	archiveDirectory, err := tasks.GetTaskData(kv, updateTaskID, "rollback/archiveDirectory")
+	if err != nil {
+		return err
+	}
+
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, t.TargetID).Registerf("Restoring the deployment definition as it was before task %q", updateTaskID)
+	// The previous archive is only kept once the update replaced it
+	deploymentPath := filepath.Join(w.cfg.WorkingDirectory, "deployments", t.TargetID)
+	if _, err = os.Stat(filepath.Join(deploymentPath, archiveDirectory)); err == nil {
+		if err = swapDeploymentArchive(deploymentPath, archiveDirectory, ""); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to check previous deployment archive")
+	}
+	err = deployments.RestoreDeploymentDefinition(ctx, kv, t.TargetID, path.Join(consulutil.TasksPrefix, updateTaskID, "rollback", "definition"))
+	if err != nil {
+		return err
+	}
+
+	// Instances of removed nodes are created again by the restore if the update deleted them,
+	// only those which are not in the state they had before the update are installed
+	toInstall := make(map[string][]string)
+	for _, nodeName := range diff.RemovedNodes {
+		instances, err := deployments.GetNodeInstancesIds(kv, t.TargetID, nodeName)
+		if err != nil {
+			return err
+		}
+		for _, instance := range instances {
+			state, err := deployments.GetInstanceState(kv, t.TargetID, nodeName, instance)
+			if err != nil {
+				return err
+			}
+			if state.String() != before[nodeName][instance] {
+				toInstall[nodeName] = append(toInstall[nodeName], instance)
+			}
+		}
+	}
+	if len(toInstall) > 0 {
+		nodes := make([]string, 0, len(toInstall))
+		for nodeName := range toInstall {
+			nodes = append(nodes, nodeName)
+		}
+		sort.Strings(nodes)
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, t.TargetID).Registerf("Installing again removed nodes %s", strings.Join(nodes, ", "))
+		if err = setTaskRelatedInstances(kv, t, toInstall); err != nil {
+			return err
+		}
+		if err = w.runWorkflows(ctx, t, []string{"install"}, false); err != nil {
+			return err
+		}
+	}
+
+	return w.runChangedNodesUpdateOperation(ctx, t, diff, updateOperation)
+}
+
+// cleanupUpdateRollback removes the definition and the archive kept to rollback an update that won't be rolled back
+func (w worker) cleanupUpdateRollback(t *task) {
+	err := w.removeUpdateBackup(t)
+	if err != nil {
+		log.Printf("Deployment id: %q, Task id: %q, Failed to remove data kept to rollback update: %+v", t.TargetID, t.ID, err)
+	}
+}
+
+// removeUpdateBackup removes the definition and the archive kept to rollback an update
+func (w worker) removeUpdateBackup(t *task) error {
+	kv := w.consulClient.KV()
+	_, err := kv.DeleteTree(path.Join(consulutil.TasksPrefix, t.ID, "rollback", "definition")+"/", nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	archiveDirectory, err := tasks.GetTaskData(kv, t.ID, "rollback/archiveDirectory")
+	if err != nil || archiveDirectory == "" {
+		return err
+	}
+	err = os.RemoveAll(filepath.Join(w.cfg.WorkingDirectory, "deployments", t.TargetID, archiveDirectory))
+	return errors.Wrap(err, "failed to remove previous deployment archive")
+}
+
+// setTaskRelatedNodes restricts the workflows steps run by a task to the given nodes and their instances
+func setTaskRelatedNodes(kv *api.KV, t *task, nodes []string) error {
+	nodesInstances := make(map[string][]string, len(nodes))
+	for _, nodeName := range nodes {
+		instances, err := deployments.GetNodeInstancesIds(kv, t.TargetID, nodeName)
+		if err != nil {
+			return err
+		}
+		nodesInstances[nodeName] = instances
+	}
+	return setTaskRelatedInstances(kv, t, nodesInstances)
+}
+
+// setTaskRelatedInstances restricts the workflows steps run by a task to the given nodes instances
+func setTaskRelatedInstances(kv *api.KV, t *task, nodesInstances map[string][]string) error {
+	nodesPrefix := path.Join(consulutil.TasksPrefix, t.ID, "nodes")
+	_, err := kv.DeleteTree(nodesPrefix+"/", nil)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	for nodeName, instances := range nodesInstances {
+		_, err = kv.Put(&api.KVPair{Key: path.Join(nodesPrefix, nodeName), Value: []byte(strings.Join(instances, ","))}, nil)
+		if err != nil {
+			return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+	}
+	return nil
+}
+
+// swapDeploymentArchive replaces the current deployment archive and its extracted content by the ones
+// staged in the given update directory
+//
+// If a backup directory is given, the current archive and its extracted content are moved into it,
+// otherwise they are removed.
+func swapDeploymentArchive(deploymentPath, updateDirectory, backupDirectory string) error {
+	updatePath := filepath.Join(deploymentPath, updateDirectory)
+	overlayPath := filepath.Join(deploymentPath, "overlay")
+	if backupDirectory == "" {
+		if err := os.RemoveAll(overlayPath); err != nil {
+			return errors.Wrap(err, "failed to remove previous deployment overlay")
+		}
+	} else {
+		backupPath := filepath.Join(deploymentPath, backupDirectory)
+		if err := os.MkdirAll(backupPath, 0775); err != nil {
+			return errors.Wrap(err, "failed to create previous deployment archive backup directory")
+		}
+		if err := os.Rename(overlayPath, filepath.Join(backupPath, "overlay")); err != nil {
+			return errors.Wrap(err, "failed to keep previous deployment overlay")
+		}
+		if err := os.Rename(filepath.Join(deploymentPath, "deployment.zip"), filepath.Join(backupPath, "deployment.zip")); err != nil {
+			return errors.Wrap(err, "failed to keep previous deployment archive")
+		}
+	}
+	if err := os.Rename(filepath.Join(updatePath, "overlay"), overlayPath); err != nil {
+		return errors.Wrap(err, "failed to install updated deployment overlay")
+	}
+	if err := os.Rename(filepath.Join(updatePath, "deployment.zip"), filepath.Join(deploymentPath, "deployment.zip")); err != nil {
+		return errors.Wrap(err, "failed to install updated deployment archive")
+	}
+	return errors.Wrap(os.RemoveAll(updatePath), "failed to remove deployment update directory")
+}
+
+// runUpdateOperation runs the given operation on all instances of a changed node, unimplemented operations are skipped
+func (w worker) runUpdateOperation(ctx context.Context, t *task, nodeName, operationName string) error {
+	kv := w.consulClient.KV()
+	op, err := operations.GetOperation(ctx, kv, t.TargetID, nodeName, operationName, "", "")
+	if err != nil {
+		if deployments.IsOperationNotImplemented(err) {
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.DEBUG, t.TargetID).Registerf("Operation %q not implemented by node %q, skipping it", operationName, nodeName)
+			return nil
+		}
+		return err
+	}
+	exec, err := getOperationExecutor(kv, t.TargetID, op.ImplementationArtifact)
+	if err != nil {
+		return err
+	}
+	nodeType, err := deployments.GetNodeType(kv, t.TargetID, nodeName)
+	if err != nil {
+		return err
+	}
+	release, err := w.acquireOperationExecutionSlots(ctx, t.TargetID, nodeName, op.Name)
+	if err != nil {
+		return err
+	}
+	defer release()
+	err = func() error {
+		defer metrics.MeasureSince(metricsutil.CleanupMetricKey([]string{"executor", "operation", t.TargetID, nodeType, op.Name}), time.Now())
+		return execOperation(ctx, kv, w.cfg, t.ID, t.TargetID, nodeName, exec, op)
+	}()
+	if err != nil {
+		metrics.IncrCounter(metricsutil.CleanupMetricKey([]string{"executor", "operation", t.TargetID, nodeType, op.Name, "failures"}), 1)
+		return err
+	}
+	metrics.IncrCounter(metricsutil.CleanupMetricKey([]string{"executor", "operation", t.TargetID, nodeType, op.Name, "successes"}), 1)
+	return nil
+}
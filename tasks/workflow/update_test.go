@@ -0,0 +1,94 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/config"
+	"github.com/ystia/yorc/deployments"
+	"github.com/ystia/yorc/registry"
+)
+
+func testRunUpdateOperationWithTimeout(t *testing.T, client *api.Client) {
+	deploymentID := strings.Replace(t.Name(), "/", "_", -1)
+	err := deployments.StoreDeploymentDefinition(context.Background(), client.KV(), deploymentID, "testdata/workflow.yaml")
+	require.Nil(t, err)
+
+	mockExecutor := &mockExecutor{blockCallOps: true}
+	registry.GetRegistry().RegisterOperationExecutor([]string{"ystia.yorc.tests.artifacts.Implementation.Custom"}, mockExecutor, "tests")
+
+	w := worker{consulClient: client, cfg: config.Configuration{OperationTimeout: 10 * time.Millisecond}}
+	err = w.runUpdateOperation(context.Background(), &task{ID: "taskUpdateTimeoutID", TargetID: deploymentID}, "WFNode", "standard.create")
+	require.Error(t, err)
+	require.True(t, isTimeoutError(err), "expecting a timeout error, got %v", err)
+	require.Equal(t, 1, mockExecutor.callOpsCount)
+}
+
+func writeDeploymentArchive(t *testing.T, dir, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "overlay"), 0775))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "deployment.zip"), []byte(content), 0664))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "overlay", "topology.yaml"), []byte(content), 0664))
+}
+
+func requireDeploymentArchive(t *testing.T, dir, content string) {
+	for _, file := range []string{"deployment.zip", filepath.Join("overlay", "topology.yaml")} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, file))
+		require.NoError(t, err)
+		require.Equal(t, content, string(data), "unexpected content for %q", file)
+	}
+}
+
+func TestSwapDeploymentArchive(t *testing.T) {
+	t.Parallel()
+	t.Run("WithoutBackup", func(t *testing.T) {
+		deploymentPath, err := ioutil.TempDir("", "yorc-update-")
+		require.NoError(t, err)
+		defer os.RemoveAll(deploymentPath)
+		writeDeploymentArchive(t, deploymentPath, "current")
+		writeDeploymentArchive(t, filepath.Join(deploymentPath, "update"), "updated")
+
+		require.NoError(t, swapDeploymentArchive(deploymentPath, "update", ""))
+		requireDeploymentArchive(t, deploymentPath, "updated")
+		_, err = os.Stat(filepath.Join(deploymentPath, "update"))
+		require.True(t, os.IsNotExist(err), "update directory should be removed")
+	})
+	t.Run("WithBackupAndRestore", func(t *testing.T) {
+		deploymentPath, err := ioutil.TempDir("", "yorc-update-")
+		require.NoError(t, err)
+		defer os.RemoveAll(deploymentPath)
+		writeDeploymentArchive(t, deploymentPath, "current")
+		writeDeploymentArchive(t, filepath.Join(deploymentPath, "update"), "updated")
+
+		require.NoError(t, swapDeploymentArchive(deploymentPath, "update", "rollback"))
+		requireDeploymentArchive(t, deploymentPath, "updated")
+		requireDeploymentArchive(t, filepath.Join(deploymentPath, "rollback"), "current")
+
+		// Restoring the previous archive is the reverse swap
+		require.NoError(t, swapDeploymentArchive(deploymentPath, "rollback", ""))
+		requireDeploymentArchive(t, deploymentPath, "current")
+		_, err = os.Stat(filepath.Join(deploymentPath, "rollback"))
+		require.True(t, os.IsNotExist(err), "backup directory should be removed")
+	})
+}
@@ -42,6 +42,7 @@ import (
 	"github.com/ystia/yorc/log"
 	"github.com/ystia/yorc/prov/operations"
 	"github.com/ystia/yorc/registry"
+	"github.com/ystia/yorc/scheduling"
 	"github.com/ystia/yorc/tasks"
 	"github.com/ystia/yorc/tosca"
 )
@@ -131,6 +132,17 @@ func (w worker) handleTask(t *task) {
 	t.WithStatus(tasks.RUNNING)
 	kv := w.consulClient.KV()
 
+	if t.TaskType != tasks.Query {
+		// Use the infrastructures configuration specific to the deployment tenant
+		tenant, err := deployments.GetDeploymentTenant(kv, t.TargetID)
+		if err != nil {
+			log.Printf("Deployment id: %q, Task id: %q, Failed to get deployment tenant: %+v", t.TargetID, t.ID, err)
+			t.WithStatus(tasks.FAILED)
+			return
+		}
+		w.cfg = w.cfg.ForTenant(tenant)
+	}
+
 	// Fill log optional fields for log registration
 	wfName, _ := tasks.GetTaskData(kv, t.ID, "workflowName")
 	logOptFields := events.LogOptionalFields{
@@ -151,10 +163,14 @@ func (w worker) handleTask(t *task) {
 	}(t, time.Now())
 	switch t.TaskType {
 	case tasks.Deploy:
+		rollback := w.setupRollback(ctx, t)
 		w.setDeploymentStatus(t.TargetID, deployments.DEPLOYMENT_IN_PROGRESS)
 		err := w.runWorkflows(ctx, t, []string{"install"}, false)
 		if err != nil {
 			w.setDeploymentStatus(t.TargetID, deployments.DEPLOYMENT_FAILED)
+			if rollback {
+				w.registerRollback(ctx, t)
+			}
 			return
 		}
 		w.setDeploymentStatus(t.TargetID, deployments.DEPLOYED)
@@ -217,6 +233,26 @@ func (w worker) handleTask(t *task) {
 				t.WithStatus(tasks.FAILED)
 				return
 			}
+			if err = scheduling.DeleteDeploymentSchedules(kv, t.TargetID); err != nil {
+				log.Printf("Deployment id: %q, Task id: %q, Failed to purge schedules: %+v", t.TargetID, t.ID, err)
+				t.WithStatus(tasks.FAILED)
+				return
+			}
+			_, err = kv.DeleteTree(path.Join(consulutil.ExecutionsSemaphoresPrefix, "deployments", t.TargetID), nil)
+			if err != nil {
+				log.Printf("Deployment id: %q, Task id: %q, Failed to purge executions semaphore: %+v", t.TargetID, t.ID, err)
+				t.WithStatus(tasks.FAILED)
+				return
+			}
+			// Delete monitoring data stored per deployment: auto-scaling state, instances metrics and healing attempts
+			for _, monitoringDir := range []string{"autoscaling", "metrics", "healing"} {
+				_, err = kv.DeleteTree(path.Join(consulutil.MonitoringKVPrefix, monitoringDir, t.TargetID)+"/", nil)
+				if err != nil {
+					log.Printf("Deployment id: %q, Task id: %q, Failed to purge monitoring data: %+v", t.TargetID, t.ID, err)
+					t.WithStatus(tasks.FAILED)
+					return
+				}
+			}
 			err = os.RemoveAll(filepath.Join(w.cfg.WorkingDirectory, "deployments", t.TargetID))
 			if err != nil {
 				log.Printf("Deployment id: %q, Task id: %q, Failed to purge tasks related to deployment: %+v", t.TargetID, t.ID, err)
@@ -289,6 +325,11 @@ func (w worker) handleTask(t *task) {
 			return
 		}
 		err = func() error {
+			release, err := w.acquireOperationExecutionSlots(ctx, t.TargetID, nodeName, op.Name)
+			if err != nil {
+				return err
+			}
+			defer release()
 			defer metrics.MeasureSince(metricsutil.CleanupMetricKey([]string{"executor", "operation", t.TargetID, nodeType, op.Name}), time.Now())
 			return exec.ExecOperation(ctx, w.cfg, t.ID, t.TargetID, nodeName, op)
 		}()
@@ -304,15 +345,27 @@ func (w worker) handleTask(t *task) {
 		}
 		metrics.IncrCounter(metricsutil.CleanupMetricKey([]string{"executor", "operation", t.TargetID, nodeType, op.Name, "successes"}), 1)
 	case tasks.ScaleOut:
+		if err := w.resolveScalingInstances(t); err != nil {
+			w.failScaling(ctx, t, err)
+			return
+		}
+		rollback := w.setupRollback(ctx, t)
 		w.setDeploymentStatus(t.TargetID, deployments.SCALING_IN_PROGRESS)
 
 		err := w.runWorkflows(ctx, t, []string{"install"}, false)
 		if err != nil {
 			w.setDeploymentStatus(t.TargetID, deployments.DEPLOYMENT_FAILED)
+			if rollback {
+				w.registerRollback(ctx, t)
+			}
 			return
 		}
 		w.setDeploymentStatus(t.TargetID, deployments.DEPLOYED)
 	case tasks.ScaleIn:
+		if err := w.resolveScalingInstances(t); err != nil {
+			w.failScaling(ctx, t, err)
+			return
+		}
 		w.setDeploymentStatus(t.TargetID, deployments.SCALING_IN_PROGRESS)
 		err := w.runWorkflows(ctx, t, []string{"uninstall"}, true)
 		if err != nil {
@@ -356,6 +409,57 @@ func (w worker) handleTask(t *task) {
 		if err != nil {
 			return
 		}
+	case tasks.Heal:
+		// A custom repair workflow may be defined otherwise related instances are uninstalled then installed again
+		wfName, err := tasks.GetTaskData(kv, t.ID, "workflowName")
+		if err != nil && !tasks.IsTaskDataNotFoundError(err) {
+			log.Printf("Deployment id: %q, Task id: %q Failed: %v", t.TargetID, t.ID, err)
+			log.Debugf("%+v", err)
+			t.WithStatus(tasks.FAILED)
+			return
+		}
+		if wfName != "" {
+			err = w.runWorkflows(ctx, t, []string{wfName}, false)
+		} else if err = w.runWorkflows(ctx, t, []string{"uninstall"}, true); err == nil {
+			err = w.runWorkflows(ctx, t, []string{"install"}, false)
+		}
+		if err != nil {
+			return
+		}
+	case tasks.Update:
+		rollback := w.setupRollback(ctx, t)
+		w.setDeploymentStatus(t.TargetID, deployments.UPDATE_IN_PROGRESS)
+		err := w.runUpdate(ctx, t)
+		if err != nil {
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.ERROR, t.TargetID).RegisterAsString(fmt.Sprintf("Deployment update failed: %v", err))
+			log.Printf("Deployment id: %q, Task id: %q, Failed to update deployment: %+v", t.TargetID, t.ID, err)
+			if t.Status() == tasks.RUNNING {
+				t.WithStatus(tasks.FAILED)
+			}
+			w.setDeploymentStatus(t.TargetID, deployments.UPDATE_FAILED)
+			if rollback && t.Status() == tasks.FAILED {
+				w.registerRollback(ctx, t)
+			} else if rollback {
+				w.cleanupUpdateRollback(t)
+			}
+			return
+		}
+		if rollback {
+			w.cleanupUpdateRollback(t)
+		}
+		w.setDeploymentStatus(t.TargetID, deployments.DEPLOYED)
+	case tasks.Rollback:
+		w.setDeploymentStatus(t.TargetID, deployments.ROLLBACK_IN_PROGRESS)
+		err := w.runRollback(ctx, t)
+		if err != nil {
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.ERROR, t.TargetID).RegisterAsString(fmt.Sprintf("Deployment rollback failed: %v", err))
+			log.Printf("Deployment id: %q, Task id: %q, Failed to rollback deployment: %+v", t.TargetID, t.ID, err)
+			if t.Status() == tasks.RUNNING {
+				t.WithStatus(tasks.FAILED)
+			}
+			w.setDeploymentStatus(t.TargetID, deployments.ROLLBACK_FAILED)
+			return
+		}
 	case tasks.Query:
 		split := strings.Split(t.TargetID, ":")
 		if len(split) != 2 {
@@ -540,6 +644,14 @@ func (w worker) runWorkflows(rootCtx context.Context, t *task, workflows []strin
 				consulutil.StoreConsulKeyAsString(path.Join(consulutil.WorkflowsPrefix, t.ID, step.Name), "")
 			}
 		}
+		if err = checkPreconditions(kv, t, workflow); err != nil {
+			if t.Status() == tasks.RUNNING {
+				t.WithStatus(tasks.FAILED)
+			}
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.ERROR, t.TargetID).RegisterAsString(fmt.Sprintf("Workflow %q not processed: %v", workflow, err))
+			log.Printf("%v. Aborting", err)
+			return err
+		}
 		if err = w.processWorkflow(ctx, workflow, wf, t.TargetID, bypassErrors); err != nil {
 			if t.Status() == tasks.RUNNING {
 				t.WithStatus(tasks.FAILED)
@@ -43,10 +43,14 @@ type step struct {
 	Target             string
 	TargetRelationship string
 	OperationHost      string
+	Filter             []tosca.ConditionClause
+	Retry              retryPolicy
+	Timeout            time.Duration
 	Activities         []Activity
 	Next               []*step
+	OnFailure          []*step
 	Previous           []*step
-	NotifyChan         chan struct{}
+	NotifyChan         chan bool
 	kv                 *api.KV
 	stepPrefix         string
 	t                  *task
@@ -60,13 +64,24 @@ func (s *step) SetTaskID(taskID *task) {
 	s.t = taskID
 }
 
-func (s *step) notifyNext() {
+// notify notifies next steps (on success) and on failure steps that this step is done.
+//
+// The notification value indicates if the notified step is activated by this step or not.
+func (s *step) notify(nextActivated, onFailureActivated bool) {
 	for _, next := range s.Next {
 		log.Debugf("Step %q, notifying step %q", s.Name, next.Name)
-		next.NotifyChan <- struct{}{}
+		next.NotifyChan <- nextActivated
+	}
+	for _, onFailure := range s.OnFailure {
+		log.Debugf("Step %q, notifying on failure step %q", s.Name, onFailure.Name)
+		onFailure.NotifyChan <- onFailureActivated
 	}
 }
 
+func (s *step) notifyNext() {
+	s.notify(true, false)
+}
+
 type visitStep struct {
 	refCount int
 	s        *step
@@ -84,6 +99,27 @@ func (s *step) setStatus(status tasks.TaskStepStatus) error {
 	return err
 }
 
+// acquireExecutionSlots blocks until the step is allowed to run a delegate or an operation according to executions limits
+//
+// The step is set in waiting status while blocked.
+func (s *step) acquireExecutionSlots(ctx context.Context, w worker, deploymentID string) (func(), error) {
+	waiting := false
+	release, err := w.acquireExecutionSlots(ctx, deploymentID, s.Target, func(slot executionSlot) {
+		if !waiting {
+			waiting = true
+			s.setStatus(tasks.TaskStepStatusWAITING)
+		}
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, deploymentID).Registerf("Step %q waiting for %s", s.Name, slot)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if waiting {
+		s.setStatus(tasks.TaskStepStatusRUNNING)
+	}
+	return release, nil
+}
+
 func (s *step) isRelationshipTargetNodeRelated() (bool, error) {
 	if s.TargetRelationship != "" && strings.ToUpper(s.OperationHost) == "TARGET" {
 		targetNodeName, err := deployments.GetTargetNodeForRequirement(s.kv, s.t.TargetID, s.Target, s.TargetRelationship)
@@ -103,7 +139,7 @@ func (s *step) isRelationshipTargetNodeRelated() (bool, error) {
 // isRunnable Checks if a step should be run or bypassed
 //
 // It first checks if the step is not already done in this workflow instance
-// And for ScaleOut and ScaleDown it checks if the node or the target node in case of an operation running on the target node is part of the operation
+// And for ScaleOut, ScaleDown and Heal it checks if the node or the target node in case of an operation running on the target node is part of the operation
 func (s *step) isRunnable() (bool, error) {
 	kvp, _, err := s.kv.Get(path.Join(consulutil.WorkflowsPrefix, s.t.ID, s.Name), nil)
 	if err != nil {
@@ -123,7 +159,7 @@ func (s *step) isRunnable() (bool, error) {
 		}
 	}
 
-	if s.t.TaskType == tasks.ScaleOut || s.t.TaskType == tasks.ScaleIn {
+	if s.t.TaskType == tasks.ScaleOut || s.t.TaskType == tasks.ScaleIn || s.t.TaskType == tasks.Heal || s.t.TaskType == tasks.Update || s.t.TaskType == tasks.Rollback {
 		isNodeTargetTask, err := tasks.IsTaskRelatedNode(s.kv, s.t.ID, s.Target)
 		if err != nil {
 			return false, err
@@ -139,6 +175,55 @@ func (s *step) isRunnable() (bool, error) {
 	return true, nil
 }
 
+// checkFilter checks if the step filter is satisfied by every instance of the step target involved in this task
+func (s *step) checkFilter() (bool, error) {
+	if len(s.Filter) == 0 {
+		return true, nil
+	}
+	instances, err := tasks.GetInstances(s.kv, s.t.ID, s.t.TargetID, s.Target)
+	if err != nil {
+		return false, err
+	}
+	for _, instance := range instances {
+		satisfied, err := deployments.AreConditionsSatisfiedForInstance(s.kv, s.t.TargetID, s.Target, instance, s.Filter)
+		if err != nil || !satisfied {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// checkPreconditions checks that the preconditions of a workflow are satisfied by every instance of their targets
+func checkPreconditions(kv *api.KV, t *task, workflowName string) error {
+	preconditions, err := deployments.GetWorkflowPreconditions(kv, t.TargetID, workflowName)
+	if err != nil {
+		return err
+	}
+	for i, precondition := range preconditions {
+		nodeName := precondition.Target
+		if precondition.TargetRelationShip != "" {
+			nodeName, err = deployments.GetTargetNodeForRequirementByName(kv, t.TargetID, precondition.Target, precondition.TargetRelationShip)
+			if err != nil {
+				return err
+			}
+		}
+		instances, err := tasks.GetInstances(kv, t.ID, t.TargetID, nodeName)
+		if err != nil {
+			return err
+		}
+		for _, instance := range instances {
+			satisfied, err := deployments.AreConditionsSatisfiedForInstance(kv, t.TargetID, nodeName, instance, precondition.Condition)
+			if err != nil {
+				return err
+			}
+			if !satisfied {
+				return errors.Errorf("precondition %d of workflow %q is not satisfied by instance %q of node %q", i, workflowName, instance, nodeName)
+			}
+		}
+	}
+	return nil
+}
+
 func setNodeStatus(kv *api.KV, taskID, deploymentID, nodeName, status string) error {
 	instancesIDs, err := tasks.GetInstances(kv, taskID, deploymentID, nodeName)
 	if err != nil {
@@ -170,13 +255,16 @@ func (s *step) run(ctx context.Context, deploymentID string, kv *api.KV, ignored
 
 	s.setStatus(tasks.TaskStepStatusINITIAL)
 	haveErr := false
+	// A step without previous steps is always activated, otherwise at least one previous step should lead to it
+	activated := len(s.Previous) == 0
 	for i := 0; i < len(s.Previous); i++ {
 		// Wait for previous be done
 		log.Debugf("Step %q waiting for %d previous steps", s.Name, len(s.Previous)-i)
 		for {
 			select {
-			case <-s.NotifyChan:
+			case a := <-s.NotifyChan:
 				log.Debugf("Step %q caught a notification", s.Name)
+				activated = activated || a
 				goto BR
 			case <-shutdownChan:
 				log.Printf("Step %q canceled", s.Name)
@@ -192,6 +280,13 @@ func (s *step) run(ctx context.Context, deploymentID string, kv *api.KV, ignored
 		}
 	BR:
 	}
+	if !activated {
+		log.Debugf("Deployment %q: Skipping Step %q as none of its previous steps leads to it", deploymentID, s.Name)
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, deploymentID).RegisterAsString(fmt.Sprintf("Skipping Step %q as none of its previous steps leads to it", s.Name))
+		s.setStatus(tasks.TaskStepStatusDONE)
+		s.notify(false, false)
+		return nil
+	}
 	// First: we check if step is runnable
 	if runnable, err := s.isRunnable(); err != nil {
 		return err
@@ -202,6 +297,16 @@ func (s *step) run(ctx context.Context, deploymentID string, kv *api.KV, ignored
 		s.notifyNext()
 		return nil
 	}
+	// Then we check that the step filter is satisfied
+	if satisfied, err := s.checkFilter(); err != nil {
+		return err
+	} else if !satisfied {
+		log.Debugf("Deployment %q: Skipping Step %q as its filter is not satisfied", deploymentID, s.Name)
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.INFO, deploymentID).RegisterAsString(fmt.Sprintf("Skipping Step %q as its filter is not satisfied", s.Name))
+		s.setStatus(tasks.TaskStepStatusDONE)
+		s.notifyNext()
+		return nil
+	}
 
 	s.setStatus(tasks.TaskStepStatusRUNNING)
 
@@ -247,12 +352,19 @@ func (s *step) run(ctx context.Context, deploymentID string, kv *api.KV, ignored
 					hook(wfCtx, cfg, s.t.ID, deploymentID, s.Target, activity)
 				}
 			}()
-			err := s.runActivity(wfCtx, kv, cfg, deploymentID, bypassErrors, w, activity)
+			err := s.runActivityWithRetries(wfCtx, kv, cfg, deploymentID, bypassErrors, w, activity)
 			if err != nil {
 				setNodeStatus(kv, s.t.ID, deploymentID, s.Target, tosca.NodeStateError.String())
 				events.WithContextOptionalFields(ctx).NewLogEntry(events.DEBUG, deploymentID).Registerf("Step %q: error details: %+v", s.Name, err)
-				if !bypassErrors {
-					s.setStatus(tasks.TaskStepStatusERROR)
+				if isTimeoutError(err) {
+					events.WithContextOptionalFields(ctx).NewLogEntry(events.ERROR, deploymentID).Registerf("Step %q: %v", s.Name, err)
+				}
+				if !bypassErrors || len(s.OnFailure) > 0 {
+					if isTimeoutError(err) {
+						s.setStatus(tasks.TaskStepStatusTIMEOUT)
+					} else {
+						s.setStatus(tasks.TaskStepStatusERROR)
+					}
 					return err
 				}
 				events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).Registerf("Step %q: Bypassing error: %v", s.Name, err)
@@ -262,6 +374,12 @@ func (s *step) run(ctx context.Context, deploymentID string, kv *api.KV, ignored
 			return nil
 		}()
 		if err != nil {
+			if len(s.OnFailure) > 0 {
+				// Error is handled by the on failure steps, so the rest of the workflow should not be canceled
+				events.WithContextOptionalFields(ctx).NewLogEntry(events.WARN, deploymentID).Registerf("Step %q failed: %v. Running its on failure steps.", s.Name, err)
+				s.notify(false, true)
+				return nil
+			}
 			return err
 		}
 	}
@@ -291,14 +409,21 @@ func (s *step) runActivity(wfCtx context.Context, kv *api.KV, cfg config.Configu
 			return err
 		}
 		delegateOp := activity.Value()
+		release, err := s.acquireExecutionSlots(wfCtx, w, deploymentID)
+		if err != nil {
+			return err
+		}
+		defer release()
+		stepCtx, cancel := s.withStepTimeout(wfCtx)
+		defer cancel()
 		err = func() error {
 			defer metrics.MeasureSince(metricsutil.CleanupMetricKey([]string{"executor", "delegate", deploymentID, nodeType, delegateOp}), time.Now())
-			return provisioner.ExecDelegate(wfCtx, cfg, s.t.ID, deploymentID, s.Target, delegateOp)
+			return provisioner.ExecDelegate(stepCtx, cfg, s.t.ID, deploymentID, s.Target, delegateOp)
 		}()
 
 		if err != nil {
 			metrics.IncrCounter(metricsutil.CleanupMetricKey([]string{"executor", "delegate", deploymentID, nodeType, delegateOp, "failures"}), 1)
-			return err
+			return s.checkStepTimeout(stepCtx, wfCtx, err, activity)
 		}
 		metrics.IncrCounter(metricsutil.CleanupMetricKey([]string{"executor", "delegate", deploymentID, nodeType, delegateOp, "successes"}), 1)
 
@@ -323,19 +448,28 @@ func (s *step) runActivity(wfCtx context.Context, kv *api.KV, cfg config.Configu
 		if err != nil {
 			return err
 		}
+		release, err := s.acquireExecutionSlots(wfCtx, w, deploymentID)
+		if err != nil {
+			return err
+		}
+		defer release()
+		stepCtx, cancel := s.withStepTimeout(wfCtx)
+		defer cancel()
 		err = func() error {
 			defer metrics.MeasureSince(metricsutil.CleanupMetricKey([]string{"executor", "operation", deploymentID, nodeType, op.Name}), time.Now())
-			return exec.ExecOperation(wfCtx, cfg, s.t.ID, deploymentID, s.Target, op)
+			return execOperation(stepCtx, kv, cfg, s.t.ID, deploymentID, s.Target, exec, op)
 		}()
 		if err != nil {
 			metrics.IncrCounter(metricsutil.CleanupMetricKey([]string{"executor", "operation", deploymentID, nodeType, op.Name, "failures"}), 1)
-			return err
+			return s.checkStepTimeout(stepCtx, wfCtx, err, activity)
 		}
 		metrics.IncrCounter(metricsutil.CleanupMetricKey([]string{"executor", "operation", deploymentID, nodeType, op.Name, "successes"}), 1)
 
 	case ActivityTypeInline:
-		if err := w.runWorkflows(wfCtx, s.t, []string{activity.Value()}, bypassErrors); err != nil {
-			return err
+		stepCtx, cancel := s.withStepTimeout(wfCtx)
+		defer cancel()
+		if err := w.runWorkflows(stepCtx, s.t, []string{activity.Value()}, bypassErrors); err != nil {
+			return s.checkStepTimeout(stepCtx, wfCtx, err, activity)
 		}
 	}
 	return nil
@@ -368,6 +502,11 @@ func readStep(kv *api.KV, stepsPrefix, stepName string, visitedMap map[string]*v
 		return nil, errors.Errorf("Invalid value %q for operation host with step %s : only SELF, HOST and ORCHESTRATOR values are accepted", s.OperationHost, stepName)
 	}
 
+	s.Retry, s.Timeout, err = readRetryPolicy(kv, stepPrefix)
+	if err != nil {
+		return nil, err
+	}
+
 	kvKeys, _, err := kv.List(stepPrefix+"/activities/", nil)
 	if err != nil {
 		return nil, err
@@ -414,6 +553,14 @@ func readStep(kv *api.KV, stepsPrefix, stepName string, visitedMap map[string]*v
 		s.Target = string(kvPair.Value)
 	}
 
+	s.Filter, err = deployments.ReadConditionClauses(kv, stepPrefix+"/filter")
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Filter) > 0 && s.Target == "" {
+		return nil, errors.Errorf("Missing target attribute for step %s which defines a filter", stepName)
+	}
+
 	kvPairs, _, err := kv.List(stepPrefix+"/next", nil)
 	if err != nil {
 		return nil, err
@@ -421,29 +568,53 @@ func readStep(kv *api.KV, stepsPrefix, stepName string, visitedMap map[string]*v
 	s.Next = make([]*step, 0)
 	s.Previous = make([]*step, 0)
 	for _, nextKV := range kvPairs {
-		var nextStep *step
 		nextStepName := strings.TrimPrefix(nextKV.Key, stepPrefix+"/next/")
-		if visitStep, ok := visitedMap[nextStepName]; ok {
-			log.Debugf("Found existing step %s", nextStepName)
-			nextStep = visitStep.s
-		} else {
-			log.Debugf("Reading new step %s from Consul", nextStepName)
-			nextStep, err = readStep(kv, stepsPrefix, nextStepName, visitedMap)
-			if err != nil {
-				return nil, err
-			}
+		nextStep, err := readLinkedStep(kv, stepsPrefix, nextStepName, visitedMap)
+		if err != nil {
+			return nil, err
 		}
-
 		s.Next = append(s.Next, nextStep)
 		nextStep.Previous = append(nextStep.Previous, s)
-		visitedMap[nextStepName].refCount++
-		log.Debugf("RefCount for step %s set to %d", nextStepName, visitedMap[nextStepName].refCount)
+	}
+
+	kvPairs, _, err = kv.List(stepPrefix+"/on-failure", nil)
+	if err != nil {
+		return nil, err
+	}
+	s.OnFailure = make([]*step, 0)
+	for _, onFailureKV := range kvPairs {
+		onFailureStepName := strings.TrimPrefix(onFailureKV.Key, stepPrefix+"/on-failure/")
+		onFailureStep, err := readLinkedStep(kv, stepsPrefix, onFailureStepName, visitedMap)
+		if err != nil {
+			return nil, err
+		}
+		s.OnFailure = append(s.OnFailure, onFailureStep)
+		onFailureStep.Previous = append(onFailureStep.Previous, s)
 	}
 	visitedMap[stepName] = &visitStep{refCount: 0, s: s}
 	return s, nil
 
 }
 
+// readLinkedStep returns a step referenced by another one either from the visited steps or by reading it from Consul
+func readLinkedStep(kv *api.KV, stepsPrefix, stepName string, visitedMap map[string]*visitStep) (*step, error) {
+	var linkedStep *step
+	if visitStep, ok := visitedMap[stepName]; ok {
+		log.Debugf("Found existing step %s", stepName)
+		linkedStep = visitStep.s
+	} else {
+		log.Debugf("Reading new step %s from Consul", stepName)
+		var err error
+		linkedStep, err = readStep(kv, stepsPrefix, stepName, visitedMap)
+		if err != nil {
+			return nil, err
+		}
+	}
+	visitedMap[stepName].refCount++
+	log.Debugf("RefCount for step %s set to %d", stepName, visitedMap[stepName].refCount)
+	return linkedStep, nil
+}
+
 // Creates a workflow tree from values stored in Consul at the given prefix.
 // It returns roots (starting) Steps.
 func readWorkFlowFromConsul(kv *api.KV, wfPrefix string) ([]*step, error) {
@@ -470,7 +641,7 @@ func readWorkFlowFromConsul(kv *api.KV, wfPrefix string) ([]*step, error) {
 
 	// build buffered NotifyChan with a size related to the previous steps nb
 	for _, s := range steps {
-		s.NotifyChan = make(chan struct{}, len(s.Previous))
+		s.NotifyChan = make(chan bool, len(s.Previous))
 	}
 	return steps, nil
 }
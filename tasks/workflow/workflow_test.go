@@ -18,6 +18,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -120,6 +121,52 @@ func testReadStepWithNext(t *testing.T, srv1 *testutil.TestServer, kv *api.KV) {
 	require.Equal(t, 1, visitedMap["downstream"].refCount)
 }
 
+func testReadStepWithOnFailureAndFilter(t *testing.T, srv1 *testutil.TestServer, kv *api.KV) {
+	t.Parallel()
+
+	wfName := "wf_" + path.Base(t.Name())
+	data := make(map[string][]byte)
+	data[wfName+"/steps/stepName/activities/0/call-operation"] = []byte("custom.backup")
+	data[wfName+"/steps/stepName/filter/0/assert/0/attribute"] = []byte("state")
+	data[wfName+"/steps/stepName/filter/0/assert/0/constraints/0/operator"] = []byte("equal")
+	data[wfName+"/steps/stepName/filter/0/assert/0/constraints/0/values/0"] = []byte("started")
+	data[wfName+"/steps/stepName/next/downstream"] = []byte("")
+	data[wfName+"/steps/stepName/on-failure/recover"] = []byte("")
+	data[wfName+"/steps/stepName/target"] = []byte("nodeName")
+
+	data[wfName+"/steps/downstream/activities/0/delegate"] = []byte("stop")
+	data[wfName+"/steps/downstream/target"] = []byte("nodeName")
+
+	data[wfName+"/steps/recover/activities/0/set-state"] = []byte("error")
+	data[wfName+"/steps/recover/target"] = []byte("nodeName")
+
+	srv1.PopulateKV(t, data)
+
+	visitedMap := make(map[string]*visitStep)
+	step, err := readStep(kv, wfName+"/steps/", "stepName", visitedMap)
+	require.Nil(t, err)
+	require.Len(t, step.Filter, 1)
+	require.Equal(t, "state", step.Filter[0].Assert[0].AttributeName)
+	require.Len(t, step.Next, 1)
+	require.Equal(t, "downstream", step.Next[0].Name)
+	require.Len(t, step.OnFailure, 1)
+	require.Equal(t, "recover", step.OnFailure[0].Name)
+	require.Len(t, step.OnFailure[0].Previous, 1)
+
+	require.Len(t, visitedMap, 3)
+	require.Equal(t, 1, visitedMap["recover"].refCount)
+
+	// A filter requires a target
+	data = make(map[string][]byte)
+	data[wfName+"/steps/noTarget/activities/0/inline"] = []byte("my_custom_wf")
+	data[wfName+"/steps/noTarget/filter/0/assert/0/attribute"] = []byte("state")
+	data[wfName+"/steps/noTarget/filter/0/assert/0/constraints/0/operator"] = []byte("equal")
+	data[wfName+"/steps/noTarget/filter/0/assert/0/constraints/0/values/0"] = []byte("started")
+	srv1.PopulateKV(t, data)
+	_, err = readStep(kv, wfName+"/steps/", "noTarget", make(map[string]*visitStep))
+	require.Error(t, err)
+}
+
 func testReadWorkFlowFromConsul(t *testing.T, srv1 *testutil.TestServer, kv *api.KV) {
 	t.Parallel()
 
@@ -159,8 +206,10 @@ func testReadWorkFlowFromConsul(t *testing.T, srv1 *testutil.TestServer, kv *api
 type mockExecutor struct {
 	delegateCalled bool
 	callOpsCalled  bool
+	callOpsCount   int
 	errorsDelegate bool
 	errorsCallOps  bool
+	blockCallOps   bool
 }
 
 func (m *mockExecutor) ExecDelegate(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName, delegateOperation string) error {
@@ -172,6 +221,11 @@ func (m *mockExecutor) ExecDelegate(ctx context.Context, conf config.Configurati
 }
 func (m *mockExecutor) ExecOperation(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation) error {
 	m.callOpsCalled = true
+	m.callOpsCount++
+	if m.blockCallOps {
+		<-ctx.Done()
+		return ctx.Err()
+	}
 	if m.errorsCallOps {
 		return errors.New("Failed required for mock")
 	}
@@ -294,6 +348,77 @@ func testRunWorkflow(t *testing.T, kv *api.KV) {
 	}
 }
 
+func testRunStepWithRetries(t *testing.T, kv *api.KV) {
+	deploymentID := strings.Replace(t.Name(), "/", "_", -1)
+	err := deployments.StoreDeploymentDefinition(context.Background(), kv, deploymentID, "testdata/workflow.yaml")
+	require.Nil(t, err)
+
+	mockExecutor := &mockExecutor{errorsCallOps: true}
+	registry.GetRegistry().RegisterOperationExecutor([]string{"ystia.yorc.tests.artifacts.Implementation.Custom"}, mockExecutor, "tests")
+	clearActivityHooks()
+
+	stepsPrefix := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "workflows", "retry", "steps") + "/"
+	s, err := readStep(kv, stepsPrefix, "WFNode_create_retry", make(map[string]*visitStep))
+	require.NoError(t, err)
+	require.Equal(t, 2, s.Retry.retries)
+	require.Equal(t, time.Millisecond, s.Retry.delay)
+	require.Equal(t, 5*time.Minute, s.Timeout)
+
+	s.SetTaskID(&task{ID: "taskID", TargetID: deploymentID})
+	err = s.run(context.Background(), deploymentID, kv, make(chan error, 10), make(chan struct{}), config.Configuration{}, false, "retry", worker{})
+	require.Error(t, err)
+	require.Equal(t, 3, mockExecutor.callOpsCount)
+
+	mockExecutor.callOpsCount = 0
+	mockExecutor.errorsCallOps = false
+	err = s.run(context.Background(), deploymentID, kv, make(chan error, 10), make(chan struct{}), config.Configuration{}, false, "retry", worker{})
+	require.NoError(t, err)
+	require.Equal(t, 1, mockExecutor.callOpsCount)
+}
+
+func testRunStepWithOperationTimeout(t *testing.T, kv *api.KV) {
+	deploymentID := strings.Replace(t.Name(), "/", "_", -1)
+	err := deployments.StoreDeploymentDefinition(context.Background(), kv, deploymentID, "testdata/workflow.yaml")
+	require.Nil(t, err)
+
+	mockExecutor := &mockExecutor{blockCallOps: true}
+	registry.GetRegistry().RegisterOperationExecutor([]string{"ystia.yorc.tests.artifacts.Implementation.Custom"}, mockExecutor, "tests")
+	clearActivityHooks()
+
+	stepsPrefix := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "workflows", "retry", "steps") + "/"
+	s, err := readStep(kv, stepsPrefix, "WFNode_create_retry", make(map[string]*visitStep))
+	require.NoError(t, err)
+
+	s.SetTaskID(&task{ID: "taskTimeoutID", TargetID: deploymentID})
+	err = s.run(context.Background(), deploymentID, kv, make(chan error, 10), make(chan struct{}), config.Configuration{OperationTimeout: 10 * time.Millisecond}, false, "retry", worker{})
+	require.Error(t, err)
+	require.True(t, isTimeoutError(err), "expecting a timeout error, got %v", err)
+	require.Equal(t, 3, mockExecutor.callOpsCount)
+
+	kvp, _, err := kv.Get(path.Join(consulutil.WorkflowsPrefix, "taskTimeoutID", "WFNode_create_retry"), nil)
+	require.NoError(t, err)
+	require.NotNil(t, kvp)
+	require.Equal(t, "timeout", string(kvp.Value))
+}
+
+func TestRetryPolicyNextDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		rp      retryPolicy
+		current time.Duration
+		want    time.Duration
+	}{
+		{"NoBackoff", retryPolicy{delay: time.Second}, time.Second, time.Second},
+		{"Backoff", retryPolicy{delay: time.Second, backoff: 2}, 2 * time.Second, 4 * time.Second},
+		{"BackoffMaxDelay", retryPolicy{delay: time.Second, backoff: 3, maxDelay: 5 * time.Second}, 2 * time.Second, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.rp.nextDelay(tt.current))
+		})
+	}
+}
+
 func clearActivityHooks() {
 	preActivityHooks = make([]ActivityHook, 0)
 	postActivityHooks = make([]ActivityHook, 0)
@@ -0,0 +1,306 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tosca
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// An AttributeGetter is a function used to retrieve the value of an attribute when evaluating a condition.
+//
+// It returns true if a value is found false otherwise as first return parameter.
+type AttributeGetter func(attributeName string) (bool, string, error)
+
+// A ConditionClause is the representation of a TOSCA Condition Clause
+//
+// Only one of And, Or, Not or Assert is expected to be set. A condition clause directly expressed
+// as an attribute name followed by a list of constraints is considered as an Assert clause.
+//
+// See http://docs.oasis-open.org/tosca/TOSCA-Simple-Profile-YAML/v1.2/TOSCA-Simple-Profile-YAML-v1.2.html#DEFN_ELEMENT_CONDITION_CLAUSE_DEFN
+// for more details
+type ConditionClause struct {
+	And    []ConditionClause     `yaml:"and,omitempty" json:"and,omitempty"`
+	Or     []ConditionClause     `yaml:"or,omitempty" json:"or,omitempty"`
+	Not    []ConditionClause     `yaml:"not,omitempty" json:"not,omitempty"`
+	Assert []AssertionDefinition `yaml:"assert,omitempty" json:"assert,omitempty"`
+}
+
+// An AssertionDefinition is the representation of a TOSCA Assertion Definition
+//
+// See http://docs.oasis-open.org/tosca/TOSCA-Simple-Profile-YAML/v1.2/TOSCA-Simple-Profile-YAML-v1.2.html#DEFN_ELEMENT_ASSERTION_DEFN
+// for more details
+type AssertionDefinition struct {
+	AttributeName string             `json:"attribute_name"`
+	Constraints   []ConstraintClause `json:"constraints"`
+}
+
+// A ConstraintClause is the representation of a TOSCA Constraint Clause
+//
+// Values contains a single value for scalar operators, two values for the in_range operator and
+// the list of accepted values for the valid_values operator.
+//
+// See http://docs.oasis-open.org/tosca/TOSCA-Simple-Profile-YAML/v1.2/TOSCA-Simple-Profile-YAML-v1.2.html#DEFN_ELEMENT_CONSTRAINTS_CLAUSE
+// for more details
+type ConstraintClause struct {
+	Operator string   `json:"operator"`
+	Values   []string `json:"values"`
+}
+
+var constraintOperators = []string{"equal", "greater_than", "greater_or_equal", "less_than", "less_or_equal", "in_range", "valid_values", "length", "min_length", "max_length", "pattern"}
+
+// UnmarshalYAML unmarshals a yaml into a ConditionClause
+func (c *ConditionClause) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var m map[string]interface{}
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+	if len(m) != 1 {
+		return errors.Errorf("a condition clause should contain exactly one keyname, found %d", len(m))
+	}
+	for k := range m {
+		switch k {
+		case "and", "or", "not":
+			var clauses map[string][]ConditionClause
+			if err := unmarshal(&clauses); err != nil {
+				return err
+			}
+			switch k {
+			case "and":
+				c.And = clauses[k]
+			case "or":
+				c.Or = clauses[k]
+			default:
+				c.Not = clauses[k]
+			}
+		case "assert":
+			var assertions map[string][]AssertionDefinition
+			if err := unmarshal(&assertions); err != nil {
+				return err
+			}
+			c.Assert = assertions[k]
+		default:
+			var assertion AssertionDefinition
+			if err := unmarshal(&assertion); err != nil {
+				return err
+			}
+			c.Assert = []AssertionDefinition{assertion}
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML unmarshals a yaml into an AssertionDefinition
+func (a *AssertionDefinition) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var m map[string][]ConstraintClause
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+	if len(m) != 1 {
+		return errors.Errorf("an assertion definition should contain exactly one attribute name, found %d", len(m))
+	}
+	for k, v := range m {
+		a.AttributeName = k
+		a.Constraints = v
+	}
+	return nil
+}
+
+// UnmarshalYAML unmarshals a yaml into a ConstraintClause
+func (cc *ConstraintClause) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var m map[string]interface{}
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+	if len(m) != 1 {
+		return errors.Errorf("a constraint clause should contain exactly one operator, found %d", len(m))
+	}
+	for k, v := range m {
+		cc.Operator = k
+		if l, ok := v.([]interface{}); ok {
+			cc.Values = make([]string, len(l))
+			for i := range l {
+				cc.Values[i] = fmt.Sprint(l[i])
+			}
+		} else {
+			cc.Values = []string{fmt.Sprint(v)}
+		}
+	}
+	return cc.validate()
+}
+
+func (cc ConstraintClause) validate() error {
+	found := false
+	for _, op := range constraintOperators {
+		if op == cc.Operator {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.Errorf("unsupported constraint operator %q", cc.Operator)
+	}
+	switch cc.Operator {
+	case "in_range":
+		if len(cc.Values) != 2 {
+			return errors.Errorf("in_range constraint expects exactly 2 values, got %d", len(cc.Values))
+		}
+	case "valid_values":
+	default:
+		if len(cc.Values) != 1 {
+			return errors.Errorf("%s constraint expects exactly one value, got %d", cc.Operator, len(cc.Values))
+		}
+	}
+	if cc.Operator == "pattern" {
+		if _, err := regexp.Compile(cc.Values[0]); err != nil {
+			return errors.Wrapf(err, "invalid pattern constraint %q", cc.Values[0])
+		}
+	}
+	return nil
+}
+
+// compareValues compares two values numerically if both are numbers or lexically otherwise.
+func compareValues(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+func parseLength(v string) (int, error) {
+	l, err := strconv.Atoi(v)
+	return l, errors.Wrapf(err, "expecting an integer as length constraint, got %q", v)
+}
+
+// Evaluate checks if the given value satisfies this ConstraintClause
+func (cc ConstraintClause) Evaluate(value string) (bool, error) {
+	if err := cc.validate(); err != nil {
+		return false, err
+	}
+	switch cc.Operator {
+	case "equal":
+		return compareValues(value, cc.Values[0]) == 0, nil
+	case "greater_than":
+		return compareValues(value, cc.Values[0]) > 0, nil
+	case "greater_or_equal":
+		return compareValues(value, cc.Values[0]) >= 0, nil
+	case "less_than":
+		return compareValues(value, cc.Values[0]) < 0, nil
+	case "less_or_equal":
+		return compareValues(value, cc.Values[0]) <= 0, nil
+	case "in_range":
+		if compareValues(value, cc.Values[0]) < 0 {
+			return false, nil
+		}
+		return strings.ToUpper(cc.Values[1]) == "UNBOUNDED" || compareValues(value, cc.Values[1]) <= 0, nil
+	case "valid_values":
+		for _, v := range cc.Values {
+			if compareValues(value, v) == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "length", "min_length", "max_length":
+		expected, err := parseLength(cc.Values[0])
+		if err != nil {
+			return false, err
+		}
+		l := utf8.RuneCountInString(value)
+		switch cc.Operator {
+		case "min_length":
+			return l >= expected, nil
+		case "max_length":
+			return l <= expected, nil
+		}
+		return l == expected, nil
+	}
+	// pattern
+	return regexp.MatchString("^(?:"+cc.Values[0]+")$", value)
+}
+
+// Evaluate checks if the attribute value retrieved using the given AttributeGetter satisfies
+// all the constraints of this AssertionDefinition.
+//
+// An attribute that could not be found never satisfies an assertion.
+func (a AssertionDefinition) Evaluate(getAttribute AttributeGetter) (bool, error) {
+	found, value, err := getAttribute(a.AttributeName)
+	if err != nil || !found {
+		return false, err
+	}
+	for _, cc := range a.Constraints {
+		ok, err := cc.Evaluate(value)
+		if err != nil || !ok {
+			return false, errors.Wrapf(err, "failed to evaluate constraint on attribute %q", a.AttributeName)
+		}
+	}
+	return true, nil
+}
+
+// Evaluate checks if this ConditionClause is satisfied using the given AttributeGetter to retrieve attributes values
+//
+// A 'not' condition clause is satisfied if none of its nested condition clauses is satisfied.
+func (c ConditionClause) Evaluate(getAttribute AttributeGetter) (bool, error) {
+	switch {
+	case c.And != nil:
+		return EvaluateConditions(c.And, getAttribute)
+	case c.Or != nil:
+		for _, cc := range c.Or {
+			ok, err := cc.Evaluate(getAttribute)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case c.Not != nil:
+		for _, cc := range c.Not {
+			ok, err := cc.Evaluate(getAttribute)
+			if err != nil || ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	for _, a := range c.Assert {
+		ok, err := a.Evaluate(getAttribute)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// EvaluateConditions checks if all the given condition clauses are satisfied
+func EvaluateConditions(conditions []ConditionClause, getAttribute AttributeGetter) (bool, error) {
+	for _, cc := range conditions {
+		ok, err := cc.Evaluate(getAttribute)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tosca
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConditionClauseParsing(t *testing.T) {
+	data := `
+- my_attribute: [{equal: my_value}]
+- assert:
+  - other: [{greater_than: 5}, {less_or_equal: 10}]
+- or:
+  - state: [{valid_values: [started, configured]}]
+  - not:
+    - state: [{equal: error}]
+`
+	var conditions []ConditionClause
+	err := yaml.Unmarshal([]byte(data), &conditions)
+	require.NoError(t, err)
+	require.Len(t, conditions, 3)
+	require.Equal(t, []AssertionDefinition{{AttributeName: "my_attribute", Constraints: []ConstraintClause{{Operator: "equal", Values: []string{"my_value"}}}}}, conditions[0].Assert)
+	require.Len(t, conditions[1].Assert, 1)
+	require.Len(t, conditions[1].Assert[0].Constraints, 2)
+	require.Len(t, conditions[2].Or, 2)
+	require.Equal(t, []string{"started", "configured"}, conditions[2].Or[0].Assert[0].Constraints[0].Values)
+	require.Len(t, conditions[2].Or[1].Not, 1)
+
+	err = yaml.Unmarshal([]byte(`- attr: [{unknown_op: 5}]`), &conditions)
+	require.Error(t, err)
+	err = yaml.Unmarshal([]byte(`- attr: [{in_range: [1]}]`), &conditions)
+	require.Error(t, err)
+}
+
+func TestConstraintClauseEvaluate(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint ConstraintClause
+		value      string
+		want       bool
+		wantErr    bool
+	}{
+		{"EqualString", ConstraintClause{"equal", []string{"started"}}, "started", true, false},
+		{"EqualNumeric", ConstraintClause{"equal", []string{"5"}}, "5.0", true, false},
+		{"NotEqual", ConstraintClause{"equal", []string{"started"}}, "error", false, false},
+		{"GreaterThan", ConstraintClause{"greater_than", []string{"5"}}, "10", true, false},
+		{"GreaterThanNumericNotLexical", ConstraintClause{"greater_than", []string{"9"}}, "10", true, false},
+		{"GreaterOrEqual", ConstraintClause{"greater_or_equal", []string{"5"}}, "5", true, false},
+		{"LessThan", ConstraintClause{"less_than", []string{"5"}}, "5", false, false},
+		{"LessOrEqual", ConstraintClause{"less_or_equal", []string{"5"}}, "4", true, false},
+		{"InRange", ConstraintClause{"in_range", []string{"1", "4"}}, "4", true, false},
+		{"OutOfRange", ConstraintClause{"in_range", []string{"1", "4"}}, "5", false, false},
+		{"InRangeUnbounded", ConstraintClause{"in_range", []string{"1", "UNBOUNDED"}}, "500", true, false},
+		{"ValidValues", ConstraintClause{"valid_values", []string{"a", "b"}}, "b", true, false},
+		{"InvalidValues", ConstraintClause{"valid_values", []string{"a", "b"}}, "c", false, false},
+		{"Length", ConstraintClause{"length", []string{"3"}}, "abc", true, false},
+		{"MinLength", ConstraintClause{"min_length", []string{"4"}}, "abc", false, false},
+		{"MaxLength", ConstraintClause{"max_length", []string{"4"}}, "abc", true, false},
+		{"BadLength", ConstraintClause{"length", []string{"x"}}, "abc", false, true},
+		{"Pattern", ConstraintClause{"pattern", []string{"[a-z]+"}}, "abc", true, false},
+		{"PatternFullMatch", ConstraintClause{"pattern", []string{"[a-z]+"}}, "abc1", false, false},
+		{"UnknownOperator", ConstraintClause{"unknown", []string{"a"}}, "a", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.constraint.Evaluate(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEvaluateConditions(t *testing.T) {
+	attributes := map[string]string{"state": "started", "env": "production", "replicas": "3"}
+	getAttribute := func(name string) (bool, string, error) {
+		v, ok := attributes[name]
+		return ok, v, nil
+	}
+	data := `
+- env: [{equal: production}]
+- or:
+  - replicas: [{greater_than: 5}]
+  - not:
+    - state: [{equal: error}]
+`
+	var conditions []ConditionClause
+	require.NoError(t, yaml.Unmarshal([]byte(data), &conditions))
+	ok, err := EvaluateConditions(conditions, getAttribute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	attributes["state"] = "error"
+	ok, err = EvaluateConditions(conditions, getAttribute)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Missing attributes never satisfy an assertion
+	ok, err = EvaluateConditions([]ConditionClause{{Assert: []AssertionDefinition{{AttributeName: "missing", Constraints: []ConstraintClause{{"equal", []string{""}}}}}}}, getAttribute)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,27 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tosca
+
+// A Group is the representation of a TOSCA Group Definition
+//
+// See http://docs.oasis-open.org/tosca/TOSCA-Simple-Profile-YAML/v1.2/TOSCA-Simple-Profile-YAML-v1.2.html#DEFN_ELEMENT_GROUP_DEF
+// for more details
+type Group struct {
+	Type        string                      `yaml:"type" json:"type"`
+	Description string                      `yaml:"description,omitempty" json:"description,omitempty"`
+	Metadata    map[string]string           `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	Properties  map[string]*ValueAssignment `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Members     []string                    `yaml:"members,omitempty,flow" json:"members,omitempty"`
+}
@@ -28,6 +28,7 @@ func TestGroupedImplementationsParallel(t *testing.T) {
 		t.Run("TestImplementationArtifact", implementationArtifact)
 		t.Run("TestImplementationComplexGrammarWithDependencies", implementationComplexGrammarWithDependencies)
 		t.Run("TestImplementationFailing", implementationFailing)
+		t.Run("TestImplementationTimeout", implementationTimeout)
 	})
 }
 
@@ -110,3 +111,26 @@ implementation:
 	assert.NotNil(t, err, "Expecting an error when unmarshaling Implementation with an array as primary")
 
 }
+
+func implementationTimeout(t *testing.T) {
+	t.Parallel()
+	var inputYaml = `
+implementation:
+  primary: scripts/install.sh
+  timeout: 600`
+	implem := implementationTestType{}
+
+	err := yaml.Unmarshal([]byte(inputYaml), &implem)
+	assert.Nil(t, err, "Expecting no error when unmarshaling Implementation with a timeout")
+	assert.Equal(t, "scripts/install.sh", implem.Implementation.Primary)
+	assert.Equal(t, "600", implem.Implementation.Timeout)
+
+	inputYaml = `
+implementation:
+  primary: scripts/install.sh
+  timeout: 30m`
+	implem = implementationTestType{}
+	err = yaml.Unmarshal([]byte(inputYaml), &implem)
+	assert.Nil(t, err, "Expecting no error when unmarshaling Implementation with a timeout")
+	assert.Equal(t, "30m", implem.Implementation.Timeout)
+}
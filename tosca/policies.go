@@ -0,0 +1,60 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tosca
+
+import "github.com/pkg/errors"
+
+// A Policy is the representation of a TOSCA Policy Definition
+//
+// See http://docs.oasis-open.org/tosca/TOSCA-Simple-Profile-YAML/v1.2/TOSCA-Simple-Profile-YAML-v1.2.html#DEFN_ELEMENT_POLICY_DEF
+// for more details
+type Policy struct {
+	Type        string                      `yaml:"type" json:"type"`
+	Description string                      `yaml:"description,omitempty" json:"description,omitempty"`
+	Metadata    map[string]string           `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	Properties  map[string]*ValueAssignment `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Targets     []string                    `yaml:"targets,omitempty,flow" json:"targets,omitempty"`
+}
+
+// A PolicyDefinitionMap is a map of policies definitions indexed by policy name
+type PolicyDefinitionMap map[string]Policy
+
+// UnmarshalYAML unmarshals a yaml into a PolicyDefinitionMap
+//
+// TOSCA defines policies as a list of single-entry maps but some tools generate them as a map
+// so both forms are accepted.
+func (pdm *PolicyDefinitionMap) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*pdm = make(PolicyDefinitionMap)
+	var l []map[string]Policy
+	if err := unmarshal(&l); err == nil {
+		for _, m := range l {
+			for k, v := range m {
+				if _, ok := (*pdm)[k]; ok {
+					return errors.Errorf("policy %q is defined more than once", k)
+				}
+				(*pdm)[k] = v
+			}
+		}
+		return nil
+	}
+	var m map[string]Policy
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+	for k, v := range m {
+		(*pdm)[k] = v
+	}
+	return nil
+}
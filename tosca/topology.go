@@ -43,8 +43,8 @@ type Topology struct {
 	NodeTypes         map[string]NodeType         `yaml:"node_types,omitempty"`
 	CapabilityTypes   map[string]CapabilityType   `yaml:"capability_types,omitempty"`
 	RelationshipTypes map[string]RelationshipType `yaml:"relationship_types,omitempty"`
-	// TODO Group Types
-	// TODO Policy Types
+	GroupTypes        map[string]GroupType        `yaml:"group_types,omitempty"`
+	PolicyTypes       map[string]PolicyType       `yaml:"policy_types,omitempty"`
 
 	TopologyTemplate TopologyTemplate `yaml:"topology_template"`
 }
@@ -59,9 +59,9 @@ type TopologyTemplate struct {
 	Outputs            map[string]ParameterDefinition `yaml:"outputs,omitempty"`
 	SubstitionMappings *SubstitutionMapping           `yaml:"substitution_mappings,omitempty"`
 	Workflows          map[string]Workflow
+	Groups             map[string]Group    `yaml:"groups,omitempty"`
+	Policies           PolicyDefinitionMap `yaml:"policies,omitempty"`
 	//RelationshipTemplates []RelationshipTemplate `yaml:"relationship_templates,omitempty"`
-	//Groups                []Group `yaml:",omitempty"`
-	//Policies              []Policy                 `yaml:",omitempty"`
 }
 
 // An NodeTemplate is the representation of a TOSCA Node Template
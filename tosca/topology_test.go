@@ -55,3 +55,55 @@ topology_template:
 	require.Equal(t, false, *input.Required)
 	require.Equal(t, `http://10.197.132.16/sla`, input.Value.GetLiteral())
 }
+
+func TestTopologyTemplate_GroupsAndPolicies(t *testing.T) {
+	data := `
+topology_template:
+  groups:
+    servers:
+      type: tosca.groups.Root
+      members: [ server1, server2 ]
+  policies:
+    - placement:
+        type: tosca.policies.Placement
+        targets: [ servers ]
+    - scaling:
+        type: tosca.policies.Scaling
+        properties:
+          max: 3
+        targets: [ server1 ]
+`
+	topo := Topology{}
+	err := yaml.Unmarshal([]byte(data), &topo)
+	require.Nil(t, err)
+	require.Contains(t, topo.TopologyTemplate.Groups, "servers")
+	require.Equal(t, []string{"server1", "server2"}, topo.TopologyTemplate.Groups["servers"].Members)
+	require.Len(t, topo.TopologyTemplate.Policies, 2)
+	require.Equal(t, "tosca.policies.Placement", topo.TopologyTemplate.Policies["placement"].Type)
+	require.Equal(t, []string{"servers"}, topo.TopologyTemplate.Policies["placement"].Targets)
+	require.Equal(t, "3", topo.TopologyTemplate.Policies["scaling"].Properties["max"].GetLiteral())
+
+	// Policies may also be defined as a map
+	data = `
+topology_template:
+  policies:
+    scaling:
+      type: tosca.policies.Scaling
+      targets: [ server1 ]
+`
+	topo = Topology{}
+	err = yaml.Unmarshal([]byte(data), &topo)
+	require.Nil(t, err)
+	require.Equal(t, []string{"server1"}, topo.TopologyTemplate.Policies["scaling"].Targets)
+
+	data = `
+topology_template:
+  policies:
+    - scaling:
+        type: tosca.policies.Scaling
+    - scaling:
+        type: tosca.policies.Scaling
+`
+	err = yaml.Unmarshal([]byte(data), &Topology{})
+	require.Error(t, err)
+}
@@ -37,9 +37,12 @@ const (
 	GetOperationOutputOperator Operator = "get_operation_output"
 	// ConcatOperator is the Operator of the concat function
 	ConcatOperator Operator = "concat"
-	// TokenOperator     Operator = "token"
-	// GetNodesOfTypeOperator     Operator = "get_nodes_of_type"
-	// GetArtifactOperator        Operator = "get_artifact"
+	// TokenOperator is the Operator of the token function
+	TokenOperator Operator = "token"
+	// GetNodesOfTypeOperator is the Operator of the get_nodes_of_type function
+	GetNodesOfTypeOperator Operator = "get_nodes_of_type"
+	// GetArtifactOperator is the Operator of the get_artifact function
+	GetArtifactOperator Operator = "get_artifact"
 )
 
 // IsOperator checks if a given token is a known TOSCA function keyword
@@ -48,7 +51,10 @@ func IsOperator(op string) bool {
 		op == string(GetAttributeOperator) ||
 		op == string(GetInputOperator) ||
 		op == string(GetOperationOutputOperator) ||
-		op == string(ConcatOperator)
+		op == string(ConcatOperator) ||
+		op == string(TokenOperator) ||
+		op == string(GetNodesOfTypeOperator) ||
+		op == string(GetArtifactOperator)
 }
 
 func parseOperator(op string) (Operator, error) {
@@ -63,6 +69,12 @@ func parseOperator(op string) (Operator, error) {
 		return GetOperationOutputOperator, nil
 	case op == string(ConcatOperator):
 		return ConcatOperator, nil
+	case op == string(TokenOperator):
+		return TokenOperator, nil
+	case op == string(GetNodesOfTypeOperator):
+		return GetNodesOfTypeOperator, nil
+	case op == string(GetArtifactOperator):
+		return GetArtifactOperator, nil
 	default:
 		return GetPropertyOperator, errors.Errorf("%q is not a known or supported TOSCA operator", op)
 
@@ -40,6 +40,9 @@ func TestFunctionParsing(t *testing.T) {
 		{"TestConcatFunction", inputs{yml: "concat: [get_property: [SELF, ip_address], get_attribute: [SELF, port]]"}, false},
 		{"TestGetInputFunction", inputs{yml: "get_input: ip_address"}, false},
 		{"TestConcatFunctionQuoting", inputs{yml: `concat: ["http://", get_property: [SELF, ip_address], get_attribute: [SELF, port], "\"ff\""]`}, false},
+		{"TestTokenFunction", inputs{yml: `token: [get_attribute: [SELF, url], ":", 1]`}, false},
+		{"TestGetNodesOfTypeFunction", inputs{yml: "get_nodes_of_type: tosca.nodes.Compute"}, false},
+		{"TestGetArtifactFunction", inputs{yml: "get_artifact: [SELF, war, /opt/app/app.war, true]"}, false},
 	}
 
 	for _, tt := range tests {
@@ -108,3 +108,24 @@ type DataType struct {
 	// Constraints not enforced in Yorc so we don't parse them
 	// Constraints []ConstraintClause
 }
+
+// A GroupType is the representation of a TOSCA Group Type
+//
+// See http://docs.oasis-open.org/tosca/TOSCA-Simple-Profile-YAML/v1.2/TOSCA-Simple-Profile-YAML-v1.2.html#DEFN_ENTITY_GROUP_TYPE
+// for more details
+type GroupType struct {
+	Type       `yaml:",inline"`
+	Properties map[string]PropertyDefinition  `yaml:"properties,omitempty"`
+	Attributes map[string]AttributeDefinition `yaml:"attributes,omitempty"`
+	Members    []string                       `yaml:"members,omitempty,flow"`
+}
+
+// A PolicyType is the representation of a TOSCA Policy Type
+//
+// See http://docs.oasis-open.org/tosca/TOSCA-Simple-Profile-YAML/v1.2/TOSCA-Simple-Profile-YAML-v1.2.html#DEFN_ENTITY_POLICY_TYPE
+// for more details
+type PolicyType struct {
+	Type       `yaml:",inline"`
+	Properties map[string]PropertyDefinition `yaml:"properties,omitempty"`
+	Targets    []string                      `yaml:"targets,omitempty,flow"`
+}
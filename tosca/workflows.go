@@ -18,7 +18,18 @@ package tosca
 //
 // Currently Workflows are not part of the TOSCA specification
 type Workflow struct {
-	Steps map[string]Step `yaml:"steps,omitempty" json:"steps,omitempty"`
+	Preconditions []Precondition  `yaml:"preconditions,omitempty" json:"preconditions,omitempty"`
+	Steps         map[string]Step `yaml:"steps,omitempty" json:"steps,omitempty"`
+}
+
+// A Precondition is the representation of a TOSCA Workflow Precondition
+//
+// See http://docs.oasis-open.org/tosca/TOSCA-Simple-Profile-YAML/v1.2/TOSCA-Simple-Profile-YAML-v1.2.html#DEFN_ENTITY_WORKFLOW_PRECONDITION_DEFN
+// for more details
+type Precondition struct {
+	Target             string            `yaml:"target" json:"target"`
+	TargetRelationShip string            `yaml:"target_relationship,omitempty" json:"target_relationship,omitempty"`
+	Condition          []ConditionClause `yaml:"condition,omitempty" json:"condition,omitempty"`
 }
 
 // An Step is the representation of a TOSCA Workflow Step
@@ -26,11 +37,27 @@ type Workflow struct {
 // See http://docs.oasis-open.org/tosca/TOSCA-Simple-Profile-YAML/v1.2/TOSCA-Simple-Profile-YAML-v1.2.html#DEFN_ENTITY_WORKFLOW_STEP_DEFN
 // for more details
 type Step struct {
-	Target             string     `yaml:"target,omitempty" json:"target,omitempty"`
-	TargetRelationShip string     `yaml:"target_relationship,omitempty" json:"target_relationship,omitempty"`
-	Activities         []Activity `yaml:"activities" json:"activities"`
-	OnSuccess          []string   `yaml:"on_success,omitempty" json:"on_success,omitempty"`
-	OperationHost      string     `yaml:"operation_host,omitempty" json:"operation_host,omitempty"`
+	Target             string            `yaml:"target,omitempty" json:"target,omitempty"`
+	TargetRelationShip string            `yaml:"target_relationship,omitempty" json:"target_relationship,omitempty"`
+	Filter             []ConditionClause `yaml:"filter,omitempty" json:"filter,omitempty"`
+	Activities         []Activity        `yaml:"activities" json:"activities"`
+	OnSuccess          []string          `yaml:"on_success,omitempty" json:"on_success,omitempty"`
+	OnFailure          []string          `yaml:"on_failure,omitempty" json:"on_failure,omitempty"`
+	OperationHost      string            `yaml:"operation_host,omitempty" json:"operation_host,omitempty"`
+	// Retry and Timeout are Yorc specific extensions applied to each activity of the step
+	Retry   *RetryPolicy `yaml:"retry,omitempty" json:"retry,omitempty"`
+	Timeout string       `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// A RetryPolicy defines how a failing workflow step activity should be retried
+//
+// This is not part of the TOSCA specification. Delays are expressed as Go durations (ex: 30s or 5m).
+// After each failed attempt the delay is multiplied by the Backoff factor without exceeding MaxDelay.
+type RetryPolicy struct {
+	Retries  int     `yaml:"retries,omitempty" json:"retries,omitempty"`
+	Delay    string  `yaml:"delay,omitempty" json:"delay,omitempty"`
+	Backoff  float64 `yaml:"backoff,omitempty" json:"backoff,omitempty"`
+	MaxDelay string  `yaml:"max_delay,omitempty" json:"max_delay,omitempty"`
 }
 
 // An Activity is the representation of a TOSCA Workflow Step Activity